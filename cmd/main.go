@@ -1,54 +1,249 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/collector"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/filter"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/region"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/remotewrite"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/utils"
 )
 
-const (
-	// MaxInstanceIdentifiers defines the maximum number of instance identifiers
-	// allowed in the ?identifiers query parameter to prevent service overload
-	MaxInstanceIdentifiers = 5
-)
-
 func main() {
-	log.Println("[MAIN] Starting Database Insights Exporter")
+	validateConfigPath := flag.String("validate-config", "", "Validate the given configuration file and exit without starting the server")
+	checkAWS := flag.Bool("check-aws", false, "With -validate-config, also check that every configured region is reachable with the AWS credentials")
+	flag.Parse()
 
-	cfg, err := utils.LoadConfig("config.yml")
-	if err != nil {
-		log.Fatalf("[MAIN] Error loading configuration: %v", err)
+	if *validateConfigPath != "" {
+		var checkRegion regionChecker
+		if *checkAWS {
+			checkRegion = checkRegionConnectivity
+		}
+		os.Exit(validateConfig(os.Stdout, *validateConfigPath, checkRegion))
 	}
 
-	factory := region.NewRegionManagerFactory()
-	regionManager, err := factory.CreateRegionManager(cfg)
+	slog.Info("Starting Database Insights Exporter")
+
+	// AWS API calls are recorded for the lifetime of the exporter, across config reloads
+	apiMetrics := clients.NewAPIMetrics()
+	factory := region.NewRegionManagerFactory(apiMetrics)
+	exporter, err := newReloadableExporter("config.yml", utils.LoadConfig, factory.CreateRegionManager)
 	if err != nil {
-		log.Fatalf("[MAIN] Error creating region manager: %v", err)
+		slog.Error("Error starting exporter", "error", err)
+		os.Exit(1)
+	}
+	cfg := exporter.current().cfg
+	if err := utils.CheckPortAvailable(cfg.Export); err != nil {
+		slog.Error("Error starting exporter", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	go exporter.watchReload(ctx, reloadSignals)
+
+	if cfg.Export.RemoteWriteURL != "" {
+		remoteWriter := remotewrite.NewRemoteWriter(cfg.Export.RemoteWriteURL, cfg.Export.RemoteWriteInterval, exporter)
+		go remoteWriter.Run(ctx)
 	}
 
-	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
-		metricsHandler(w, r, regionManager)
+	authConfig := func() models.ParsedAuthConfig {
+		return exporter.current().cfg.Export.Auth
+	}
+
+	http.HandleFunc("/metrics", withAuth(func(w http.ResponseWriter, r *http.Request) {
+		state := exporter.current()
+		var apiCollector prometheus.Collector
+		if state.cfg.Export.Prometheus.AWSAPIMetrics {
+			apiCollector = collector.NewAPIMetricsCollector(apiMetrics, state.cfg.Export.Prometheus)
+		}
+		metricsHandler(w, r, state.regionManager, state.cfg.Export.MaxIdentifiers, state.cfg.Export.AllowRefresh, state.cfg.Export.MaxScrapeDuration, state.cfg.Export.ScrapeTimeoutOffset, state.health, apiCollector)
+	}, authConfig))
+	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		healthHandler(w, r, exporter.current().health)
 	})
 
-	log.Printf("[MAIN] Starting HTTP server on port %d", cfg.Export.Port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", cfg.Export.Port), nil))
+	if cfg.Export.Debug {
+		slog.Info("Debug endpoints enabled")
+		http.HandleFunc("/filter-debug", withAuth(func(w http.ResponseWriter, r *http.Request) {
+			filterDebugHandler(w, r, exporter.current().cfg)
+		}, authConfig))
+		http.HandleFunc("/metrics/excluded", withAuth(func(w http.ResponseWriter, r *http.Request) {
+			excludedMetricsHandler(w, r, exporter.current().regionManager)
+		}, authConfig))
+		http.HandleFunc("/metrics/stream", withAuth(func(w http.ResponseWriter, r *http.Request) {
+			state := exporter.current()
+			streamMetricsHandler(w, r, state.regionManager, state.cfg.Export.MaxScrapeDuration, state.cfg.Export.ScrapeTimeoutOffset)
+		}, authConfig))
+		http.HandleFunc("/debug/instances", withAuth(func(w http.ResponseWriter, r *http.Request) {
+			debugInstancesHandler(w, r, exporter.current().regionManager)
+		}, authConfig))
+		http.HandleFunc("/debug/metrics-catalog", withAuth(func(w http.ResponseWriter, r *http.Request) {
+			metricsCatalogHandler(w, r, exporter.current().regionManager)
+		}, authConfig))
+	}
+
+	server := &http.Server{Addr: cfg.Export.ListenAddress()}
+	serve := server.ListenAndServe
+	if cfg.Export.TLS.Enabled() {
+		slog.Info("Starting HTTPS server", "address", cfg.Export.ListenAddress())
+		serve = func() error {
+			return server.ListenAndServeTLS(cfg.Export.TLS.CertFile, cfg.Export.TLS.KeyFile)
+		}
+	} else {
+		slog.Info("Starting HTTP server", "address", cfg.Export.ListenAddress())
+	}
+
+	gracePeriod := func() time.Duration {
+		return exporter.current().cfg.Export.ShutdownGracePeriod
+	}
+	if err := runServer(ctx, server, serve, gracePeriod); err != nil {
+		slog.Error("Server error", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Server stopped")
+}
+
+// runServer serves until serve fails or the context is cancelled, then shuts the server down: it stops accepting
+// connections and gives in-flight requests the grace period to complete before their connections are closed.
+func runServer(ctx context.Context, server *http.Server, serve func() error, gracePeriod func() time.Duration) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- serve()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	period := gracePeriod()
+	slog.Info("Shutting down, waiting for in-flight requests to complete", "grace_period", period)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), period)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("in-flight requests did not complete within export.shutdown-grace-period of %v: %w", period, err)
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// withAuth wraps handler so requests without the credentials configured in export.auth are rejected with 401.
+// The credentials are read for every request so they follow config reloads; without export.auth requests are served as is.
+func withAuth(handler http.HandlerFunc, authConfig func() models.ParsedAuthConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		auth := authConfig()
+		if auth.Enabled() && !isAuthorized(r, auth) {
+			requestLogger(r).Warn("Unauthorized request", "remote_addr", r.RemoteAddr)
+			if auth.BearerToken != "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			} else {
+				w.Header().Set("WWW-Authenticate", `Basic realm="dbi-exporter"`)
+			}
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// isAuthorized checks the request's Authorization header against the bearer token or basic-auth credentials in
+// constant time.
+func isAuthorized(r *http.Request, auth models.ParsedAuthConfig) bool {
+	if auth.BearerToken != "" {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		return found && subtle.ConstantTimeCompare([]byte(token), []byte(auth.BearerToken)) == 1
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	passwordSHA256 := sha256.Sum256([]byte(password))
+	usernameMatches := subtle.ConstantTimeCompare([]byte(username), []byte(auth.Username)) == 1
+	passwordMatches := subtle.ConstantTimeCompare(passwordSHA256[:], auth.PasswordSHA256) == 1
+	return usernameMatches && passwordMatches
 }
 
-func metricsHandler(w http.ResponseWriter, r *http.Request, regionManager region.RegionManager) {
+// requestLogger returns a logger for the records of an HTTP request, with its method and path.
+func requestLogger(r *http.Request) *slog.Logger {
+	return slog.With("method", r.Method, "path", r.URL.Path)
+}
+
+// metricsHandler collects and serves the metrics of all instances, or of the instances in the identifiers query parameter,
+// which may name at most maxIdentifiers instances to prevent service overload.
+// When allowRefresh is set, the refresh query parameter makes the scrape discover instances (instances), list the metric
+// metadata of instances (metadata), or both (all), regardless of their TTLs. The caches of later scrapes are left as
+// they are.
+// Collection is cancelled once the scrape timeout from scrapeTimeout elapses and the metrics collected so far are served,
+// or when the client disconnects.
+// Scrapes of all instances are recorded in the health tracker, scrapes filtered by identifiers are not.
+// The AWS API call metrics of apiCollector, when set, are served with every scrape.
+func metricsHandler(w http.ResponseWriter, r *http.Request, regionManager region.RegionManager, maxIdentifiers int, allowRefresh bool, maxScrapeDuration, scrapeTimeoutOffset time.Duration, health *collector.HealthTracker, apiCollector prometheus.Collector) {
 	start := time.Now()
 
+	ctx := r.Context()
+	timeout := scrapeTimeout(r, maxScrapeDuration, scrapeTimeoutOffset)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	query := r.URL.Query()
 	instanceIdentifiers := query.Get("identifiers")
 
+	if refreshString := query.Get("refresh"); refreshString != "" {
+		if !allowRefresh {
+			requestLogger(r).Warn("Refresh requested but export.allow-refresh is disabled", "refresh", refreshString)
+			http.Error(w, "The refresh query parameter is disabled, enable it with export.allow-refresh", http.StatusForbidden)
+			return
+		}
+
+		refresh := models.NewScrapeRefresh(refreshString)
+		if refresh == models.ScrapeRefreshNone {
+			http.Error(w, fmt.Sprintf("Invalid refresh %s, must be one of: instances, metadata, all", refreshString), http.StatusBadRequest)
+			return
+		}
+
+		requestLogger(r).Info("Scraping with refreshed caches", "refresh", refresh)
+		ctx = models.ContextWithScrapeRefresh(ctx, refresh)
+	}
+
+	stats := models.NewScrapeStats()
+
 	var collectorInstance prometheus.Collector
 	if instanceIdentifiers != "" {
 		identifiers := strings.Split(instanceIdentifiers, ",")
@@ -56,25 +251,348 @@ func metricsHandler(w http.ResponseWriter, r *http.Request, regionManager region
 			identifiers[i] = strings.TrimSpace(id)
 		}
 
-		if len(identifiers) > MaxInstanceIdentifiers {
-			log.Printf("[HTTP] %s %s - Too many identifiers: %d (max: %d)", r.Method, r.URL.Path, len(identifiers), MaxInstanceIdentifiers)
-			http.Error(w, fmt.Sprintf("Too many instance identifiers provided. Maximum allowed: %d, provided: %d", MaxInstanceIdentifiers, len(identifiers)), http.StatusBadRequest)
+		if len(identifiers) > maxIdentifiers {
+			requestLogger(r).Warn("Too many identifiers", "identifiers", len(identifiers), "max_identifiers", maxIdentifiers)
+			http.Error(w, fmt.Sprintf("Too many instance identifiers provided. Maximum allowed: %d, provided: %d", maxIdentifiers, len(identifiers)), http.StatusBadRequest)
 			return
 		}
 
-		log.Printf("[HTTP] %s %s - Filtering for instance: %s", r.Method, r.URL.Path, instanceIdentifiers)
-		collectorInstance = collector.NewFilteredCollector(regionManager, identifiers)
+		requestLogger(r).Debug("Scraping selected instances", "identifiers", identifiers)
+		collectorInstance = collector.NewFilteredCollector(regionManager, identifiers, stats).WithContext(ctx)
 	} else {
-		log.Printf("[HTTP] %s %s - All instances", r.Method, r.URL.Path)
-		collectorInstance = collector.NewCollector(regionManager)
+		requestLogger(r).Debug("Scraping all instances")
+		collectorInstance = collector.NewCollector(regionManager, stats).WithContext(ctx).WithHealth(health)
 	}
 
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(collectorInstance)
+	if apiCollector != nil {
+		registry.MustRegister(apiCollector)
+	}
 
 	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
 	handler.ServeHTTP(w, r)
 
-	duration := time.Since(start)
-	log.Printf("[HTTP] %s %s - Completed in %v", r.Method, r.URL.Path, duration)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		requestLogger(r).Warn("Scrape exceeded its timeout and was cancelled, served the metrics collected so far", "timeout", timeout)
+	} else if errors.Is(ctx.Err(), context.Canceled) {
+		requestLogger(r).Warn("Client disconnected, scrape was cancelled")
+	}
+
+	requestLogger(r).Info("Scrape summary", "stats", stats, "duration", time.Since(start))
+}
+
+// scrapeTimeout returns the duration after which a scrape is cancelled: the Prometheus scrape timeout from the
+// X-Prometheus-Scrape-Timeout-Seconds header minus offset, capped at maxScrapeDuration. Without the header it is
+// maxScrapeDuration; 0 leaves the scrape unbounded.
+func scrapeTimeout(r *http.Request, maxScrapeDuration, offset time.Duration) time.Duration {
+	header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if header == "" {
+		return maxScrapeDuration
+	}
+
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil || seconds <= 0 {
+		requestLogger(r).Warn("Ignoring invalid X-Prometheus-Scrape-Timeout-Seconds header", "header", header)
+		return maxScrapeDuration
+	}
+
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > offset {
+		timeout -= offset
+	}
+	if maxScrapeDuration > 0 && maxScrapeDuration < timeout {
+		return maxScrapeDuration
+	}
+	return timeout
+}
+
+// healthHandler reports the health decided from the last full scrape as JSON, with status 503 while the fraction of
+// instances that failed collection exceeds export.unhealthy-threshold. Without a threshold the exporter is always healthy.
+func healthHandler(w http.ResponseWriter, r *http.Request, health *collector.HealthTracker) {
+	status := health.Status()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Healthy {
+		requestLogger(r).Warn("Unhealthy, instances failed collection", "failed_instances", status.FailedInstances, "instances", status.Instances)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		requestLogger(r).Error("Error encoding health status", "error", err)
+	}
+}
+
+// filterDebugResult is the filter decision for a single instance or metric along with the values that were matched.
+type filterDebugResult struct {
+	Fields map[string]string `json:"fields"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	filter.Decision
+	Reason string `json:"reason"`
+}
+
+type filterDebugResponse struct {
+	Instance *filterDebugResult `json:"instance,omitempty"`
+	Metric   *filterDebugResult `json:"metric,omitempty"`
+}
+
+// filterDebugHandler runs the configured instance and metric filters against the values in the query and returns
+// the decision, the matching patterns and a human-readable reason as JSON. Instances are described with identifier, engine, class, status, storage_type,
+// encrypted, pi_retention, subnet_group, vpc_id, cluster and tag.<Key> parameters, metrics with metric (with or without a statistic suffix) and unit parameters.
+func filterDebugHandler(w http.ResponseWriter, r *http.Request, cfg *models.ParsedConfig) {
+	query := r.URL.Query()
+	identifier := query.Get("identifier")
+	metricName := query.Get("metric")
+
+	if identifier == "" && metricName == "" {
+		requestLogger(r).Warn("Missing identifier and metric parameters")
+		http.Error(w, "At least one of the identifier or metric query parameters is required", http.StatusBadRequest)
+		return
+	}
+
+	var response filterDebugResponse
+	if identifier != "" {
+		piRetention, _ := strconv.Atoi(query.Get("pi_retention"))
+		instance := models.Instance{
+			Identifier: identifier,
+			Engine:     models.Engine(query.Get("engine")),
+			Status:     query.Get("status"),
+			Tags:       make(map[string]string),
+
+			StorageEncrypted: query.Get("encrypted") == "true",
+			StorageType:      query.Get("storage_type"),
+
+			PIRetentionPeriod: int32(piRetention),
+			SubnetGroup:       query.Get("subnet_group"),
+			VpcID:             query.Get("vpc_id"),
+			ClusterIdentifier: query.Get("cluster"),
+			InstanceClass:     query.Get("class"),
+		}
+		for key, values := range query {
+			if strings.HasPrefix(key, filter.TagPrefix) && len(values) > 0 {
+				instance.Tags[strings.TrimPrefix(key, filter.TagPrefix)] = values[0]
+			}
+		}
+
+		decision := cfg.Discovery.Instances.EvaluateInstance(instance)
+		response.Instance = &filterDebugResult{
+			Fields:   instance.GetFilterableFields(),
+			Tags:     instance.GetFilterableTags(),
+			Decision: decision,
+			Reason:   decision.Reason(),
+		}
+	}
+
+	if metricName != "" {
+		if trimmed := utils.TrimStatisticFromMetricName(metricName); trimmed != "" {
+			metricName = trimmed
+		}
+		metricDetails := models.MetricDetails{
+			Name: metricName,
+			Unit: query.Get("unit"),
+		}
+
+		decision := cfg.Discovery.Metrics.EvaluateMetric(metricDetails)
+		response.Metric = &filterDebugResult{
+			Fields:   metricDetails.GetFilterableFields(),
+			Decision: decision,
+			Reason:   decision.Reason(),
+		}
+	}
+
+	requestLogger(r).Info("Filter debug", "identifier", identifier, "metric", metricName)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		requestLogger(r).Error("Error encoding filter debug response", "error", err)
+	}
+}
+
+type excludedMetricsResponse struct {
+	Identifier string                  `json:"identifier"`
+	Excluded   []models.ExcludedMetric `json:"excluded"`
+}
+
+// excludedMetricsHandler returns the metrics Performance Insights reports as available for the instance that the
+// exporter does not collect, each annotated with the reason it was filtered out.
+func excludedMetricsHandler(w http.ResponseWriter, r *http.Request, regionManager region.RegionManager) {
+	identifier := r.URL.Query().Get("identifier")
+	if identifier == "" {
+		requestLogger(r).Warn("Missing identifier parameter")
+		http.Error(w, "The identifier query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	excluded, err := regionManager.ExplainExcludedMetrics(r.Context(), identifier)
+	if errors.Is(err, region.ErrInstanceNotFound) {
+		requestLogger(r).Warn("Instance not found", "identifier", identifier)
+		http.Error(w, fmt.Sprintf("Instance %s not found", identifier), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		requestLogger(r).Error("Error listing excluded metrics", "identifier", identifier, "error", err)
+		http.Error(w, "Error listing excluded metrics", http.StatusInternalServerError)
+		return
+	}
+
+	requestLogger(r).Info("Excluded metrics", "identifier", identifier, "count", len(excluded))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(excludedMetricsResponse{Identifier: identifier, Excluded: excluded}); err != nil {
+		requestLogger(r).Error("Error encoding excluded metrics response", "error", err)
+	}
+}
+
+type metricsCatalogResponse struct {
+	Identifier string                          `json:"identifier"`
+	Metrics    map[string]models.CatalogMetric `json:"metrics"`
+}
+
+// metricsCatalogHandler returns every metric Performance Insights reports as available for the instance, with its
+// description, unit and category, to help write metrics.include and metrics.exclude patterns.
+func metricsCatalogHandler(w http.ResponseWriter, r *http.Request, regionManager region.RegionManager) {
+	identifier := r.URL.Query().Get("identifier")
+	if identifier == "" {
+		requestLogger(r).Warn("Missing identifier parameter")
+		http.Error(w, "The identifier query parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	catalog, err := regionManager.GetMetricCatalog(r.Context(), identifier)
+	if errors.Is(err, region.ErrInstanceNotFound) {
+		requestLogger(r).Warn("Instance not found", "identifier", identifier)
+		http.Error(w, fmt.Sprintf("Instance %s not found", identifier), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		requestLogger(r).Error("Error listing the metric catalog", "identifier", identifier, "error", err)
+		http.Error(w, "Error listing the metric catalog", http.StatusInternalServerError)
+		return
+	}
+
+	requestLogger(r).Info("Metric catalog", "identifier", identifier, "count", len(catalog))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(metricsCatalogResponse{Identifier: identifier, Metrics: catalog}); err != nil {
+		requestLogger(r).Error("Error encoding metric catalog response", "error", err)
+	}
+}
+
+type debugInstancesResponse struct {
+	Instances []models.InstanceDecision `json:"instances"`
+	Error     string                    `json:"error,omitempty"`
+}
+
+// debugInstancesHandler lists the discovered instances of every region with the decision of the instance filters.
+// When some regions fail, the instances of the other regions are returned along with the error.
+func debugInstancesHandler(w http.ResponseWriter, r *http.Request, regionManager region.RegionManager) {
+	decisions, err := regionManager.ExplainInstances(r.Context())
+	if err != nil && len(decisions) == 0 {
+		requestLogger(r).Error("Error listing instances", "error", err)
+		http.Error(w, "Error listing instances", http.StatusInternalServerError)
+		return
+	}
+
+	response := debugInstancesResponse{Instances: decisions}
+	if response.Instances == nil {
+		response.Instances = []models.InstanceDecision{}
+	}
+	if err != nil {
+		requestLogger(r).Warn("Partial instance list", "error", err)
+		response.Error = err.Error()
+	}
+
+	requestLogger(r).Info("Listed instances", "count", len(decisions))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		requestLogger(r).Error("Error encoding instances response", "error", err)
+	}
+}
+
+// streamMetricsHandler runs a full scrape and streams the completion of every instance as Server-Sent Events,
+// so the progress of a slow scrape can be followed interactively. The stream ends with a metrics event carrying
+// the text exposition of the scrape. Like metricsHandler, collection is cancelled once the scrape timeout from
+// scrapeTimeout elapses or when the client disconnects.
+func streamMetricsHandler(w http.ResponseWriter, r *http.Request, regionManager region.RegionManager, maxScrapeDuration, scrapeTimeoutOffset time.Duration) {
+	start := time.Now()
+
+	ctx := r.Context()
+	timeout := scrapeTimeout(r, maxScrapeDuration, scrapeTimeoutOffset)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		requestLogger(r).Error("Streaming is not supported by the connection")
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Progress is reported from collection workers, so events are handed to this goroutine, the only one writing the response
+	events := make(chan models.InstanceProgress)
+	progress := models.NewScrapeProgress(func(event models.InstanceProgress) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	})
+
+	stats := models.NewScrapeStats()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector.NewCollector(regionManager, stats).WithContext(ctx).WithProgress(progress))
+
+	var families []*dto.MetricFamily
+	var gatherErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		families, gatherErr = registry.Gather()
+	}()
+
+	requestLogger(r).Info("Streaming scrape progress")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	for {
+		select {
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				requestLogger(r).Error("Error encoding progress event", "error", err)
+				continue
+			}
+			writeServerSentEvent(w, "progress", string(data))
+			flusher.Flush()
+		case <-done:
+			if gatherErr != nil {
+				writeServerSentEvent(w, "error", gatherErr.Error())
+			}
+
+			var exposition bytes.Buffer
+			for _, family := range families {
+				if _, err := expfmt.MetricFamilyToText(&exposition, family); err != nil {
+					requestLogger(r).Error("Error encoding metric family", "metric", family.GetName(), "error", err)
+				}
+			}
+			writeServerSentEvent(w, "metrics", exposition.String())
+			flusher.Flush()
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				requestLogger(r).Warn("Scrape exceeded its timeout and was cancelled, streamed the metrics collected so far", "timeout", timeout)
+			}
+			requestLogger(r).Info("Scrape summary", "stats", stats, "duration", time.Since(start))
+			return
+		case <-r.Context().Done():
+			requestLogger(r).Warn("Client disconnected before the scrape finished")
+			return
+		}
+	}
+}
+
+// writeServerSentEvent writes a single event, splitting multi-line data across data fields as the SSE format requires.
+func writeServerSentEvent(w http.ResponseWriter, event string, data string) {
+	fmt.Fprintf(w, "event: %s\n", event)
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
 }
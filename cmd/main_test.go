@@ -1,14 +1,30 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/collector"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/filter"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/region"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/utils"
 )
 
 func TestMetricsHandler(t *testing.T) {
@@ -87,10 +103,864 @@ func TestMetricsHandler(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/metrics"+tc.queryParams, nil)
 			recorder := httptest.NewRecorder()
 
-			metricsHandler(recorder, req, mockRM)
+			metricsHandler(recorder, req, mockRM, utils.DefaultMaxInstanceIdentifiers, false, 0, 0, nil, nil)
 
 			assert.Equal(t, tc.expectedStatusCode, recorder.Code)
 			mockRM.AssertExpectations(t)
 		})
 	}
 }
+
+func TestMetricsHandlerMaxIdentifiers(t *testing.T) {
+	identifiers := []string{"test-db-1", "test-db-2", "test-db-3", "test-db-4", "test-db-5", "test-db-6"}
+
+	t.Run("identifiers within a raised limit are collected", func(t *testing.T) {
+		mockRM := &mocks.MockRegionManager{}
+		mockRM.On("CollectMetricsForInstances", mock.Anything, identifiers, mock.Anything).Return(nil)
+
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/metrics?identifiers=test-db-1,test-db-2,test-db-3,test-db-4,test-db-5,test-db-6", nil)
+		metricsHandler(recorder, req, mockRM, 10, false, 0, 0, nil, nil)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockRM.AssertExpectations(t)
+	})
+
+	t.Run("identifiers above a lowered limit are rejected", func(t *testing.T) {
+		mockRM := &mocks.MockRegionManager{}
+
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/metrics?identifiers=test-db-1,test-db-2", nil)
+		metricsHandler(recorder, req, mockRM, 1, false, 0, 0, nil, nil)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "Maximum allowed: 1, provided: 2")
+		mockRM.AssertNotCalled(t, "CollectMetricsForInstances", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestMetricsHandlerMaxScrapeDuration(t *testing.T) {
+	mockRM := &mocks.MockRegionManager{}
+	mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			ch := args.Get(1).(chan<- prometheus.Metric)
+
+			desc := prometheus.NewDesc("dbi_os_general_numvcpus_avg", "The number of virtual CPUs", nil, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 4)
+			<-ctx.Done()
+		}).
+		Return(context.DeadlineExceeded)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	recorder := httptest.NewRecorder()
+
+	metricsHandler(recorder, req, mockRM, utils.DefaultMaxInstanceIdentifiers, false, 10*time.Millisecond, 0, nil, nil)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "dbi_os_general_numvcpus_avg 4")
+	mockRM.AssertExpectations(t)
+}
+
+func TestMetricsHandlerAPIMetrics(t *testing.T) {
+	mockRM := &mocks.MockRegionManager{}
+	mockRM.On("CollectMetrics", mock.Anything, mock.Anything).Return(nil)
+
+	apiMetrics := clients.NewAPIMetrics()
+	apiMetrics.Record("PI", "GetResourceMetrics", nil, 100*time.Millisecond)
+	apiCollector := collector.NewAPIMetricsCollector(apiMetrics, testutils.TestPrometheusConfig)
+
+	recorder := httptest.NewRecorder()
+	metricsHandler(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil), mockRM, utils.DefaultMaxInstanceIdentifiers, false, 0, 0, nil, apiCollector)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `dbi_aws_api_calls_total{operation="GetResourceMetrics",result="success",service="PI"} 1`)
+	assert.Contains(t, recorder.Body.String(), `dbi_aws_api_call_duration_seconds_count{operation="GetResourceMetrics",service="PI"} 1`)
+}
+
+func TestMetricsHandlerRefresh(t *testing.T) {
+	t.Run("refresh is carried to the collection", func(t *testing.T) {
+		var refresh models.ScrapeRefresh
+		mockRM := &mocks.MockRegionManager{}
+		mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				refresh = models.ScrapeRefreshFromContext(args.Get(0).(context.Context))
+			}).
+			Return(nil)
+
+		recorder := httptest.NewRecorder()
+		metricsHandler(recorder, httptest.NewRequest(http.MethodGet, "/metrics?refresh=all", nil), mockRM, utils.DefaultMaxInstanceIdentifiers, true, 0, 0, nil, nil)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, models.ScrapeRefreshAll, refresh)
+	})
+
+	t.Run("refresh is forbidden unless allowed", func(t *testing.T) {
+		mockRM := &mocks.MockRegionManager{}
+
+		recorder := httptest.NewRecorder()
+		metricsHandler(recorder, httptest.NewRequest(http.MethodGet, "/metrics?refresh=instances", nil), mockRM, utils.DefaultMaxInstanceIdentifiers, false, 0, 0, nil, nil)
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		mockRM.AssertNotCalled(t, "CollectMetrics", mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid refresh is rejected", func(t *testing.T) {
+		mockRM := &mocks.MockRegionManager{}
+
+		recorder := httptest.NewRecorder()
+		metricsHandler(recorder, httptest.NewRequest(http.MethodGet, "/metrics?refresh=everything", nil), mockRM, utils.DefaultMaxInstanceIdentifiers, true, 0, 0, nil, nil)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockRM.AssertNotCalled(t, "CollectMetrics", mock.Anything, mock.Anything)
+	})
+}
+
+func TestMetricsHandlerScrapeTimeoutHeader(t *testing.T) {
+	mockRM := &mocks.MockRegionManager{}
+	mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			ch := args.Get(1).(chan<- prometheus.Metric)
+
+			desc := prometheus.NewDesc("dbi_os_general_numvcpus_avg", "The number of virtual CPUs", nil, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 4)
+			<-ctx.Done()
+		}).
+		Return(context.DeadlineExceeded)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", "0.01")
+	recorder := httptest.NewRecorder()
+
+	metricsHandler(recorder, req, mockRM, utils.DefaultMaxInstanceIdentifiers, false, 0, 0, nil, nil)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "dbi_os_general_numvcpus_avg 4")
+	mockRM.AssertExpectations(t)
+}
+
+func TestMetricsHandlerClientDisconnect(t *testing.T) {
+	mockRM := &mocks.MockRegionManager{}
+	mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			<-ctx.Done()
+		}).
+		Return(context.Canceled)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil).WithContext(ctx)
+	recorder := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		metricsHandler(recorder, req, mockRM, utils.DefaultMaxInstanceIdentifiers, false, 0, 0, nil, nil)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scrape was not cancelled when the client disconnected")
+	}
+	mockRM.AssertExpectations(t)
+}
+
+func TestScrapeTimeout(t *testing.T) {
+	offset := 500 * time.Millisecond
+
+	testCases := []struct {
+		name              string
+		header            string
+		maxScrapeDuration time.Duration
+		offset            time.Duration
+		expected          time.Duration
+	}{
+		{
+			name:     "no header and no max-scrape-duration is unbounded",
+			expected: 0,
+		},
+		{
+			name:              "no header uses max-scrape-duration",
+			maxScrapeDuration: time.Minute,
+			expected:          time.Minute,
+		},
+		{
+			name:     "header minus offset",
+			header:   "10",
+			offset:   offset,
+			expected: 10*time.Second - offset,
+		},
+		{
+			name:     "fractional header",
+			header:   "2.5",
+			offset:   offset,
+			expected: 2 * time.Second,
+		},
+		{
+			name:     "header without offset",
+			header:   "10",
+			offset:   0,
+			expected: 10 * time.Second,
+		},
+		{
+			name:     "custom offset",
+			header:   "10",
+			offset:   2 * time.Second,
+			expected: 8 * time.Second,
+		},
+		{
+			name:     "header shorter than offset is used as is",
+			header:   "0.2",
+			offset:   offset,
+			expected: 200 * time.Millisecond,
+		},
+		{
+			name:              "max-scrape-duration caps header",
+			header:            "30",
+			maxScrapeDuration: 10 * time.Second,
+			offset:            offset,
+			expected:          10 * time.Second,
+		},
+		{
+			name:              "header shorter than max-scrape-duration",
+			header:            "10",
+			maxScrapeDuration: time.Minute,
+			offset:            offset,
+			expected:          10*time.Second - offset,
+		},
+		{
+			name:              "invalid header uses max-scrape-duration",
+			header:            "soon",
+			maxScrapeDuration: time.Minute,
+			expected:          time.Minute,
+		},
+		{
+			name:     "non-positive header is ignored",
+			header:   "0",
+			expected: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.header != "" {
+				req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", tc.header)
+			}
+			assert.Equal(t, tc.expected, scrapeTimeout(req, tc.maxScrapeDuration, tc.offset))
+		})
+	}
+}
+
+func TestWithAuth(t *testing.T) {
+	passwordSHA256 := sha256.Sum256([]byte("secret"))
+	bearerAuth := models.ParsedAuthConfig{BearerToken: "token"}
+	basicAuth := models.ParsedAuthConfig{Username: "prometheus", PasswordSHA256: passwordSHA256[:]}
+
+	testCases := []struct {
+		name               string
+		auth               models.ParsedAuthConfig
+		setAuthorization   func(req *http.Request)
+		expectedStatusCode int
+	}{
+		{
+			name:               "no auth configured",
+			auth:               models.ParsedAuthConfig{},
+			setAuthorization:   func(req *http.Request) {},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "valid bearer token",
+			auth:               bearerAuth,
+			setAuthorization:   func(req *http.Request) { req.Header.Set("Authorization", "Bearer token") },
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "wrong bearer token",
+			auth:               bearerAuth,
+			setAuthorization:   func(req *http.Request) { req.Header.Set("Authorization", "Bearer other") },
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "missing bearer token",
+			auth:               bearerAuth,
+			setAuthorization:   func(req *http.Request) {},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "basic auth credentials for bearer token",
+			auth:               bearerAuth,
+			setAuthorization:   func(req *http.Request) { req.SetBasicAuth("prometheus", "token") },
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "valid basic auth",
+			auth:               basicAuth,
+			setAuthorization:   func(req *http.Request) { req.SetBasicAuth("prometheus", "secret") },
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "wrong basic auth password",
+			auth:               basicAuth,
+			setAuthorization:   func(req *http.Request) { req.SetBasicAuth("prometheus", "wrong") },
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "wrong basic auth username",
+			auth:               basicAuth,
+			setAuthorization:   func(req *http.Request) { req.SetBasicAuth("grafana", "secret") },
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "missing basic auth",
+			auth:               basicAuth,
+			setAuthorization:   func(req *http.Request) {},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			served := false
+			handler := withAuth(func(w http.ResponseWriter, r *http.Request) {
+				served = true
+			}, func() models.ParsedAuthConfig { return tc.auth })
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			tc.setAuthorization(req)
+			recorder := httptest.NewRecorder()
+
+			handler(recorder, req)
+
+			assert.Equal(t, tc.expectedStatusCode, recorder.Code)
+			assert.Equal(t, tc.expectedStatusCode == http.StatusOK, served)
+			if tc.expectedStatusCode == http.StatusUnauthorized {
+				assert.NotEmpty(t, recorder.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestRunServer(t *testing.T) {
+	testCases := []struct {
+		name            string
+		gracePeriod     time.Duration
+		completeRequest bool
+		expectedError   bool
+	}{
+		{
+			name:            "in-flight request completes within the grace period",
+			gracePeriod:     5 * time.Second,
+			completeRequest: true,
+			expectedError:   false,
+		},
+		{
+			name:            "in-flight request exceeding the grace period is closed",
+			gracePeriod:     50 * time.Millisecond,
+			completeRequest: false,
+			expectedError:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			started := make(chan struct{})
+			release := make(chan struct{})
+			defer close(release)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				if tc.completeRequest {
+					time.Sleep(100 * time.Millisecond)
+				} else {
+					<-release
+				}
+				w.WriteHeader(http.StatusOK)
+			})}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			serverErr := make(chan error, 1)
+			go func() {
+				serverErr <- runServer(ctx, server, func() error { return server.Serve(listener) },
+					func() time.Duration { return tc.gracePeriod })
+			}()
+
+			type result struct {
+				status int
+				err    error
+			}
+			responses := make(chan result, 1)
+			go func() {
+				resp, err := http.Get("http://" + listener.Addr().String())
+				if err != nil {
+					responses <- result{err: err}
+					return
+				}
+				resp.Body.Close()
+				responses <- result{status: resp.StatusCode}
+			}()
+
+			<-started
+			cancel()
+
+			response := <-responses
+			if tc.expectedError {
+				assert.Error(t, <-serverErr)
+				assert.Error(t, response.err)
+			} else {
+				assert.NoError(t, <-serverErr)
+				require.NoError(t, response.err)
+				assert.Equal(t, http.StatusOK, response.status)
+			}
+		})
+	}
+}
+
+func TestRunServerServeError(t *testing.T) {
+	server := &http.Server{}
+	err := runServer(context.Background(), server, func() error { return assert.AnError },
+		func() time.Duration { return time.Second })
+
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestHealthHandler(t *testing.T) {
+	testCases := []struct {
+		name               string
+		threshold          float64
+		failedInstances    int
+		expectedStatusCode int
+		expectedHealthy    bool
+	}{
+		{
+			name:               "healthy scrape",
+			threshold:          0.5,
+			failedInstances:    1,
+			expectedStatusCode: http.StatusOK,
+			expectedHealthy:    true,
+		},
+		{
+			name:               "failed fraction above the threshold",
+			threshold:          0.5,
+			failedInstances:    3,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedHealthy:    false,
+		},
+		{
+			name:               "threshold disabled",
+			threshold:          0,
+			failedInstances:    4,
+			expectedStatusCode: http.StatusOK,
+			expectedHealthy:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRM := &mocks.MockRegionManager{}
+			mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					stats := models.ScrapeStatsFromContext(args.Get(0).(context.Context))
+					stats.AddInstancesDiscovered(4)
+					stats.AddInstancesFailed(tc.failedInstances)
+				}).
+				Return(nil)
+
+			health := collector.NewHealthTracker(tc.threshold, testutils.TestPrometheusConfig)
+			metricsHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil), mockRM, utils.DefaultMaxInstanceIdentifiers, false, 0, 0, health, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+			recorder := httptest.NewRecorder()
+
+			healthHandler(recorder, req, health)
+
+			assert.Equal(t, tc.expectedStatusCode, recorder.Code)
+			var status collector.HealthStatus
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+			assert.Equal(t, tc.expectedHealthy, status.Healthy)
+			mockRM.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFilterDebugHandler(t *testing.T) {
+	cfg := testutils.CreateDefaultParsedTestConfig()
+	cfg.Discovery.Instances.Filter = filter.NewPatternFilter(
+		filter.Patterns{"tag.Environment": {regexp.MustCompile("^production$")}},
+		filter.Patterns{"identifier": {regexp.MustCompile("-temp-")}},
+	)
+	cfg.Discovery.Metrics.Filter = filter.NewPatternFilter(
+		nil,
+		filter.Patterns{"name": {regexp.MustCompile(`\.idle$`)}},
+	)
+
+	testCases := []struct {
+		name               string
+		queryParams        string
+		expectedStatusCode int
+		expected           filterDebugResponse
+	}{
+		{
+			name:               "missing parameters",
+			queryParams:        "",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "included instance",
+			queryParams:        "?identifier=prod-db&engine=postgres&class=db.r6g.large&status=available&storage_type=gp3&encrypted=true&subnet_group=prod-private&vpc_id=vpc-0abc1234&cluster=prod-aurora&tag.Environment=production",
+			expectedStatusCode: http.StatusOK,
+			expected: filterDebugResponse{
+				Instance: &filterDebugResult{
+					Fields: map[string]string{"identifier": "prod-db", "engine": "postgres", "class": "db.r6g.large", "status": "available", "encrypted": "true", "storage_type": "gp3", "pi_retention": "0", "subnet_group": "prod-private", "vpc_id": "vpc-0abc1234", "cluster": "prod-aurora"},
+					Tags:   map[string]string{"Environment": "production"},
+					Decision: filter.Decision{
+						Included:       true,
+						IncludeMatches: []filter.PatternMatch{{Field: "tag.Environment", Pattern: "^production$"}},
+					},
+					Reason: "included: field 'tag.Environment' matched include pattern '^production$'",
+				},
+			},
+		},
+		{
+			name:               "excluded instance and metric",
+			queryParams:        "?identifier=prod-temp-db&metric=os.cpuUtilization.idle.avg",
+			expectedStatusCode: http.StatusOK,
+			expected: filterDebugResponse{
+				Instance: &filterDebugResult{
+					Fields: map[string]string{"identifier": "prod-temp-db", "engine": "", "class": "", "status": "", "encrypted": "false", "storage_type": "", "pi_retention": "0", "subnet_group": "", "vpc_id": "", "cluster": ""},
+					Decision: filter.Decision{
+						Included:       false,
+						ExcludeMatches: []filter.PatternMatch{{Field: "identifier", Pattern: "-temp-"}},
+						IncludeMisses:  []string{"tag.Environment"},
+					},
+					Reason: "excluded: field 'identifier' matched exclude pattern '-temp-'; field 'tag.Environment' matched no include pattern",
+				},
+				Metric: &filterDebugResult{
+					Fields: map[string]string{"name": "os.cpuUtilization.idle", "category": "os", "unit": ""},
+					Decision: filter.Decision{
+						Included:       false,
+						ExcludeMatches: []filter.PatternMatch{{Field: "name", Pattern: `\.idle$`}},
+					},
+					Reason: `excluded: field 'name' matched exclude pattern '\.idle$'`,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/filter-debug"+tc.queryParams, nil)
+			recorder := httptest.NewRecorder()
+
+			filterDebugHandler(recorder, req, cfg)
+
+			assert.Equal(t, tc.expectedStatusCode, recorder.Code)
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var response filterDebugResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, tc.expected, response)
+		})
+	}
+}
+
+func TestFilterDebugHandlerIdentifiersAllowlist(t *testing.T) {
+	cfg := testutils.CreateDefaultParsedTestConfig()
+	cfg.Discovery.Instances.Identifiers = []string{"prod-db"}
+
+	req := httptest.NewRequest(http.MethodGet, "/filter-debug?identifier=staging-db", nil)
+	recorder := httptest.NewRecorder()
+
+	filterDebugHandler(recorder, req, cfg)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var response filterDebugResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.NotNil(t, response.Instance)
+	assert.False(t, response.Instance.Included)
+	assert.Equal(t, []string{models.IdentifierNotAllowedReason}, response.Instance.ExcludeReasons)
+	assert.Equal(t, "excluded: identifier not in instances.identifiers", response.Instance.Reason)
+}
+
+func TestExcludedMetricsHandler(t *testing.T) {
+	excluded := []models.ExcludedMetric{
+		{
+			Name:     "os.cpuUtilization.idle",
+			Category: "os",
+			Unit:     "Percent",
+			Reason:   models.ExclusionReasonExcludePattern,
+			Decision: filter.Decision{ExcludeMatches: []filter.PatternMatch{{Field: "name", Pattern: `\.idle$`}}},
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		queryParams        string
+		explainResult      []models.ExcludedMetric
+		explainError       error
+		expectedStatusCode int
+	}{
+		{
+			name:               "missing identifier",
+			queryParams:        "",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "excluded metrics",
+			queryParams:        "?identifier=prod-db",
+			explainResult:      excluded,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "instance not found",
+			queryParams:        "?identifier=prod-db",
+			explainError:       region.ErrInstanceNotFound,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "explain error",
+			queryParams:        "?identifier=prod-db",
+			explainError:       errors.New("ListAvailableResourceMetrics failed"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRegionManager := &mocks.MockRegionManager{}
+			if tc.queryParams != "" {
+				mockRegionManager.On("ExplainExcludedMetrics", mock.Anything, "prod-db").Return(tc.explainResult, tc.explainError)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics/excluded"+tc.queryParams, nil)
+			recorder := httptest.NewRecorder()
+
+			excludedMetricsHandler(recorder, req, mockRegionManager)
+
+			assert.Equal(t, tc.expectedStatusCode, recorder.Code)
+			mockRegionManager.AssertExpectations(t)
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var response excludedMetricsResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, excludedMetricsResponse{Identifier: "prod-db", Excluded: tc.explainResult}, response)
+		})
+	}
+}
+
+func TestMetricsCatalogHandler(t *testing.T) {
+	catalog := map[string]models.CatalogMetric{
+		"os.cpuUtilization.idle":  {Name: "os.cpuUtilization.idle", Description: "The percentage of CPU that is idle", Unit: "Percent", Category: "os"},
+		"db.User.max_connections": {Name: "db.User.max_connections", Description: "The maximum number of connections", Unit: "Connections", Category: "db"},
+	}
+
+	testCases := []struct {
+		name               string
+		queryParams        string
+		catalogResult      map[string]models.CatalogMetric
+		catalogError       error
+		expectedStatusCode int
+	}{
+		{
+			name:               "missing identifier",
+			queryParams:        "",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "metric catalog",
+			queryParams:        "?identifier=prod-db",
+			catalogResult:      catalog,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "instance not found",
+			queryParams:        "?identifier=prod-db",
+			catalogError:       region.ErrInstanceNotFound,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "catalog error",
+			queryParams:        "?identifier=prod-db",
+			catalogError:       errors.New("ListAvailableResourceMetrics failed"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRegionManager := &mocks.MockRegionManager{}
+			if tc.queryParams != "" {
+				mockRegionManager.On("GetMetricCatalog", mock.Anything, "prod-db").Return(tc.catalogResult, tc.catalogError)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/debug/metrics-catalog"+tc.queryParams, nil)
+			recorder := httptest.NewRecorder()
+
+			metricsCatalogHandler(recorder, req, mockRegionManager)
+
+			assert.Equal(t, tc.expectedStatusCode, recorder.Code)
+			mockRegionManager.AssertExpectations(t)
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var response metricsCatalogResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, metricsCatalogResponse{Identifier: "prod-db", Metrics: tc.catalogResult}, response)
+		})
+	}
+}
+
+func TestDebugInstancesHandler(t *testing.T) {
+	decisions := []models.InstanceDecision{
+		{
+			Identifier: "prod-db",
+			Engine:     models.PostgreSQL,
+			ResourceID: "db-PROD",
+			Region:     "us-east-1",
+			Reason:     "included: no pattern excludes it",
+			Decision:   filter.Decision{Included: true},
+		},
+		{
+			Identifier: "test-db",
+			Engine:     models.MySQL,
+			ResourceID: "db-TEST",
+			Region:     "us-east-1",
+			Reason:     models.IdentifierNotAllowedReason,
+			Decision:   filter.Decision{ExcludeReasons: []string{models.IdentifierNotAllowedReason}},
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		explainResult      []models.InstanceDecision
+		explainError       error
+		expectedStatusCode int
+		expectedResponse   debugInstancesResponse
+	}{
+		{
+			name:               "instances with decisions",
+			explainResult:      decisions,
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   debugInstancesResponse{Instances: decisions},
+		},
+		{
+			name:               "no instances",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   debugInstancesResponse{Instances: []models.InstanceDecision{}},
+		},
+		{
+			name:               "partial failure keeps the other regions",
+			explainResult:      decisions,
+			explainError:       errors.New("region eu-west-1: DescribeDBInstances failed"),
+			expectedStatusCode: http.StatusOK,
+			expectedResponse:   debugInstancesResponse{Instances: decisions, Error: "region eu-west-1: DescribeDBInstances failed"},
+		},
+		{
+			name:               "every region failed",
+			explainError:       errors.New("region us-east-1: DescribeDBInstances failed"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRegionManager := &mocks.MockRegionManager{}
+			mockRegionManager.On("ExplainInstances", mock.Anything).Return(tc.explainResult, tc.explainError)
+
+			req := httptest.NewRequest(http.MethodGet, "/debug/instances", nil)
+			recorder := httptest.NewRecorder()
+
+			debugInstancesHandler(recorder, req, mockRegionManager)
+
+			assert.Equal(t, tc.expectedStatusCode, recorder.Code)
+			mockRegionManager.AssertExpectations(t)
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var response debugInstancesResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, tc.expectedResponse, response)
+		})
+	}
+}
+
+func TestDebugInstancesRequiresAuth(t *testing.T) {
+	mockRegionManager := &mocks.MockRegionManager{}
+	authConfig := func() models.ParsedAuthConfig {
+		return models.ParsedAuthConfig{BearerToken: "secret"}
+	}
+	handler := withAuth(func(w http.ResponseWriter, r *http.Request) {
+		debugInstancesHandler(w, r, mockRegionManager)
+	}, authConfig)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/instances", nil)
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	mockRegionManager.AssertNotCalled(t, "ExplainInstances", mock.Anything)
+}
+
+func TestStreamMetricsHandler(t *testing.T) {
+	mockRM := &mocks.MockRegionManager{}
+	mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			ch := args.Get(1).(chan<- prometheus.Metric)
+
+			models.ScrapeProgressFromContext(ctx).InstanceCompleted(models.InstanceProgress{Region: "us-west-2", Identifier: "prod-db", Batches: 2})
+			desc := prometheus.NewDesc("dbi_os_general_numvcpus_avg", "The number of virtual CPUs", nil, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 4)
+		}).
+		Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics/stream", nil)
+	recorder := httptest.NewRecorder()
+
+	streamMetricsHandler(recorder, req, mockRM, 0, 0)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "event: progress\n"+
+		`data: {"region":"us-west-2","identifier":"prod-db","batches":2}`+"\n\n"+
+		"event: metrics\n"+
+		"data: # HELP dbi_os_general_numvcpus_avg The number of virtual CPUs\n"+
+		"data: # TYPE dbi_os_general_numvcpus_avg gauge\n"+
+		"data: dbi_os_general_numvcpus_avg 4\n\n", recorder.Body.String())
+	mockRM.AssertExpectations(t)
+}
+
+func TestStreamMetricsHandlerMaxScrapeDuration(t *testing.T) {
+	mockRM := &mocks.MockRegionManager{}
+	mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			ch := args.Get(1).(chan<- prometheus.Metric)
+
+			desc := prometheus.NewDesc("dbi_os_general_numvcpus_avg", "The number of virtual CPUs", nil, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 4)
+			<-ctx.Done()
+		}).
+		Return(context.DeadlineExceeded)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics/stream", nil)
+	recorder := httptest.NewRecorder()
+
+	streamMetricsHandler(recorder, req, mockRM, 10*time.Millisecond, 0)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "data: dbi_os_general_numvcpus_avg 4\n")
+	mockRM.AssertExpectations(t)
+}
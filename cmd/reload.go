@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/collector"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/logging"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/region"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+)
+
+// exporterState is the configuration and the components built from it, replaced together on a config reload.
+type exporterState struct {
+	cfg           *models.ParsedConfig
+	regionManager region.RegionManager
+	health        *collector.HealthTracker
+}
+
+// reloadableExporter holds the current exporterState and rebuilds it from the config file on reload. Handlers load
+// the state once per request, so a scrape in flight during a reload finishes with the region manager it started with.
+// It implements region.RegionManager by delegating to the current region manager.
+type reloadableExporter struct {
+	configPath          string
+	loadConfig          func(path string) (*models.ParsedConfig, error)
+	createRegionManager func(cfg *models.ParsedConfig) (region.RegionManager, error)
+
+	state atomic.Pointer[exporterState]
+}
+
+func newReloadableExporter(configPath string, loadConfig func(path string) (*models.ParsedConfig, error), createRegionManager func(cfg *models.ParsedConfig) (region.RegionManager, error)) (*reloadableExporter, error) {
+	exporter := &reloadableExporter{
+		configPath:          configPath,
+		loadConfig:          loadConfig,
+		createRegionManager: createRegionManager,
+	}
+
+	state, err := exporter.buildState()
+	if err != nil {
+		return nil, err
+	}
+	exporter.state.Store(state)
+
+	return exporter, nil
+}
+
+// current returns the state to serve a request with.
+func (exporter *reloadableExporter) current() *exporterState {
+	return exporter.state.Load()
+}
+
+// reload loads the config file and swaps in a region manager built from it. The caches of the new region manager are
+// invalidated, so instances and metric metadata are discovered again rather than read from the metadata cache file.
+// If the config is invalid or the region manager cannot be created, the current state is kept and the error is returned.
+func (exporter *reloadableExporter) reload() error {
+	state, err := exporter.buildState()
+	if err != nil {
+		return err
+	}
+	state.regionManager.Invalidate()
+
+	previous := exporter.state.Swap(state)
+	if restartRequired(previous.cfg.Export, state.cfg.Export) {
+		slog.Warn("Changes to export.port, export.bind-address, export.debug, export.tls and export.remote-write-* only take effect after a restart")
+	}
+	return nil
+}
+
+// watchReload reloads the config on every signal received until the context is cancelled. A failed reload is logged
+// and the exporter keeps serving with the previous config.
+func (exporter *reloadableExporter) watchReload(ctx context.Context, signals <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signals:
+			slog.Info("Reloading configuration", "signal", sig.String(), "path", exporter.configPath)
+			if err := exporter.reload(); err != nil {
+				slog.Error("Error reloading configuration, keeping the previous configuration", "error", err)
+				continue
+			}
+			slog.Info("Configuration reloaded")
+		}
+	}
+}
+
+func (exporter *reloadableExporter) buildState() (*exporterState, error) {
+	cfg, err := exporter.loadConfig(exporter.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %w", err)
+	}
+
+	// The region managers derive their loggers from the default logger when they are created, so logging is
+	// configured first. When the region manager cannot be created, the logging of the current config is restored.
+	logging.Configure(cfg.Log)
+	regionManager, err := exporter.createRegionManager(cfg)
+	if err != nil {
+		if current := exporter.current(); current != nil {
+			logging.Configure(current.cfg.Log)
+		}
+		return nil, fmt.Errorf("error creating region manager: %w", err)
+	}
+
+	return &exporterState{
+		cfg:           cfg,
+		regionManager: regionManager,
+		health:        collector.NewHealthTracker(cfg.Export.UnhealthyThreshold, cfg.Export.Prometheus),
+	}, nil
+}
+
+func (exporter *reloadableExporter) CollectMetrics(ctx context.Context, ch chan<- prometheus.Metric) error {
+	return exporter.current().regionManager.CollectMetrics(ctx, ch)
+}
+
+func (exporter *reloadableExporter) CollectMetricsForInstances(ctx context.Context, instanceIdentifiers []string, ch chan<- prometheus.Metric) error {
+	return exporter.current().regionManager.CollectMetricsForInstances(ctx, instanceIdentifiers, ch)
+}
+
+func (exporter *reloadableExporter) ExplainExcludedMetrics(ctx context.Context, instanceIdentifier string) ([]models.ExcludedMetric, error) {
+	return exporter.current().regionManager.ExplainExcludedMetrics(ctx, instanceIdentifier)
+}
+
+func (exporter *reloadableExporter) GetMetricCatalog(ctx context.Context, instanceIdentifier string) (map[string]models.CatalogMetric, error) {
+	return exporter.current().regionManager.GetMetricCatalog(ctx, instanceIdentifier)
+}
+
+func (exporter *reloadableExporter) ExplainInstances(ctx context.Context) ([]models.InstanceDecision, error) {
+	return exporter.current().regionManager.ExplainInstances(ctx)
+}
+
+func (exporter *reloadableExporter) Invalidate() {
+	exporter.current().regionManager.Invalidate()
+}
+
+// restartRequired reports whether export settings that are only read at startup changed.
+func restartRequired(previous, next models.ParsedExportConfig) bool {
+	return previous.ListenAddress() != next.ListenAddress() ||
+		previous.Debug != next.Debug ||
+		previous.TLS != next.TLS ||
+		previous.RemoteWriteURL != next.RemoteWriteURL ||
+		previous.RemoteWriteInterval != next.RemoteWriteInterval
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/region"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/utils"
+)
+
+// newTestReloadableExporter returns an exporter whose config loads and region manager creations return the given
+// results in order, starting with the initial state.
+func newTestReloadableExporter(t *testing.T, configs []*models.ParsedConfig, loadErrors []error, regionManagers []region.RegionManager, createErrors []error) *reloadableExporter {
+	t.Helper()
+	loads, creates := 0, 0
+	exporter, err := newReloadableExporter("config.yml",
+		func(path string) (*models.ParsedConfig, error) {
+			defer func() { loads++ }()
+			return configs[loads], loadErrors[loads]
+		},
+		func(cfg *models.ParsedConfig) (region.RegionManager, error) {
+			defer func() { creates++ }()
+			return regionManagers[creates], createErrors[creates]
+		})
+	require.NoError(t, err)
+	return exporter
+}
+
+func TestReloadableExporterReload(t *testing.T) {
+	initialConfig := testutils.NewTestConfigBuilder().Build()
+	reloadedConfig := testutils.NewTestConfigBuilder().WithPort(9090).Build()
+
+	testCases := []struct {
+		name          string
+		loadError     error
+		createError   error
+		expectedError bool
+	}{
+		{
+			name:          "valid config is swapped in",
+			expectedError: false,
+		},
+		{
+			name:          "invalid config keeps the previous config",
+			loadError:     assert.AnError,
+			expectedError: true,
+		},
+		{
+			name:          "region manager error keeps the previous config",
+			createError:   assert.AnError,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			initialRM := &mocks.MockRegionManager{}
+			reloadedRM := &mocks.MockRegionManager{}
+			reloadedRM.On("Invalidate").Return()
+
+			var reloadedConfigs []*models.ParsedConfig
+			var reloadedManagers []region.RegionManager
+			if tc.loadError == nil {
+				reloadedConfigs = []*models.ParsedConfig{reloadedConfig}
+			} else {
+				reloadedConfigs = []*models.ParsedConfig{nil}
+			}
+			if tc.createError == nil {
+				reloadedManagers = []region.RegionManager{reloadedRM}
+			} else {
+				reloadedManagers = []region.RegionManager{nil}
+			}
+
+			exporter := newTestReloadableExporter(t,
+				append([]*models.ParsedConfig{initialConfig}, reloadedConfigs...), []error{nil, tc.loadError},
+				append([]region.RegionManager{initialRM}, reloadedManagers...), []error{nil, tc.createError})
+
+			err := exporter.reload()
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.Same(t, initialConfig, exporter.current().cfg)
+				assert.Same(t, initialRM, exporter.current().regionManager)
+			} else {
+				assert.NoError(t, err)
+				assert.Same(t, reloadedConfig, exporter.current().cfg)
+				assert.Same(t, reloadedRM, exporter.current().regionManager)
+				reloadedRM.AssertCalled(t, "Invalidate")
+			}
+			initialRM.AssertNotCalled(t, "Invalidate")
+		})
+	}
+}
+
+func TestReloadableExporterReloadLogLevel(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	initialConfig := testutils.NewTestConfigBuilder().Build()
+	initialConfig.Log.Level = slog.LevelWarn
+	debugConfig := testutils.NewTestConfigBuilder().Build()
+	debugConfig.Log.Level = slog.LevelDebug
+
+	reloadedRM := &mocks.MockRegionManager{}
+	reloadedRM.On("Invalidate").Return()
+	exporter := newTestReloadableExporter(t,
+		[]*models.ParsedConfig{initialConfig, debugConfig, debugConfig}, []error{nil, nil, nil},
+		[]region.RegionManager{&mocks.MockRegionManager{}, nil, reloadedRM}, []error{nil, assert.AnError, nil})
+	assert.False(t, slog.Default().Enabled(context.Background(), slog.LevelInfo))
+
+	require.Error(t, exporter.reload())
+	assert.False(t, slog.Default().Enabled(context.Background(), slog.LevelDebug), "a failed reload keeps the log level of the current config")
+
+	require.NoError(t, exporter.reload())
+	assert.True(t, slog.Default().Enabled(context.Background(), slog.LevelDebug))
+}
+
+func TestReloadableExporterReloadWhileListening(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	writeConfig := func(maxInstances int) {
+		configContent := fmt.Sprintf("discovery:\n  regions:\n  - us-west-2\n  instances:\n    max-instances: %d\nexport:\n  bind-address: 127.0.0.1\n  port: %d\n", maxInstances, port)
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+	}
+
+	writeConfig(10)
+	exporter, err := newReloadableExporter(configPath, utils.LoadConfig, func(cfg *models.ParsedConfig) (region.RegionManager, error) {
+		regionManager := &mocks.MockRegionManager{}
+		regionManager.On("Invalidate").Return()
+		return regionManager, nil
+	})
+	require.NoError(t, err)
+
+	writeConfig(20)
+	require.NoError(t, exporter.reload())
+	assert.Equal(t, 20, exporter.current().cfg.Discovery.Instances.MaxInstances)
+	assert.Equal(t, port, exporter.current().cfg.Export.Port)
+}
+
+func TestReloadableExporterReloadDuringScrape(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	initialRM := &mocks.MockRegionManager{}
+	initialRM.On("CollectMetrics", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ch := args.Get(1).(chan<- prometheus.Metric)
+			close(started)
+			<-release
+			desc := prometheus.NewDesc("dbi_initial", "Collected by the initial region manager", nil, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1)
+		}).
+		Return(nil).Once()
+
+	reloadedRM := &mocks.MockRegionManager{}
+	reloadedRM.On("CollectMetrics", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ch := args.Get(1).(chan<- prometheus.Metric)
+			desc := prometheus.NewDesc("dbi_reloaded", "Collected by the reloaded region manager", nil, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1)
+		}).
+		Return(nil).Once()
+	reloadedRM.On("Invalidate").Return().Once()
+
+	cfg := testutils.NewTestConfigBuilder().Build()
+	exporter := newTestReloadableExporter(t,
+		[]*models.ParsedConfig{cfg, cfg}, []error{nil, nil},
+		[]region.RegionManager{initialRM, reloadedRM}, []error{nil, nil})
+
+	inFlight := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		state := exporter.current()
+		metricsHandler(inFlight, httptest.NewRequest(http.MethodGet, "/metrics", nil), state.regionManager, utils.DefaultMaxInstanceIdentifiers, false, 0, 0, state.health, nil)
+	}()
+
+	<-started
+	require.NoError(t, exporter.reload())
+	close(release)
+	<-done
+
+	assert.Equal(t, http.StatusOK, inFlight.Code)
+	assert.Contains(t, inFlight.Body.String(), "dbi_initial 1")
+	assert.NotContains(t, inFlight.Body.String(), "dbi_reloaded")
+
+	next := httptest.NewRecorder()
+	state := exporter.current()
+	metricsHandler(next, httptest.NewRequest(http.MethodGet, "/metrics", nil), state.regionManager, utils.DefaultMaxInstanceIdentifiers, false, 0, 0, state.health, nil)
+
+	assert.Contains(t, next.Body.String(), "dbi_reloaded 1")
+	initialRM.AssertExpectations(t)
+	reloadedRM.AssertExpectations(t)
+}
+
+func TestReloadableExporterWatchReload(t *testing.T) {
+	initialRM := &mocks.MockRegionManager{}
+	reloadedRM := &mocks.MockRegionManager{}
+	reloadedRM.On("Invalidate").Return()
+	cfg := testutils.NewTestConfigBuilder().Build()
+	exporter := newTestReloadableExporter(t,
+		[]*models.ParsedConfig{cfg, nil, cfg}, []error{nil, assert.AnError, nil},
+		[]region.RegionManager{initialRM, reloadedRM}, []error{nil, nil})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal)
+	go exporter.watchReload(ctx, signals)
+
+	signals <- syscall.SIGHUP
+	signals <- syscall.SIGHUP
+
+	assert.Eventually(t, func() bool {
+		return exporter.current().regionManager == region.RegionManager(reloadedRM)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRestartRequired(t *testing.T) {
+	base := testutils.NewTestConfigBuilder().Build().Export
+
+	testCases := []struct {
+		name     string
+		modify   func(config *models.ParsedExportConfig)
+		expected bool
+	}{
+		{
+			name:     "unchanged",
+			modify:   func(config *models.ParsedExportConfig) {},
+			expected: false,
+		},
+		{
+			name:     "reloadable setting changed",
+			modify:   func(config *models.ParsedExportConfig) { config.MaxScrapeDuration = time.Minute },
+			expected: false,
+		},
+		{
+			name:     "port changed",
+			modify:   func(config *models.ParsedExportConfig) { config.Port = base.Port + 1 },
+			expected: true,
+		},
+		{
+			name:     "debug changed",
+			modify:   func(config *models.ParsedExportConfig) { config.Debug = !base.Debug },
+			expected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			next := base
+			tc.modify(&next)
+			assert.Equal(t, tc.expected, restartRequired(base, next))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients/rds"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/utils"
+)
+
+// regionCheckTimeout bounds the AWS connectivity check of a single region.
+const regionCheckTimeout = 30 * time.Second
+
+// regionChecker checks that a region is reachable with the configured AWS credentials, returning the number of
+// DB instances it describes.
+type regionChecker func(ctx context.Context, region string, awsConfig models.ParsedAWSConfig) (int, error)
+
+// validateConfig loads and validates the config file without starting the server and, when checkRegion is set,
+// checks that every configured region is reachable. The result is printed to out; the returned exit code is 0
+// if the config is valid and every region could be reached. A missing file is invalid, although the exporter itself
+// falls back to the default config without one.
+func validateConfig(out io.Writer, configPath string, checkRegion regionChecker) int {
+	if _, err := os.Stat(configPath); err != nil {
+		fmt.Fprintf(out, "Configuration %s is invalid: %v\n", configPath, err)
+		return 1
+	}
+
+	cfg, err := utils.LoadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(out, "Configuration %s is invalid: %v\n", configPath, err)
+		return 1
+	}
+	fmt.Fprintf(out, "Configuration %s is valid\n", configPath)
+
+	if checkRegion == nil {
+		return 0
+	}
+
+	exitCode := 0
+	for _, region := range cfg.Discovery.Regions {
+		ctx, cancel := context.WithTimeout(context.Background(), regionCheckTimeout)
+		instances, err := checkRegion(ctx, region, cfg.AWS)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(out, "Region %s is not reachable: %v\n", region, err)
+			exitCode = 1
+			continue
+		}
+		fmt.Fprintf(out, "Region %s is reachable, %d DB instances described\n", region, instances)
+	}
+	return exitCode
+}
+
+// checkRegionConnectivity describes the DB instances of the region, the call instance discovery starts with.
+func checkRegionConnectivity(ctx context.Context, region string, awsConfig models.ParsedAWSConfig) (int, error) {
+	rdsClient, err := rds.NewRDSClient(region, awsConfig, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	instances, err := rdsClient.DescribeDBInstancesPaginator(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+	return len(instances), nil
+}
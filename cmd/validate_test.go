@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+)
+
+func TestValidateConfig(t *testing.T) {
+	validConfig := `discovery:
+  regions:
+  - us-west-2
+  - us-east-1
+export:
+  port: 8081`
+
+	testCases := []struct {
+		name             string
+		configContent    string
+		checkRegion      regionChecker
+		expectedExitCode int
+		expectedOutput   []string
+	}{
+		{
+			name:             "valid config",
+			configContent:    validConfig,
+			expectedExitCode: 0,
+			expectedOutput:   []string{"is valid"},
+		},
+		{
+			name: "invalid config",
+			configContent: `discovery:
+  metrics:
+    statistic: "invalid"`,
+			expectedExitCode: 1,
+			expectedOutput:   []string{"is invalid", "statistic"},
+		},
+		{
+			name:          "reachable regions",
+			configContent: validConfig,
+			checkRegion: func(ctx context.Context, region string, awsConfig models.ParsedAWSConfig) (int, error) {
+				return 2, nil
+			},
+			expectedExitCode: 0,
+			expectedOutput:   []string{"Region us-west-2 is reachable, 2 DB instances", "Region us-east-1 is reachable, 2 DB instances"},
+		},
+		{
+			name:          "unreachable region",
+			configContent: validConfig,
+			checkRegion: func(ctx context.Context, region string, awsConfig models.ParsedAWSConfig) (int, error) {
+				if region == "us-east-1" {
+					return 0, assert.AnError
+				}
+				return 1, nil
+			},
+			expectedExitCode: 1,
+			expectedOutput:   []string{"Region us-west-2 is reachable", "Region us-east-1 is not reachable: " + assert.AnError.Error()},
+		},
+		{
+			name:          "regions are not checked for an invalid config",
+			configContent: "discovery:\n  regions: [[[",
+			checkRegion: func(ctx context.Context, region string, awsConfig models.ParsedAWSConfig) (int, error) {
+				t.Fatal("region checked for an invalid config")
+				return 0, nil
+			},
+			expectedExitCode: 1,
+			expectedOutput:   []string{"is invalid"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yml")
+			require.NoError(t, os.WriteFile(configPath, []byte(tc.configContent), 0o600))
+
+			var out bytes.Buffer
+			exitCode := validateConfig(&out, configPath, tc.checkRegion)
+
+			assert.Equal(t, tc.expectedExitCode, exitCode)
+			for _, expected := range tc.expectedOutput {
+				assert.Contains(t, out.String(), expected)
+			}
+		})
+	}
+}
+
+func TestValidateConfigMissingFile(t *testing.T) {
+	var out bytes.Buffer
+	exitCode := validateConfig(&out, filepath.Join(t.TempDir(), "missing.yml"), nil)
+
+	assert.Equal(t, 1, exitCode)
+	assert.Contains(t, out.String(), "is invalid")
+	assert.Contains(t, out.String(), "no such file or directory")
+}
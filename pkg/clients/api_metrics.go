@@ -0,0 +1,161 @@
+package clients
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/utils"
+)
+
+// APICallLatencyBuckets are the upper bounds, in seconds, of the AWS API call latency histogram.
+var APICallLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Results of an AWS API call attempt, as reported in the result label of the API call counter.
+const (
+	APICallResultSuccess   = "success"
+	APICallResultThrottled = "throttled"
+	APICallResultError     = "error"
+)
+
+// apiMetricsMiddlewareID identifies the API metrics middleware in the middleware stack of the AWS clients.
+const apiMetricsMiddlewareID = "DatabaseInsightsAPIMetrics"
+
+type APICallKey struct {
+	Service   string
+	Operation string
+	Result    string
+}
+
+type APIOperationKey struct {
+	Service   string
+	Operation string
+}
+
+// APICallLatency is the latency histogram of an operation, with cumulative counts per bucket of APICallLatencyBuckets.
+type APICallLatency struct {
+	Count   uint64
+	Sum     float64
+	Buckets map[float64]uint64
+}
+
+// APIMetrics counts the AWS API calls made by the clients and their latency, by service and operation. Every attempt
+// is recorded, including the retries of the AWS SDK and of the exporter, so the counts match the calls AWS bills and
+// throttles. It lives as long as the exporter, across scrapes and config reloads, and is safe for concurrent use.
+// A nil APIMetrics records nothing.
+type APIMetrics struct {
+	mu        sync.Mutex
+	calls     map[APICallKey]uint64
+	latencies map[APIOperationKey]*APICallLatency
+}
+
+func NewAPIMetrics() *APIMetrics {
+	return &APIMetrics{
+		calls:     make(map[APICallKey]uint64),
+		latencies: make(map[APIOperationKey]*APICallLatency),
+	}
+}
+
+// Record counts an API call attempt that completed after duration with err.
+func (apiMetrics *APIMetrics) Record(service string, operation string, err error, duration time.Duration) {
+	if apiMetrics == nil {
+		return
+	}
+
+	result := APICallResultSuccess
+	if utils.IsThrottlingAWSError(err) {
+		result = APICallResultThrottled
+	} else if err != nil {
+		result = APICallResultError
+	}
+
+	apiMetrics.mu.Lock()
+	defer apiMetrics.mu.Unlock()
+
+	apiMetrics.calls[APICallKey{Service: service, Operation: operation, Result: result}]++
+
+	operationKey := APIOperationKey{Service: service, Operation: operation}
+	latency, exists := apiMetrics.latencies[operationKey]
+	if !exists {
+		latency = &APICallLatency{Buckets: make(map[float64]uint64, len(APICallLatencyBuckets))}
+		apiMetrics.latencies[operationKey] = latency
+	}
+	seconds := duration.Seconds()
+	latency.Count++
+	latency.Sum += seconds
+	for _, bound := range APICallLatencyBuckets {
+		if seconds <= bound {
+			latency.Buckets[bound]++
+		}
+	}
+}
+
+// Calls returns a copy of the API call counts, sorted by service, operation and result.
+func (apiMetrics *APIMetrics) Calls() ([]APICallKey, []uint64) {
+	if apiMetrics == nil {
+		return nil, nil
+	}
+
+	apiMetrics.mu.Lock()
+	defer apiMetrics.mu.Unlock()
+
+	keys := make([]APICallKey, 0, len(apiMetrics.calls))
+	for key := range apiMetrics.calls {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Service != keys[j].Service {
+			return keys[i].Service < keys[j].Service
+		}
+		if keys[i].Operation != keys[j].Operation {
+			return keys[i].Operation < keys[j].Operation
+		}
+		return keys[i].Result < keys[j].Result
+	})
+
+	counts := make([]uint64, len(keys))
+	for i, key := range keys {
+		counts[i] = apiMetrics.calls[key]
+	}
+	return keys, counts
+}
+
+// Latencies returns a copy of the latency histogram of every operation called so far.
+func (apiMetrics *APIMetrics) Latencies() map[APIOperationKey]APICallLatency {
+	if apiMetrics == nil {
+		return nil
+	}
+
+	apiMetrics.mu.Lock()
+	defer apiMetrics.mu.Unlock()
+
+	latencies := make(map[APIOperationKey]APICallLatency, len(apiMetrics.latencies))
+	for key, latency := range apiMetrics.latencies {
+		buckets := make(map[float64]uint64, len(latency.Buckets))
+		for bound, count := range latency.Buckets {
+			buckets[bound] = count
+		}
+		latencies[key] = APICallLatency{Count: latency.Count, Sum: latency.Sum, Buckets: buckets}
+	}
+	return latencies
+}
+
+// addMiddleware records every attempt of the calls made with the stack. It runs after the retry middleware of the
+// AWS SDK, so each retry attempt is recorded with its own latency and result.
+func (apiMetrics *APIMetrics) addMiddleware(stack *middleware.Stack) error {
+	record := middleware.FinalizeMiddlewareFunc(apiMetricsMiddlewareID, func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		start := time.Now()
+		out, metadata, err := next.HandleFinalize(ctx, in)
+		apiMetrics.Record(awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx), err, time.Since(start))
+		return out, metadata, err
+	})
+
+	if err := stack.Finalize.Insert(record, "Retry", middleware.After); err != nil {
+		return stack.Finalize.Add(record, middleware.After)
+	}
+	return nil
+}
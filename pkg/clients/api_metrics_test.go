@@ -0,0 +1,86 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errThrottled = &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+
+func TestAPIMetricsRecord(t *testing.T) {
+	apiMetrics := NewAPIMetrics()
+
+	apiMetrics.Record("PI", "GetResourceMetrics", nil, 30*time.Millisecond)
+	apiMetrics.Record("PI", "GetResourceMetrics", errThrottled, 200*time.Millisecond)
+	apiMetrics.Record("PI", "GetResourceMetrics", nil, 2*time.Second)
+	apiMetrics.Record("RDS", "DescribeDBInstances", errors.New("connection reset"), time.Second)
+
+	keys, counts := apiMetrics.Calls()
+	assert.Equal(t, []APICallKey{
+		{Service: "PI", Operation: "GetResourceMetrics", Result: APICallResultSuccess},
+		{Service: "PI", Operation: "GetResourceMetrics", Result: APICallResultThrottled},
+		{Service: "RDS", Operation: "DescribeDBInstances", Result: APICallResultError},
+	}, keys)
+	assert.Equal(t, []uint64{2, 1, 1}, counts)
+
+	latency := apiMetrics.Latencies()[APIOperationKey{Service: "PI", Operation: "GetResourceMetrics"}]
+	assert.Equal(t, uint64(3), latency.Count)
+	assert.InDelta(t, 2.23, latency.Sum, 0.001)
+	assert.Equal(t, uint64(1), latency.Buckets[0.05])
+	assert.Equal(t, uint64(2), latency.Buckets[0.25])
+	assert.Equal(t, uint64(3), latency.Buckets[2.5], "bucket counts are cumulative")
+}
+
+func TestNilAPIMetrics(t *testing.T) {
+	var apiMetrics *APIMetrics
+
+	apiMetrics.Record("PI", "GetResourceMetrics", nil, time.Second)
+	keys, counts := apiMetrics.Calls()
+	assert.Empty(t, keys)
+	assert.Empty(t, counts)
+	assert.Empty(t, apiMetrics.Latencies())
+}
+
+func TestAPIMetricsMiddlewareRecordsEveryAttempt(t *testing.T) {
+	apiMetrics := NewAPIMetrics()
+	stack := middleware.NewStack("GetResourceMetrics", func() interface{} { return nil })
+	require.NoError(t, stack.Initialize.Add(&awsmiddleware.RegisterServiceMetadata{ServiceID: "PI", OperationName: "GetResourceMetrics"}, middleware.Before))
+
+	// Stands in for the retry middleware of the AWS SDK, retrying a throttled attempt once
+	retry := middleware.FinalizeMiddlewareFunc("Retry", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		out, metadata, err := next.HandleFinalize(ctx, in)
+		if err != nil {
+			return next.HandleFinalize(ctx, in)
+		}
+		return out, metadata, err
+	})
+	require.NoError(t, stack.Finalize.Add(retry, middleware.After))
+	require.NoError(t, apiMetrics.addMiddleware(stack))
+
+	attempts := 0
+	handler := middleware.DecorateHandler(middleware.HandlerFunc(func(ctx context.Context, input interface{}) (interface{}, middleware.Metadata, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, middleware.Metadata{}, errThrottled
+		}
+		return struct{}{}, middleware.Metadata{}, nil
+	}), stack)
+
+	_, _, err := handler.Handle(context.Background(), struct{}{})
+	require.NoError(t, err)
+
+	keys, counts := apiMetrics.Calls()
+	assert.Equal(t, []APICallKey{
+		{Service: "PI", Operation: "GetResourceMetrics", Result: APICallResultSuccess},
+		{Service: "PI", Operation: "GetResourceMetrics", Result: APICallResultThrottled},
+	}, keys)
+	assert.Equal(t, []uint64{1, 1}, counts)
+}
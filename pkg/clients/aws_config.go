@@ -0,0 +1,66 @@
+package clients
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+)
+
+// RoleSessionName identifies the exporter in CloudTrail when it assumes the role configured for a region.
+const RoleSessionName = "database-insights-exporter"
+
+// LoadAWSConfig loads the AWS SDK configuration for clients targeting the given region.
+// When an STS region is configured and differs from the target region, credentials are resolved through a separate
+// configuration in the STS region, so credential providers that call STS (web identity, assume-role profiles)
+// use that regional endpoint rather than the region being monitored.
+// When aws.region-roles configures a role for the region, the clients use the credentials of that role, assumed
+// with the base credentials through STS in the STS region.
+// Every call made with the configuration, including the STS calls assuming the role, is recorded in apiMetrics when set.
+func LoadAWSConfig(ctx context.Context, region string, awsConfig models.ParsedAWSConfig, apiMetrics *APIMetrics) (aws.Config, error) {
+	cfg, err := loadRegionConfig(ctx, region, awsConfig)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if apiMetrics != nil {
+		cfg.APIOptions = append(cfg.APIOptions, apiMetrics.addMiddleware)
+	}
+
+	role, exists := awsConfig.RegionRoles[region]
+	if !exists {
+		return cfg, nil
+	}
+
+	stsConfig := cfg.Copy()
+	if awsConfig.STSRegion != "" {
+		stsConfig.Region = awsConfig.STSRegion
+	}
+	cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(stsConfig), role.RoleARN,
+		func(options *stscreds.AssumeRoleOptions) {
+			options.RoleSessionName = RoleSessionName
+			if role.ExternalID != "" {
+				options.ExternalID = aws.String(role.ExternalID)
+			}
+		}))
+	return cfg, nil
+}
+
+func loadRegionConfig(ctx context.Context, region string, awsConfig models.ParsedAWSConfig) (aws.Config, error) {
+	if awsConfig.STSRegion == "" || awsConfig.STSRegion == region {
+		return config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	}
+
+	credentialsConfig, err := config.LoadDefaultConfig(ctx, config.WithRegion(awsConfig.STSRegion))
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	return config.LoadDefaultConfig(ctx,
+		config.WithRegion(region),
+		config.WithCredentialsProvider(credentialsConfig.Credentials),
+	)
+}
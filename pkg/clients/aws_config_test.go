@@ -0,0 +1,91 @@
+package clients
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+)
+
+func TestLoadAWSConfig(t *testing.T) {
+	testCases := []struct {
+		name      string
+		region    string
+		awsConfig models.ParsedAWSConfig
+	}{
+		{
+			name:      "sts region unset uses target region",
+			region:    "us-west-2",
+			awsConfig: models.ParsedAWSConfig{},
+		},
+		{
+			name:      "sts region same as target region",
+			region:    "us-west-2",
+			awsConfig: models.ParsedAWSConfig{STSRegion: "us-west-2"},
+		},
+		{
+			name:      "sts region distinct from target region",
+			region:    "eu-west-1",
+			awsConfig: models.ParsedAWSConfig{STSRegion: "us-east-1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := LoadAWSConfig(context.Background(), tc.region, tc.awsConfig, nil)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.region, cfg.Region)
+			assert.NotNil(t, cfg.Credentials)
+		})
+	}
+}
+
+func TestLoadAWSConfigWithRegionRole(t *testing.T) {
+	awsConfig := models.ParsedAWSConfig{
+		STSRegion: "us-east-1",
+		RegionRoles: map[string]models.AssumeRoleConfig{
+			"eu-west-1": {RoleARN: "arn:aws:iam::210987654321:role/database-insights", ExternalID: "exporter"},
+		},
+	}
+
+	testCases := []struct {
+		name            string
+		region          string
+		expectedAssumed bool
+	}{
+		{
+			name:            "region with a role assumes it",
+			region:          "eu-west-1",
+			expectedAssumed: true,
+		},
+		{
+			name:            "region without a role uses the base credentials",
+			region:          "us-west-2",
+			expectedAssumed: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := LoadAWSConfig(context.Background(), tc.region, awsConfig, nil)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.region, cfg.Region)
+			assert.Equal(t, tc.expectedAssumed, aws.IsCredentialsProvider(cfg.Credentials, (*stscreds.AssumeRoleProvider)(nil)))
+		})
+	}
+}
+
+func TestLoadAWSConfigWithAPIMetrics(t *testing.T) {
+	withoutMetrics, err := LoadAWSConfig(context.Background(), "us-west-2", models.ParsedAWSConfig{}, nil)
+	assert.NoError(t, err)
+
+	withMetrics, err := LoadAWSConfig(context.Background(), "us-west-2", models.ParsedAWSConfig{}, NewAPIMetrics())
+	assert.NoError(t, err)
+	assert.Len(t, withMetrics.APIOptions, len(withoutMetrics.APIOptions)+1)
+}
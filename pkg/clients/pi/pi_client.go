@@ -2,14 +2,14 @@ package pi
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/pi"
 	"github.com/aws/aws-sdk-go-v2/service/pi/types"
 
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 )
 
@@ -17,23 +17,25 @@ const PIMetricLookbackSeconds = 60
 
 type PIClient struct {
 	client *pi.Client
+	region string
 }
 
 // AWS Performance Insights (PI) is a database monitoring tool that provides visibility into database performance by collecting real-time performance metrics.
 
 // PIClient wraps the AWS Performance Insights SDK client with application-specific functionality.
 // It provides high-level methos for metric discovery and data collection operations.
-func NewPIClient(region string) (*PIClient, error) {
-	log.Println("[PI] Creating new PI client...")
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+func NewPIClient(region string, awsConfig models.ParsedAWSConfig, apiMetrics *clients.APIMetrics) (*PIClient, error) {
+	slog.Debug("Creating new PI client", "region", region)
+	cfg, err := clients.LoadAWSConfig(context.TODO(), region, awsConfig, apiMetrics)
 	if err != nil {
-		log.Printf("[PI] FATAL: Failed to load AWS config: %v", err)
+		slog.Error("Failed to load AWS config", "region", region, "error", err)
 		return nil, err
 	}
 
-	log.Printf("[PI] AWS config loaded, region: %s", region)
+	slog.Info("AWS config loaded for PI client", "region", region, "sts_region", awsConfig.STSRegion)
 	return &PIClient{
 		client: pi.NewFromConfig(cfg),
+		region: region,
 	}, nil
 }
 
@@ -46,7 +48,7 @@ func (piClient *PIClient) ListAvailableResourceMetrics(ctx context.Context, reso
 
 	result, err := piClient.client.ListAvailableResourceMetrics(ctx, input)
 	if err != nil {
-		log.Printf("[LIST_AVAILABLE_RESOURCE_METRICS] Error listing available metrics for resourceID: %s, error: %v", resourceID, err)
+		slog.Error("Error listing available metrics", "region", piClient.region, "operation", "ListAvailableResourceMetrics", "resource_id", resourceID, "error", err)
 		return nil, err
 	}
 
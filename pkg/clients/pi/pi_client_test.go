@@ -10,7 +10,14 @@ import (
 
 func TestNewPIClient(t *testing.T) {
 	t.Run("creates new PI client successfully", func(t *testing.T) {
-		piClient, err := NewPIClient(testutils.TestRegion)
+		piClient, err := NewPIClient(testutils.TestRegion, testutils.TestAWSConfig, nil)
+		assert.NoError(t, err)
+		assert.NotNil(t, piClient)
+		assert.NotNil(t, piClient.client)
+	})
+
+	t.Run("creates new PI client with distinct sts region", func(t *testing.T) {
+		piClient, err := NewPIClient("eu-west-1", testutils.TestAWSConfig, nil)
 		assert.NoError(t, err)
 		assert.NotNil(t, piClient)
 		assert.NotNil(t, piClient.client)
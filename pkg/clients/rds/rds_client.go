@@ -2,16 +2,22 @@ package rds
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 )
 
+// DescribeDBInstancesPageSize is the number of DB instances requested per DescribeDBInstances page, the API maximum.
+const DescribeDBInstancesPageSize = 100
+
 type RDSClient struct {
-	client *rds.Client
+	client rds.DescribeDBInstancesAPIClient
+	region string
 }
 
 // AWS Relational Database Service (RDS) manages relational databases in the cloud.
@@ -19,39 +25,48 @@ type RDSClient struct {
 
 // RDSClient wraps the AWS RDS SDK with application-specific database discovery functionality.
 // It provides methods for describing database instances.
-func NewRDSClient(region string) (*RDSClient, error) {
-	log.Println("[RDS] Creating new RDS client...")
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+func NewRDSClient(region string, awsConfig models.ParsedAWSConfig, apiMetrics *clients.APIMetrics) (*RDSClient, error) {
+	slog.Debug("Creating new RDS client", "region", region)
+	cfg, err := clients.LoadAWSConfig(context.TODO(), region, awsConfig, apiMetrics)
 	if err != nil {
-		log.Printf("[RDS] FATAL: Failed to load AWS config: %v", err)
+		slog.Error("Failed to load AWS config", "region", region, "error", err)
 		return nil, err
 	}
 
-	log.Printf("[RDS] AWS config loaded, region: %s", region)
+	slog.Info("AWS config loaded for RDS client", "region", region, "sts_region", awsConfig.STSRegion)
 	return &RDSClient{
 		client: rds.NewFromConfig(cfg),
+		region: region,
 	}, nil
 }
 
-func (rdsClient *RDSClient) DescribeDBInstancesPaginator(ctx context.Context) ([]types.DBInstance, error) {
+// DescribeDBInstancesPaginator reads the pages of DB instances in order. When maxPages is reached while more pages
+// remain, the instances read so far are returned and a warning is logged, so a very large account cannot page forever.
+func (rdsClient *RDSClient) DescribeDBInstancesPaginator(ctx context.Context, maxPages int) ([]types.DBInstance, error) {
 	input := &rds.DescribeDBInstancesInput{
-		MaxRecords: aws.Int32(100),
+		MaxRecords: aws.Int32(DescribeDBInstancesPageSize),
 	}
 
 	var allInstances []types.DBInstance
 
 	paginator := rds.NewDescribeDBInstancesPaginator(rdsClient.client, input)
 
-	for paginator.HasMorePages() {
+	for pages := 0; paginator.HasMorePages(); pages++ {
+		if maxPages > 0 && pages == maxPages {
+			slog.Warn("Stopped describing DB instances at instances.max-pages, more instances were not discovered",
+				"region", rdsClient.region, "operation", "DescribeDBInstances", "max_pages", maxPages, "instances", len(allInstances))
+			break
+		}
+
 		page, err := paginator.NextPage(ctx)
 		if err != nil {
-			log.Printf("[RDS] Failed to describe DB instances: %v", err)
+			slog.Error("Failed to describe DB instances", "region", rdsClient.region, "operation", "DescribeDBInstances", "error", err)
 			return nil, err
 		}
 
 		allInstances = append(allInstances, page.DBInstances...)
 	}
 
-	log.Printf("[RDS] Retrieved %d DB instances", len(allInstances))
+	slog.Info("Retrieved DB instances", "region", rdsClient.region, "operation", "DescribeDBInstances", "instances", len(allInstances))
 	return allInstances, nil
 }
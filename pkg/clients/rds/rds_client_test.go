@@ -2,16 +2,22 @@ package rds
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
 )
 
 func TestNewRDSClient(t *testing.T) {
 	t.Run("creates new RDS client successfully", func(t *testing.T) {
-		rdsClient, err := NewRDSClient(testutils.TestRegion)
+		rdsClient, err := NewRDSClient(testutils.TestRegion, testutils.TestAWSConfig, nil)
 		assert.NoError(t, err)
 		assert.NotNil(t, rdsClient)
 		assert.NotNil(t, rdsClient.client)
@@ -20,12 +26,19 @@ func TestNewRDSClient(t *testing.T) {
 	t.Run("creates new RDS client with valid region", func(t *testing.T) {
 		regions := []string{"us-west-2", "us-east-1", "eu-west-1"}
 		for _, region := range regions {
-			rdsClient, err := NewRDSClient(region)
+			rdsClient, err := NewRDSClient(region, testutils.TestAWSConfig, nil)
 			assert.NoError(t, err)
 			assert.NotNil(t, rdsClient)
 			assert.NotNil(t, rdsClient.client)
 		}
 	})
+
+	t.Run("creates new RDS client with distinct sts region", func(t *testing.T) {
+		rdsClient, err := NewRDSClient("eu-west-1", testutils.TestAWSConfig, nil)
+		assert.NoError(t, err)
+		assert.NotNil(t, rdsClient)
+		assert.NotNil(t, rdsClient.client)
+	})
 }
 
 func TestDescribeDBInstancesPaginatorIntegration(t *testing.T) {
@@ -49,10 +62,10 @@ func TestDescribeDBInstancesPaginatorIntegration(t *testing.T) {
 				t.Skip("Skipping integration test - requires AWS credentials and actual RDS instances")
 			}
 
-			rdsClient, err := NewRDSClient(tc.region)
+			rdsClient, err := NewRDSClient(tc.region, testutils.TestAWSConfig, nil)
 			assert.NoError(t, err)
 
-			instances, err := rdsClient.DescribeDBInstancesPaginator(context.Background())
+			instances, err := rdsClient.DescribeDBInstancesPaginator(context.Background(), 0)
 			if tc.expectError {
 				assert.Error(t, err)
 			} else {
@@ -63,3 +76,89 @@ func TestDescribeDBInstancesPaginatorIntegration(t *testing.T) {
 		})
 	}
 }
+
+func TestDescribeDBInstancesPaginatorPages(t *testing.T) {
+	pages := []*rds.DescribeDBInstancesOutput{
+		{
+			DBInstances: []types.DBInstance{{DBInstanceIdentifier: aws.String("db-1")}, {DBInstanceIdentifier: aws.String("db-2")}},
+			Marker:      aws.String("page-2"),
+		},
+		{
+			DBInstances: []types.DBInstance{{DBInstanceIdentifier: aws.String("db-3")}},
+			Marker:      aws.String("page-3"),
+		},
+		{
+			DBInstances: []types.DBInstance{{DBInstanceIdentifier: aws.String("db-4")}},
+		},
+	}
+
+	isPage := func(marker *string) func(*rds.DescribeDBInstancesInput) bool {
+		return func(input *rds.DescribeDBInstancesInput) bool {
+			return aws.ToInt32(input.MaxRecords) == DescribeDBInstancesPageSize && aws.ToString(input.Marker) == aws.ToString(marker)
+		}
+	}
+
+	testCases := []struct {
+		name                string
+		maxPages            int
+		pageError           error
+		expectedIdentifiers []string
+		expectedCalls       int
+		expectError         bool
+	}{
+		{
+			name:                "reads every page in order without a limit",
+			maxPages:            0,
+			expectedIdentifiers: []string{"db-1", "db-2", "db-3", "db-4"},
+			expectedCalls:       3,
+		},
+		{
+			name:                "reads every page when the limit is not reached",
+			maxPages:            3,
+			expectedIdentifiers: []string{"db-1", "db-2", "db-3", "db-4"},
+			expectedCalls:       3,
+		},
+		{
+			name:                "stops after max pages and returns the instances read",
+			maxPages:            2,
+			expectedIdentifiers: []string{"db-1", "db-2", "db-3"},
+			expectedCalls:       2,
+		},
+		{
+			name:          "returns the error of a failed page",
+			maxPages:      0,
+			pageError:     errors.New("throttled"),
+			expectedCalls: 2,
+			expectError:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := &mocks.MockAWSRDSClient{}
+			mockClient.On("DescribeDBInstances", mock.Anything, mock.MatchedBy(isPage(nil)), mock.Anything).Return(pages[0], nil).Maybe()
+			if tc.pageError != nil {
+				mockClient.On("DescribeDBInstances", mock.Anything, mock.MatchedBy(isPage(pages[0].Marker)), mock.Anything).Return(nil, tc.pageError).Maybe()
+			} else {
+				mockClient.On("DescribeDBInstances", mock.Anything, mock.MatchedBy(isPage(pages[0].Marker)), mock.Anything).Return(pages[1], nil).Maybe()
+			}
+			mockClient.On("DescribeDBInstances", mock.Anything, mock.MatchedBy(isPage(pages[1].Marker)), mock.Anything).Return(pages[2], nil).Maybe()
+
+			rdsClient := &RDSClient{client: mockClient}
+			instances, err := rdsClient.DescribeDBInstancesPaginator(context.Background(), tc.maxPages)
+
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, instances)
+			} else {
+				assert.NoError(t, err)
+				identifiers := make([]string, 0, len(instances))
+				for _, instance := range instances {
+					identifiers = append(identifiers, aws.ToString(instance.DBInstanceIdentifier))
+				}
+				assert.Equal(t, tc.expectedIdentifiers, identifiers)
+			}
+			mockClient.AssertNumberOfCalls(t, "DescribeDBInstances", tc.expectedCalls)
+		})
+	}
+}
@@ -7,5 +7,7 @@ import (
 )
 
 type RDSService interface {
-	DescribeDBInstancesPaginator(ctx context.Context) ([]types.DBInstance, error)
+	// DescribeDBInstancesPaginator describes the DB instances of the region, reading at most maxPages pages of
+	// results, or every page if maxPages is 0.
+	DescribeDBInstancesPaginator(ctx context.Context, maxPages int) ([]types.DBInstance, error)
 }
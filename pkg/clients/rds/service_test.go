@@ -42,9 +42,9 @@ func TestDescribeDBInstancesPaginator(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockService := &mocks.MockRDSService{}
-			mockService.On("DescribeDBInstancesPaginator", mock.Anything).Return(tc.mockResponse, tc.expectedError)
+			mockService.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).Return(tc.mockResponse, tc.expectedError)
 
-			instances, err := mockService.DescribeDBInstancesPaginator(context.Background())
+			instances, err := mockService.DescribeDBInstancesPaginator(context.Background(), 0)
 			if tc.expectedError != nil {
 				assert.Error(t, err)
 				assert.Nil(t, instances)
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"log/slog"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/processing/formatting"
+)
+
+// APIMetricsCollector exports the AWS API calls recorded since the exporter started. It is registered next to the
+// scrape collector, so the counts it reports keep growing across scrapes rather than being collected per scrape.
+type APIMetricsCollector struct {
+	apiMetrics       *clients.APIMetrics
+	prometheusConfig models.ParsedPrometheusConfig
+}
+
+func NewAPIMetricsCollector(apiMetrics *clients.APIMetrics, prometheusConfig models.ParsedPrometheusConfig) *APIMetricsCollector {
+	return &APIMetricsCollector{
+		apiMetrics:       apiMetrics,
+		prometheusConfig: prometheusConfig,
+	}
+}
+
+func (collector *APIMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	// Metrics are described during Collect(), as the operations called are only known once they were called
+}
+
+// Collect emits the call counter of every service, operation and result, and the latency histogram of every operation.
+func (collector *APIMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	keys, counts := collector.apiMetrics.Calls()
+	for i, key := range keys {
+		metric, err := formatting.NewAWSAPICallsMetric(collector.prometheusConfig, key.Service, key.Operation, key.Result, counts[i])
+		if err != nil {
+			slog.Error("Error creating AWS API calls metric", "service", key.Service, "operation", key.Operation, "error", err)
+			continue
+		}
+		ch <- metric
+	}
+
+	latencies := collector.apiMetrics.Latencies()
+	operations := make([]clients.APIOperationKey, 0, len(latencies))
+	for key := range latencies {
+		operations = append(operations, key)
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].Service != operations[j].Service {
+			return operations[i].Service < operations[j].Service
+		}
+		return operations[i].Operation < operations[j].Operation
+	})
+
+	for _, key := range operations {
+		latency := latencies[key]
+		metric, err := formatting.NewAWSAPICallDurationMetric(collector.prometheusConfig, key.Service, key.Operation, latency.Count, latency.Sum, latency.Buckets)
+		if err != nil {
+			slog.Error("Error creating AWS API call duration metric", "service", key.Service, "operation", key.Operation, "error", err)
+			continue
+		}
+		ch <- metric
+	}
+}
@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
+)
+
+func TestAPIMetricsCollector(t *testing.T) {
+	apiMetrics := clients.NewAPIMetrics()
+	apiMetrics.Record("PI", "GetResourceMetrics", nil, 100*time.Millisecond)
+	apiMetrics.Record("PI", "GetResourceMetrics", errors.New("connection reset"), time.Second)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewAPIMetricsCollector(apiMetrics, testutils.TestPrometheusConfig))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+
+	calls := byName["dbi_aws_api_calls_total"]
+	require.NotNil(t, calls)
+	assert.Equal(t, dto.MetricType_COUNTER, calls.GetType())
+	assert.Len(t, calls.GetMetric(), 2)
+
+	duration := byName["dbi_aws_api_call_duration_seconds"]
+	require.NotNil(t, duration)
+	require.Len(t, duration.GetMetric(), 1)
+	histogram := duration.GetMetric()[0].GetHistogram()
+	assert.Equal(t, uint64(2), histogram.GetSampleCount())
+	assert.InDelta(t, 1.1, histogram.GetSampleSum(), 0.001)
+
+	// Calls recorded after a scrape are added to the totals of the next one
+	apiMetrics.Record("PI", "GetResourceMetrics", nil, 100*time.Millisecond)
+	families, err = registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "dbi_aws_api_call_duration_seconds" {
+			assert.Equal(t, uint64(3), family.GetMetric()[0].GetHistogram().GetSampleCount())
+		}
+	}
+}
@@ -2,26 +2,52 @@ package collector
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/region"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 )
 
 type Collector struct {
+	ctx           context.Context
 	regionManager region.RegionManager
+	stats         *models.ScrapeStats
+	progress      *models.ScrapeProgress
+	health        *HealthTracker
 }
 
 // Collector implements prometheus.Collector interface for collecting database insights metrics.
 // It orchestrates metric collection across configured regions and database isntances,
 // converting AWS Performance Insights data into Prometheus-compatible metrics.
-func NewCollector(regionManager region.RegionManager) *Collector {
+// The optional stats accumulator is populated during collection for per-scrape summary logging.
+func NewCollector(regionManager region.RegionManager, stats *models.ScrapeStats) *Collector {
 	return &Collector{
+		ctx:           context.Background(),
 		regionManager: regionManager,
+		stats:         stats,
 	}
 }
 
+// WithProgress reports the completion of every instance to the provided progress reporter during collection.
+func (collector *Collector) WithProgress(progress *models.ScrapeProgress) *Collector {
+	collector.progress = progress
+	return collector
+}
+
+// WithHealth records the outcome of every collection in the provided health tracker and emits the exporter healthy gauge.
+func (collector *Collector) WithHealth(health *HealthTracker) *Collector {
+	collector.health = health
+	return collector
+}
+
+// WithContext collects metrics under the provided context, so cancelling it aborts the in-flight AWS calls of a scrape.
+func (collector *Collector) WithContext(ctx context.Context) *Collector {
+	collector.ctx = ctx
+	return collector
+}
+
 func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
 	// Dynamic metrics are described during Collect()
 }
@@ -29,11 +55,16 @@ func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
 // Collect gathers metrics from all configured regions and sends them to the provided channel.
 // This method is invoked by Prometheus during metric scraping operations.
 func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
-	log.Println("[COLLECT] Collect() called - Prometheus is scraping")
-	ctx := context.Background()
+	slog.Debug("Collecting metrics for scrape")
+	ctx := models.ContextWithScrapeStats(collector.ctx, collector.stats)
+	ctx = models.ContextWithMetricDescriptions(ctx, models.NewMetricDescriptions())
+	ctx = models.ContextWithScrapeProgress(ctx, collector.progress)
 
 	err := collector.regionManager.CollectMetrics(ctx, ch)
 	if err != nil {
-		log.Println("[COLLECT] Error collecting metrics:", err)
+		slog.Error("Error collecting metrics", "error", err)
 	}
+
+	collector.health.RecordScrape(collector.stats)
+	collector.health.emit(ch)
 }
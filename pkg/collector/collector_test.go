@@ -9,13 +9,14 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
 )
 
 func TestNewCollector(t *testing.T) {
 	t.Run("creates new collector successfully", func(t *testing.T) {
 		mockRegionManager := &mocks.MockRegionManager{}
-		collector := NewCollector(mockRegionManager)
+		collector := NewCollector(mockRegionManager, nil)
 
 		assert.NotNil(t, collector)
 		assert.Equal(t, mockRegionManager, collector.regionManager)
@@ -52,7 +53,7 @@ func TestCollect(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockRegionManager := &mocks.MockRegionManager{}
-			collector := NewCollector(mockRegionManager)
+			collector := NewCollector(mockRegionManager, nil)
 
 			if tc.shouldCallRegionManager {
 				mockRegionManager.On("CollectMetrics", mock.Anything, mock.Anything).
@@ -69,3 +70,52 @@ func TestCollect(t *testing.T) {
 		})
 	}
 }
+
+func TestCollectPassesScrapeStats(t *testing.T) {
+	mockRegionManager := &mocks.MockRegionManager{}
+	stats := models.NewScrapeStats()
+	collector := NewCollector(mockRegionManager, stats)
+
+	mockRegionManager.On("CollectMetrics", mock.MatchedBy(func(ctx context.Context) bool {
+		return models.ScrapeStatsFromContext(ctx) == stats
+	}), mock.Anything).Return(nil)
+
+	ch := make(chan prometheus.Metric, 1)
+	collector.Collect(ch)
+	close(ch)
+
+	mockRegionManager.AssertExpectations(t)
+}
+
+func TestCollectPassesScrapeProgress(t *testing.T) {
+	mockRegionManager := &mocks.MockRegionManager{}
+	progress := models.NewScrapeProgress(func(models.InstanceProgress) {})
+	collector := NewCollector(mockRegionManager, nil).WithProgress(progress)
+
+	mockRegionManager.On("CollectMetrics", mock.MatchedBy(func(ctx context.Context) bool {
+		return models.ScrapeProgressFromContext(ctx) == progress
+	}), mock.Anything).Return(nil)
+
+	ch := make(chan prometheus.Metric, 1)
+	collector.Collect(ch)
+	close(ch)
+
+	mockRegionManager.AssertExpectations(t)
+}
+
+func TestCollectUsesContext(t *testing.T) {
+	mockRegionManager := &mocks.MockRegionManager{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	collector := NewCollector(mockRegionManager, nil).WithContext(ctx)
+
+	mockRegionManager.On("CollectMetrics", mock.MatchedBy(func(ctx context.Context) bool {
+		return errors.Is(ctx.Err(), context.Canceled)
+	}), mock.Anything).Return(context.Canceled)
+
+	ch := make(chan prometheus.Metric, 1)
+	collector.Collect(ch)
+	close(ch)
+
+	mockRegionManager.AssertExpectations(t)
+}
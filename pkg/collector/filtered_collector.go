@@ -2,28 +2,39 @@ package collector
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/region"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 )
 
 type FilteredCollector struct {
+	ctx            context.Context
 	regionManager  region.RegionManager
 	instanceFilter []string
+	stats          *models.ScrapeStats
 }
 
 // FilteredCollector implements prometheus.Collector interface for targeted metric collection
 // It provies the same functionality as Collector with instance-level filtering,
 // allowing Prometheus to collect metrics from specific database instances rather than all discovered instances across all regions.
-func NewFilteredCollector(regionManager region.RegionManager, instanceFilter []string) *FilteredCollector {
+func NewFilteredCollector(regionManager region.RegionManager, instanceFilter []string, stats *models.ScrapeStats) *FilteredCollector {
 	return &FilteredCollector{
+		ctx:            context.Background(),
 		regionManager:  regionManager,
 		instanceFilter: instanceFilter,
+		stats:          stats,
 	}
 }
 
+// WithContext collects metrics under the provided context, so cancelling it aborts the in-flight AWS calls of a scrape.
+func (fc *FilteredCollector) WithContext(ctx context.Context) *FilteredCollector {
+	fc.ctx = ctx
+	return fc
+}
+
 func (fc *FilteredCollector) Describe(ch chan<- *prometheus.Desc) {
 	// Dynamic metrics are described during Collect()
 }
@@ -31,11 +42,12 @@ func (fc *FilteredCollector) Describe(ch chan<- *prometheus.Desc) {
 // Collect gathers metrics from the specific instances and sends them to the provided channel.
 // This method is invoked by Prometheus during metric scraping operations.
 func (fc *FilteredCollector) Collect(ch chan<- prometheus.Metric) {
-	log.Println("[FILTERED COLLECT] Collect() called - Prometheus is scraping")
-	ctx := context.Background()
+	slog.Debug("Collecting metrics for targeted scrape", "identifiers", fc.instanceFilter)
+	ctx := models.ContextWithScrapeStats(fc.ctx, fc.stats)
+	ctx = models.ContextWithMetricDescriptions(ctx, models.NewMetricDescriptions())
 
 	err := fc.regionManager.CollectMetricsForInstances(ctx, fc.instanceFilter, ch)
 	if err != nil {
-		log.Println("[FILTERED COLLECT] Error collecting metrics:", err)
+		slog.Error("Error collecting metrics", "identifiers", fc.instanceFilter, "error", err)
 	}
 }
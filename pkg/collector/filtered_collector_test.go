@@ -37,7 +37,7 @@ func TestNewFilteredCollector(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			collector := NewFilteredCollector(tc.regionManager, tc.instanceFilter)
+			collector := NewFilteredCollector(tc.regionManager, tc.instanceFilter, nil)
 
 			assert.NotNil(t, collector)
 			assert.Equal(t, tc.regionManager, collector.regionManager)
@@ -49,7 +49,7 @@ func TestNewFilteredCollector(t *testing.T) {
 func TestFilteredCollectorDescribe(t *testing.T) {
 	t.Run("describe does not panic", func(t *testing.T) {
 		mockRegionManager := &mocks.MockRegionManager{}
-		collector := NewFilteredCollector(mockRegionManager, []string{"instance1"})
+		collector := NewFilteredCollector(mockRegionManager, []string{"instance1"}, nil)
 
 		ch := make(chan *prometheus.Desc, 10)
 
@@ -102,7 +102,7 @@ func TestFilteredCollectorCollect(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockRegionManager := &mocks.MockRegionManager{}
-			collector := NewFilteredCollector(mockRegionManager, tc.instanceFilter)
+			collector := NewFilteredCollector(mockRegionManager, tc.instanceFilter, nil)
 
 			if tc.shouldCallRegionManager {
 				mockRegionManager.On("CollectMetricsForInstances", mock.Anything, tc.instanceFilter, mock.Anything).
@@ -119,3 +119,20 @@ func TestFilteredCollectorCollect(t *testing.T) {
 		})
 	}
 }
+
+func TestFilteredCollectorCollectUsesContext(t *testing.T) {
+	mockRegionManager := &mocks.MockRegionManager{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	collector := NewFilteredCollector(mockRegionManager, []string{"instance1"}, nil).WithContext(ctx)
+
+	mockRegionManager.On("CollectMetricsForInstances", mock.MatchedBy(func(ctx context.Context) bool {
+		return errors.Is(ctx.Err(), context.Canceled)
+	}), []string{"instance1"}, mock.Anything).Return(context.Canceled)
+
+	ch := make(chan prometheus.Metric, 1)
+	collector.Collect(ch)
+	close(ch)
+
+	mockRegionManager.AssertExpectations(t)
+}
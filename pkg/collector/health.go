@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/processing/formatting"
+)
+
+// HealthStatus is the health of the exporter as decided from the last full scrape.
+type HealthStatus struct {
+	Healthy         bool    `json:"healthy"`
+	Instances       int64   `json:"instances"`
+	FailedInstances int64   `json:"failedInstances"`
+	Threshold       float64 `json:"threshold"`
+}
+
+// HealthTracker decides whether the exporter is healthy from the fraction of discovered instances that failed
+// collection in the last full scrape, so a couple of flaky instances are told apart from a systemic failure.
+// The exporter is unhealthy while more than threshold of the instances failed, or when discovery failed without finding
+// any instance. Methods are safe for concurrent use and are no-ops on a nil receiver, which always reports healthy.
+type HealthTracker struct {
+	threshold        float64
+	prometheusConfig models.ParsedPrometheusConfig
+
+	mu     sync.Mutex
+	status HealthStatus
+}
+
+// NewHealthTracker returns a tracker for the provided export.unhealthy-threshold, or nil when the threshold is 0.
+func NewHealthTracker(threshold float64, prometheusConfig models.ParsedPrometheusConfig) *HealthTracker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &HealthTracker{
+		threshold:        threshold,
+		prometheusConfig: prometheusConfig,
+		status:           HealthStatus{Healthy: true, Threshold: threshold},
+	}
+}
+
+// RecordScrape updates the health from the stats of a completed full scrape.
+func (tracker *HealthTracker) RecordScrape(stats *models.ScrapeStats) {
+	if tracker == nil || stats == nil {
+		return
+	}
+
+	status := HealthStatus{
+		Healthy:         true,
+		Instances:       stats.InstancesDiscovered(),
+		FailedInstances: stats.InstancesFailed(),
+		Threshold:       tracker.threshold,
+	}
+	if status.Instances == 0 {
+		status.Healthy = stats.Errors() == 0
+	} else {
+		status.Healthy = float64(status.FailedInstances)/float64(status.Instances) <= tracker.threshold
+	}
+
+	tracker.mu.Lock()
+	if tracker.status.Healthy != status.Healthy {
+		slog.Info("Exporter health changed", "healthy", status.Healthy, "failed_instances", status.FailedInstances, "instances", status.Instances, "threshold", tracker.threshold)
+	}
+	tracker.status = status
+	tracker.mu.Unlock()
+}
+
+// Status returns the health decided from the last full scrape. The exporter is healthy until a scrape completes.
+func (tracker *HealthTracker) Status() HealthStatus {
+	if tracker == nil {
+		return HealthStatus{Healthy: true}
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	return tracker.status
+}
+
+// emit sends the exporter healthy gauge to the channel.
+func (tracker *HealthTracker) emit(ch chan<- prometheus.Metric) {
+	if tracker == nil {
+		return
+	}
+
+	metric, err := formatting.NewExporterHealthyMetric(tracker.prometheusConfig, tracker.Status().Healthy)
+	if err != nil {
+		slog.Error("Error creating exporter healthy metric", "error", err)
+		return
+	}
+	ch <- metric
+}
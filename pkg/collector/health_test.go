@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
+)
+
+func TestHealthTrackerRecordScrape(t *testing.T) {
+	testCases := []struct {
+		name            string
+		threshold       float64
+		discovered      int
+		failed          int
+		errors          int
+		expectedHealthy bool
+	}{
+		{
+			name:            "no failed instances",
+			threshold:       0.5,
+			discovered:      10,
+			expectedHealthy: true,
+		},
+		{
+			name:            "failed fraction at the threshold",
+			threshold:       0.2,
+			discovered:      10,
+			failed:          2,
+			errors:          2,
+			expectedHealthy: true,
+		},
+		{
+			name:            "failed fraction above the threshold",
+			threshold:       0.2,
+			discovered:      10,
+			failed:          3,
+			errors:          3,
+			expectedHealthy: false,
+		},
+		{
+			name:            "discovery failed without finding any instance",
+			threshold:       1,
+			errors:          1,
+			expectedHealthy: false,
+		},
+		{
+			name:            "no instances discovered without errors",
+			threshold:       0.5,
+			expectedHealthy: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := NewHealthTracker(tc.threshold, testutils.TestPrometheusConfig)
+			require.NotNil(t, tracker)
+			assert.True(t, tracker.Status().Healthy, "healthy until a scrape completes")
+
+			stats := models.NewScrapeStats()
+			stats.AddInstancesDiscovered(tc.discovered)
+			stats.AddInstancesFailed(tc.failed)
+			stats.AddErrors(tc.errors)
+			tracker.RecordScrape(stats)
+
+			assert.Equal(t, HealthStatus{
+				Healthy:         tc.expectedHealthy,
+				Instances:       int64(tc.discovered),
+				FailedInstances: int64(tc.failed),
+				Threshold:       tc.threshold,
+			}, tracker.Status())
+		})
+	}
+}
+
+func TestHealthTrackerDisabled(t *testing.T) {
+	tracker := NewHealthTracker(0, testutils.TestPrometheusConfig)
+	assert.Nil(t, tracker)
+
+	stats := models.NewScrapeStats()
+	stats.AddErrors(1)
+	tracker.RecordScrape(stats)
+	assert.True(t, tracker.Status().Healthy)
+
+	ch := make(chan prometheus.Metric, 1)
+	tracker.emit(ch)
+	assert.Empty(t, ch)
+}
+
+func TestCollectWithHealth(t *testing.T) {
+	mockRegionManager := &mocks.MockRegionManager{}
+	mockRegionManager.On("CollectMetrics", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			stats := models.ScrapeStatsFromContext(args.Get(0).(context.Context))
+			stats.AddInstancesDiscovered(2)
+			stats.AddInstancesFailed(2)
+			stats.AddErrors(2)
+		}).
+		Return(errors.New("get resource metrics failed"))
+
+	tracker := NewHealthTracker(0.5, testutils.TestPrometheusConfig)
+	collector := NewCollector(mockRegionManager, models.NewScrapeStats()).WithHealth(tracker)
+
+	ch := make(chan prometheus.Metric, 10)
+	collector.Collect(ch)
+	close(ch)
+
+	require.Len(t, ch, 1)
+	metric := <-ch
+	assert.Contains(t, metric.Desc().String(), `"dbi_exporter_healthy"`)
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+	assert.Equal(t, 0.0, written.GetGauge().GetValue())
+	assert.False(t, tracker.Status().Healthy)
+}
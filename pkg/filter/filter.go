@@ -1,5 +1,10 @@
 package filter
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Filterable interface {
 	GetFilterableFields() map[string]string
 	GetFilterableTags() map[string]string
@@ -8,4 +13,62 @@ type Filterable interface {
 type Filter interface {
 	ShouldInclude(obj Filterable) bool
 	HasFilters() bool
+	Evaluate(obj Filterable) Decision
+}
+
+// PatternMatch identifies a single pattern that matched the value of a field.
+type PatternMatch struct {
+	Field   string `json:"field"`
+	Pattern string `json:"pattern"`
+}
+
+// Decision describes how a filter reached its include/exclude result for an object.
+// ExcludeMatches and IncludeMatches list every pattern that matched, and IncludeMisses lists the include fields
+// that were missing or matched none of their patterns. ExcludeReasons describes the checks other than patterns that
+// excluded the object, such as an allowlist it is not in.
+type Decision struct {
+	Included       bool           `json:"included"`
+	ExcludeReasons []string       `json:"excludeReasons,omitempty"`
+	ExcludeMatches []PatternMatch `json:"excludeMatches,omitempty"`
+	IncludeMatches []PatternMatch `json:"includeMatches,omitempty"`
+	IncludeMisses  []string       `json:"includeMisses,omitempty"`
+}
+
+// Merge combines the decisions of two filters applied to the same object. The object is included only if both included it.
+func (decision Decision) Merge(other Decision) Decision {
+	return Decision{
+		Included:       decision.Included && other.Included,
+		ExcludeReasons: append(append([]string(nil), decision.ExcludeReasons...), other.ExcludeReasons...),
+		ExcludeMatches: append(append([]PatternMatch(nil), decision.ExcludeMatches...), other.ExcludeMatches...),
+		IncludeMatches: append(append([]PatternMatch(nil), decision.IncludeMatches...), other.IncludeMatches...),
+		IncludeMisses:  append(append([]string(nil), decision.IncludeMisses...), other.IncludeMisses...),
+	}
+}
+
+// Reason describes the decision in a human-readable form, e.g. "excluded: field 'identifier' matched exclude pattern
+// '-temp-'", listing every exclude reason, exclude match and include miss of an excluded object, or every include
+// match of an included one.
+func (decision Decision) Reason() string {
+	var reasons []string
+	if decision.Included {
+		for _, match := range decision.IncludeMatches {
+			reasons = append(reasons, fmt.Sprintf("field '%s' matched include pattern '%s'", match.Field, match.Pattern))
+		}
+		if len(reasons) == 0 {
+			return "included: no pattern excludes it"
+		}
+		return "included: " + strings.Join(reasons, "; ")
+	}
+
+	reasons = append(reasons, decision.ExcludeReasons...)
+	for _, match := range decision.ExcludeMatches {
+		reasons = append(reasons, fmt.Sprintf("field '%s' matched exclude pattern '%s'", match.Field, match.Pattern))
+	}
+	for _, field := range decision.IncludeMisses {
+		reasons = append(reasons, fmt.Sprintf("field '%s' matched no include pattern", field))
+	}
+	if len(reasons) == 0 {
+		return "excluded"
+	}
+	return "excluded: " + strings.Join(reasons, "; ")
 }
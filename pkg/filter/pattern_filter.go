@@ -2,6 +2,7 @@ package filter
 
 import (
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -11,15 +12,91 @@ const (
 
 type Patterns map[string][]*regexp.Regexp
 
+// Mode controls how exclude patterns combine with include patterns.
+type Mode string
+
+const (
+	// ModeExcludeAlways applies exclude patterns to every object, whether or not include patterns are configured.
+	ModeExcludeAlways Mode = "exclude-always"
+	// ModeExcludeWithinInclude only applies exclude patterns when include patterns are configured, so exclude
+	// narrows the include allowlist and is ignored on its own.
+	ModeExcludeWithinInclude Mode = "exclude-within-include"
+)
+
+func (mode Mode) IsValid() bool {
+	switch mode {
+	case ModeExcludeAlways, ModeExcludeWithinInclude:
+		return true
+	default:
+		return false
+	}
+}
+
+// Combinator controls how the patterns of a single field combine.
+type Combinator string
+
+const (
+	// CombinatorAny matches a field when any of its patterns matches (OR logic).
+	CombinatorAny Combinator = "any"
+	// CombinatorAll matches a field only when every one of its patterns matches (AND logic).
+	CombinatorAll Combinator = "all"
+)
+
+func (combinator Combinator) IsValid() bool {
+	switch combinator {
+	case CombinatorAny, CombinatorAll:
+		return true
+	default:
+		return false
+	}
+}
+
+// Options configures a pattern filter. The zero value applies exclude patterns always and ORs the patterns of a field.
+type Options struct {
+	Mode Mode
+	// Combinators sets how the patterns of a field combine, by field name, in both include and exclude patterns.
+	// Fields without a combinator match when any pattern matches.
+	Combinators map[string]Combinator
+}
+
 type PatternFilter struct {
 	IncludePatterns Patterns
 	ExcludePatterns Patterns
+	Mode            Mode
+	includeMatchers map[string]*fieldMatcher
+	excludeMatchers map[string]*fieldMatcher
+}
+
+// fieldMatcher splits the compiled patterns for a single field so the cheap checks run first: patterns anchored
+// to an exact literal (e.g. ^prod-db$) are answered with a map lookup, unanchored literals (e.g. prod) with a
+// substring check, and only patterns using regex syntax fall back to the regex engine.
+type fieldMatcher struct {
+	exact    map[string]struct{}
+	literals []string
+	regexes  []*regexp.Regexp
+	// all requires every pattern to match instead of any
+	all bool
 }
 
+// NewPatternFilter returns a filter that includes objects matching every include field, unless they match an exclude
+// pattern. Exclude patterns apply even without include patterns, and a field matches when any of its patterns matches.
 func NewPatternFilter(includePatterns, excludePatterns Patterns) Filter {
+	return NewPatternFilterWithOptions(includePatterns, excludePatterns, Options{})
+}
+
+// NewPatternFilterWithOptions returns a pattern filter combining include and exclude patterns according to the options.
+func NewPatternFilterWithOptions(includePatterns, excludePatterns Patterns, options Options) Filter {
+	mode := options.Mode
+	if mode == "" {
+		mode = ModeExcludeAlways
+	}
+
 	return &PatternFilter{
 		IncludePatterns: includePatterns,
 		ExcludePatterns: excludePatterns,
+		Mode:            mode,
+		includeMatchers: newFieldMatchers(includePatterns, options.Combinators),
+		excludeMatchers: newFieldMatchers(excludePatterns, options.Combinators),
 	}
 }
 
@@ -28,44 +105,90 @@ func (patternFilter *PatternFilter) ShouldInclude(obj Filterable) bool {
 		return false
 	}
 
-	// Exclude patterns: ANY field match should exclude (OR logic)
-	if len(patternFilter.ExcludePatterns) > 0 {
-		if patternFilter.matchesAnyField(obj, patternFilter.ExcludePatterns) {
+	if len(patternFilter.excludeMatchers) == 0 && len(patternFilter.includeMatchers) == 0 {
+		return true
+	}
+
+	fieldMap := obj.GetFilterableFields()
+	tagMap := obj.GetFilterableTags()
+
+	// Exclude patterns: ANY field match should exclude (OR logic), checked first so excluded objects skip include matching
+	if len(patternFilter.excludeMatchers) > 0 && patternFilter.appliesExclude() {
+		if matchesAnyField(fieldMap, tagMap, patternFilter.excludeMatchers) {
 			return false
 		}
 	}
 
 	// Include patterns: ALL fields must match (AND logic)
-	if len(patternFilter.IncludePatterns) > 0 {
-		return patternFilter.matchesAllFields(obj, patternFilter.IncludePatterns)
+	if len(patternFilter.includeMatchers) > 0 {
+		return matchesAllFields(fieldMap, tagMap, patternFilter.includeMatchers)
 	}
 
 	return true
 }
 
-func (patternFilter *PatternFilter) HasFilters() bool {
-	return len(patternFilter.IncludePatterns) > 0 || len(patternFilter.ExcludePatterns) > 0
-}
+// Evaluate reports every exclude and include pattern matching the object along with the resulting decision,
+// which always agrees with ShouldInclude. Unlike ShouldInclude it does not short-circuit, so it is intended
+// for debugging rather than the collection path.
+func (patternFilter *PatternFilter) Evaluate(obj Filterable) Decision {
+	if obj == nil {
+		return Decision{Included: false}
+	}
 
-// matchesAnyField returns true if ANY field matches its patterns (OR logic)
-// Used for exclude patterns: any match should exclude the object
-func (patternFilter *PatternFilter) matchesAnyField(obj Filterable, patterns Patterns) bool {
 	fieldMap := obj.GetFilterableFields()
 	tagMap := obj.GetFilterableTags()
 
-	for filterKey, regexPatterns := range patterns {
-		var fieldValue string
-		var exists bool
+	var decision Decision
+	for _, filterKey := range sortedKeys(patternFilter.ExcludePatterns) {
+		if !patternFilter.appliesExclude() {
+			break
+		}
+		if fieldValue, exists := lookupField(fieldMap, tagMap, filterKey); exists && patternFilter.excludeMatchers[filterKey].matches(fieldValue) {
+			decision.ExcludeMatches = append(decision.ExcludeMatches, matchingPatterns(filterKey, fieldValue, patternFilter.ExcludePatterns[filterKey])...)
+		}
+	}
+
+	for _, filterKey := range sortedKeys(patternFilter.IncludePatterns) {
+		var matches []PatternMatch
+		fieldValue, exists := lookupField(fieldMap, tagMap, filterKey)
+		if exists {
+			matches = matchingPatterns(filterKey, fieldValue, patternFilter.IncludePatterns[filterKey])
+		}
 
-		if fieldValue, exists = fieldMap[filterKey]; !exists {
-			if strings.HasPrefix(filterKey, TagPrefix) {
-				tagKey := filterKey[len(TagPrefix):]
-				fieldValue, exists = tagMap[tagKey]
-			}
+		if !exists || !patternFilter.includeMatchers[filterKey].matches(fieldValue) {
+			decision.IncludeMisses = append(decision.IncludeMisses, filterKey)
 		}
+		decision.IncludeMatches = append(decision.IncludeMatches, matches...)
+	}
+
+	decision.Included = patternFilter.ShouldInclude(obj)
+	return decision
+}
+
+// appliesExclude reports whether exclude patterns are checked, which in ModeExcludeWithinInclude requires include patterns.
+func (patternFilter *PatternFilter) appliesExclude() bool {
+	return patternFilter.Mode != ModeExcludeWithinInclude || len(patternFilter.includeMatchers) > 0
+}
+
+// Explain returns whether the object is included along with a human-readable reason naming the fields and patterns
+// that made the decision. Like Evaluate, it is intended for debugging rather than the collection path.
+func (patternFilter *PatternFilter) Explain(obj Filterable) (bool, string) {
+	decision := patternFilter.Evaluate(obj)
+	return decision.Included, decision.Reason()
+}
+
+func (patternFilter *PatternFilter) HasFilters() bool {
+	return len(patternFilter.IncludePatterns) > 0 || len(patternFilter.ExcludePatterns) > 0
+}
+
+// matchesAnyField returns true if ANY field matches its patterns (OR logic)
+// Used for exclude patterns: any match should exclude the object
+func matchesAnyField(fieldMap, tagMap map[string]string, matchers map[string]*fieldMatcher) bool {
+	for filterKey, matcher := range matchers {
+		fieldValue, exists := lookupField(fieldMap, tagMap, filterKey)
 
 		// If field exists and matches any pattern, return true immediately
-		if exists && matchesPatterns(fieldValue, regexPatterns) {
+		if exists && matcher.matches(fieldValue) {
 			return true
 		}
 	}
@@ -75,30 +198,151 @@ func (patternFilter *PatternFilter) matchesAnyField(obj Filterable, patterns Pat
 
 // matchesAllFields returns true only if ALL fields match their patterns (AND logic)
 // Used for include patterns: all fields must match to include the object
-func (patternFilter *PatternFilter) matchesAllFields(obj Filterable, patterns Patterns) bool {
-	fieldMap := obj.GetFilterableFields()
-	tagMap := obj.GetFilterableTags()
+func matchesAllFields(fieldMap, tagMap map[string]string, matchers map[string]*fieldMatcher) bool {
+	for filterKey, matcher := range matchers {
+		fieldValue, exists := lookupField(fieldMap, tagMap, filterKey)
 
+		// If field doesn't exist or doesn't match any pattern, return false
+		if !exists || !matcher.matches(fieldValue) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func lookupField(fieldMap, tagMap map[string]string, filterKey string) (string, bool) {
+	if fieldValue, exists := fieldMap[filterKey]; exists {
+		return fieldValue, true
+	}
+
+	if strings.HasPrefix(filterKey, TagPrefix) {
+		fieldValue, exists := tagMap[filterKey[len(TagPrefix):]]
+		return fieldValue, exists
+	}
+
+	return "", false
+}
+
+func newFieldMatchers(patterns Patterns, combinators map[string]Combinator) map[string]*fieldMatcher {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	matchers := make(map[string]*fieldMatcher, len(patterns))
 	for filterKey, regexPatterns := range patterns {
-		var fieldValue string
-		var exists bool
+		matchers[filterKey] = newFieldMatcher(regexPatterns, combinators[filterKey] == CombinatorAll)
+	}
+	return matchers
+}
+
+func newFieldMatcher(regexPatterns []*regexp.Regexp, all bool) *fieldMatcher {
+	matcher := &fieldMatcher{
+		exact: make(map[string]struct{}),
+		all:   all,
+	}
 
-		if fieldValue, exists = fieldMap[filterKey]; !exists {
-			if strings.HasPrefix(filterKey, TagPrefix) {
-				tagKey := filterKey[len(TagPrefix):]
-				fieldValue, exists = tagMap[tagKey]
-			}
+	for _, pattern := range regexPatterns {
+		if pattern == nil {
+			continue
 		}
 
-		// If field doesn't exist or doesn't match any pattern, return false
-		if !exists || !matchesPatterns(fieldValue, regexPatterns) {
+		if literal, ok := exactLiteral(pattern); ok {
+			matcher.exact[literal] = struct{}{}
+			continue
+		}
+
+		if literal, complete := pattern.LiteralPrefix(); complete {
+			matcher.literals = append(matcher.literals, literal)
+			continue
+		}
+
+		matcher.regexes = append(matcher.regexes, pattern)
+	}
+
+	return matcher
+}
+
+// exactLiteral reports whether the pattern is a literal anchored at both ends, e.g. ^prod-db$, returning the literal.
+func exactLiteral(pattern *regexp.Regexp) (string, bool) {
+	source := pattern.String()
+	if len(source) < 2 || !strings.HasPrefix(source, "^") || !strings.HasSuffix(source, "$") {
+		return "", false
+	}
+
+	inner, err := regexp.Compile(source[1 : len(source)-1])
+	if err != nil {
+		return "", false
+	}
+
+	literal, complete := inner.LiteralPrefix()
+	return literal, complete
+}
+
+func (matcher *fieldMatcher) matches(value string) bool {
+	if matcher.all {
+		return matcher.matchesAll(value)
+	}
+
+	if _, exists := matcher.exact[value]; exists {
+		return true
+	}
+
+	for _, literal := range matcher.literals {
+		// An unanchored literal regex matches anywhere in the value
+		if strings.Contains(value, literal) {
+			return true
+		}
+	}
+
+	return matchesPatterns(value, matcher.regexes)
+}
+
+// matchesAll reports whether every pattern matches the value. A field without patterns never matches, as with any.
+func (matcher *fieldMatcher) matchesAll(value string) bool {
+	if len(matcher.exact) == 0 && len(matcher.literals) == 0 && len(matcher.regexes) == 0 {
+		return false
+	}
+
+	for literal := range matcher.exact {
+		if value != literal {
+			return false
+		}
+	}
+
+	for _, literal := range matcher.literals {
+		if !strings.Contains(value, literal) {
 			return false
 		}
 	}
 
+	for _, pattern := range matcher.regexes {
+		if !pattern.MatchString(value) {
+			return false
+		}
+	}
 	return true
 }
 
+func matchingPatterns(filterKey string, value string, regexPatterns []*regexp.Regexp) []PatternMatch {
+	var matches []PatternMatch
+	for _, pattern := range regexPatterns {
+		if pattern != nil && pattern.MatchString(value) {
+			matches = append(matches, PatternMatch{Field: filterKey, Pattern: pattern.String()})
+		}
+	}
+	return matches
+}
+
+func sortedKeys(patterns Patterns) []string {
+	keys := make([]string, 0, len(patterns))
+	for filterKey := range patterns {
+		keys = append(keys, filterKey)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func matchesPatterns(value string, regexPatterns []*regexp.Regexp) bool {
 	for _, pattern := range regexPatterns {
 		if pattern != nil && pattern.MatchString(value) {
@@ -782,6 +782,105 @@ func TestSingleFieldMultiplePatterns_ORLogic(t *testing.T) {
 	}
 }
 
+func TestSingleFieldMultiplePatterns_Combinators(t *testing.T) {
+	identifierPatterns := Patterns{
+		"identifier": []*regexp.Regexp{
+			regexp.MustCompile("-prod-"),
+			regexp.MustCompile("-primary$"),
+		},
+	}
+
+	tests := []struct {
+		name            string
+		combinator      Combinator
+		includePatterns Patterns
+		excludePatterns Patterns
+		identifier      string
+		expected        bool
+	}{
+		{
+			name:            "any includes when one pattern matches",
+			combinator:      CombinatorAny,
+			includePatterns: identifierPatterns,
+			identifier:      "orders-prod-replica",
+			expected:        true,
+		},
+		{
+			name:            "all excludes when one pattern matches",
+			combinator:      CombinatorAll,
+			includePatterns: identifierPatterns,
+			identifier:      "orders-prod-replica",
+			expected:        false,
+		},
+		{
+			name:            "all includes when every pattern matches",
+			combinator:      CombinatorAll,
+			includePatterns: identifierPatterns,
+			identifier:      "orders-prod-primary",
+			expected:        true,
+		},
+		{
+			name:       "all combines exact, literal and regex patterns",
+			combinator: CombinatorAll,
+			includePatterns: Patterns{
+				"identifier": []*regexp.Regexp{
+					regexp.MustCompile("^orders-prod-primary$"),
+					regexp.MustCompile("prod"),
+					regexp.MustCompile("-p[a-z]+$"),
+				},
+			},
+			identifier: "orders-prod-primary",
+			expected:   true,
+		},
+		{
+			name:            "any exclude drops when one pattern matches",
+			combinator:      CombinatorAny,
+			excludePatterns: identifierPatterns,
+			identifier:      "orders-prod-replica",
+			expected:        false,
+		},
+		{
+			name:            "all exclude keeps when one pattern matches",
+			combinator:      CombinatorAll,
+			excludePatterns: identifierPatterns,
+			identifier:      "orders-prod-replica",
+			expected:        true,
+		},
+		{
+			name:            "all exclude drops when every pattern matches",
+			combinator:      CombinatorAll,
+			excludePatterns: identifierPatterns,
+			identifier:      "orders-prod-primary",
+			expected:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewPatternFilterWithOptions(tt.includePatterns, tt.excludePatterns, Options{
+				Combinators: map[string]Combinator{"identifier": tt.combinator},
+			})
+			obj := MockFilterable{Fields: map[string]string{"identifier": tt.identifier}}
+
+			assert.Equal(t, tt.expected, filter.ShouldInclude(obj))
+			assert.Equal(t, tt.expected, filter.Evaluate(obj).Included)
+		})
+	}
+
+	t.Run("all reports a partially matched include field as a miss", func(t *testing.T) {
+		filter := NewPatternFilterWithOptions(identifierPatterns, nil, Options{
+			Combinators: map[string]Combinator{"identifier": CombinatorAll},
+		})
+		decision := filter.Evaluate(MockFilterable{Fields: map[string]string{"identifier": "orders-prod-replica"}})
+
+		assert.Equal(t, Decision{
+			Included:       false,
+			IncludeMatches: []PatternMatch{{Field: "identifier", Pattern: "-prod-"}},
+			IncludeMisses:  []string{"identifier"},
+		}, decision)
+	})
+}
+
 func TestSingleTagMultiplePatterns_ORLogic(t *testing.T) {
 	// Test that multiple patterns for a single tag use OR logic
 	// (any pattern matching means the tag matches)
@@ -1278,3 +1377,386 @@ func TestUntaggedInstanceHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestNewFieldMatcher(t *testing.T) {
+	tests := []struct {
+		name             string
+		pattern          string
+		expectedExact    []string
+		expectedLiterals []string
+		expectedRegexes  int
+	}{
+		{
+			name:          "anchored literal uses exact lookup",
+			pattern:       "^prod-db$",
+			expectedExact: []string{"prod-db"},
+		},
+		{
+			name:          "anchored escaped literal uses exact lookup",
+			pattern:       `^db\.load\.avg$`,
+			expectedExact: []string{"db.load.avg"},
+		},
+		{
+			name:             "unanchored literal uses substring check",
+			pattern:          "prod",
+			expectedExact:    []string{},
+			expectedLiterals: []string{"prod"},
+		},
+		{
+			name:            "prefix anchored pattern falls back to regex",
+			pattern:         "^prod-",
+			expectedExact:   []string{},
+			expectedRegexes: 1,
+		},
+		{
+			name:            "pattern with regex syntax falls back to regex",
+			pattern:         "^(prod|staging)-.*$",
+			expectedExact:   []string{},
+			expectedRegexes: 1,
+		},
+		{
+			name:            "unescaped dot is not treated as literal",
+			pattern:         "^db.load$",
+			expectedExact:   []string{},
+			expectedRegexes: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher := newFieldMatcher([]*regexp.Regexp{regexp.MustCompile(tt.pattern)}, false)
+
+			exact := make([]string, 0, len(matcher.exact))
+			for literal := range matcher.exact {
+				exact = append(exact, literal)
+			}
+			assert.ElementsMatch(t, tt.expectedExact, exact)
+			assert.Equal(t, tt.expectedLiterals, matcher.literals)
+			assert.Len(t, matcher.regexes, tt.expectedRegexes)
+		})
+	}
+}
+
+func TestFieldMatcherMatchesLikeRegex(t *testing.T) {
+	patterns := []string{"^prod-db$", "prod", "^staging-", `^db\.load\.avg$`, "^db.load$", "^$"}
+	values := []string{"prod-db", "prod-db-2", "my-prod", "staging-db", "db.load.avg", "dbXload", "db.load", "", "test"}
+
+	for _, pattern := range patterns {
+		regex := regexp.MustCompile(pattern)
+		matcher := newFieldMatcher([]*regexp.Regexp{regex}, false)
+		for _, value := range values {
+			assert.Equal(t, regex.MatchString(value), matcher.matches(value), "pattern %q value %q", pattern, value)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	includePatterns := Patterns{
+		"identifier":      []*regexp.Regexp{regexp.MustCompile("^prod-"), regexp.MustCompile("-db$")},
+		"tag.Environment": []*regexp.Regexp{regexp.MustCompile("^production$")},
+	}
+	excludePatterns := Patterns{
+		"identifier": []*regexp.Regexp{regexp.MustCompile("-temp-")},
+	}
+	filter := NewPatternFilter(includePatterns, excludePatterns)
+
+	tests := []struct {
+		name     string
+		obj      Filterable
+		expected Decision
+	}{
+		{
+			name: "included object lists every matching include pattern",
+			obj: MockFilterable{
+				Fields: map[string]string{"identifier": "prod-db"},
+				Tags:   map[string]string{"Environment": "production"},
+			},
+			expected: Decision{
+				Included: true,
+				IncludeMatches: []PatternMatch{
+					{Field: "identifier", Pattern: "^prod-"},
+					{Field: "identifier", Pattern: "-db$"},
+					{Field: "tag.Environment", Pattern: "^production$"},
+				},
+			},
+		},
+		{
+			name: "excluded object lists exclude match",
+			obj: MockFilterable{
+				Fields: map[string]string{"identifier": "prod-temp-db"},
+				Tags:   map[string]string{"Environment": "production"},
+			},
+			expected: Decision{
+				Included:       false,
+				ExcludeMatches: []PatternMatch{{Field: "identifier", Pattern: "-temp-"}},
+				IncludeMatches: []PatternMatch{
+					{Field: "identifier", Pattern: "^prod-"},
+					{Field: "identifier", Pattern: "-db$"},
+					{Field: "tag.Environment", Pattern: "^production$"},
+				},
+			},
+		},
+		{
+			name: "missing tag is reported as include miss",
+			obj: MockFilterable{
+				Fields: map[string]string{"identifier": "prod-db"},
+			},
+			expected: Decision{
+				Included: false,
+				IncludeMatches: []PatternMatch{
+					{Field: "identifier", Pattern: "^prod-"},
+					{Field: "identifier", Pattern: "-db$"},
+				},
+				IncludeMisses: []string{"tag.Environment"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := filter.Evaluate(tt.obj)
+			assert.Equal(t, tt.expected, decision)
+			assert.Equal(t, filter.ShouldInclude(tt.obj), decision.Included)
+		})
+	}
+
+	t.Run("nil object is not included", func(t *testing.T) {
+		assert.Equal(t, Decision{Included: false}, filter.Evaluate(nil))
+	})
+}
+
+func TestShouldIncludeWithMode(t *testing.T) {
+	prodInclude := Patterns{"identifier": []*regexp.Regexp{regexp.MustCompile("^prod-")}}
+	tempExclude := Patterns{"identifier": []*regexp.Regexp{regexp.MustCompile("-temp-")}}
+
+	tests := []struct {
+		name            string
+		mode            Mode
+		includePatterns Patterns
+		excludePatterns Patterns
+		identifier      string
+		expected        bool
+	}{
+		{
+			name:            "exclude always without include excludes match",
+			mode:            ModeExcludeAlways,
+			excludePatterns: tempExclude,
+			identifier:      "dev-temp-db",
+			expected:        false,
+		},
+		{
+			name:            "exclude always with include excludes match",
+			mode:            ModeExcludeAlways,
+			includePatterns: prodInclude,
+			excludePatterns: tempExclude,
+			identifier:      "prod-temp-db",
+			expected:        false,
+		},
+		{
+			name:            "exclude within include without include ignores exclude",
+			mode:            ModeExcludeWithinInclude,
+			excludePatterns: tempExclude,
+			identifier:      "dev-temp-db",
+			expected:        true,
+		},
+		{
+			name:            "exclude within include narrows include",
+			mode:            ModeExcludeWithinInclude,
+			includePatterns: prodInclude,
+			excludePatterns: tempExclude,
+			identifier:      "prod-temp-db",
+			expected:        false,
+		},
+		{
+			name:            "exclude within include keeps included object",
+			mode:            ModeExcludeWithinInclude,
+			includePatterns: prodInclude,
+			excludePatterns: tempExclude,
+			identifier:      "prod-db",
+			expected:        true,
+		},
+		{
+			name:            "exclude within include still requires include match",
+			mode:            ModeExcludeWithinInclude,
+			includePatterns: prodInclude,
+			excludePatterns: tempExclude,
+			identifier:      "dev-db",
+			expected:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := NewPatternFilterWithOptions(tt.includePatterns, tt.excludePatterns, Options{Mode: tt.mode})
+			obj := MockFilterable{Fields: map[string]string{"identifier": tt.identifier}}
+
+			assert.Equal(t, tt.expected, filter.ShouldInclude(obj))
+			assert.Equal(t, tt.expected, filter.Evaluate(obj).Included)
+		})
+	}
+
+	t.Run("exclude within include without include reports no exclude match", func(t *testing.T) {
+		filter := NewPatternFilterWithOptions(nil, tempExclude, Options{Mode: ModeExcludeWithinInclude})
+		decision := filter.Evaluate(MockFilterable{Fields: map[string]string{"identifier": "dev-temp-db"}})
+		assert.Equal(t, Decision{Included: true}, decision)
+	})
+}
+
+func TestModeIsValid(t *testing.T) {
+	assert.True(t, ModeExcludeAlways.IsValid())
+	assert.True(t, ModeExcludeWithinInclude.IsValid())
+	assert.False(t, Mode("include-wins").IsValid())
+	assert.False(t, Mode("").IsValid())
+}
+
+func TestCombinatorIsValid(t *testing.T) {
+	assert.True(t, CombinatorAny.IsValid())
+	assert.True(t, CombinatorAll.IsValid())
+	assert.False(t, Combinator("none").IsValid())
+	assert.False(t, Combinator("").IsValid())
+}
+
+func BenchmarkShouldInclude(b *testing.B) {
+	includePatterns := Patterns{
+		"identifier":      []*regexp.Regexp{regexp.MustCompile("^prod-db-1$"), regexp.MustCompile("^prod-db-2$"), regexp.MustCompile("^(prod|staging)-.*$")},
+		"engine":          []*regexp.Regexp{regexp.MustCompile("postgres")},
+		"tag.Environment": []*regexp.Regexp{regexp.MustCompile("^production$")},
+	}
+	excludePatterns := Patterns{
+		"identifier": []*regexp.Regexp{regexp.MustCompile("-temp-"), regexp.MustCompile("-test$")},
+	}
+
+	objects := []Filterable{
+		MockFilterable{
+			Fields: map[string]string{"identifier": "prod-db-1", "engine": "aurora-postgresql"},
+			Tags:   map[string]string{"Environment": "production"},
+		},
+		MockFilterable{
+			Fields: map[string]string{"identifier": "staging-db-7", "engine": "aurora-postgresql"},
+			Tags:   map[string]string{"Environment": "staging"},
+		},
+		MockFilterable{
+			Fields: map[string]string{"identifier": "prod-temp-db", "engine": "mysql"},
+			Tags:   map[string]string{"Environment": "production"},
+		},
+	}
+
+	filter := NewPatternFilter(includePatterns, excludePatterns)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, obj := range objects {
+			filter.ShouldInclude(obj)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	includePatterns := Patterns{
+		"tag.Environment": []*regexp.Regexp{regexp.MustCompile("^production$")},
+	}
+	excludePatterns := Patterns{
+		"name": []*regexp.Regexp{regexp.MustCompile("-temp$")},
+	}
+
+	tests := []struct {
+		name             string
+		includePatterns  Patterns
+		excludePatterns  Patterns
+		obj              Filterable
+		expectedIncluded bool
+		expectedReason   string
+	}{
+		{
+			name:            "excluded by exclude pattern",
+			includePatterns: nil,
+			excludePatterns: excludePatterns,
+			obj: MockFilterable{
+				Fields: map[string]string{"name": "orders-temp"},
+			},
+			expectedIncluded: false,
+			expectedReason:   "excluded: field 'name' matched exclude pattern '-temp$'",
+		},
+		{
+			name:            "excluded by include miss",
+			includePatterns: includePatterns,
+			excludePatterns: excludePatterns,
+			obj: MockFilterable{
+				Fields: map[string]string{"name": "orders"},
+				Tags:   map[string]string{"Environment": "staging"},
+			},
+			expectedIncluded: false,
+			expectedReason:   "excluded: field 'tag.Environment' matched no include pattern",
+		},
+		{
+			name:            "included by include pattern",
+			includePatterns: includePatterns,
+			excludePatterns: excludePatterns,
+			obj: MockFilterable{
+				Fields: map[string]string{"name": "orders"},
+				Tags:   map[string]string{"Environment": "production"},
+			},
+			expectedIncluded: true,
+			expectedReason:   "included: field 'tag.Environment' matched include pattern '^production$'",
+		},
+		{
+			name:            "included without include patterns",
+			includePatterns: nil,
+			excludePatterns: excludePatterns,
+			obj: MockFilterable{
+				Fields: map[string]string{"name": "orders"},
+			},
+			expectedIncluded: true,
+			expectedReason:   "included: no pattern excludes it",
+		},
+		{
+			name:             "nil object is excluded",
+			includePatterns:  includePatterns,
+			excludePatterns:  excludePatterns,
+			obj:              nil,
+			expectedIncluded: false,
+			expectedReason:   "excluded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patternFilter := NewPatternFilter(tt.includePatterns, tt.excludePatterns).(*PatternFilter)
+
+			included, reason := patternFilter.Explain(tt.obj)
+			assert.Equal(t, tt.expectedIncluded, included)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestDecisionMerge(t *testing.T) {
+	global := Decision{
+		Included:       false,
+		ExcludeMatches: []PatternMatch{{Field: "tag.monitoring", Pattern: "^off$"}},
+	}
+	local := Decision{
+		Included:       true,
+		IncludeMatches: []PatternMatch{{Field: "identifier", Pattern: "^prod-"}},
+		IncludeMisses:  []string{"engine"},
+	}
+
+	assert.Equal(t, Decision{
+		Included:       false,
+		ExcludeMatches: []PatternMatch{{Field: "tag.monitoring", Pattern: "^off$"}},
+		IncludeMatches: []PatternMatch{{Field: "identifier", Pattern: "^prod-"}},
+		IncludeMisses:  []string{"engine"},
+	}, global.Merge(local))
+	assert.True(t, Decision{Included: true}.Merge(Decision{Included: true}).Included)
+	assert.Equal(t, []string{"not allowed"}, Decision{ExcludeReasons: []string{"not allowed"}}.Merge(Decision{Included: true}).ExcludeReasons)
+}
+
+func TestDecisionReasonWithExcludeReasons(t *testing.T) {
+	decision := Decision{
+		Included:       false,
+		ExcludeReasons: []string{"identifier not in instances.identifiers"},
+		ExcludeMatches: []PatternMatch{{Field: "name", Pattern: "-temp$"}},
+	}
+
+	assert.Equal(t, "excluded: identifier not in instances.identifiers; field 'name' matched exclude pattern '-temp$'", decision.Reason())
+}
@@ -0,0 +1,25 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+)
+
+// NewLogger returns a logger writing the records at or above the configured level to w, in the configured format.
+func NewLogger(w io.Writer, config models.ParsedLogConfig) *slog.Logger {
+	options := &slog.HandlerOptions{Level: config.Level}
+	if config.Format == models.LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, options))
+	}
+	return slog.New(slog.NewTextHandler(w, options))
+}
+
+// Configure makes the default logger write to stderr with the level and format of the log config. Components log
+// with the default logger, or with a logger derived from it when they are created, so it is configured before the
+// region managers are created. The records of the standard log package go through the default logger as well.
+func Configure(config models.ParsedLogConfig) {
+	slog.SetDefault(NewLogger(os.Stderr, config))
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+)
+
+func TestNewLogger(t *testing.T) {
+	t.Run("text format", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := NewLogger(&buf, models.ParsedLogConfig{Level: slog.LevelInfo, Format: models.LogFormatText})
+
+		logger.Info("Discovered instances", "region", "us-west-2", "count", 3)
+
+		assert.Contains(t, buf.String(), `level=INFO msg="Discovered instances" region=us-west-2 count=3`)
+	})
+
+	t.Run("json format", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := NewLogger(&buf, models.ParsedLogConfig{Level: slog.LevelInfo, Format: models.LogFormatJSON})
+
+		logger.Warn("Discovery truncated", "region", "us-west-2", "identifier", "prod-db")
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, "WARN", record["level"])
+		assert.Equal(t, "Discovery truncated", record["msg"])
+		assert.Equal(t, "us-west-2", record["region"])
+		assert.Equal(t, "prod-db", record["identifier"])
+	})
+
+	t.Run("records below the level are dropped", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := NewLogger(&buf, models.ParsedLogConfig{Level: slog.LevelWarn, Format: models.LogFormatText})
+
+		logger.Debug("debug")
+		logger.Info("info")
+		assert.Empty(t, buf.String())
+
+		logger.Error("error")
+		assert.Contains(t, buf.String(), "level=ERROR")
+	})
+}
+
+func TestConfigure(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	Configure(models.ParsedLogConfig{Level: slog.LevelError, Format: models.LogFormatJSON})
+
+	assert.False(t, slog.Default().Enabled(context.Background(), slog.LevelWarn))
+	assert.True(t, slog.Default().Enabled(context.Background(), slog.LevelError))
+}
@@ -2,9 +2,15 @@ package instance
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
-	"log"
-	"sort"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/rds/types"
@@ -15,11 +21,16 @@ import (
 )
 
 const (
-	MaxRetries          = 3
 	BaseDelay           = time.Second
 	InstanceTTL         = 5 * time.Minute
 	MetricsTTL          = 60 * time.Minute
 	ValidInstanceStatus = "available"
+	// InstanceTTLJitter is the largest fraction of the instance TTL added at random to the TTL after each discovery,
+	// so that replicas started together don't refresh in lockstep
+	InstanceTTLJitter = 0.1
+	// PersistentlyEmptyDiscoveries is the number of consecutive discoveries finding no instances after which the
+	// region is reported as persistently empty
+	PersistentlyEmptyDiscoveries = 3
 )
 
 type RDSInstanceManager struct {
@@ -27,7 +38,25 @@ type RDSInstanceManager struct {
 	Instances            []models.Instance
 	InstancesLastUpdated time.Time
 	InstanceTTL          time.Duration
+	EmptyTTL             time.Duration
+	MinRefreshInterval   time.Duration
+	lastDiscoveryAttempt time.Time
 	configuration        *models.ParsedConfig
+	maxRetries           int
+	retryBaseDelay       time.Duration
+	retryJitter          models.RetryJitter
+
+	// refreshMu serializes GetInstances, so concurrent scrapes after the TTL expired share one discovery call
+	refreshMu sync.Mutex
+	ttlJitter time.Duration
+	// instanceLimitReached reports whether the last discovery found more instances than instances.max-instances
+	instanceLimitReached atomic.Bool
+	// filteredInstances are the instances the instance filters excluded in the last discovery, guarded by refreshMu
+	filteredInstances []models.Instance
+	// emptyDiscoveries counts the consecutive discoveries that found no instances, guarded by refreshMu
+	emptyDiscoveries int
+
+	logger *slog.Logger
 }
 
 type SafeInstanceFields struct {
@@ -37,6 +66,15 @@ type SafeInstanceFields struct {
 	DbiResourceId              string
 	DBInstanceIdentifier       string
 	InstanceCreateTime         time.Time
+	StorageEncrypted           bool
+	StorageType                string
+	PIRetentionPeriod          int32
+	DBInstanceArn              string
+	DBSubnetGroupName          string
+	VpcId                      string
+	DBClusterIdentifier        string
+	DBInstanceClass            string
+	AllocatedStorage           int32
 }
 
 // RDSInstanceManager handles discovery and caching of RDS database instances within a region.
@@ -46,58 +84,208 @@ func NewRDSInstanceManager(rds rds.RDSService, config *models.ParsedConfig) (*RD
 		return nil, fmt.Errorf("configuration parameter cannot be nil")
 	}
 	return &RDSInstanceManager{
-		rdsService:    rds,
-		InstanceTTL:   config.Discovery.Instances.InstanceTTL,
-		configuration: config,
+		rdsService:         rds,
+		InstanceTTL:        config.Discovery.Instances.InstanceTTL,
+		EmptyTTL:           config.Discovery.Instances.EmptyTTL,
+		MinRefreshInterval: config.Discovery.MinRefreshInterval,
+		configuration:      config,
+		maxRetries:         config.Discovery.Processing.DiscoveryMaxRetries,
+		retryBaseDelay:     BaseDelay,
+		retryJitter:        config.Discovery.Processing.RetryJitter,
+		logger:             slog.Default(),
 	}, nil
 }
 
+// WithLogger makes the manager log with logger, e.g. a logger carrying the region the manager discovers instances in.
+func (instanceManager *RDSInstanceManager) WithLogger(logger *slog.Logger) *RDSInstanceManager {
+	instanceManager.logger = logger
+	return instanceManager
+}
+
 // GetInstances returns cached database instances, refreshing from AWS if TTL is expired.
+// Discovery never runs more often than MinRefreshInterval, even when the TTL has expired or the cache is empty.
+// Concurrent callers wait for a refresh in progress and are served the instances it discovered. The TTL is extended
+// by a random jitter of up to InstanceTTLJitter after each discovery. A discovery that found no instances is cached
+// for EmptyTTL instead, so a region without instances doesn't call RDS every time the shorter TTL expires.
+// A scrape refreshing instances, see models.ScrapeRefresh, discovers them regardless of the TTL without updating the cache.
 func (instanceManager *RDSInstanceManager) GetInstances(ctx context.Context) ([]models.Instance, error) {
 	if instanceManager.configuration == nil {
 		return nil, fmt.Errorf("configuration cannot be nil")
 	}
 
-	if instanceManager.Instances == nil || instanceManager.InstancesLastUpdated.IsZero() || time.Now().After(instanceManager.InstancesLastUpdated.Add(instanceManager.InstanceTTL)) {
-		instances, err := instanceManager.discoverInstances(ctx)
+	instanceManager.refreshMu.Lock()
+	defer instanceManager.refreshMu.Unlock()
+
+	if models.ScrapeRefreshFromContext(ctx).Instances() {
+		return instanceManager.refreshInstances(ctx)
+	}
+
+	ttl := instanceManager.InstanceTTL + instanceManager.ttlJitter
+	if instanceManager.emptyDiscoveries > 0 {
+		ttl = max(instanceManager.InstanceTTL, instanceManager.EmptyTTL) + instanceManager.ttlJitter
+	}
+	if instanceManager.InstancesLastUpdated.IsZero() || time.Now().After(instanceManager.InstancesLastUpdated.Add(ttl)) {
+		if instanceManager.refreshTooSoon() {
+			if instanceManager.InstancesLastUpdated.IsZero() && instanceManager.Instances == nil {
+				return nil, fmt.Errorf("instance discovery skipped, last attempt was less than %v ago", instanceManager.MinRefreshInterval)
+			}
+			instanceManager.logger.Info("Skipping discovery, last attempt was too recent, serving cached instances", "min_refresh_interval", instanceManager.MinRefreshInterval)
+			return instanceManager.Instances, nil
+		}
+
+		instanceManager.lastDiscoveryAttempt = time.Now()
+		instances, filteredInstances, err := instanceManager.discoverInstances(ctx)
 		if err != nil {
 			return nil, err
 		}
-		log.Printf("[INSTANCE] Discovered %d instances ", len(instances))
+		instanceManager.filteredInstances = filteredInstances
+		instanceManager.logger.Info("Discovered instances", "count", len(instances))
+		instanceManager.recordEmptyDiscovery(len(instances) == 0)
 
 		maxInstances := instanceManager.configuration.Discovery.Instances.MaxInstances
 		if len(instances) > maxInstances {
 			instanceManager.Instances = instances[:maxInstances]
-			log.Printf("[INSTANCE] Limited to %d instances ", len(instanceManager.Instances))
+			instanceManager.logger.Warn("Discovery truncated, instances exceed instances.max-instances, collecting the first ones", "count", len(instances), "max_instances", maxInstances)
 		} else {
 			instanceManager.Instances = instances
 		}
+		instanceManager.instanceLimitReached.Store(len(instances) > maxInstances)
 		instanceManager.InstancesLastUpdated = time.Now()
+		instanceManager.ttlJitter = randomTTLJitter(instanceManager.InstanceTTL)
 	}
 
 	return instanceManager.Instances, nil
 }
 
-func (instanceManager *RDSInstanceManager) discoverInstances(ctx context.Context) ([]models.Instance, error) {
-	discoveredInstances, err := utils.WithRetry(ctx, func() ([]types.DBInstance, error) {
-		return instanceManager.rdsService.DescribeDBInstancesPaginator(ctx)
-	}, MaxRetries, BaseDelay)
+// refreshInstances discovers the instances for a single scrape, leaving the cached instances, their TTL and the
+// filtered instances to the scrapes that don't refresh. Instances already cached keep their metrics, so refreshing
+// instances doesn't list their metric metadata again. Discovery still never runs more often than MinRefreshInterval.
+func (instanceManager *RDSInstanceManager) refreshInstances(ctx context.Context) ([]models.Instance, error) {
+	if instanceManager.refreshTooSoon() {
+		if instanceManager.InstancesLastUpdated.IsZero() {
+			return nil, fmt.Errorf("instance discovery skipped, last attempt was less than %v ago", instanceManager.MinRefreshInterval)
+		}
+		instanceManager.logger.Info("Skipping refresh, last attempt was too recent, serving cached instances", "min_refresh_interval", instanceManager.MinRefreshInterval)
+		return instanceManager.Instances, nil
+	}
+
+	instanceManager.lastDiscoveryAttempt = time.Now()
+	instances, _, err := instanceManager.discoverInstances(ctx)
 	if err != nil {
-		log.Printf("[INSTANCE] Error discovering instances: %v", err)
 		return nil, err
 	}
+	instanceManager.logger.Info("Refreshed instances for the scrape", "count", len(instances))
+
+	cachedMetrics := make(map[string]*models.Metrics, len(instanceManager.Instances))
+	for _, instance := range instanceManager.Instances {
+		cachedMetrics[instance.ResourceID] = instance.Metrics
+	}
+	for i := range instances {
+		if metrics, cached := cachedMetrics[instances[i].ResourceID]; cached {
+			instances[i].Metrics = metrics
+		}
+	}
+
+	if maxInstances := instanceManager.configuration.Discovery.Instances.MaxInstances; len(instances) > maxInstances {
+		return instances[:maxInstances], nil
+	}
+	return instances, nil
+}
+
+// Invalidate resets InstancesLastUpdated, so the next call to GetInstances discovers instances regardless of the TTL.
+// The cached instances are kept and still served while MinRefreshInterval holds discovery back.
+func (instanceManager *RDSInstanceManager) Invalidate() {
+	instanceManager.refreshMu.Lock()
+	defer instanceManager.refreshMu.Unlock()
+
+	instanceManager.InstancesLastUpdated = time.Time{}
+}
+
+// recordEmptyDiscovery counts the consecutive discoveries that found no instances, and logs when the region has been
+// empty for PersistentlyEmptyDiscoveries discoveries in a row, which usually means it is misconfigured.
+func (instanceManager *RDSInstanceManager) recordEmptyDiscovery(empty bool) {
+	if !empty {
+		instanceManager.emptyDiscoveries = 0
+		return
+	}
+
+	instanceManager.emptyDiscoveries++
+	if instanceManager.emptyDiscoveries == PersistentlyEmptyDiscoveries {
+		instanceManager.logger.Warn("No instances discovered in consecutive discoveries, check the region and the instance filters",
+			"discoveries", instanceManager.emptyDiscoveries, "rediscover_every", max(instanceManager.InstanceTTL, instanceManager.EmptyTTL))
+	}
+}
+
+// InstanceLimitReached reports whether the last discovery found more instances than instances.max-instances,
+// so that only the first ones are collected.
+func (instanceManager *RDSInstanceManager) InstanceLimitReached() bool {
+	return instanceManager.instanceLimitReached.Load()
+}
+
+// FilteredInstances returns the instances the instance filters excluded in the last discovery.
+func (instanceManager *RDSInstanceManager) FilteredInstances() []models.Instance {
+	instanceManager.refreshMu.Lock()
+	defer instanceManager.refreshMu.Unlock()
+
+	return slices.Clone(instanceManager.filteredInstances)
+}
+
+// randomTTLJitter returns a random duration between 0 and InstanceTTLJitter of the TTL.
+func randomTTLJitter(ttl time.Duration) time.Duration {
+	maxJitter := time.Duration(float64(ttl) * InstanceTTLJitter)
+	if maxJitter <= 0 {
+		return 0
+	}
+	return rand.N(maxJitter + 1)
+}
+
+// refreshTooSoon reports whether the last discovery attempt is more recent than MinRefreshInterval.
+func (instanceManager *RDSInstanceManager) refreshTooSoon() bool {
+	if instanceManager.MinRefreshInterval <= 0 || instanceManager.lastDiscoveryAttempt.IsZero() {
+		return false
+	}
+	return time.Since(instanceManager.lastDiscoveryAttempt) < instanceManager.MinRefreshInterval
+}
+
+// discoverInstances lists the instances in the region, retrying transient RDS errors such as throttling.
+// Every attempt restarts pagination from the first page, so a failure on a later page never yields a partial list.
+// Instances are returned oldest first, or by descending discovery.priority-tag value when set, so the
+// instances kept by the max-instances cap and collected first are the oldest or the highest priority ones.
+// The instances the instance filters excluded are returned as well.
+func (instanceManager *RDSInstanceManager) discoverInstances(ctx context.Context) ([]models.Instance, []models.Instance, error) {
+	discoveredInstances, err := utils.WithRetryJitter(ctx, func() ([]types.DBInstance, error) {
+		return instanceManager.rdsService.DescribeDBInstancesPaginator(ctx, instanceManager.configuration.Discovery.Instances.MaxPages)
+	}, instanceManager.maxRetries, instanceManager.retryBaseDelay, instanceManager.retryJitter, utils.IsRetryableAWSError)
+	if err != nil {
+		instanceManager.logger.Error("Error discovering instances", "operation", "DescribeDBInstances", "error", err)
+		return nil, nil, err
+	}
 
-	var instances []models.Instance
+	var instances, filteredInstances []models.Instance
 	for _, dbInstance := range discoveredInstances {
 		instanceFields, err := safeExtractInstanceFields(dbInstance)
 		if err != nil {
-			log.Printf("[INSTANCE] Error extracting instance fields: %v", err)
+			instanceManager.logger.Error("Error extracting instance fields", "error", err)
+			continue
+		}
+
+		if !slices.Contains(instanceManager.configuration.Discovery.Instances.Statuses, instanceFields.DBInstanceStatus) {
+			instanceManager.logger.Info("Skipping instance, status not in instances.statuses", "identifier", instanceFields.DBInstanceIdentifier, "status", instanceFields.DBInstanceStatus)
+			continue
+		}
+
+		if !instanceFields.PerformanceInsightsEnabled {
+			instanceManager.logger.Info("Skipping instance without Performance Insights enabled", "identifier", instanceFields.DBInstanceIdentifier)
 			continue
 		}
 
 		var instance models.Instance
 		engine := models.NewEngine(instanceFields.Engine)
-		if instanceFields.PerformanceInsightsEnabled && engine != "" {
+		if engine == "" && instanceManager.configuration.Discovery.UnknownEngineBehavior == models.UnknownEngineIncludeAsOther {
+			instanceManager.logger.Info("Unrecognized engine, including instance as other", "identifier", instanceFields.DBInstanceIdentifier, "engine", instanceFields.Engine)
+			engine = models.Other
+		}
+		if engine != "" {
 			// Extract tags from DBInstance
 			tags := make(map[string]string)
 			for _, tag := range dbInstance.TagList {
@@ -110,8 +298,21 @@ func (instanceManager *RDSInstanceManager) discoverInstances(ctx context.Context
 				ResourceID:   instanceFields.DbiResourceId,
 				Identifier:   instanceFields.DBInstanceIdentifier,
 				Engine:       engine,
+				Status:       instanceFields.DBInstanceStatus,
 				CreationTime: instanceFields.InstanceCreateTime,
 				Tags:         tags,
+
+				StorageEncrypted: instanceFields.StorageEncrypted,
+				StorageType:      instanceFields.StorageType,
+
+				PIRetentionPeriod: instanceFields.PIRetentionPeriod,
+				SubnetGroup:       instanceFields.DBSubnetGroupName,
+				VpcID:             instanceFields.VpcId,
+				ClusterIdentifier: instanceFields.DBClusterIdentifier,
+				InstanceClass:     instanceFields.DBInstanceClass,
+				AllocatedStorage:  instanceFields.AllocatedStorage,
+				ARN:               instanceFields.DBInstanceArn,
+				AccountID:         models.AccountIDFromARN(instanceFields.DBInstanceArn),
 				Metrics: &models.Metrics{
 					MetadataTTL: instanceManager.configuration.Discovery.Metrics.MetadataTTL,
 				},
@@ -120,6 +321,9 @@ func (instanceManager *RDSInstanceManager) discoverInstances(ctx context.Context
 
 		instanceConfig := instanceManager.configuration.Discovery.Instances
 		if !instanceConfig.ShouldIncludeInstance(instance) {
+			if instance.Identifier != "" {
+				filteredInstances = append(filteredInstances, instance)
+			}
 			continue
 		}
 
@@ -127,14 +331,36 @@ func (instanceManager *RDSInstanceManager) discoverInstances(ctx context.Context
 			continue
 		}
 
+		if minRetention := instanceManager.configuration.Discovery.MinPIRetention; instance.PIRetentionPeriod < minRetention {
+			instanceManager.logger.Info("Skipping instance with Performance Insights retention below discovery.min-pi-retention",
+				"identifier", instance.Identifier, "pi_retention_days", instance.PIRetentionPeriod, "min_pi_retention_days", minRetention)
+			continue
+		}
+
+		if !isSampled(instance.Identifier, instanceManager.configuration.Discovery.SampleRate) {
+			continue
+		}
+
 		instances = append(instances, instance)
 	}
 
-	sort.Slice(instances, func(i, j int) bool {
-		return instances[i].CreationTime.Before(instances[j].CreationTime)
-	})
+	models.SortInstances(instances, instanceManager.configuration.Discovery.Instances.Selection)
+	if priorityTag := instanceManager.configuration.Discovery.PriorityTag; priorityTag != "" {
+		models.SortInstancesByPriority(instances, priorityTag)
+	}
 
-	return instances, nil
+	return instances, filteredInstances, nil
+}
+
+// isSampled deterministically selects an instance for collection by hashing its identifier, so the
+// same subset of instances is kept across discoveries for a given sample rate. An unset rate keeps every instance.
+func isSampled(identifier string, sampleRate float64) bool {
+	if sampleRate <= 0 || sampleRate >= 1 {
+		return true
+	}
+
+	sum := sha256.Sum256([]byte(identifier))
+	return float64(binary.BigEndian.Uint64(sum[:8]))/float64(math.MaxUint64) < sampleRate
 }
 
 func safeExtractInstanceFields(instance types.DBInstance) (*SafeInstanceFields, error) {
@@ -175,5 +401,42 @@ func safeExtractInstanceFields(instance types.DBInstance) (*SafeInstanceFields,
 	}
 	fields.InstanceCreateTime = *instance.InstanceCreateTime
 
+	if instance.StorageEncrypted != nil {
+		fields.StorageEncrypted = *instance.StorageEncrypted
+	}
+
+	if instance.StorageType != nil {
+		fields.StorageType = *instance.StorageType
+	}
+
+	if instance.PerformanceInsightsRetentionPeriod != nil {
+		fields.PIRetentionPeriod = *instance.PerformanceInsightsRetentionPeriod
+	}
+
+	if instance.DBInstanceArn != nil {
+		fields.DBInstanceArn = *instance.DBInstanceArn
+	}
+
+	if instance.DBSubnetGroup != nil {
+		if instance.DBSubnetGroup.DBSubnetGroupName != nil {
+			fields.DBSubnetGroupName = *instance.DBSubnetGroup.DBSubnetGroupName
+		}
+		if instance.DBSubnetGroup.VpcId != nil {
+			fields.VpcId = *instance.DBSubnetGroup.VpcId
+		}
+	}
+
+	if instance.DBClusterIdentifier != nil {
+		fields.DBClusterIdentifier = *instance.DBClusterIdentifier
+	}
+
+	if instance.DBInstanceClass != nil {
+		fields.DBInstanceClass = *instance.DBInstanceClass
+	}
+
+	if instance.AllocatedStorage != nil {
+		fields.AllocatedStorage = *instance.AllocatedStorage
+	}
+
 	return fields, nil
 }
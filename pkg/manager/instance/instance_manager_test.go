@@ -3,14 +3,21 @@ package instance
 import (
 	"context"
 	"errors"
+	"fmt"
+	"regexp"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/aws/smithy-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients/rds"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/filter"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
@@ -47,6 +54,7 @@ func TestNewRDSInstanceManager(t *testing.T) {
 			assert.NotNil(t, manager)
 			assert.Equal(t, tc.mockRDSService, manager.rdsService)
 			assert.Equal(t, tc.config, manager.configuration)
+			assert.Equal(t, tc.config.Discovery.Processing.DiscoveryMaxRetries, manager.maxRetries)
 			assert.Empty(t, manager.Instances)
 			assert.True(t, manager.InstancesLastUpdated.Before(time.Now().Add(-5*time.Minute)))
 		})
@@ -155,7 +163,7 @@ func TestGetInstances(t *testing.T) {
 			manager := tc.setupManager()
 
 			if tc.shouldCallRDS {
-				manager.rdsService.(*mocks.MockRDSService).On("DescribeDBInstancesPaginator", mock.Anything).
+				manager.rdsService.(*mocks.MockRDSService).On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).
 					Return(tc.mockResponse, tc.expectedError)
 			}
 
@@ -180,6 +188,377 @@ func TestGetInstances(t *testing.T) {
 	}
 }
 
+func TestGetInstancesInstanceLimit(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		maxInstances         int
+		maxPages             int
+		selection            models.InstanceSelection
+		expectedIdentifiers  []string
+		expectedLimitReached bool
+	}{
+		{
+			name:                 "keeps the oldest instance across pages when truncated",
+			maxInstances:         1,
+			maxPages:             5,
+			expectedIdentifiers:  []string{"test-mysql-db"},
+			expectedLimitReached: true,
+		},
+		{
+			name:                 "keeps the newest instance with newest selection",
+			maxInstances:         1,
+			selection:            models.InstanceSelectionNewest,
+			expectedIdentifiers:  []string{"test-postgres-db"},
+			expectedLimitReached: true,
+		},
+		{
+			name:                 "keeps the first identifier with alphabetical selection",
+			maxInstances:         1,
+			selection:            models.InstanceSelectionAlphabetical,
+			expectedIdentifiers:  []string{"test-mysql-db"},
+			expectedLimitReached: true,
+		},
+		{
+			name:                 "keeps every instance sorted by creation time within the limit",
+			maxInstances:         2,
+			maxPages:             0,
+			expectedIdentifiers:  []string{"test-mysql-db", "test-postgres-db"},
+			expectedLimitReached: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testutils.NewTestConfigBuilder().WithMaxInstances(tc.maxInstances).WithInstanceSelection(tc.selection).Build()
+			config.Discovery.Instances.MaxPages = tc.maxPages
+
+			mockRDSService := &mocks.MockRDSService{}
+			mockRDSService.On("DescribeDBInstancesPaginator", mock.Anything, tc.maxPages).Return(mocks.NewMockRDSDescribeInstances(), nil)
+
+			manager, err := NewRDSInstanceManager(mockRDSService, config)
+			require.NoError(t, err)
+
+			instances, err := manager.GetInstances(context.Background())
+			require.NoError(t, err)
+
+			identifiers := make([]string, 0, len(instances))
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+			}
+			assert.Equal(t, tc.expectedIdentifiers, identifiers)
+			assert.Equal(t, tc.expectedLimitReached, manager.InstanceLimitReached())
+			mockRDSService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetInstancesMinRefreshInterval(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		lastDiscoveryAttempt time.Duration
+		cached               bool
+		shouldCallRDS        bool
+		expectedError        bool
+		expectedCount        int
+	}{
+		{
+			name:                 "expired cache within min refresh interval serves cached instances",
+			lastDiscoveryAttempt: 10 * time.Second,
+			cached:               true,
+			shouldCallRDS:        false,
+			expectedCount:        len(testutils.TestInstances),
+		},
+		{
+			name:                 "empty cache within min refresh interval returns an error",
+			lastDiscoveryAttempt: 10 * time.Second,
+			cached:               false,
+			shouldCallRDS:        false,
+			expectedError:        true,
+		},
+		{
+			name:                 "expired cache after min refresh interval discovers instances",
+			lastDiscoveryAttempt: 2 * time.Minute,
+			cached:               true,
+			shouldCallRDS:        true,
+			expectedCount:        2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDSService := &mocks.MockRDSService{}
+			config := testutils.NewTestConfigBuilder().WithMinRefreshInterval(time.Minute).Build()
+			manager, _ := NewRDSInstanceManager(mockRDSService, config)
+			manager.lastDiscoveryAttempt = time.Now().Add(-tc.lastDiscoveryAttempt)
+			if tc.cached {
+				manager.Instances = testutils.TestInstances
+				manager.InstancesLastUpdated = time.Now().Add(-time.Hour)
+			}
+
+			if tc.shouldCallRDS {
+				mockRDSService.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).
+					Return(mocks.NewMockRDSDescribeInstances(), nil)
+			}
+
+			instances, err := manager.GetInstances(context.Background())
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, instances)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, instances, tc.expectedCount)
+			}
+
+			mockRDSService.AssertExpectations(t)
+			if !tc.shouldCallRDS {
+				mockRDSService.AssertNotCalled(t, "DescribeDBInstancesPaginator", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestGetInstancesRefresh(t *testing.T) {
+	t.Run("refresh discovers instances without updating the cache", func(t *testing.T) {
+		mockRDSService := &mocks.MockRDSService{}
+		mockRDSService.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).
+			Return(mocks.NewMockRDSDescribeInstances(), nil).Once()
+		manager, err := NewRDSInstanceManager(mockRDSService, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+
+		cachedInstances := []models.Instance{testutils.TestInstancePostgreSQL}
+		lastUpdated := time.Now().Add(-time.Minute)
+		manager.Instances = cachedInstances
+		manager.InstancesLastUpdated = lastUpdated
+
+		ctx := models.ContextWithScrapeRefresh(context.Background(), models.ScrapeRefreshInstances)
+		instances, err := manager.GetInstances(ctx)
+		require.NoError(t, err)
+		require.Len(t, instances, 2)
+		for _, instance := range instances {
+			if instance.ResourceID == testutils.TestInstancePostgreSQL.ResourceID {
+				assert.Same(t, testutils.TestInstancePostgreSQL.Metrics, instance.Metrics, "cached instances keep their metric metadata")
+			} else {
+				assert.Empty(t, instance.Metrics.MetricsList)
+			}
+		}
+
+		assert.Equal(t, cachedInstances, manager.Instances)
+		assert.True(t, lastUpdated.Equal(manager.InstancesLastUpdated))
+
+		instances, err = manager.GetInstances(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, instances, 1, "scrapes that don't refresh are served the cached instances")
+		mockRDSService.AssertExpectations(t)
+	})
+
+	t.Run("refresh of metadata only serves cached instances", func(t *testing.T) {
+		mockRDSService := &mocks.MockRDSService{}
+		manager, err := NewRDSInstanceManager(mockRDSService, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+		manager.Instances = testutils.TestInstances
+		manager.InstancesLastUpdated = time.Now()
+
+		ctx := models.ContextWithScrapeRefresh(context.Background(), models.ScrapeRefreshMetadata)
+		instances, err := manager.GetInstances(ctx)
+		require.NoError(t, err)
+		assert.Len(t, instances, len(testutils.TestInstances))
+		mockRDSService.AssertNotCalled(t, "DescribeDBInstancesPaginator", mock.Anything, mock.Anything)
+	})
+
+	t.Run("refresh within min refresh interval serves cached instances", func(t *testing.T) {
+		mockRDSService := &mocks.MockRDSService{}
+		config := testutils.NewTestConfigBuilder().WithMinRefreshInterval(time.Minute).Build()
+		manager, _ := NewRDSInstanceManager(mockRDSService, config)
+		manager.Instances = testutils.TestInstances
+		manager.InstancesLastUpdated = time.Now()
+		manager.lastDiscoveryAttempt = time.Now()
+
+		ctx := models.ContextWithScrapeRefresh(context.Background(), models.ScrapeRefreshAll)
+		instances, err := manager.GetInstances(ctx)
+		require.NoError(t, err)
+		assert.Len(t, instances, len(testutils.TestInstances))
+		mockRDSService.AssertNotCalled(t, "DescribeDBInstancesPaginator", mock.Anything, mock.Anything)
+	})
+}
+
+func TestInvalidate(t *testing.T) {
+	t.Run("next call discovers instances regardless of the TTL", func(t *testing.T) {
+		mockRDSService := &mocks.MockRDSService{}
+		mockRDSService.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).
+			Return(mocks.NewMockRDSDescribeInstances(), nil).Once()
+		manager, err := NewRDSInstanceManager(mockRDSService, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+		manager.Instances = []models.Instance{testutils.TestInstancePostgreSQL}
+		manager.InstancesLastUpdated = time.Now()
+
+		manager.Invalidate()
+		assert.True(t, manager.InstancesLastUpdated.IsZero())
+
+		instances, err := manager.GetInstances(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, instances, 2)
+		mockRDSService.AssertExpectations(t)
+	})
+
+	t.Run("cached instances are served within min refresh interval", func(t *testing.T) {
+		mockRDSService := &mocks.MockRDSService{}
+		config := testutils.NewTestConfigBuilder().WithMinRefreshInterval(time.Minute).Build()
+		manager, _ := NewRDSInstanceManager(mockRDSService, config)
+		manager.Instances = testutils.TestInstances
+		manager.InstancesLastUpdated = time.Now()
+		manager.lastDiscoveryAttempt = time.Now()
+
+		manager.Invalidate()
+
+		instances, err := manager.GetInstances(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, instances, len(testutils.TestInstances))
+		mockRDSService.AssertNotCalled(t, "DescribeDBInstancesPaginator", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetInstancesConcurrentScrapesShareDiscovery(t *testing.T) {
+	mockRDSService := &mocks.MockRDSService{}
+	mockRDSService.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			time.Sleep(50 * time.Millisecond)
+		}).
+		Return(mocks.NewMockRDSDescribeInstances(), nil)
+	manager, err := NewRDSInstanceManager(mockRDSService, testutils.CreateDefaultParsedTestConfig())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instances, err := manager.GetInstances(context.Background())
+			assert.NoError(t, err)
+			assert.Len(t, instances, 2)
+		}()
+	}
+	wg.Wait()
+
+	mockRDSService.AssertNumberOfCalls(t, "DescribeDBInstancesPaginator", 1)
+}
+
+func TestGetInstancesTTLJitter(t *testing.T) {
+	mockRDSService := &mocks.MockRDSService{}
+	mockRDSService.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).
+		Return(mocks.NewMockRDSDescribeInstances(), nil)
+	manager, err := NewRDSInstanceManager(mockRDSService, testutils.CreateDefaultParsedTestConfig())
+	require.NoError(t, err)
+
+	_, err = manager.GetInstances(context.Background())
+	require.NoError(t, err)
+
+	maxJitter := time.Duration(float64(manager.InstanceTTL) * InstanceTTLJitter)
+	assert.GreaterOrEqual(t, manager.ttlJitter, time.Duration(0))
+	assert.LessOrEqual(t, manager.ttlJitter, maxJitter)
+
+	t.Run("cache is served until the jittered TTL expires", func(t *testing.T) {
+		manager.ttlJitter = maxJitter
+		manager.InstancesLastUpdated = time.Now().Add(-manager.InstanceTTL - maxJitter/2)
+
+		_, err := manager.GetInstances(context.Background())
+		require.NoError(t, err)
+		mockRDSService.AssertNumberOfCalls(t, "DescribeDBInstancesPaginator", 1)
+	})
+}
+
+func TestGetInstancesEmptyRegion(t *testing.T) {
+	newManager := func(t *testing.T, responses ...[]rdstypes.DBInstance) (*RDSInstanceManager, *mocks.MockRDSService) {
+		mockRDSService := &mocks.MockRDSService{}
+		for _, response := range responses {
+			mockRDSService.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).Return(response, nil).Once()
+		}
+		config := testutils.CreateDefaultParsedTestConfig()
+		config.Discovery.Instances.EmptyTTL = time.Hour
+		manager, err := NewRDSInstanceManager(mockRDSService, config)
+		require.NoError(t, err)
+		return manager, mockRDSService
+	}
+
+	t.Run("empty result is cached for the TTL", func(t *testing.T) {
+		manager, mockRDSService := newManager(t, mocks.NewMockRDSDescribeInstancesEmpty())
+
+		for i := 0; i < 3; i++ {
+			instances, err := manager.GetInstances(context.Background())
+			require.NoError(t, err)
+			assert.Empty(t, instances)
+		}
+		mockRDSService.AssertNumberOfCalls(t, "DescribeDBInstancesPaginator", 1)
+	})
+
+	t.Run("empty result is cached for the empty TTL after the TTL expired", func(t *testing.T) {
+		manager, mockRDSService := newManager(t, mocks.NewMockRDSDescribeInstancesEmpty())
+
+		_, err := manager.GetInstances(context.Background())
+		require.NoError(t, err)
+		manager.InstancesLastUpdated = time.Now().Add(-2 * manager.InstanceTTL)
+
+		_, err = manager.GetInstances(context.Background())
+		require.NoError(t, err)
+		mockRDSService.AssertNumberOfCalls(t, "DescribeDBInstancesPaginator", 1)
+	})
+
+	t.Run("empty region is rediscovered after the empty TTL and reset by instances", func(t *testing.T) {
+		manager, mockRDSService := newManager(t, mocks.NewMockRDSDescribeInstancesEmpty(), mocks.NewMockRDSDescribeInstances())
+
+		_, err := manager.GetInstances(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, manager.emptyDiscoveries)
+		manager.InstancesLastUpdated = time.Now().Add(-2 * manager.EmptyTTL)
+
+		instances, err := manager.GetInstances(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, instances, 2)
+		assert.Equal(t, 0, manager.emptyDiscoveries)
+		mockRDSService.AssertNumberOfCalls(t, "DescribeDBInstancesPaginator", 2)
+	})
+
+	t.Run("consecutive empty discoveries are counted", func(t *testing.T) {
+		manager, _ := newManager(t)
+
+		for i := 1; i <= PersistentlyEmptyDiscoveries+1; i++ {
+			manager.recordEmptyDiscovery(true)
+			assert.Equal(t, i, manager.emptyDiscoveries)
+		}
+		manager.recordEmptyDiscovery(false)
+		assert.Equal(t, 0, manager.emptyDiscoveries)
+	})
+}
+
+func TestRandomTTLJitter(t *testing.T) {
+	testCases := []struct {
+		name        string
+		ttl         time.Duration
+		expectedMax time.Duration
+	}{
+		{
+			name:        "up to a tenth of the TTL",
+			ttl:         5 * time.Minute,
+			expectedMax: 30 * time.Second,
+		},
+		{
+			name:        "zero TTL has no jitter",
+			ttl:         0,
+			expectedMax: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				jitter := randomTTLJitter(tc.ttl)
+				assert.GreaterOrEqual(t, jitter, time.Duration(0))
+				assert.LessOrEqual(t, jitter, tc.expectedMax)
+			}
+		})
+	}
+}
+
 func TestDiscoverInstances(t *testing.T) {
 	testCases := []struct {
 		name              string
@@ -240,11 +619,11 @@ func TestDiscoverInstances(t *testing.T) {
 			manager, _ := NewRDSInstanceManager(mockRDS, testutils.CreateDefaultParsedTestConfig())
 
 			if tc.shouldCallRDS {
-				mockRDS.On("DescribeDBInstancesPaginator", mock.Anything).
+				mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).
 					Return(tc.mockResponse, tc.expectedError)
 			}
 
-			instances, err := manager.discoverInstances(context.Background())
+			instances, _, err := manager.discoverInstances(context.Background())
 
 			if tc.expectedError != nil {
 				assert.Error(t, err)
@@ -271,3 +650,615 @@ func TestDiscoverInstances(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscoverInstancesRetry(t *testing.T) {
+	testCases := []struct {
+		name              string
+		maxRetries        int
+		failure           error
+		transientFailures int
+		expectedError     bool
+		expectedCalls     int
+	}{
+		{
+			name:              "transient error succeeds on retry",
+			maxRetries:        3,
+			failure:           errors.New("Throttling: Rate exceeded"),
+			transientFailures: 1,
+			expectedCalls:     2,
+		},
+		{
+			name:              "throttling error succeeds on retry",
+			maxRetries:        3,
+			failure:           &smithy.GenericAPIError{Code: "Throttling", Message: "Rate exceeded"},
+			transientFailures: 2,
+			expectedCalls:     3,
+		},
+		{
+			name:              "fails after retries are exhausted",
+			maxRetries:        2,
+			failure:           errors.New("Throttling: Rate exceeded"),
+			transientFailures: 3,
+			expectedError:     true,
+			expectedCalls:     3,
+		},
+		{
+			name:              "permanent error is not retried",
+			maxRetries:        3,
+			failure:           &smithy.GenericAPIError{Code: "AccessDenied", Message: "User is not authorized to perform rds:DescribeDBInstances"},
+			transientFailures: 1,
+			expectedError:     true,
+			expectedCalls:     1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDS := &mocks.MockRDSService{}
+			config := testutils.NewTestConfigBuilder().WithDiscoveryMaxRetries(tc.maxRetries).Build()
+			manager, err := NewRDSInstanceManager(mockRDS, config)
+			require.NoError(t, err)
+			manager.retryBaseDelay = time.Millisecond
+
+			mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).
+				Return(nil, tc.failure).Times(tc.transientFailures)
+			mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).
+				Return(mocks.NewMockRDSDescribeInstances(), nil)
+
+			instances, _, err := manager.discoverInstances(context.Background())
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, instances)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, instances, 2)
+			}
+			mockRDS.AssertNumberOfCalls(t, "DescribeDBInstancesPaginator", tc.expectedCalls)
+		})
+	}
+}
+
+func TestDiscoverInstancesStopped(t *testing.T) {
+	testCases := []struct {
+		name               string
+		includeStopped     bool
+		expectedIdentifier []string
+	}{
+		{
+			name:               "stopped instances are skipped by default",
+			includeStopped:     false,
+			expectedIdentifier: []string{"test-mysql-db", "test-postgres-db"},
+		},
+		{
+			name:               "stopped instances are included with include-stopped",
+			includeStopped:     true,
+			expectedIdentifier: []string{"test-mysql-db", "test-postgres-db", "test-stopped-db"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDS := &mocks.MockRDSService{}
+			config := testutils.NewTestConfigBuilder().WithIncludeStopped(tc.includeStopped).Build()
+			manager, _ := NewRDSInstanceManager(mockRDS, config)
+
+			mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).
+				Return(mocks.NewMockRDSDescribeInstancesWithStopped(), nil)
+
+			instances, _, err := manager.discoverInstances(context.Background())
+			require.NoError(t, err)
+
+			identifiers := make([]string, 0, len(instances))
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+				if instance.Identifier == "test-stopped-db" {
+					assert.Equal(t, models.InstanceStatusStopped, instance.Status)
+				} else {
+					assert.Equal(t, models.InstanceStatusAvailable, instance.Status)
+				}
+			}
+			assert.Equal(t, tc.expectedIdentifier, identifiers)
+
+			mockRDS.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiscoverInstancesStatuses(t *testing.T) {
+	testCases := []struct {
+		name               string
+		statuses           []string
+		expectedIdentifier []string
+	}{
+		{
+			name:               "only available instances are discovered by default",
+			expectedIdentifier: []string{"test-mysql-db"},
+		},
+		{
+			name:               "configured statuses are discovered",
+			statuses:           []string{"available", "backing-up"},
+			expectedIdentifier: []string{"test-mysql-db", "test-postgres-db"},
+		},
+		{
+			name:               "instances in other statuses are skipped",
+			statuses:           []string{"backing-up"},
+			expectedIdentifier: []string{"test-postgres-db"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDS := &mocks.MockRDSService{}
+			config := testutils.NewTestConfigBuilder().WithInstanceStatuses(tc.statuses...).Build()
+			manager, _ := NewRDSInstanceManager(mockRDS, config)
+
+			dbInstances := mocks.NewMockRDSDescribeInstances()
+			dbInstances[0].DBInstanceStatus = aws.String("backing-up")
+			mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).Return(dbInstances, nil)
+
+			instances, _, err := manager.discoverInstances(context.Background())
+			require.NoError(t, err)
+
+			identifiers := make([]string, 0, len(instances))
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+			}
+			assert.Equal(t, tc.expectedIdentifier, identifiers)
+		})
+	}
+}
+
+func TestDiscoverInstancesUnknownEngine(t *testing.T) {
+	testCases := []struct {
+		name               string
+		behavior           models.UnknownEngineBehavior
+		expectedIdentifier []string
+	}{
+		{
+			name:               "unknown engine instances are dropped by default",
+			behavior:           models.UnknownEngineDrop,
+			expectedIdentifier: []string{"test-mysql-db", "test-postgres-db"},
+		},
+		{
+			name:               "unknown engine instances are included as other",
+			behavior:           models.UnknownEngineIncludeAsOther,
+			expectedIdentifier: []string{"test-mysql-db", "test-postgres-db", "test-unknown-db"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDS := &mocks.MockRDSService{}
+			config := testutils.NewTestConfigBuilder().WithUnknownEngineBehavior(tc.behavior).Build()
+			manager, _ := NewRDSInstanceManager(mockRDS, config)
+
+			mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).
+				Return(mocks.NewMockRDSDescribeInstancesWithUnknownEngine(), nil)
+
+			instances, _, err := manager.discoverInstances(context.Background())
+			require.NoError(t, err)
+
+			identifiers := make([]string, 0, len(instances))
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+				if instance.Identifier == "test-unknown-db" {
+					assert.Equal(t, models.Other, instance.Engine)
+				}
+			}
+			assert.Equal(t, tc.expectedIdentifier, identifiers)
+
+			mockRDS.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiscoverInstancesPerformanceInsightsDisabled(t *testing.T) {
+	testCases := []struct {
+		name                       string
+		performanceInsightsEnabled *bool
+		expectedIdentifier         []string
+	}{
+		{
+			name:                       "enabled instances are discovered",
+			performanceInsightsEnabled: aws.Bool(true),
+			expectedIdentifier:         []string{"test-mysql-db", "test-postgres-db"},
+		},
+		{
+			name:                       "disabled instances are skipped",
+			performanceInsightsEnabled: aws.Bool(false),
+			expectedIdentifier:         []string{"test-mysql-db"},
+		},
+		{
+			name:                       "instances without the field are skipped",
+			performanceInsightsEnabled: nil,
+			expectedIdentifier:         []string{"test-mysql-db"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDS := &mocks.MockRDSService{}
+			manager, _ := NewRDSInstanceManager(mockRDS, testutils.NewTestConfigBuilder().Build())
+
+			dbInstances := mocks.NewMockRDSDescribeInstances()
+			dbInstances[0].PerformanceInsightsEnabled = tc.performanceInsightsEnabled
+			mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).Return(dbInstances, nil)
+
+			instances, _, err := manager.discoverInstances(context.Background())
+			require.NoError(t, err)
+
+			identifiers := make([]string, 0, len(instances))
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+			}
+			assert.Equal(t, tc.expectedIdentifier, identifiers)
+		})
+	}
+}
+
+func TestDiscoverInstancesStorageFields(t *testing.T) {
+	mockRDS := &mocks.MockRDSService{}
+	config := testutils.NewTestConfigBuilder().Build()
+	manager, _ := NewRDSInstanceManager(mockRDS, config)
+
+	dbInstances := mocks.NewMockRDSDescribeInstances()
+	dbInstances[1].StorageEncrypted = aws.Bool(true)
+	dbInstances[1].StorageType = aws.String("gp3")
+	dbInstances[1].DBSubnetGroup = &rdstypes.DBSubnetGroup{
+		DBSubnetGroupName: aws.String("prod-private"),
+		VpcId:             aws.String("vpc-0abc1234"),
+	}
+	dbInstances[1].DBClusterIdentifier = aws.String("prod-aurora")
+
+	mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).Return(dbInstances, nil)
+
+	instances, _, err := manager.discoverInstances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+
+	byIdentifier := make(map[string]models.Instance, len(instances))
+	for _, instance := range instances {
+		byIdentifier[instance.Identifier] = instance
+	}
+
+	assert.False(t, byIdentifier["test-postgres-db"].StorageEncrypted, "nil StorageEncrypted should default to false")
+	assert.Empty(t, byIdentifier["test-postgres-db"].StorageType, "nil StorageType should default to empty")
+	assert.Empty(t, byIdentifier["test-postgres-db"].SubnetGroup, "nil DBSubnetGroup should default to empty")
+	assert.Empty(t, byIdentifier["test-postgres-db"].VpcID, "nil DBSubnetGroup should default to empty")
+	assert.Empty(t, byIdentifier["test-postgres-db"].ClusterIdentifier, "nil DBClusterIdentifier should default to empty")
+	assert.Equal(t, "db.t3.micro", byIdentifier["test-postgres-db"].InstanceClass)
+	assert.Equal(t, int32(20), byIdentifier["test-postgres-db"].AllocatedStorage)
+	assert.True(t, byIdentifier["test-mysql-db"].StorageEncrypted)
+	assert.Equal(t, "gp3", byIdentifier["test-mysql-db"].StorageType)
+	assert.Equal(t, "prod-private", byIdentifier["test-mysql-db"].SubnetGroup)
+	assert.Equal(t, "vpc-0abc1234", byIdentifier["test-mysql-db"].VpcID)
+	assert.Equal(t, "prod-aurora", byIdentifier["test-mysql-db"].ClusterIdentifier)
+	assert.Equal(t, "db.t3.small", byIdentifier["test-mysql-db"].InstanceClass)
+	assert.Equal(t, int32(50), byIdentifier["test-mysql-db"].AllocatedStorage)
+	assert.Equal(t, "arn:aws:rds:us-west-2:123456789012:db:test-mysql-db", byIdentifier["test-mysql-db"].ARN)
+	assert.Equal(t, "123456789012", byIdentifier["test-mysql-db"].AccountID)
+	assert.Equal(t, "us-west-2", byIdentifier["test-mysql-db"].Region())
+	assert.Equal(t, map[string]string{"Environment": "production", "Team": "data"}, byIdentifier["test-mysql-db"].Tags)
+
+	mockRDS.AssertExpectations(t)
+}
+
+func TestDiscoverInstancesTagFilter(t *testing.T) {
+	prodEnvironment := filter.Patterns{"tag.Environment": {regexp.MustCompile("^prod")}}
+
+	testCases := []struct {
+		name                string
+		include             filter.Patterns
+		exclude             filter.Patterns
+		expectedIdentifiers []string
+	}{
+		{
+			name:                "include by tag",
+			include:             prodEnvironment,
+			expectedIdentifiers: []string{"test-mysql-db"},
+		},
+		{
+			name:                "exclude by tag",
+			exclude:             prodEnvironment,
+			expectedIdentifiers: []string{"test-postgres-db"},
+		},
+		{
+			name:                "include by tag missing on every instance",
+			include:             filter.Patterns{"tag.Owner": {regexp.MustCompile(".*")}},
+			expectedIdentifiers: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDS := &mocks.MockRDSService{}
+			config := testutils.NewTestConfigBuilder().Build()
+			config.Discovery.Instances.Filter = filter.NewPatternFilter(tc.include, tc.exclude)
+			manager, _ := NewRDSInstanceManager(mockRDS, config)
+
+			mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).Return(mocks.NewMockRDSDescribeInstances(), nil)
+
+			instances, _, err := manager.discoverInstances(context.Background())
+			require.NoError(t, err)
+
+			var identifiers []string
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+			}
+			assert.Equal(t, tc.expectedIdentifiers, identifiers)
+			mockRDS.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiscoverInstancesClassAndStatusFilter(t *testing.T) {
+	testCases := []struct {
+		name                string
+		include             filter.Patterns
+		exclude             filter.Patterns
+		expectedIdentifiers []string
+	}{
+		{
+			name:                "exclude by class",
+			exclude:             filter.Patterns{"class": {regexp.MustCompile(`^db\.t3\.micro$`)}},
+			expectedIdentifiers: []string{"test-mysql-db"},
+		},
+		{
+			name:                "include by class",
+			include:             filter.Patterns{"class": {regexp.MustCompile(`^db\.t3\.`)}},
+			expectedIdentifiers: []string{"test-mysql-db", "test-postgres-db"},
+		},
+		{
+			name:                "exclude by status",
+			exclude:             filter.Patterns{"status": {regexp.MustCompile("^available$")}},
+			expectedIdentifiers: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDS := &mocks.MockRDSService{}
+			config := testutils.NewTestConfigBuilder().Build()
+			config.Discovery.Instances.Filter = filter.NewPatternFilter(tc.include, tc.exclude)
+			manager, _ := NewRDSInstanceManager(mockRDS, config)
+
+			mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).Return(mocks.NewMockRDSDescribeInstances(), nil)
+
+			instances, _, err := manager.discoverInstances(context.Background())
+			require.NoError(t, err)
+
+			var identifiers []string
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+			}
+			assert.Equal(t, tc.expectedIdentifiers, identifiers)
+			mockRDS.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiscoverInstancesIdentifiers(t *testing.T) {
+	testCases := []struct {
+		name                string
+		identifiers         []string
+		exclude             filter.Patterns
+		expectedIdentifiers []string
+		expectedFiltered    []string
+	}{
+		{
+			name:                "no allowlist keeps every instance",
+			identifiers:         nil,
+			expectedIdentifiers: []string{"test-mysql-db", "test-postgres-db"},
+		},
+		{
+			name:                "allowlist keeps the listed instances",
+			identifiers:         []string{"test-postgres-db", "missing-db"},
+			expectedIdentifiers: []string{"test-postgres-db"},
+			expectedFiltered:    []string{"test-mysql-db"},
+		},
+		{
+			name:                "allowlist is intersected with the filter",
+			identifiers:         []string{"test-postgres-db", "test-mysql-db"},
+			exclude:             filter.Patterns{"engine": {regexp.MustCompile("mysql")}},
+			expectedIdentifiers: []string{"test-postgres-db"},
+			expectedFiltered:    []string{"test-mysql-db"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDS := &mocks.MockRDSService{}
+			config := testutils.NewTestConfigBuilder().Build()
+			config.Discovery.Instances.Identifiers = tc.identifiers
+			if tc.exclude != nil {
+				config.Discovery.Instances.Filter = filter.NewPatternFilter(nil, tc.exclude)
+			}
+			manager, _ := NewRDSInstanceManager(mockRDS, config)
+
+			mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).Return(mocks.NewMockRDSDescribeInstances(), nil)
+
+			instances, filteredInstances, err := manager.discoverInstances(context.Background())
+			require.NoError(t, err)
+
+			var identifiers []string
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+			}
+			assert.Equal(t, tc.expectedIdentifiers, identifiers)
+
+			var filtered []string
+			for _, instance := range filteredInstances {
+				filtered = append(filtered, instance.Identifier)
+			}
+			assert.Equal(t, tc.expectedFiltered, filtered)
+			mockRDS.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIsSampled(t *testing.T) {
+	identifiers := make([]string, 1000)
+	for i := range identifiers {
+		identifiers[i] = fmt.Sprintf("db-instance-%d", i)
+	}
+
+	testCases := []struct {
+		name        string
+		sampleRate  float64
+		minSelected int
+		maxSelected int
+	}{
+		{name: "unset sample rate keeps every instance", sampleRate: 0, minSelected: 1000, maxSelected: 1000},
+		{name: "full sample rate keeps every instance", sampleRate: 1, minSelected: 1000, maxSelected: 1000},
+		{name: "half sample rate keeps about half", sampleRate: 0.5, minSelected: 400, maxSelected: 600},
+		{name: "small sample rate keeps a small subset", sampleRate: 0.1, minSelected: 50, maxSelected: 150},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected := 0
+			for _, identifier := range identifiers {
+				sampled := isSampled(identifier, tc.sampleRate)
+				assert.Equal(t, sampled, isSampled(identifier, tc.sampleRate), "selection must be stable for %s", identifier)
+				if sampled {
+					selected++
+				}
+			}
+			assert.GreaterOrEqual(t, selected, tc.minSelected)
+			assert.LessOrEqual(t, selected, tc.maxSelected)
+		})
+	}
+}
+
+func TestDiscoverInstancesSampleRate(t *testing.T) {
+	mockRDS := &mocks.MockRDSService{}
+	config := testutils.NewTestConfigBuilder().WithSampleRate(0.5).Build()
+	manager, _ := NewRDSInstanceManager(mockRDS, config)
+
+	mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).Return(mocks.NewMockRDSDescribeInstances(), nil)
+
+	instances, _, err := manager.discoverInstances(context.Background())
+	require.NoError(t, err)
+
+	expected := make([]string, 0)
+	for _, identifier := range []string{"test-mysql-db", "test-postgres-db"} {
+		if isSampled(identifier, 0.5) {
+			expected = append(expected, identifier)
+		}
+	}
+
+	identifiers := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		identifiers = append(identifiers, instance.Identifier)
+	}
+	assert.Equal(t, expected, identifiers)
+
+	mockRDS.AssertExpectations(t)
+}
+
+func TestDiscoverInstancesMinPIRetention(t *testing.T) {
+	testCases := []struct {
+		name               string
+		minRetention       int32
+		expectedIdentifier []string
+	}{
+		{
+			name:               "no minimum keeps every instance",
+			minRetention:       0,
+			expectedIdentifier: []string{"test-mysql-db", "test-postgres-db"},
+		},
+		{
+			name:               "instances below the minimum are skipped",
+			minRetention:       31,
+			expectedIdentifier: []string{"test-mysql-db"},
+		},
+		{
+			name:               "minimum above every retention skips all instances",
+			minRetention:       731,
+			expectedIdentifier: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDS := &mocks.MockRDSService{}
+			config := testutils.NewTestConfigBuilder().WithMinPIRetention(tc.minRetention).Build()
+			manager, _ := NewRDSInstanceManager(mockRDS, config)
+
+			dbInstances := mocks.NewMockRDSDescribeInstances()
+			dbInstances[0].PerformanceInsightsRetentionPeriod = aws.Int32(7)
+			dbInstances[1].PerformanceInsightsRetentionPeriod = aws.Int32(93)
+
+			mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).Return(dbInstances, nil)
+
+			instances, _, err := manager.discoverInstances(context.Background())
+			require.NoError(t, err)
+
+			identifiers := make([]string, 0, len(instances))
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+				if instance.Identifier == "test-mysql-db" {
+					assert.Equal(t, int32(93), instance.PIRetentionPeriod)
+				}
+			}
+			assert.Equal(t, tc.expectedIdentifier, identifiers)
+
+			mockRDS.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetInstancesPriorityTag(t *testing.T) {
+	testCases := []struct {
+		name               string
+		priorityTag        string
+		priorities         []string
+		expectedIdentifier []string
+	}{
+		{
+			name:               "without priority tag the oldest instance survives capping",
+			expectedIdentifier: []string{"test-mysql-db"},
+		},
+		{
+			name:               "highest priority instance survives capping",
+			priorityTag:        "Priority",
+			priorities:         []string{"10", "1"},
+			expectedIdentifier: []string{"test-postgres-db"},
+		},
+		{
+			name:               "instance with priority survives capping over untagged instance",
+			priorityTag:        "Priority",
+			priorities:         []string{"0", ""},
+			expectedIdentifier: []string{"test-postgres-db"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRDS := &mocks.MockRDSService{}
+			config := testutils.NewTestConfigBuilder().WithMaxInstances(1).WithPriorityTag(tc.priorityTag).Build()
+			manager, _ := NewRDSInstanceManager(mockRDS, config)
+
+			dbInstances := mocks.NewMockRDSDescribeInstances()
+			for i, priority := range tc.priorities {
+				if priority != "" {
+					dbInstances[i].TagList = append(dbInstances[i].TagList, rdstypes.Tag{Key: aws.String(tc.priorityTag), Value: aws.String(priority)})
+				}
+			}
+
+			mockRDS.On("DescribeDBInstancesPaginator", mock.Anything, mock.Anything).Return(dbInstances, nil)
+
+			instances, err := manager.GetInstances(context.Background())
+			require.NoError(t, err)
+
+			identifiers := make([]string, 0, len(instances))
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+			}
+			assert.Equal(t, tc.expectedIdentifier, identifiers)
+
+			mockRDS.AssertExpectations(t)
+		})
+	}
+}
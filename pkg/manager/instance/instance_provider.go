@@ -8,4 +8,18 @@ import (
 
 type InstanceProvider interface {
 	GetInstances(ctx context.Context) ([]models.Instance, error)
+	// Invalidate drops the cached instances, so the next call to GetInstances discovers them again.
+	Invalidate()
+}
+
+// InstanceLimitReporter is implemented by instance providers that cap the number of discovered instances,
+// to report whether the last discovery was truncated by the cap.
+type InstanceLimitReporter interface {
+	InstanceLimitReached() bool
+}
+
+// FilteredInstanceReporter is implemented by instance providers that keep the instances excluded by the instance
+// filters in the last discovery, to explain the filter decisions.
+type FilteredInstanceReporter interface {
+	FilteredInstances() []models.Instance
 }
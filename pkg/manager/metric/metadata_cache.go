@@ -0,0 +1,185 @@
+package metric
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/pi/types"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+)
+
+// MetadataCacheFlushDelay is how long writes to the metadata cache file are delayed, so that the metadata fetched for
+// many instances at startup is written once rather than once per instance.
+const MetadataCacheFlushDelay = 5 * time.Second
+
+// metadataCacheFile is the on-disk format of the metadata cache, with entries keyed by resource ID and engine
+type metadataCacheFile struct {
+	Entries map[string]metadataCacheEntry `json:"entries"`
+}
+
+type metadataCacheEntry struct {
+	FetchedAt time.Time             `json:"fetched_at"`
+	Metrics   []metadataCacheMetric `json:"metrics"`
+}
+
+type metadataCacheMetric struct {
+	Metric      string `json:"metric"`
+	Unit        string `json:"unit"`
+	Description string `json:"description,omitempty"`
+}
+
+// MetadataCache persists the metrics Performance Insights lists as available for each instance in a JSON file, so
+// that metric metadata younger than metrics.metadata-ttl survives restarts instead of being listed again for every
+// instance. Entries are read once when the cache is created; writes are delayed by MetadataCacheFlushDelay and
+// replace the file atomically. Expired entries are dropped when the file is written. A nil cache stores nothing.
+type MetadataCache struct {
+	mu           sync.Mutex
+	path         string
+	ttl          time.Duration
+	entries      map[string]metadataCacheEntry
+	flushPending bool
+}
+
+// NewMetadataCache loads the metadata cache stored at path. A missing file starts an empty cache, and an unreadable
+// or corrupt file is logged and replaced on the next write, as the metadata can always be listed again.
+func NewMetadataCache(path string, ttl time.Duration) *MetadataCache {
+	cache := &MetadataCache{
+		path:    path,
+		ttl:     ttl,
+		entries: make(map[string]metadataCacheEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache
+	}
+	if err != nil {
+		slog.Warn("Failed to read metadata cache, starting empty", "path", path, "error", err)
+		return cache
+	}
+
+	var file metadataCacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		slog.Warn("Failed to parse metadata cache, starting empty", "path", path, "error", err)
+		return cache
+	}
+	for key, entry := range file.Entries {
+		if cache.isFresh(entry) {
+			cache.entries[key] = entry
+		}
+	}
+	slog.Info("Loaded metric metadata from cache", "path", path, "instances", len(cache.entries))
+	return cache
+}
+
+func metadataCacheKey(resourceID string, engine models.Engine) string {
+	return resourceID + "/" + string(engine)
+}
+
+func (cache *MetadataCache) isFresh(entry metadataCacheEntry) bool {
+	return time.Now().Before(entry.FetchedAt.Add(cache.ttl))
+}
+
+// Get returns the available metrics cached for the instance and when they were listed, unless they are older than
+// the metadata TTL.
+func (cache *MetadataCache) Get(resourceID string, engine models.Engine) ([]types.ResponseResourceMetric, time.Time, bool) {
+	if cache == nil {
+		return nil, time.Time{}, false
+	}
+
+	cache.mu.Lock()
+	entry, exists := cache.entries[metadataCacheKey(resourceID, engine)]
+	cache.mu.Unlock()
+	if !exists || !cache.isFresh(entry) {
+		return nil, time.Time{}, false
+	}
+
+	metrics := make([]types.ResponseResourceMetric, 0, len(entry.Metrics))
+	for _, metric := range entry.Metrics {
+		metrics = append(metrics, types.ResponseResourceMetric{
+			Metric:      aws.String(metric.Metric),
+			Unit:        aws.String(metric.Unit),
+			Description: aws.String(metric.Description),
+		})
+	}
+	return metrics, entry.FetchedAt, true
+}
+
+// Put caches the available metrics listed for the instance at fetchedAt and schedules a write of the cache file.
+func (cache *MetadataCache) Put(resourceID string, engine models.Engine, availableMetrics []types.ResponseResourceMetric, fetchedAt time.Time) {
+	if cache == nil {
+		return
+	}
+
+	metrics := make([]metadataCacheMetric, 0, len(availableMetrics))
+	for _, metric := range availableMetrics {
+		if metric.Metric == nil || metric.Unit == nil {
+			continue
+		}
+		metrics = append(metrics, metadataCacheMetric{
+			Metric:      *metric.Metric,
+			Unit:        *metric.Unit,
+			Description: aws.ToString(metric.Description),
+		})
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.entries[metadataCacheKey(resourceID, engine)] = metadataCacheEntry{FetchedAt: fetchedAt, Metrics: metrics}
+	if !cache.flushPending {
+		cache.flushPending = true
+		time.AfterFunc(MetadataCacheFlushDelay, func() {
+			if err := cache.Flush(); err != nil {
+				slog.Warn("Failed to write metadata cache", "path", cache.path, "error", err)
+			}
+		})
+	}
+}
+
+// Flush writes the unexpired entries of the cache to its file, replacing the previous file atomically.
+func (cache *MetadataCache) Flush() error {
+	if cache == nil {
+		return nil
+	}
+
+	cache.mu.Lock()
+	cache.flushPending = false
+	file := metadataCacheFile{Entries: make(map[string]metadataCacheEntry, len(cache.entries))}
+	for key, entry := range cache.entries {
+		if !cache.isFresh(entry) {
+			delete(cache.entries, key)
+			continue
+		}
+		file.Entries[key] = entry
+	}
+	cache.mu.Unlock()
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(cache.path), filepath.Base(cache.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cache.path)
+}
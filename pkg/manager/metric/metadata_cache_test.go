@@ -0,0 +1,182 @@
+package metric
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
+)
+
+func TestMetadataCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	available := mocks.NewMockPIListMetricsResponse().Metrics
+	fetchedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	cache := NewMetadataCache(path, time.Hour)
+	cache.Put("db-ABC", models.AuroraPostgreSQL, available, fetchedAt)
+	require.NoError(t, cache.Flush())
+
+	reloaded := NewMetadataCache(path, time.Hour)
+	metrics, cachedAt, found := reloaded.Get("db-ABC", models.AuroraPostgreSQL)
+	require.True(t, found)
+	assert.True(t, fetchedAt.Equal(cachedAt))
+	require.Len(t, metrics, len(available))
+	assert.Equal(t, *available[0].Metric, *metrics[0].Metric)
+	assert.Equal(t, *available[0].Unit, *metrics[0].Unit)
+	assert.Equal(t, *available[0].Description, *metrics[0].Description)
+
+	_, _, found = reloaded.Get("db-ABC", models.AuroraMySQL)
+	assert.False(t, found, "entries are keyed by resource ID and engine")
+}
+
+func TestMetadataCacheExpiredEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	available := mocks.NewMockPIListMetricsResponse().Metrics
+
+	cache := NewMetadataCache(path, time.Hour)
+	cache.Put("db-OLD", models.AuroraPostgreSQL, available, time.Now().Add(-2*time.Hour))
+	cache.Put("db-NEW", models.AuroraPostgreSQL, available, time.Now())
+
+	_, _, found := cache.Get("db-OLD", models.AuroraPostgreSQL)
+	assert.False(t, found, "entries older than the TTL are not returned")
+
+	require.NoError(t, cache.Flush())
+	reloaded := NewMetadataCache(path, time.Hour)
+	assert.Len(t, reloaded.entries, 1, "expired entries are dropped when the file is written")
+}
+
+func TestNewMetadataCacheUnreadableFile(t *testing.T) {
+	t.Run("missing file starts empty", func(t *testing.T) {
+		cache := NewMetadataCache(filepath.Join(t.TempDir(), "missing.json"), time.Hour)
+		assert.Empty(t, cache.entries)
+	})
+
+	t.Run("corrupt file starts empty and is replaced", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "metadata.json")
+		require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+		cache := NewMetadataCache(path, time.Hour)
+		assert.Empty(t, cache.entries)
+
+		cache.Put("db-ABC", models.AuroraPostgreSQL, mocks.NewMockPIListMetricsResponse().Metrics, time.Now())
+		require.NoError(t, cache.Flush())
+		assert.Len(t, NewMetadataCache(path, time.Hour).entries, 1)
+	})
+}
+
+func TestNilMetadataCache(t *testing.T) {
+	var cache *MetadataCache
+
+	cache.Put("db-ABC", models.AuroraPostgreSQL, mocks.NewMockPIListMetricsResponse().Metrics, time.Now())
+	_, _, found := cache.Get("db-ABC", models.AuroraPostgreSQL)
+	assert.False(t, found)
+	assert.NoError(t, cache.Flush())
+}
+
+func TestGetMetricBatchesWithMetadataCache(t *testing.T) {
+	t.Run("cached metadata is used without listing metrics", func(t *testing.T) {
+		instance := testutils.NewTestInstanceNoMetrics()
+		fetchedAt := time.Now().Add(-time.Minute)
+		cache := NewMetadataCache(filepath.Join(t.TempDir(), "metadata.json"), time.Hour)
+		cache.Put(instance.ResourceID, instance.Engine, mocks.NewMockPIListMetricsResponse().Metrics, fetchedAt)
+
+		mockPI := &mocks.MockPIService{}
+		manager, err := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+		manager.WithMetadataCache(cache)
+
+		batches, err := manager.GetMetricBatches(context.Background(), instance)
+		require.NoError(t, err)
+		assert.Len(t, batches, 1)
+		assert.True(t, fetchedAt.Equal(instance.Metrics.MetricsLastUpdated), "the metadata TTL counts from when the metrics were listed")
+		mockPI.AssertNotCalled(t, "ListAvailableResourceMetrics", mock.Anything, mock.Anything)
+	})
+
+	t.Run("listed metadata is stored in the cache", func(t *testing.T) {
+		instance := testutils.NewTestInstanceNoMetrics()
+		cache := NewMetadataCache(filepath.Join(t.TempDir(), "metadata.json"), time.Hour)
+
+		mockPI := &mocks.MockPIService{}
+		manager, err := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+		manager.WithMetadataCache(cache)
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+			Return(mocks.NewMockPIListMetricsResponse(), nil).Once()
+
+		_, err = manager.GetMetricBatches(context.Background(), instance)
+		require.NoError(t, err)
+
+		_, _, found := cache.Get(instance.ResourceID, instance.Engine)
+		assert.True(t, found)
+		mockPI.AssertExpectations(t)
+	})
+
+	t.Run("refreshing metadata lists metrics without using or updating the cache", func(t *testing.T) {
+		instance := testutils.NewTestInstanceNoMetrics()
+		fetchedAt := time.Now().Add(-time.Minute)
+		cache := NewMetadataCache(filepath.Join(t.TempDir(), "metadata.json"), time.Hour)
+		cache.Put(instance.ResourceID, instance.Engine, mocks.NewMockPIListMetricsResponse().Metrics, fetchedAt)
+
+		mockPI := &mocks.MockPIService{}
+		manager, err := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+		manager.WithMetadataCache(cache)
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+			Return(mocks.NewMockPIListMetricsResponse(), nil).Once()
+
+		ctx := models.ContextWithScrapeRefresh(context.Background(), models.ScrapeRefreshMetadata)
+		_, err = manager.GetMetricBatches(ctx, instance)
+		require.NoError(t, err)
+
+		_, cachedAt, found := cache.Get(instance.ResourceID, instance.Engine)
+		require.True(t, found)
+		assert.True(t, fetchedAt.Equal(cachedAt))
+		mockPI.AssertExpectations(t)
+	})
+
+	t.Run("entries listed before the metadata was invalidated are listed again", func(t *testing.T) {
+		instance := testutils.NewTestInstanceNoMetrics()
+		cache := NewMetadataCache(filepath.Join(t.TempDir(), "metadata.json"), time.Hour)
+		cache.Put(instance.ResourceID, instance.Engine, mocks.NewMockPIListMetricsResponse().Metrics, time.Now().Add(-time.Minute))
+
+		mockPI := &mocks.MockPIService{}
+		manager, err := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+		manager.WithMetadataCache(cache)
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+			Return(mocks.NewMockPIListMetricsResponse(), nil).Once()
+
+		manager.Invalidate()
+
+		_, err = manager.GetMetricBatches(context.Background(), instance)
+		require.NoError(t, err)
+		mockPI.AssertExpectations(t)
+	})
+
+	t.Run("no available metrics are not cached", func(t *testing.T) {
+		instance := testutils.NewTestInstanceNoMetrics()
+		cache := NewMetadataCache(filepath.Join(t.TempDir(), "metadata.json"), time.Hour)
+
+		mockPI := &mocks.MockPIService{}
+		manager, err := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+		manager.WithMetadataCache(cache)
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+			Return(mocks.NewMockPIListMetricsResponseEmpty(), nil).Once()
+
+		_, err = manager.GetMetricBatches(context.Background(), instance)
+		require.NoError(t, err)
+
+		_, _, found := cache.Get(instance.ResourceID, instance.Engine)
+		assert.False(t, found)
+	})
+}
@@ -2,10 +2,17 @@ package metric
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	awsPI "github.com/aws/aws-sdk-go-v2/service/pi"
 	"github.com/aws/aws-sdk-go-v2/service/pi/types"
 	"github.com/prometheus/client_golang/prometheus"
@@ -21,10 +28,70 @@ const (
 	BaseDelay  = time.Second
 )
 
+// staleMarker is the NaN bit pattern Prometheus uses as a staleness marker (value.StaleNaN in Prometheus).
+var staleMarker = math.Float64frombits(0x7ff0000000000002)
+
+// ErrPerformanceInsightsUnsupported is returned by GetMetricBatches for instances that Performance Insights
+// definitively reported as unsupported. Such instances are skipped until their next re-check.
+var ErrPerformanceInsightsUnsupported = errors.New("performance insights is not supported for instance")
+
+// availableCatalog is the unfiltered list of metrics Performance Insights reports as available for an instance
+type availableCatalog struct {
+	metrics     map[string]models.CatalogMetric
+	lastUpdated time.Time
+}
+
+// engineCatalog is the filtered metric catalog of an engine shared by its instances when metrics.share-catalog-per-engine is enabled
+type engineCatalog struct {
+	details     map[string]models.MetricDetails
+	list        []string
+	lastUpdated time.Time
+}
+
 type MetricManager struct {
 	piService     pi.PIService
 	configuration *models.ParsedConfig
 	registry      *utils.PerEngineMetricRegistry
+
+	postProcessors []formatting.MetricPostProcessor
+
+	discoveredMu          sync.Mutex
+	discoveredMetricNames map[models.Engine]map[string]int
+
+	// engineCatalogs caches the filtered metric catalog per engine when metrics.share-catalog-per-engine is enabled.
+	// catalogLocks serializes the catalog fetch of each engine so concurrent cold instances trigger a single fetch.
+	catalogMu      sync.Mutex
+	catalogLocks   map[models.Engine]*sync.Mutex
+	engineCatalogs map[models.Engine]engineCatalog
+
+	// availableCatalogs caches the unfiltered catalog of available metrics per resource ID, refreshed with the metadata
+	availableMu       sync.Mutex
+	availableCatalogs map[string]availableCatalog
+
+	// pruneMu serializes the pruning of invalid metrics from the cached metric lists of instances
+	pruneMu sync.Mutex
+
+	// dataPointsReturned counts the data points returned per metric name when prometheus.datapoints-returned-metric is enabled
+	dataPointsMu       sync.Mutex
+	dataPointsReturned map[string]uint64
+
+	// conversionErrors counts the metric data dropped per conversion error reason when prometheus.conversion-errors-metric is enabled
+	conversionErrorsMu sync.Mutex
+	conversionErrors   map[string]uint64
+
+	// unsupportedInstances maps the resource ID of instances unsupported by Performance Insights to their next re-check time
+	unsupportedMu        sync.Mutex
+	unsupportedInstances map[string]time.Time
+	retryBaseDelay       time.Duration
+
+	// metadataCache persists the available metrics of instances across restarts when metrics.metadata-cache-file is set
+	metadataCache *MetadataCache
+
+	// invalidatedAt is when the metadata was last invalidated, metadata listed before is treated as expired
+	invalidatedMu sync.Mutex
+	invalidatedAt time.Time
+
+	logger *slog.Logger
 }
 
 // MetricManager handles Performance Insights metric collection and caching for database instances.
@@ -33,94 +100,673 @@ func NewMetricManager(pi pi.PIService, config *models.ParsedConfig) (*MetricMana
 	if config == nil {
 		return nil, fmt.Errorf("configuration parameter cannot be nil")
 	}
+
+	postProcessors := make([]formatting.MetricPostProcessor, 0, len(config.Discovery.Metrics.PostProcessors))
+	for _, name := range config.Discovery.Metrics.PostProcessors {
+		postProcessor, err := formatting.NewMetricPostProcessor(name)
+		if err != nil {
+			return nil, err
+		}
+		postProcessors = append(postProcessors, postProcessor)
+	}
+
 	return &MetricManager{
-		piService:     pi,
-		configuration: config,
-		registry:      utils.NewPerEngineMetricRegistry(),
+		piService:      pi,
+		configuration:  config,
+		registry:       utils.NewPerEngineMetricRegistry(),
+		postProcessors: postProcessors,
+
+		discoveredMetricNames: make(map[models.Engine]map[string]int),
+		catalogLocks:          make(map[models.Engine]*sync.Mutex),
+		engineCatalogs:        make(map[models.Engine]engineCatalog),
+		availableCatalogs:     make(map[string]availableCatalog),
+		dataPointsReturned:    make(map[string]uint64),
+		conversionErrors:      make(map[string]uint64),
+		unsupportedInstances:  make(map[string]time.Time),
+		retryBaseDelay:        BaseDelay,
+		logger:                slog.Default(),
 	}, nil
 }
 
+// WithMetadataCache makes the manager read the available metrics of instances from the cache before listing them
+// with Performance Insights, and store the metrics it lists in the cache.
+func (metricManager *MetricManager) WithMetadataCache(cache *MetadataCache) *MetricManager {
+	metricManager.metadataCache = cache
+	return metricManager
+}
+
+// WithLogger makes the manager log with logger, e.g. a logger carrying the region the manager collects metrics in.
+func (metricManager *MetricManager) WithLogger(logger *slog.Logger) *MetricManager {
+	metricManager.logger = logger
+	return metricManager
+}
+
 // GetMetricBatches retrieves and batches the metrics for an instance without collecting data.
 // This method is used by the queue-based worker pool to generate all metric batch requests upfront.
+// Instances that Performance Insights reported as unsupported are not queried again until the metadata TTL elapses;
+// until then ErrPerformanceInsightsUnsupported is returned without calling AWS. A scrape refreshing metadata, see
+// models.ScrapeRefresh, checks them again without changing when the other scrapes do.
 func (metricManager *MetricManager) GetMetricBatches(ctx context.Context, instance models.Instance) ([][]string, error) {
+	refreshMetadata := models.ScrapeRefreshFromContext(ctx).Metadata()
+	if !refreshMetadata && metricManager.isUnsupported(instance.ResourceID) {
+		return nil, fmt.Errorf("%w %s", ErrPerformanceInsightsUnsupported, instance.Identifier)
+	}
+
 	metricsList, err := metricManager.getMetrics(ctx, instance.ResourceID, instance.Engine, instance.Metrics)
 	if err != nil {
+		var invalidArgument *types.InvalidArgumentException
+		if errors.As(err, &invalidArgument) {
+			metricManager.logger.Info("Performance Insights does not support instance, skipping until re-check", "identifier", instance.Identifier, "engine", instance.Engine, "error", err)
+			if !refreshMetadata {
+				metricManager.markUnsupported(instance.ResourceID)
+			}
+			return nil, fmt.Errorf("%w %s", ErrPerformanceInsightsUnsupported, instance.Identifier)
+		}
+		if instance.Status == models.InstanceStatusStopped {
+			metricManager.logger.Info("Skipping stopped instance, no metrics available", "identifier", instance.Identifier, "error", err)
+			return nil, nil
+		}
 		return nil, err
 	}
 
-	return utils.BatchMetricNames(metricsList, utils.BatchSize), nil
+	return utils.BatchMetricNames(metricsList, metricManager.configuration.Discovery.Processing.BatchSize), nil
 }
 
 // CollectMetricsForBatch collects metric data for a specific batch of metrics for an instance.
 // This method is called by worker goroutines in the queue-based worker pool pattern.
+// When metrics.on-invalid is prune and Performance Insights rejects metrics of the batch as invalid, for example because
+// AWS removed them before the metadata TTL expired, they are pruned from the cached metric list and the batch is retried once without them.
 func (metricManager *MetricManager) CollectMetricsForBatch(ctx context.Context, instance models.Instance, metricsBatch []string, ch chan<- prometheus.Metric) error {
 	metricData, err := metricManager.getMetricData(ctx, instance.ResourceID, metricsBatch)
+	if invalidMetrics := metricManager.findInvalidMetrics(err, metricsBatch); len(invalidMetrics) > 0 {
+		metricManager.logger.Warn("Performance Insights rejected metrics, pruning them until the next metadata refresh", "identifier", instance.Identifier, "metrics", invalidMetrics)
+		metricManager.pruneMetrics(instance.Metrics, invalidMetrics)
+
+		metricsBatch = slices.DeleteFunc(slices.Clone(metricsBatch), func(metricName string) bool {
+			return slices.Contains(invalidMetrics, metricName)
+		})
+		if len(metricsBatch) == 0 {
+			return nil
+		}
+		metricData, err = metricManager.getMetricData(ctx, instance.ResourceID, metricsBatch)
+	}
 	if err != nil {
-		log.Printf("[METRIC MANAGER] Error getting metric data for these metrics: %v, error: %v", metricsBatch, err)
+		if instance.Status == models.InstanceStatusStopped {
+			metricManager.logger.Info("No metric data for stopped instance", "identifier", instance.Identifier, "error", err)
+			return nil
+		}
+		metricManager.logger.Error("Error getting metric data", "identifier", instance.Identifier, "operation", "GetResourceMetrics", "metrics", metricsBatch, "error", err)
 		return err
 	}
 
+	models.CollectedMetricDataFromContext(ctx).Add(instance, metricData)
+
+	stats := models.ScrapeStatsFromContext(ctx)
 	for _, metricDatum := range metricData {
-		if err := formatting.ConvertToPrometheusMetric(ch, instance, metricDatum, metricManager.configuration.Export.Prometheus.MetricPrefix); err != nil {
-			log.Printf("[METRIC MANAGER] Error converting metric data to prometheus metric: %v, error: %v", metricDatum, err)
+		if err := formatting.ConvertToPrometheusMetric(ch, instance, metricDatum, metricManager.configuration.Export.Prometheus); err != nil {
+			metricManager.logger.Error("Error converting metric data to prometheus metric",
+				"identifier", instance.Identifier, "metric", metricDatum.Metric, "reason", formatting.ConversionErrorReason(err), "error", err)
+			metricManager.recordConversionError(err)
 			continue
 		}
+		stats.AddMetricsEmitted(1)
+
+		if metricManager.configuration.Export.Prometheus.DescriptionInfoMetric {
+			metricManager.emitDescriptionInfo(ctx, ch, instance, metricDatum)
+		}
 	}
 
 	return nil
 }
 
+// findInvalidMetrics returns the metrics of the batch that an InvalidArgumentException names as invalid.
+// Nothing is returned unless metrics.on-invalid is prune.
+func (metricManager *MetricManager) findInvalidMetrics(err error, metricsBatch []string) []string {
+	if metricManager.configuration.Discovery.Metrics.OnInvalid != models.InvalidMetricPrune {
+		return nil
+	}
+
+	var invalidArgument *types.InvalidArgumentException
+	if !errors.As(err, &invalidArgument) {
+		return nil
+	}
+
+	message := invalidArgument.ErrorMessage()
+	var invalidMetrics []string
+	for _, metricNameWithStat := range metricsBatch {
+		if mentionsMetric(message, metricNameWithStat) || mentionsMetric(message, utils.TrimStatisticFromMetricName(metricNameWithStat)) {
+			invalidMetrics = append(invalidMetrics, metricNameWithStat)
+		}
+	}
+	return invalidMetrics
+}
+
+// mentionsMetric reports whether the message contains the metric name as a whole word, so db.SQL.queries is not
+// mistaken for a mention of db.SQL.queries_per_sec or of db.SQL.queries.avg. A trailing full stop ends the word.
+func mentionsMetric(message string, metricName string) bool {
+	if metricName == "" {
+		return false
+	}
+
+	mention := regexp.MustCompile(`(^|[^\w.])` + regexp.QuoteMeta(metricName) + `\.?($|[^\w.])`)
+	return mention.MatchString(message)
+}
+
+// pruneMetrics removes metrics from the cached metric list of an instance. The list is replaced rather than modified
+// in place, as batches built from it may still be queued.
+func (metricManager *MetricManager) pruneMetrics(metrics *models.Metrics, invalidMetrics []string) {
+	if metrics == nil {
+		return
+	}
+
+	metricManager.pruneMu.Lock()
+	defer metricManager.pruneMu.Unlock()
+
+	metrics.MetricsList = slices.DeleteFunc(slices.Clone(metrics.MetricsList), func(metricName string) bool {
+		return slices.Contains(invalidMetrics, metricName)
+	})
+}
+
+// CollectPostProcessedMetrics runs the configured post-processors once per instance over all the metric data
+// collected for it during the scrape, as accumulated in the context, and emits the derived metrics.
+func (metricManager *MetricManager) CollectPostProcessedMetrics(ctx context.Context, ch chan<- prometheus.Metric) {
+	if len(metricManager.postProcessors) == 0 {
+		return
+	}
+
+	stats := models.ScrapeStatsFromContext(ctx)
+	models.CollectedMetricDataFromContext(ctx).ForEach(func(instance models.Instance, metricData []models.MetricData) {
+		for _, postProcessor := range metricManager.postProcessors {
+			for _, derived := range postProcessor.Process(instance, metricData) {
+				if err := formatting.ConvertDerivedMetric(ch, instance, derived, metricManager.configuration.Export.Prometheus); err != nil {
+					metricManager.logger.Error("Error converting derived metric data to prometheus metric",
+						"identifier", instance.Identifier, "metric", derived.Metric, "reason", formatting.ConversionErrorReason(err), "error", err)
+					metricManager.recordConversionError(err)
+					continue
+				}
+				stats.AddMetricsEmitted(1)
+			}
+		}
+	})
+}
+
+// emitDescriptionInfo emits the description info metric the first time a metric name is exported in the scrape.
+func (metricManager *MetricManager) emitDescriptionInfo(ctx context.Context, ch chan<- prometheus.Metric, instance models.Instance, metricDatum models.MetricData) {
+	prometheusConfig := metricManager.configuration.Export.Prometheus
+	metricName, description, err := formatting.DescribePrometheusMetric(instance, metricDatum, prometheusConfig)
+	if err != nil {
+		metricManager.logger.Error("Error describing metric", "identifier", instance.Identifier, "metric", metricDatum.Metric, "error", err)
+		return
+	}
+
+	if !models.MetricDescriptionsFromContext(ctx).MarkDescribed(metricName) {
+		return
+	}
+
+	infoMetric, err := formatting.NewMetricDescriptionInfoMetric(prometheusConfig, metricName, description)
+	if err != nil {
+		metricManager.logger.Error("Error creating description info metric", "metric", metricName, "error", err)
+		return
+	}
+	ch <- infoMetric
+}
+
+func (metricManager *MetricManager) isUnsupported(resourceID string) bool {
+	metricManager.unsupportedMu.Lock()
+	defer metricManager.unsupportedMu.Unlock()
+
+	recheckAt, exists := metricManager.unsupportedInstances[resourceID]
+	if !exists {
+		return false
+	}
+	if time.Now().After(recheckAt) {
+		delete(metricManager.unsupportedInstances, resourceID)
+		return false
+	}
+	return true
+}
+
+func (metricManager *MetricManager) markUnsupported(resourceID string) {
+	metricManager.unsupportedMu.Lock()
+	metricManager.unsupportedInstances[resourceID] = time.Now().Add(metricManager.configuration.Discovery.Metrics.MetadataTTL)
+	metricManager.unsupportedMu.Unlock()
+}
+
 func (metricManager *MetricManager) getMetrics(ctx context.Context, resourceID string, engine models.Engine, metrics *models.Metrics) ([]string, error) {
 	if metrics == nil {
 		return nil, fmt.Errorf("[METRIC MANAGER] Metrics not found for instance: %s", resourceID)
 	}
 
-	if metrics.MetricsDetails == nil || metrics.MetricsLastUpdated.IsZero() || time.Now().After(metrics.MetricsLastUpdated.Add(metrics.MetadataTTL)) {
-		availableMetrics, err := metricManager.getAvailableMetrics(ctx, resourceID, engine)
+	// A zero MetricsLastUpdated marks a cold instance whose metadata has never been fetched
+	cold := metrics.MetricsLastUpdated.IsZero()
+	expired := time.Now().After(metrics.MetricsLastUpdated.Add(metrics.MetadataTTL)) || metrics.MetricsLastUpdated.Before(metricManager.lastInvalidated())
+	if cold || metrics.MetricsDetails == nil || expired {
+		var catalog engineCatalog
+		var err error
+		if metricManager.configuration.Discovery.Metrics.ShareCatalogPerEngine {
+			catalog, err = metricManager.getSharedCatalog(ctx, resourceID, engine)
+		} else {
+			catalog, err = metricManager.getCatalog(ctx, resourceID, engine)
+		}
+		if cold && errors.Is(err, utils.ErrNoAvailableMetrics) {
+			// Performance Insights has not published metrics for a new instance yet. There is nothing to collect,
+			// and the instance stays cold so its metadata is fetched again on the next scrape.
+			metricManager.logger.Info("No metrics available yet for instance", "resource_id", resourceID)
+			return nil, nil
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		filteredMetrics := make(map[string]models.MetricDetails)
-		metricConfig := metricManager.configuration.Discovery.Metrics
-		for metricName, metric := range availableMetrics {
-			if metricConfig.ShouldIncludeMetric(metric) {
-				filteredMetrics[metricName] = metric
-			}
+		metrics.MetricsDetails = catalog.details
+		metrics.MetricsList = catalog.list
+		metrics.MetricsLastUpdated = catalog.lastUpdated
+	}
+	return metrics.MetricsList, nil
+}
+
+// Invalidate makes the metric metadata of every instance expire, so it is listed again with Performance Insights on
+// the next scrape, also when the metadata cache holds it. The shared and available catalogs, the re-check times of
+// unsupported instances and the canonical metric descriptions are dropped as well.
+func (metricManager *MetricManager) Invalidate() {
+	metricManager.invalidatedMu.Lock()
+	metricManager.invalidatedAt = time.Now()
+	metricManager.invalidatedMu.Unlock()
+
+	metricManager.catalogMu.Lock()
+	metricManager.engineCatalogs = make(map[models.Engine]engineCatalog)
+	metricManager.catalogMu.Unlock()
+
+	metricManager.availableMu.Lock()
+	metricManager.availableCatalogs = make(map[string]availableCatalog)
+	metricManager.availableMu.Unlock()
+
+	metricManager.unsupportedMu.Lock()
+	metricManager.unsupportedInstances = make(map[string]time.Time)
+	metricManager.unsupportedMu.Unlock()
+
+	metricManager.registry.ResetAllRegistries()
+}
+
+// lastInvalidated returns when the metadata was last invalidated, the zero time if it never was.
+func (metricManager *MetricManager) lastInvalidated() time.Time {
+	metricManager.invalidatedMu.Lock()
+	defer metricManager.invalidatedMu.Unlock()
+
+	return metricManager.invalidatedAt
+}
+
+// getCatalog fetches the metrics available for the instance and keeps those that pass the metric filters.
+func (metricManager *MetricManager) getCatalog(ctx context.Context, resourceID string, engine models.Engine) (engineCatalog, error) {
+	availableMetrics, fetchedAt, err := metricManager.getAvailableMetrics(ctx, resourceID, engine)
+	if err != nil {
+		return engineCatalog{}, err
+	}
+
+	metricConfig := metricManager.configuration.Discovery.Metrics
+	for _, pattern := range utils.FindUnmatchedIncludePatterns(availableMetrics, metricConfig.Include) {
+		metricManager.logger.Info("Include pattern matched no available metrics for instance", "resource_id", resourceID, "pattern", pattern)
+	}
+
+	filteredMetrics := make(map[string]models.MetricDetails)
+	for metricName, metric := range availableMetrics {
+		if metricConfig.ShouldIncludeMetric(metric) {
+			filteredMetrics[metricName] = metric
 		}
+	}
+
+	return engineCatalog{
+		details:     filteredMetrics,
+		list:        utils.GetMetricNamesWithStatistic(filteredMetrics),
+		lastUpdated: fetchedAt,
+	}, nil
+}
 
-		filteredMetricList := utils.GetMetricNamesWithStatistic(filteredMetrics)
+// getSharedCatalog returns the cached catalog of the engine while it is younger than the metadata TTL, and otherwise
+// fetches it for the instance and caches it for every instance of the engine. Instances sharing a catalog share its
+// details and list, which are never modified in place. Failed fetches are not cached. A scrape refreshing metadata
+// fetches the catalog without using or replacing the cached one.
+func (metricManager *MetricManager) getSharedCatalog(ctx context.Context, resourceID string, engine models.Engine) (engineCatalog, error) {
+	if models.ScrapeRefreshFromContext(ctx).Metadata() {
+		return metricManager.getCatalog(ctx, resourceID, engine)
+	}
 
-		metrics.MetricsDetails = filteredMetrics
-		metrics.MetricsList = filteredMetricList
-		metrics.MetricsLastUpdated = time.Now()
+	metricManager.catalogMu.Lock()
+	engineLock, exists := metricManager.catalogLocks[engine]
+	if !exists {
+		engineLock = &sync.Mutex{}
+		metricManager.catalogLocks[engine] = engineLock
 	}
-	return metrics.MetricsList, nil
+	metricManager.catalogMu.Unlock()
+
+	engineLock.Lock()
+	defer engineLock.Unlock()
+
+	metricManager.catalogMu.Lock()
+	catalog, exists := metricManager.engineCatalogs[engine]
+	metricManager.catalogMu.Unlock()
+	if exists && time.Now().Before(catalog.lastUpdated.Add(metricManager.configuration.Discovery.Metrics.MetadataTTL)) {
+		return catalog, nil
+	}
+
+	catalog, err := metricManager.getCatalog(ctx, resourceID, engine)
+	if err != nil {
+		return engineCatalog{}, err
+	}
+
+	metricManager.catalogMu.Lock()
+	metricManager.engineCatalogs[engine] = catalog
+	metricManager.catalogMu.Unlock()
+	return catalog, nil
+}
+
+// apiCallContext returns the context of a single Performance Insights call, bounded by aws.api-call-timeout when set,
+// so a stalled call fails with a retryable error instead of hanging the whole scrape.
+func (metricManager *MetricManager) apiCallContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := metricManager.configuration.AWS.APICallTimeout; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
+
+// getAvailableMetrics returns the definitions of the metrics available for the instance and when they were listed.
+// Metrics found in the metadata cache are used without calling Performance Insights while they are younger than the
+// metadata TTL; metrics listed with Performance Insights are stored in the cache. A scrape refreshing metadata always
+// lists them and leaves the caches as they are.
+func (metricManager *MetricManager) getAvailableMetrics(ctx context.Context, resourceID string, engine models.Engine) (map[string]models.MetricDetails, time.Time, error) {
+	refreshMetadata := models.ScrapeRefreshFromContext(ctx).Metadata()
+
+	var availableMetrics []types.ResponseResourceMetric
+	var fetchedAt time.Time
+	var cached bool
+	if !refreshMetadata {
+		availableMetrics, fetchedAt, cached = metricManager.metadataCache.Get(resourceID, engine)
+		cached = cached && !fetchedAt.Before(metricManager.lastInvalidated())
+	}
+	if !cached {
+		output, err := utils.WithRetryJitter(ctx, func() (*awsPI.ListAvailableResourceMetricsOutput, error) {
+			callCtx, cancel := metricManager.apiCallContext(ctx)
+			defer cancel()
+			return metricManager.piService.ListAvailableResourceMetrics(callCtx, resourceID)
+		}, MaxRetries, metricManager.retryBaseDelay, metricManager.configuration.Discovery.Processing.RetryJitter, utils.IsRetryableAWSError)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		availableMetrics, fetchedAt = output.Metrics, time.Now()
+		// New instances without published metrics yet are listed again on the next scrape rather than cached
+		if len(availableMetrics) > 0 && !refreshMetadata {
+			metricManager.metadataCache.Put(resourceID, engine, availableMetrics, fetchedAt)
+		}
+	}
+	if !refreshMetadata {
+		metricManager.storeAvailableCatalog(resourceID, availableMetrics)
+	}
+
+	metricDefinitionMap, err := utils.BuildMetricDefinitionMap(availableMetrics, &metricManager.configuration.Discovery.Metrics, engine, metricManager.registry)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	metricManager.recordDiscoveredMetricNames(engine, metricDefinitionMap)
+	return metricDefinitionMap, fetchedAt, nil
+}
+
+// ExplainExcludedMetrics lists the metrics Performance Insights reports as available for the instance that are not
+// collected, annotated with the reason each was filtered out. Results are sorted by metric name.
+func (metricManager *MetricManager) ExplainExcludedMetrics(ctx context.Context, instance models.Instance) ([]models.ExcludedMetric, error) {
+	availableMetrics, err := utils.WithRetryJitter(ctx, func() (*awsPI.ListAvailableResourceMetricsOutput, error) {
+		callCtx, cancel := metricManager.apiCallContext(ctx)
+		defer cancel()
+		return metricManager.piService.ListAvailableResourceMetrics(callCtx, instance.ResourceID)
+	}, MaxRetries, metricManager.retryBaseDelay, metricManager.configuration.Discovery.Processing.RetryJitter, utils.IsRetryableAWSError)
+	if err != nil {
+		return nil, err
+	}
+
+	metricConfig := &metricManager.configuration.Discovery.Metrics
+	excluded := []models.ExcludedMetric{}
+	for _, metric := range availableMetrics.Metrics {
+		if metric.Metric == nil || metric.Unit == nil {
+			continue
+		}
+
+		metricDetails := models.MetricDetails{
+			Name:       *metric.Metric,
+			Unit:       *metric.Unit,
+			Statistics: utils.GetMetricStatistics(*metric.Metric, metricConfig, instance.Engine),
+		}
+		decision := metricConfig.EvaluateMetric(metricDetails)
+		if decision.Included && len(metricDetails.Statistics) > 0 {
+			continue
+		}
+
+		reason := models.ExclusionReasonNoStatistic
+		if len(decision.ExcludeMatches) > 0 {
+			reason = models.ExclusionReasonExcludePattern
+		} else if !decision.Included {
+			reason = models.ExclusionReasonNoIncludeMatch
+		}
+
+		excluded = append(excluded, models.ExcludedMetric{
+			Name:     metricDetails.Name,
+			Category: models.DeriveMetricCategory(metricDetails.Name),
+			Unit:     metricDetails.Unit,
+			Reason:   reason,
+			Decision: decision,
+		})
+	}
+
+	sort.Slice(excluded, func(i, j int) bool { return excluded[i].Name < excluded[j].Name })
+	return excluded, nil
 }
 
-func (metricManager *MetricManager) getAvailableMetrics(ctx context.Context, resourceID string, engine models.Engine) (map[string]models.MetricDetails, error) {
-	availableMetrics, err := utils.WithRetry(ctx, func() (*awsPI.ListAvailableResourceMetricsOutput, error) {
-		return metricManager.piService.ListAvailableResourceMetrics(ctx, resourceID)
-	}, MaxRetries, BaseDelay)
+// GetMetricCatalog returns every metric Performance Insights reports as available for the instance, keyed by metric
+// name, whether or not the metric filters collect it. The catalog fetched with the metadata of the instance is reused
+// while it is younger than the metadata TTL.
+func (metricManager *MetricManager) GetMetricCatalog(ctx context.Context, instance models.Instance) (map[string]models.CatalogMetric, error) {
+	metricManager.availableMu.Lock()
+	catalog, exists := metricManager.availableCatalogs[instance.ResourceID]
+	metricManager.availableMu.Unlock()
+	if exists && time.Now().Before(catalog.lastUpdated.Add(metricManager.configuration.Discovery.Metrics.MetadataTTL)) {
+		return catalog.metrics, nil
+	}
+
+	availableMetrics, err := utils.WithRetryJitter(ctx, func() (*awsPI.ListAvailableResourceMetricsOutput, error) {
+		callCtx, cancel := metricManager.apiCallContext(ctx)
+		defer cancel()
+		return metricManager.piService.ListAvailableResourceMetrics(callCtx, instance.ResourceID)
+	}, MaxRetries, metricManager.retryBaseDelay, metricManager.configuration.Discovery.Processing.RetryJitter, utils.IsRetryableAWSError)
 	if err != nil {
 		return nil, err
 	}
 
-	return utils.BuildMetricDefinitionMap(availableMetrics.Metrics, &metricManager.configuration.Discovery.Metrics, engine, metricManager.registry)
+	return metricManager.storeAvailableCatalog(instance.ResourceID, availableMetrics.Metrics), nil
+}
+
+// storeAvailableCatalog caches the metrics Performance Insights reported as available for the instance and returns them.
+// The cached map is never modified in place.
+func (metricManager *MetricManager) storeAvailableCatalog(resourceID string, availableMetrics []types.ResponseResourceMetric) map[string]models.CatalogMetric {
+	metrics := make(map[string]models.CatalogMetric, len(availableMetrics))
+	for _, metric := range availableMetrics {
+		if metric.Metric == nil || metric.Unit == nil {
+			continue
+		}
+		metrics[*metric.Metric] = models.CatalogMetric{
+			Name:        *metric.Metric,
+			Description: aws.ToString(metric.Description),
+			Unit:        *metric.Unit,
+			Category:    models.DeriveMetricCategory(*metric.Metric),
+		}
+	}
+
+	metricManager.availableMu.Lock()
+	metricManager.availableCatalogs[resourceID] = availableCatalog{metrics: metrics, lastUpdated: time.Now()}
+	metricManager.availableMu.Unlock()
+	return metrics
+}
+
+// recordDiscoveredMetricNames stores the number of distinct metric names per category in the latest definition map built for the engine.
+func (metricManager *MetricManager) recordDiscoveredMetricNames(engine models.Engine, metricDefinitionMap map[string]models.MetricDetails) {
+	categoryCounts := make(map[string]int)
+	for metricName := range metricDefinitionMap {
+		categoryCounts[models.DeriveMetricCategory(metricName)]++
+	}
+
+	metricManager.discoveredMu.Lock()
+	metricManager.discoveredMetricNames[engine] = categoryCounts
+	metricManager.discoveredMu.Unlock()
+}
+
+// CollectDiscoveredMetricNames emits the number of distinct metric names last discovered per engine and category in the region.
+// Engines whose metrics have not been discovered yet are not reported.
+func (metricManager *MetricManager) CollectDiscoveredMetricNames(region string, ch chan<- prometheus.Metric) {
+	metricManager.discoveredMu.Lock()
+	defer metricManager.discoveredMu.Unlock()
+
+	engines := make([]models.Engine, 0, len(metricManager.discoveredMetricNames))
+	for engine := range metricManager.discoveredMetricNames {
+		engines = append(engines, engine)
+	}
+	sort.Slice(engines, func(i, j int) bool { return engines[i] < engines[j] })
+
+	for _, engine := range engines {
+		for category, count := range metricManager.discoveredMetricNames[engine] {
+			metric, err := formatting.NewDiscoveredMetricNamesMetric(metricManager.configuration.Export.Prometheus, region, engine, category, count)
+			if err != nil {
+				metricManager.logger.Error("Error creating discovered metric names metric", "engine", engine, "error", err)
+				continue
+			}
+			ch <- metric
+		}
+	}
+}
+
+// recordDataPointsReturned adds the number of data points returned for each metric in a Performance Insights response.
+func (metricManager *MetricManager) recordDataPointsReturned(result *awsPI.GetResourceMetricsOutput) {
+	metricManager.dataPointsMu.Lock()
+	defer metricManager.dataPointsMu.Unlock()
+
+	for _, metricData := range result.MetricList {
+		if metricData.Key == nil || metricData.Key.Metric == nil {
+			continue
+		}
+		metricManager.dataPointsReturned[*metricData.Key.Metric] += uint64(len(metricData.DataPoints))
+	}
+}
+
+// CollectDataPointsReturned emits the total number of data points returned per metric name since the exporter started.
+func (metricManager *MetricManager) CollectDataPointsReturned(region string, ch chan<- prometheus.Metric) {
+	metricManager.dataPointsMu.Lock()
+	defer metricManager.dataPointsMu.Unlock()
+
+	metricNames := make([]string, 0, len(metricManager.dataPointsReturned))
+	for metricName := range metricManager.dataPointsReturned {
+		metricNames = append(metricNames, metricName)
+	}
+	sort.Strings(metricNames)
+
+	for _, metricName := range metricNames {
+		metric, err := formatting.NewDataPointsReturnedMetric(metricManager.configuration.Export.Prometheus, region, metricName, metricManager.dataPointsReturned[metricName])
+		if err != nil {
+			metricManager.logger.Error("Error creating data points returned metric", "metric", metricName, "error", err)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+// recordConversionError counts metric data dropped because of a conversion error when prometheus.conversion-errors-metric is enabled.
+func (metricManager *MetricManager) recordConversionError(err error) {
+	if !metricManager.configuration.Export.Prometheus.ConversionErrors {
+		return
+	}
+
+	metricManager.conversionErrorsMu.Lock()
+	defer metricManager.conversionErrorsMu.Unlock()
+	metricManager.conversionErrors[formatting.ConversionErrorReason(err)]++
+}
+
+// CollectConversionErrors emits the total number of metric data dropped per conversion error reason since the exporter started.
+// Reasons that never occurred are not emitted.
+func (metricManager *MetricManager) CollectConversionErrors(region string, ch chan<- prometheus.Metric) {
+	metricManager.conversionErrorsMu.Lock()
+	defer metricManager.conversionErrorsMu.Unlock()
+
+	reasons := make([]string, 0, len(metricManager.conversionErrors))
+	for reason := range metricManager.conversionErrors {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	for _, reason := range reasons {
+		metric, err := formatting.NewConversionErrorsMetric(metricManager.configuration.Export.Prometheus, region, reason, metricManager.conversionErrors[reason])
+		if err != nil {
+			metricManager.logger.Error("Error creating conversion errors metric", "reason", reason, "error", err)
+			continue
+		}
+		ch <- metric
+	}
 }
 
 func (metricManager *MetricManager) getMetricData(ctx context.Context, resourceID string, metricNamesWithStat []string) ([]models.MetricData, error) {
-	metricDataResult, err := utils.WithRetry(ctx, func() (*awsPI.GetResourceMetricsOutput, error) {
-		return metricManager.piService.GetResourceMetrics(ctx, resourceID, metricNamesWithStat)
-	}, MaxRetries, BaseDelay)
+	metricDataResult, err := utils.WithRetryJitter(ctx, func() (*awsPI.GetResourceMetricsOutput, error) {
+		callCtx, cancel := metricManager.apiCallContext(ctx)
+		defer cancel()
+		return metricManager.piService.GetResourceMetrics(callCtx, resourceID, metricNamesWithStat)
+	}, MaxRetries, metricManager.retryBaseDelay, metricManager.configuration.Discovery.Processing.RetryJitter, utils.IsRetryableAWSError)
 	if err != nil {
 		return nil, err
 	}
 
-	return metricManager.filterLatestValidMetricData(metricDataResult), nil
+	metricData := metricManager.filterLatestValidMetricData(metricDataResult)
+	return metricManager.fillMissingMetricData(ctx, metricNamesWithStat, metricData), nil
 }
 
+// fillMissingMetricData adds metric data for requested metrics that Performance Insights returned no data point for,
+// as configured by metrics.on-missing: absent leaves them out, zero reports 0 and stale reports a staleness marker,
+// both timestamped with the current time. Staleness markers are only reported to collections that carry
+// ContextWithStaleMarkers, the scrape endpoint leaves those metrics out.
+func (metricManager *MetricManager) fillMissingMetricData(ctx context.Context, metricNamesWithStat []string, metricData []models.MetricData) []models.MetricData {
+	var value float64
+	switch metricManager.configuration.Discovery.Metrics.OnMissing {
+	case models.MissingMetricZero:
+		value = 0
+	case models.MissingMetricStale:
+		if !models.StaleMarkersFromContext(ctx) {
+			return metricData
+		}
+		value = staleMarker
+	default:
+		return metricData
+	}
+
+	returned := make(map[string]bool, len(metricData))
+	for _, metricDatum := range metricData {
+		returned[metricDatum.Metric] = true
+	}
+
+	now := time.Now()
+	for _, metricName := range metricNamesWithStat {
+		if returned[metricName] {
+			continue
+		}
+		metricData = append(metricData, models.MetricData{
+			Metric:    metricName,
+			Timestamp: now,
+			Value:     value,
+		})
+	}
+
+	return metricData
+}
+
+// filterLatestValidMetricData keeps the latest valid data point of every metric in the response.
+// When prometheus.datapoints-returned-metric is enabled, the data points returned are counted before they are collapsed.
 func (metricManager *MetricManager) filterLatestValidMetricData(result *awsPI.GetResourceMetricsOutput) []models.MetricData {
+	if metricManager.configuration.Export.Prometheus.DataPointsReturned {
+		metricManager.recordDataPointsReturned(result)
+	}
+
 	var filteredData []models.MetricData
 
 	for _, metricData := range result.MetricList {
@@ -130,9 +776,14 @@ func (metricManager *MetricManager) filterLatestValidMetricData(result *awsPI.Ge
 
 		latestDataPoint := metricManager.getLatestValidDataPoint(metricData.DataPoints)
 		if latestDataPoint != nil && latestDataPoint.Value != nil && latestDataPoint.Timestamp != nil {
+			timestamp := *latestDataPoint.Timestamp
+			if now := time.Now(); timestamp.After(now) && metricManager.configuration.Discovery.Metrics.FutureTimestamp == models.FutureTimestampClamp {
+				timestamp = now
+			}
+
 			filteredData = append(filteredData, models.MetricData{
 				Metric:    *metricData.Key.Metric,
-				Timestamp: *latestDataPoint.Timestamp,
+				Timestamp: timestamp,
 				Value:     *latestDataPoint.Value,
 			})
 		}
@@ -141,14 +792,21 @@ func (metricManager *MetricManager) filterLatestValidMetricData(result *awsPI.Ge
 	return filteredData
 }
 
+// getLatestValidDataPoint returns the latest data point with both a value and a timestamp.
+// When metrics.future-timestamp is drop, data points timestamped after now are skipped.
 func (metricManager *MetricManager) getLatestValidDataPoint(dataPoints []types.DataPoint) *types.DataPoint {
 	if len(dataPoints) == 0 {
 		return nil
 	}
 
+	dropFuture := metricManager.configuration.Discovery.Metrics.FutureTimestamp == models.FutureTimestampDrop
+	now := time.Now()
 	for i := len(dataPoints) - 1; i >= 0; i-- {
 		dataPoint := &dataPoints[i]
 		if dataPoint.Value != nil && dataPoint.Timestamp != nil {
+			if dropFuture && dataPoint.Timestamp.After(now) {
+				continue
+			}
 			return dataPoint
 		}
 	}
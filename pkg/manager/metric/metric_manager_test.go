@@ -3,6 +3,10 @@ package metric
 import (
 	"context"
 	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,13 +14,18 @@ import (
 	awspi "github.com/aws/aws-sdk-go-v2/service/pi"
 	pitypes "github.com/aws/aws-sdk-go-v2/service/pi/types"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients/pi"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/filter"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/processing/formatting"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/utils"
 )
 
 func TestNewMetricManager(t *testing.T) {
@@ -90,6 +99,15 @@ func TestGetMetricBatches(t *testing.T) {
 			expectedBatches:  0,
 			shouldCallList:   true,
 		},
+		{
+			name:             "Get metric batches for stopped instance with ListAvailableResourceMetrics error",
+			instanceFactory:  testutils.NewTestInstanceStopped,
+			mockListResponse: nil,
+			listError:        errors.New("ListAvailableResourceMetrics failed"),
+			expectedError:    nil,
+			expectedBatches:  0,
+			shouldCallList:   true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -120,6 +138,100 @@ func TestGetMetricBatches(t *testing.T) {
 	}
 }
 
+func TestGetMetricBatchesBatchSize(t *testing.T) {
+	testCases := []struct {
+		name          string
+		batchSize     int
+		expectedSizes []int
+	}{
+		{name: "default batch size", batchSize: 15, expectedSizes: []int{5}},
+		{name: "smaller batch size", batchSize: 2, expectedSizes: []int{2, 2, 1}},
+		{name: "one metric per batch", batchSize: 1, expectedSizes: []int{1, 1, 1, 1, 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testutils.NewTestConfigBuilder().WithBatchSize(tc.batchSize).Build()
+			manager, err := NewMetricManager(&mocks.MockPIService{}, config)
+			require.NoError(t, err)
+
+			batches, err := manager.GetMetricBatches(context.Background(), testutils.NewTestInstancePostgreSQL())
+			require.NoError(t, err)
+
+			var sizes []int
+			for _, batch := range batches {
+				sizes = append(sizes, len(batch))
+			}
+			assert.Equal(t, tc.expectedSizes, sizes)
+		})
+	}
+}
+
+func TestGetMetricBatchesColdInstance(t *testing.T) {
+	t.Run("first collection refreshes metadata exactly once", func(t *testing.T) {
+		instance := testutils.NewTestInstanceNoMetrics()
+		mockPI := &mocks.MockPIService{}
+		manager, err := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+			Return(mocks.NewMockPIListMetricsResponse(), nil).Once()
+		mockPI.On("GetResourceMetrics", mock.Anything, instance.ResourceID, mock.Anything).
+			Return(mocks.NewMockPIGetResourceMetricsResponse(), nil)
+
+		batches, err := manager.GetMetricBatches(context.Background(), instance)
+		require.NoError(t, err)
+		require.Len(t, batches, 1)
+		assert.False(t, instance.Metrics.MetricsLastUpdated.IsZero())
+		assert.NotNil(t, instance.Metrics.MetricsDetails)
+
+		ch := make(chan prometheus.Metric, 100)
+		require.NoError(t, manager.CollectMetricsForBatch(context.Background(), instance, batches[0], ch))
+		close(ch)
+		assert.NotEmpty(t, ch)
+
+		_, err = manager.GetMetricBatches(context.Background(), instance)
+		require.NoError(t, err)
+		mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", 1)
+	})
+
+	t.Run("no available metrics yet keeps the instance cold without an error", func(t *testing.T) {
+		instance := testutils.NewTestInstanceNoMetrics()
+		mockPI := &mocks.MockPIService{}
+		manager, err := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+			Return(mocks.NewMockPIListMetricsResponseEmpty(), nil).Once()
+
+		batches, err := manager.GetMetricBatches(context.Background(), instance)
+		assert.NoError(t, err)
+		assert.Empty(t, batches)
+		assert.True(t, instance.Metrics.MetricsLastUpdated.IsZero())
+
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+			Return(mocks.NewMockPIListMetricsResponse(), nil).Once()
+
+		batches, err = manager.GetMetricBatches(context.Background(), instance)
+		assert.NoError(t, err)
+		assert.Len(t, batches, 1)
+		mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", 2)
+	})
+
+	t.Run("no available metrics on a warm instance is an error", func(t *testing.T) {
+		instance := testutils.NewTestInstancePostgreSQLExpired()
+		mockPI := &mocks.MockPIService{}
+		manager, err := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+			Return(mocks.NewMockPIListMetricsResponseEmpty(), nil)
+
+		_, err = manager.GetMetricBatches(context.Background(), instance)
+		assert.ErrorIs(t, err, utils.ErrNoAvailableMetrics)
+	})
+}
+
 func TestCollectMetricsForBatch(t *testing.T) {
 	testCases := []struct {
 		name                string
@@ -148,6 +260,15 @@ func TestCollectMetricsForBatch(t *testing.T) {
 			expectedError:       errors.New("GetResourceMetrics failed"),
 			expectedMetricCount: 0,
 		},
+		{
+			name:                "Collect metrics for batch for stopped instance with GetResourceMetrics error",
+			instanceFactory:     testutils.NewTestInstanceStopped,
+			metricsBatch:        testutils.TestMetricNamesWithStats,
+			mockGetResponse:     nil,
+			getError:            errors.New("GetResourceMetrics failed"),
+			expectedError:       nil,
+			expectedMetricCount: 0,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -184,6 +305,206 @@ func TestCollectMetricsForBatch(t *testing.T) {
 	}
 }
 
+func TestCollectMetricsForBatchWithInvalidMetrics(t *testing.T) {
+	remaining := []string{"os.general.numVCPUs.avg", "os.cpuUtilization.guest.avg", "os.cpuUtilization.idle.avg", "os.memory.total.avg"}
+
+	testCases := []struct {
+		name                string
+		onInvalid           models.InvalidMetricBehavior
+		metricsBatch        []string
+		errorMessage        string
+		expectRetry         bool
+		expectedError       bool
+		expectedMetricsList []string
+	}{
+		{
+			name:                "prune removes the named metric and retries the batch without it",
+			onInvalid:           models.InvalidMetricPrune,
+			metricsBatch:        testutils.TestMetricNamesWithStats,
+			errorMessage:        "The metric db.User.max_connections is not valid.",
+			expectRetry:         true,
+			expectedMetricsList: remaining,
+		},
+		{
+			name:                "prune matches metric names with statistic",
+			onInvalid:           models.InvalidMetricPrune,
+			metricsBatch:        testutils.TestMetricNamesWithStats,
+			errorMessage:        "Invalid metric db.User.max_connections.avg",
+			expectRetry:         true,
+			expectedMetricsList: remaining,
+		},
+		{
+			name:                "prune without metric names in the error fails the batch",
+			onInvalid:           models.InvalidMetricPrune,
+			metricsBatch:        testutils.TestMetricNamesWithStats,
+			errorMessage:        "Invalid period",
+			expectedError:       true,
+			expectedMetricsList: testutils.TestMetricNamesWithStats,
+		},
+		{
+			name:                "prune of every metric in the batch skips the retry",
+			onInvalid:           models.InvalidMetricPrune,
+			metricsBatch:        []string{"db.User.max_connections.avg"},
+			errorMessage:        "The metric db.User.max_connections is not valid.",
+			expectedMetricsList: remaining,
+		},
+		{
+			name:                "fail returns the error and keeps the cached metrics",
+			onInvalid:           models.InvalidMetricFail,
+			metricsBatch:        testutils.TestMetricNamesWithStats,
+			errorMessage:        "The metric db.User.max_connections is not valid.",
+			expectedError:       true,
+			expectedMetricsList: testutils.TestMetricNamesWithStats,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := testutils.NewTestInstancePostgreSQL()
+
+			mockPI := &mocks.MockPIService{}
+			config := testutils.NewTestConfigBuilder().WithOnInvalid(tc.onInvalid).Build()
+			manager, _ := NewMetricManager(mockPI, config)
+			manager.retryBaseDelay = time.Millisecond
+
+			mockPI.On("GetResourceMetrics", mock.Anything, instance.ResourceID, tc.metricsBatch).
+				Return(nil, &pitypes.InvalidArgumentException{Message: aws.String(tc.errorMessage)})
+			if tc.expectRetry {
+				mockPI.On("GetResourceMetrics", mock.Anything, instance.ResourceID, remaining).
+					Return(mocks.NewMockPIGetResourceMetricsResponse(), nil).Once()
+			}
+
+			ch := make(chan prometheus.Metric, 100)
+			err := manager.CollectMetricsForBatch(context.Background(), instance, tc.metricsBatch, ch)
+			close(ch)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedMetricsList, instance.Metrics.MetricsList)
+			if tc.expectRetry {
+				assert.NotEmpty(t, ch)
+			}
+			mockPI.AssertExpectations(t)
+		})
+	}
+
+	assert.Len(t, testutils.TestMetricNamesWithStats, 5, "pruning must not modify the shared metric list")
+}
+
+func TestCollectMetricsForBatchWithDescriptionInfoMetric(t *testing.T) {
+	countInfoMetrics := func(ch chan prometheus.Metric) int {
+		close(ch)
+		count := 0
+		for metric := range ch {
+			if strings.Contains(metric.Desc().String(), `"dbi_metric_description_info"`) {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("emits one info metric per metric name per scrape", func(t *testing.T) {
+		mockPI := &mocks.MockPIService{}
+		config := testutils.NewTestConfigBuilder().WithDescriptionInfoMetric(true).Build()
+		manager, _ := NewMetricManager(mockPI, config)
+		mockPI.On("GetResourceMetrics", mock.Anything, mock.Anything, testutils.TestMetricNamesWithStats).
+			Return(mocks.NewMockPIGetResourceMetricsResponse(), nil)
+
+		ctx := models.ContextWithMetricDescriptions(context.Background(), models.NewMetricDescriptions())
+
+		first := make(chan prometheus.Metric, 100)
+		require.NoError(t, manager.CollectMetricsForBatch(ctx, testutils.NewTestInstancePostgreSQL(), testutils.TestMetricNamesWithStats, first))
+		assert.Equal(t, 5, countInfoMetrics(first))
+
+		second := make(chan prometheus.Metric, 100)
+		require.NoError(t, manager.CollectMetricsForBatch(ctx, testutils.NewTestInstancePostgreSQL(), testutils.TestMetricNamesWithStats, second))
+		assert.Equal(t, 0, countInfoMetrics(second), "descriptions already emitted in this scrape must not be repeated")
+	})
+
+	t.Run("disabled emits no info metrics", func(t *testing.T) {
+		mockPI := &mocks.MockPIService{}
+		manager, _ := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		mockPI.On("GetResourceMetrics", mock.Anything, mock.Anything, testutils.TestMetricNamesWithStats).
+			Return(mocks.NewMockPIGetResourceMetricsResponse(), nil)
+
+		ctx := models.ContextWithMetricDescriptions(context.Background(), models.NewMetricDescriptions())
+		ch := make(chan prometheus.Metric, 100)
+		require.NoError(t, manager.CollectMetricsForBatch(ctx, testutils.NewTestInstancePostgreSQL(), testutils.TestMetricNamesWithStats, ch))
+		assert.Equal(t, 0, countInfoMetrics(ch))
+	})
+}
+
+func TestCollectMetricsForBatchWithConversionErrors(t *testing.T) {
+	newInstance := func() models.Instance {
+		instance := testutils.NewTestInstancePostgreSQL()
+		details := make(map[string]models.MetricDetails, len(testutils.TestMetricsDetails))
+		for name, detail := range testutils.TestMetricsDetails {
+			details[name] = detail
+		}
+		delete(details, "os.general.numVCPUs")
+		instance.Metrics.MetricsDetails = details
+		return instance
+	}
+
+	collectConversionErrors := func(manager *MetricManager) map[string]float64 {
+		ch := make(chan prometheus.Metric, 10)
+		manager.CollectConversionErrors("us-west-2", ch)
+		close(ch)
+
+		counts := make(map[string]float64)
+		for metric := range ch {
+			assert.Contains(t, metric.Desc().String(), `"dbi_metric_conversion_errors_total"`)
+			var written dto.Metric
+			require.NoError(t, metric.Write(&written))
+			labels := make(map[string]string)
+			for _, label := range written.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			assert.Equal(t, "us-west-2", labels["region"])
+			counts[labels["reason"]] = written.GetCounter().GetValue()
+		}
+		return counts
+	}
+
+	testCases := []struct {
+		name           string
+		enabled        bool
+		expectedCounts map[string]float64
+	}{
+		{
+			name:           "counts dropped metrics by reason",
+			enabled:        true,
+			expectedCounts: map[string]float64{formatting.ConversionErrorMissingDetails: 2},
+		},
+		{
+			name:           "counts nothing when disabled",
+			enabled:        false,
+			expectedCounts: map[string]float64{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPI := &mocks.MockPIService{}
+			config := testutils.NewTestConfigBuilder().WithConversionErrors(tc.enabled).Build()
+			manager, _ := NewMetricManager(mockPI, config)
+			mockPI.On("GetResourceMetrics", mock.Anything, mock.Anything, testutils.TestMetricNamesWithStats).
+				Return(mocks.NewMockPIGetResourceMetricsResponse(), nil)
+
+			for i := 0; i < 2; i++ {
+				ch := make(chan prometheus.Metric, 100)
+				require.NoError(t, manager.CollectMetricsForBatch(context.Background(), newInstance(), testutils.TestMetricNamesWithStats, ch))
+				close(ch)
+			}
+
+			assert.Equal(t, tc.expectedCounts, collectConversionErrors(manager))
+		})
+	}
+}
+
 func TestCollectMetricsForBatchWithEmptyResponse(t *testing.T) {
 	testCases := []struct {
 		name                string
@@ -237,6 +558,284 @@ func TestCollectMetricsForBatchWithEmptyResponse(t *testing.T) {
 	}
 }
 
+func TestGetMetricBatchesWithSharedCatalog(t *testing.T) {
+	newColdInstance := func(resourceID string, engine models.Engine) models.Instance {
+		instance := testutils.NewTestInstanceNoMetrics()
+		instance.ResourceID = resourceID
+		instance.Identifier = strings.ToLower(resourceID)
+		instance.Engine = engine
+		return instance
+	}
+
+	testCases := []struct {
+		name          string
+		shareCatalog  bool
+		instances     []models.Instance
+		expectedCalls int
+	}{
+		{
+			name:         "instances of the same engine share one catalog",
+			shareCatalog: true,
+			instances: []models.Instance{
+				newColdInstance("db-PG1", models.AuroraPostgreSQL),
+				newColdInstance("db-PG2", models.AuroraPostgreSQL),
+				newColdInstance("db-PG3", models.AuroraPostgreSQL),
+			},
+			expectedCalls: 1,
+		},
+		{
+			name:         "instances of different engines fetch their own catalog",
+			shareCatalog: true,
+			instances: []models.Instance{
+				newColdInstance("db-PG1", models.AuroraPostgreSQL),
+				newColdInstance("db-MYSQL1", models.AuroraMySQL),
+				newColdInstance("db-MYSQL2", models.AuroraMySQL),
+			},
+			expectedCalls: 2,
+		},
+		{
+			name:         "disabled fetches the catalog per instance",
+			shareCatalog: false,
+			instances: []models.Instance{
+				newColdInstance("db-PG1", models.AuroraPostgreSQL),
+				newColdInstance("db-PG2", models.AuroraPostgreSQL),
+			},
+			expectedCalls: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPI := &mocks.MockPIService{}
+			config := testutils.NewTestConfigBuilder().WithShareCatalogPerEngine(tc.shareCatalog).Build()
+			manager, err := NewMetricManager(mockPI, config)
+			require.NoError(t, err)
+
+			mockPI.On("ListAvailableResourceMetrics", mock.Anything, mock.Anything).
+				Return(mocks.NewMockPIListMetricsResponse(), nil)
+
+			var wg sync.WaitGroup
+			for _, instance := range tc.instances {
+				wg.Add(1)
+				go func(instance models.Instance) {
+					defer wg.Done()
+					batches, err := manager.GetMetricBatches(context.Background(), instance)
+					assert.NoError(t, err)
+					assert.Len(t, batches, 1)
+				}(instance)
+			}
+			wg.Wait()
+
+			mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", tc.expectedCalls)
+			for _, instance := range tc.instances {
+				details, exists := instance.Metrics.MetricsDetails["db.User.max_connections"]
+				if assert.True(t, exists, "instance %s", instance.Identifier) {
+					assert.Equal(t, "The maximum number of connections allowed for a DB instance as configured in max_connections parameter", details.Description)
+				}
+				assert.Equal(t, tc.instances[0].Metrics.MetricsList, instance.Metrics.MetricsList)
+			}
+		})
+	}
+
+	t.Run("expired catalog is fetched again", func(t *testing.T) {
+		mockPI := &mocks.MockPIService{}
+		config := testutils.NewTestConfigBuilder().WithShareCatalogPerEngine(true).WithMetadataTTL(time.Millisecond).Build()
+		manager, err := NewMetricManager(mockPI, config)
+		require.NoError(t, err)
+
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, mock.Anything).
+			Return(mocks.NewMockPIListMetricsResponse(), nil)
+
+		_, err = manager.GetMetricBatches(context.Background(), newColdInstance("db-PG1", models.AuroraPostgreSQL))
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+		_, err = manager.GetMetricBatches(context.Background(), newColdInstance("db-PG2", models.AuroraPostgreSQL))
+		require.NoError(t, err)
+
+		mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", 2)
+	})
+
+	t.Run("refreshing metadata fetches the catalog without replacing the cached one", func(t *testing.T) {
+		mockPI := &mocks.MockPIService{}
+		config := testutils.NewTestConfigBuilder().WithShareCatalogPerEngine(true).Build()
+		manager, err := NewMetricManager(mockPI, config)
+		require.NoError(t, err)
+
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, mock.Anything).
+			Return(mocks.NewMockPIListMetricsResponse(), nil)
+
+		_, err = manager.GetMetricBatches(context.Background(), newColdInstance("db-PG1", models.AuroraPostgreSQL))
+		require.NoError(t, err)
+		cachedAt := manager.engineCatalogs[models.AuroraPostgreSQL].lastUpdated
+
+		ctx := models.ContextWithScrapeRefresh(context.Background(), models.ScrapeRefreshMetadata)
+		_, err = manager.GetMetricBatches(ctx, newColdInstance("db-PG2", models.AuroraPostgreSQL))
+		require.NoError(t, err)
+		mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", 2)
+		assert.True(t, cachedAt.Equal(manager.engineCatalogs[models.AuroraPostgreSQL].lastUpdated))
+
+		_, err = manager.GetMetricBatches(context.Background(), newColdInstance("db-PG3", models.AuroraPostgreSQL))
+		require.NoError(t, err)
+		mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", 2)
+	})
+}
+
+func TestGetMetricBatchesWithUnsupportedInstance(t *testing.T) {
+	instance := testutils.NewTestInstanceNoMetrics()
+	mockPI := &mocks.MockPIService{}
+	manager, _ := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+	manager.retryBaseDelay = time.Millisecond
+
+	mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+		Return(nil, &pitypes.InvalidArgumentException{Message: aws.String("This engine is not supported")})
+
+	batches, err := manager.GetMetricBatches(context.Background(), instance)
+	assert.ErrorIs(t, err, ErrPerformanceInsightsUnsupported)
+	assert.Nil(t, batches)
+	mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", 1)
+
+	t.Run("skips the instance without calling AWS until re-check", func(t *testing.T) {
+		_, err := manager.GetMetricBatches(context.Background(), instance)
+		assert.ErrorIs(t, err, ErrPerformanceInsightsUnsupported)
+		mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", 1)
+	})
+
+	t.Run("re-checks the instance once the re-check time has passed", func(t *testing.T) {
+		manager.unsupportedInstances[instance.ResourceID] = time.Now().Add(-time.Second)
+
+		_, err := manager.GetMetricBatches(context.Background(), instance)
+		assert.ErrorIs(t, err, ErrPerformanceInsightsUnsupported)
+		mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", 2)
+	})
+
+	t.Run("refreshing metadata re-checks the instance without changing the re-check time", func(t *testing.T) {
+		recheckAt := manager.unsupportedInstances[instance.ResourceID]
+
+		ctx := models.ContextWithScrapeRefresh(context.Background(), models.ScrapeRefreshMetadata)
+		_, err := manager.GetMetricBatches(ctx, instance)
+		assert.ErrorIs(t, err, ErrPerformanceInsightsUnsupported)
+		mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", 3)
+		assert.Equal(t, recheckAt, manager.unsupportedInstances[instance.ResourceID])
+	})
+}
+
+func TestInvalidate(t *testing.T) {
+	t.Run("metadata listed before is listed again", func(t *testing.T) {
+		instance := testutils.NewTestInstanceNoMetrics()
+		mockPI := &mocks.MockPIService{}
+		manager, err := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+			Return(mocks.NewMockPIListMetricsResponse(), nil)
+
+		_, err = manager.GetMetricBatches(context.Background(), instance)
+		require.NoError(t, err)
+		_, err = manager.GetMetricBatches(context.Background(), instance)
+		require.NoError(t, err)
+		mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", 1)
+
+		manager.Invalidate()
+
+		_, err = manager.GetMetricBatches(context.Background(), instance)
+		require.NoError(t, err)
+		mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", 2)
+	})
+
+	t.Run("caches are dropped", func(t *testing.T) {
+		manager, err := NewMetricManager(&mocks.MockPIService{}, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+		manager.engineCatalogs[models.AuroraPostgreSQL] = engineCatalog{lastUpdated: time.Now()}
+		manager.availableCatalogs["db-ABC"] = availableCatalog{lastUpdated: time.Now()}
+		manager.unsupportedInstances["db-ABC"] = time.Now().Add(time.Hour)
+		registry := manager.registry.GetEngineRegistry(models.AuroraPostgreSQL)
+
+		manager.Invalidate()
+
+		assert.Empty(t, manager.engineCatalogs)
+		assert.Empty(t, manager.availableCatalogs)
+		assert.False(t, manager.isUnsupported("db-ABC"))
+		assert.NotSame(t, registry, manager.registry.GetEngineRegistry(models.AuroraPostgreSQL))
+	})
+}
+
+func TestCollectDiscoveredMetricNames(t *testing.T) {
+	mockPI := &mocks.MockPIService{}
+	manager, _ := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+
+	ch := make(chan prometheus.Metric, 10)
+	manager.CollectDiscoveredMetricNames("us-west-2", ch)
+	assert.Empty(t, ch, "nothing is reported before metrics are discovered")
+
+	instance := testutils.NewTestInstancePostgreSQLExpired()
+	mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+		Return(mocks.NewMockPIListMetricsResponse(), nil)
+	_, err := manager.GetMetricBatches(context.Background(), instance)
+	require.NoError(t, err)
+
+	manager.CollectDiscoveredMetricNames("us-west-2", ch)
+	close(ch)
+
+	counts := make(map[string]float64)
+	for metric := range ch {
+		assert.Contains(t, metric.Desc().String(), `"dbi_discovered_metric_names"`)
+		var written dto.Metric
+		require.NoError(t, metric.Write(&written))
+		labels := make(map[string]string)
+		for _, label := range written.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		assert.Equal(t, "us-west-2", labels["region"])
+		assert.Equal(t, string(instance.Engine), labels["engine"])
+		counts[labels["category"]] = written.GetGauge().GetValue()
+	}
+	assert.Equal(t, map[string]float64{"os": 4, "db": 1}, counts)
+}
+
+func TestCollectPostProcessedMetrics(t *testing.T) {
+	testCases := []struct {
+		name           string
+		postProcessors []models.PostProcessorName
+		expectedCount  int
+	}{
+		{
+			name:           "no post-processors emit nothing",
+			postProcessors: nil,
+			expectedCount:  0,
+		},
+		{
+			name:           "memory-free-percent emits the derived metric",
+			postProcessors: []models.PostProcessorName{models.PostProcessorMemoryFreePercent},
+			expectedCount:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testutils.NewTestConfigBuilder().WithPostProcessors(tc.postProcessors...).Build()
+			manager, err := NewMetricManager(&mocks.MockPIService{}, config)
+			require.NoError(t, err)
+
+			collected := models.NewCollectedMetricData()
+			ctx := models.ContextWithCollectedMetricData(context.Background(), collected)
+			instance := testutils.NewTestInstancePostgreSQL()
+			collected.Add(instance, []models.MetricData{testutils.NewTestMetricData("os.memory.free.avg", 256)})
+			collected.Add(instance, []models.MetricData{testutils.NewTestMetricData("os.memory.total.avg", 1024)})
+
+			ch := make(chan prometheus.Metric, 10)
+			manager.CollectPostProcessedMetrics(ctx, ch)
+			close(ch)
+
+			require.Len(t, ch, tc.expectedCount)
+			for metric := range ch {
+				assert.Contains(t, metric.Desc().String(), `"dbi_os_memory_freepercent_avg"`)
+				var written dto.Metric
+				require.NoError(t, metric.Write(&written))
+				assert.Equal(t, 25.0, written.GetGauge().GetValue())
+			}
+		})
+	}
+}
+
 func TestGetMetricBatchesWithNilMetrics(t *testing.T) {
 	instance := models.Instance{
 		ResourceID: "db-TEST",
@@ -314,6 +913,135 @@ func TestGetMetrics(t *testing.T) {
 	}
 }
 
+func TestExplainExcludedMetrics(t *testing.T) {
+	cfg := testutils.CreateDefaultParsedTestConfig()
+	cfg.Discovery.Metrics.Exclude = map[string][]string{"name": {`\.idle$`}}
+	cfg.Discovery.Metrics.Filter = filter.NewPatternFilter(
+		filter.Patterns{"category": {regexp.MustCompile("^os$")}},
+		filter.Patterns{"name": {regexp.MustCompile(`\.idle$`)}},
+	)
+
+	mockPI := &mocks.MockPIService{}
+	manager, err := NewMetricManager(mockPI, cfg)
+	require.NoError(t, err)
+
+	instance := testutils.NewTestInstancePostgreSQLExpired()
+	mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+		Return(mocks.NewMockPIListMetricsResponse(), nil)
+
+	excluded, err := manager.ExplainExcludedMetrics(context.Background(), instance)
+	require.NoError(t, err)
+
+	assert.Equal(t, []models.ExcludedMetric{
+		{
+			Name:     "db.User.max_connections",
+			Category: "db",
+			Unit:     "Connections",
+			Reason:   models.ExclusionReasonNoIncludeMatch,
+			Decision: filter.Decision{IncludeMisses: []string{"category"}},
+		},
+		{
+			Name:     "os.cpuUtilization.idle",
+			Category: "os",
+			Unit:     "Percent",
+			Reason:   models.ExclusionReasonExcludePattern,
+			Decision: filter.Decision{
+				ExcludeMatches: []filter.PatternMatch{{Field: "name", Pattern: `\.idle$`}},
+				IncludeMatches: []filter.PatternMatch{{Field: "category", Pattern: "^os$"}},
+			},
+		},
+	}, excluded)
+
+	t.Run("ListAvailableResourceMetrics error", func(t *testing.T) {
+		mockPI := &mocks.MockPIService{}
+		manager, err := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+		manager.retryBaseDelay = time.Millisecond
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+			Return(nil, errors.New("ListAvailableResourceMetrics failed"))
+
+		excluded, err := manager.ExplainExcludedMetrics(context.Background(), instance)
+		assert.Error(t, err)
+		assert.Nil(t, excluded)
+	})
+}
+
+func TestGetMetricCatalog(t *testing.T) {
+	instance := testutils.NewTestInstancePostgreSQLExpired()
+	expectedIdle := models.CatalogMetric{
+		Name:        "os.cpuUtilization.idle",
+		Description: "The percentage of CPU that is idle",
+		Unit:        "Percent",
+		Category:    "os",
+	}
+
+	testCases := []struct {
+		name          string
+		metadataTTL   time.Duration
+		scrapeFirst   bool
+		expectedCalls int
+	}{
+		{
+			name:          "catalog is cached for the metadata TTL",
+			metadataTTL:   time.Hour,
+			expectedCalls: 1,
+		},
+		{
+			name:          "catalog fetched by a scrape is reused",
+			metadataTTL:   time.Hour,
+			scrapeFirst:   true,
+			expectedCalls: 1,
+		},
+		{
+			name:          "expired catalog is fetched again",
+			metadataTTL:   0,
+			expectedCalls: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testutils.CreateDefaultParsedTestConfig()
+			cfg.Discovery.Metrics.MetadataTTL = tc.metadataTTL
+			cfg.Discovery.Metrics.Exclude = map[string][]string{"name": {`\.idle$`}}
+
+			mockPI := &mocks.MockPIService{}
+			manager, err := NewMetricManager(mockPI, cfg)
+			require.NoError(t, err)
+			mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+				Return(mocks.NewMockPIListMetricsResponse(), nil)
+
+			if tc.scrapeFirst {
+				_, _, err := manager.getAvailableMetrics(context.Background(), instance.ResourceID, instance.Engine)
+				require.NoError(t, err)
+			} else {
+				_, err := manager.GetMetricCatalog(context.Background(), instance)
+				require.NoError(t, err)
+			}
+
+			catalog, err := manager.GetMetricCatalog(context.Background(), instance)
+			require.NoError(t, err)
+
+			assert.Len(t, catalog, 5)
+			assert.Equal(t, expectedIdle, catalog["os.cpuUtilization.idle"], "excluded metrics are part of the catalog")
+			mockPI.AssertNumberOfCalls(t, "ListAvailableResourceMetrics", tc.expectedCalls)
+		})
+	}
+
+	t.Run("ListAvailableResourceMetrics error", func(t *testing.T) {
+		mockPI := &mocks.MockPIService{}
+		manager, err := NewMetricManager(mockPI, testutils.CreateDefaultParsedTestConfig())
+		require.NoError(t, err)
+		manager.retryBaseDelay = time.Millisecond
+		mockPI.On("ListAvailableResourceMetrics", mock.Anything, instance.ResourceID).
+			Return(nil, errors.New("ListAvailableResourceMetrics failed"))
+
+		catalog, err := manager.GetMetricCatalog(context.Background(), instance)
+		assert.Error(t, err)
+		assert.Nil(t, catalog)
+	})
+}
+
 func TestGetAvailableMetrics(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -346,7 +1074,7 @@ func TestGetAvailableMetrics(t *testing.T) {
 			mockPI.On("ListAvailableResourceMetrics", mock.Anything, tc.resourceID).
 				Return(tc.mockResponse, tc.expectedError)
 
-			metricsDetails, err := manager.getAvailableMetrics(context.Background(), tc.resourceID, models.PostgreSQL)
+			metricsDetails, _, err := manager.getAvailableMetrics(context.Background(), tc.resourceID, models.PostgreSQL)
 
 			if tc.expectedError != nil {
 				assert.Error(t, err)
@@ -440,6 +1168,101 @@ func TestGetMetricData(t *testing.T) {
 	}
 }
 
+func TestGetMetricDataAPICallTimeout(t *testing.T) {
+	mockPI := &mocks.MockPIService{}
+	config := testutils.NewTestConfigBuilder().WithAPICallTimeout(10 * time.Millisecond).Build()
+	manager, _ := NewMetricManager(mockPI, config)
+	manager.retryBaseDelay = time.Millisecond
+
+	metricNames := testutils.TestMetricNamesWithStats
+	mockPI.On("GetResourceMetrics", mock.Anything, "db-TESTPOSTGRES", metricNames).
+		Run(func(args mock.Arguments) {
+			ctx := args.Get(0).(context.Context)
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline, "each call should be bounded by aws.api-call-timeout")
+			<-ctx.Done()
+		}).
+		Return(nil, context.DeadlineExceeded).Once()
+	mockPI.On("GetResourceMetrics", mock.Anything, "db-TESTPOSTGRES", metricNames).
+		Return(mocks.NewMockPIGetResourceMetricsResponse(), nil).Once()
+
+	metricData, err := manager.getMetricData(context.Background(), "db-TESTPOSTGRES", metricNames)
+
+	require.NoError(t, err, "a timed out call should be retried")
+	assert.Len(t, metricData, 5)
+	mockPI.AssertExpectations(t)
+}
+
+func TestGetMetricDataOnMissing(t *testing.T) {
+	metricNames := []string{"os.general.numVCPUs.avg", "os.memory.free.avg"}
+	response := &awspi.GetResourceMetricsOutput{
+		MetricList: mocks.NewMockPIGetResourceMetricsResponse().MetricList[:1],
+	}
+
+	testCases := []struct {
+		name          string
+		onMissing     models.MissingMetricBehavior
+		ctx           context.Context
+		expectedCount int
+		checkMissing  func(t *testing.T, value float64)
+	}{
+		{
+			name:          "absent leaves missing metrics out",
+			onMissing:     models.MissingMetricAbsent,
+			ctx:           context.Background(),
+			expectedCount: 1,
+		},
+		{
+			name:          "zero reports missing metrics as 0",
+			onMissing:     models.MissingMetricZero,
+			ctx:           context.Background(),
+			expectedCount: 2,
+			checkMissing: func(t *testing.T, value float64) {
+				assert.Equal(t, 0.0, value)
+			},
+		},
+		{
+			name:          "stale reports missing metrics as a staleness marker to remote-write",
+			onMissing:     models.MissingMetricStale,
+			ctx:           models.ContextWithStaleMarkers(context.Background()),
+			expectedCount: 2,
+			checkMissing: func(t *testing.T, value float64) {
+				assert.Equal(t, uint64(0x7ff0000000000002), math.Float64bits(value))
+			},
+		},
+		{
+			name:          "stale leaves missing metrics out of scrapes",
+			onMissing:     models.MissingMetricStale,
+			ctx:           context.Background(),
+			expectedCount: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPI := &mocks.MockPIService{}
+			config := testutils.NewTestConfigBuilder().WithOnMissing(tc.onMissing).Build()
+			manager, _ := NewMetricManager(mockPI, config)
+
+			mockPI.On("GetResourceMetrics", mock.Anything, "db-TESTPOSTGRES", metricNames).Return(response, nil)
+
+			metricData, err := manager.getMetricData(tc.ctx, "db-TESTPOSTGRES", metricNames)
+			require.NoError(t, err)
+			require.Len(t, metricData, tc.expectedCount)
+
+			assert.Equal(t, "os.general.numVCPUs.avg", metricData[0].Metric)
+			assert.Equal(t, 4.0, metricData[0].Value)
+			if tc.checkMissing != nil {
+				assert.Equal(t, "os.memory.free.avg", metricData[1].Metric)
+				assert.False(t, metricData[1].Timestamp.IsZero())
+				tc.checkMissing(t, metricData[1].Value)
+			}
+
+			mockPI.AssertExpectations(t)
+		})
+	}
+}
+
 func TestFilterLatestValidMetricData(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -481,6 +1304,127 @@ func TestFilterLatestValidMetricData(t *testing.T) {
 	}
 }
 
+func TestCollectDataPointsReturned(t *testing.T) {
+	response := &awspi.GetResourceMetricsOutput{
+		MetricList: []pitypes.MetricKeyDataPoints{
+			{
+				Key: &pitypes.ResponseResourceMetricKey{Metric: aws.String("os.general.numVCPUs.avg")},
+				DataPoints: []pitypes.DataPoint{
+					{Timestamp: aws.Time(testutils.TestTimestamp.Add(-time.Minute)), Value: aws.Float64(2.0)},
+					{Timestamp: aws.Time(testutils.TestTimestamp), Value: aws.Float64(4.0)},
+					{Timestamp: aws.Time(testutils.TestTimestamp)},
+				},
+			},
+			{
+				Key: &pitypes.ResponseResourceMetricKey{Metric: aws.String("os.cpuUtilization.idle.avg")},
+				DataPoints: []pitypes.DataPoint{
+					{Timestamp: aws.Time(testutils.TestTimestamp), Value: aws.Float64(74.5)},
+				},
+			},
+		},
+	}
+
+	collect := func(manager *MetricManager) map[string]float64 {
+		ch := make(chan prometheus.Metric, 10)
+		manager.CollectDataPointsReturned("us-west-2", ch)
+		close(ch)
+
+		counts := make(map[string]float64)
+		for metric := range ch {
+			assert.Contains(t, metric.Desc().String(), `"dbi_datapoints_returned"`)
+			var written dto.Metric
+			require.NoError(t, metric.Write(&written))
+			labels := make(map[string]string)
+			for _, label := range written.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			assert.Equal(t, "us-west-2", labels["region"])
+			counts[labels["metric"]] = written.GetCounter().GetValue()
+		}
+		return counts
+	}
+
+	t.Run("counts every data point returned before keeping the latest", func(t *testing.T) {
+		config := testutils.NewTestConfigBuilder().WithDataPointsReturned(true).Build()
+		manager, _ := NewMetricManager(&mocks.MockPIService{}, config)
+
+		assert.Len(t, manager.filterLatestValidMetricData(response), 2)
+		assert.Len(t, manager.filterLatestValidMetricData(response), 2)
+
+		assert.Equal(t, map[string]float64{"os.general.numVCPUs.avg": 6, "os.cpuUtilization.idle.avg": 2}, collect(manager))
+	})
+
+	t.Run("counts nothing when disabled", func(t *testing.T) {
+		manager, _ := NewMetricManager(&mocks.MockPIService{}, testutils.CreateDefaultParsedTestConfig())
+
+		assert.Len(t, manager.filterLatestValidMetricData(response), 2)
+
+		assert.Empty(t, collect(manager))
+	})
+}
+
+func TestFilterLatestValidMetricDataWithFutureTimestamp(t *testing.T) {
+	pastTimestamp := time.Now().Add(-time.Minute).Truncate(time.Second)
+	futureTimestamp := time.Now().Add(time.Hour)
+	response := &awspi.GetResourceMetricsOutput{
+		MetricList: []pitypes.MetricKeyDataPoints{
+			{
+				Key: &pitypes.ResponseResourceMetricKey{Metric: aws.String("os.general.numVCPUs.avg")},
+				DataPoints: []pitypes.DataPoint{
+					{Timestamp: aws.Time(pastTimestamp), Value: aws.Float64(2.0)},
+					{Timestamp: aws.Time(futureTimestamp), Value: aws.Float64(4.0)},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		behavior      models.FutureTimestampBehavior
+		expectedValue float64
+		validate      func(t *testing.T, timestamp time.Time)
+	}{
+		{
+			name:          "keep serves the future data point unchanged",
+			behavior:      models.FutureTimestampKeep,
+			expectedValue: 4.0,
+			validate: func(t *testing.T, timestamp time.Time) {
+				assert.True(t, timestamp.Equal(futureTimestamp))
+			},
+		},
+		{
+			name:          "clamp serves the future data point at the current time",
+			behavior:      models.FutureTimestampClamp,
+			expectedValue: 4.0,
+			validate: func(t *testing.T, timestamp time.Time) {
+				assert.False(t, timestamp.After(time.Now()))
+				assert.True(t, timestamp.After(pastTimestamp))
+			},
+		},
+		{
+			name:          "drop falls back to the latest data point not in the future",
+			behavior:      models.FutureTimestampDrop,
+			expectedValue: 2.0,
+			validate: func(t *testing.T, timestamp time.Time) {
+				assert.True(t, timestamp.Equal(pastTimestamp))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testutils.NewTestConfigBuilder().WithFutureTimestamp(tc.behavior).Build()
+			manager, _ := NewMetricManager(&mocks.MockPIService{}, config)
+
+			filtered := manager.filterLatestValidMetricData(response)
+
+			require.Len(t, filtered, 1)
+			assert.Equal(t, tc.expectedValue, filtered[0].Value)
+			tc.validate(t, filtered[0].Timestamp)
+		})
+	}
+}
+
 func TestGetLatestValidDataPoint(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -10,4 +10,12 @@ import (
 type MetricProvider interface {
 	GetMetricBatches(ctx context.Context, instance models.Instance) ([][]string, error)
 	CollectMetricsForBatch(ctx context.Context, instance models.Instance, metricsBatch []string, ch chan<- prometheus.Metric) error
+	CollectDiscoveredMetricNames(region string, ch chan<- prometheus.Metric)
+	CollectDataPointsReturned(region string, ch chan<- prometheus.Metric)
+	CollectConversionErrors(region string, ch chan<- prometheus.Metric)
+	CollectPostProcessedMetrics(ctx context.Context, ch chan<- prometheus.Metric)
+	ExplainExcludedMetrics(ctx context.Context, instance models.Instance) ([]models.ExcludedMetric, error)
+	GetMetricCatalog(ctx context.Context, instance models.Instance) (map[string]models.CatalogMetric, error)
+	// Invalidate drops the cached metric metadata, so the metrics of every instance are listed again on next use.
+	Invalidate()
 }
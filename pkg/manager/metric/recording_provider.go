@@ -0,0 +1,374 @@
+package metric
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/processing/formatting"
+)
+
+// Value types of a RecordedMetric. Traces recorded without a type hold gauges.
+const (
+	RecordedGauge   = "gauge"
+	RecordedCounter = "counter"
+)
+
+// MetricTrace is a structured record of every MetricProvider interaction captured by a RecordingMetricProvider.
+// It can be written to disk and served back by a ReplayMetricProvider to reproduce a collection deterministically.
+type MetricTrace struct {
+	Batches     []BatchesRecord    `json:"batches"`
+	Collections []CollectionRecord `json:"collections"`
+}
+
+// BatchesRecord captures a single GetMetricBatches call and its result.
+type BatchesRecord struct {
+	ResourceID string     `json:"resourceId"`
+	Identifier string     `json:"identifier"`
+	Batches    [][]string `json:"batches,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// CollectionRecord captures a single CollectMetricsForBatch call and the metrics it emitted.
+type CollectionRecord struct {
+	ResourceID   string           `json:"resourceId"`
+	Identifier   string           `json:"identifier"`
+	MetricsBatch []string         `json:"metricsBatch"`
+	Metrics      []RecordedMetric `json:"metrics,omitempty"`
+	Error        string           `json:"error,omitempty"`
+}
+
+// RecordedMetric is the serializable form of an emitted Prometheus gauge or counter.
+type RecordedMetric struct {
+	Name      string            `json:"name"`
+	Help      string            `json:"help"`
+	Type      string            `json:"type,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Value     float64           `json:"value"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+type RecordingMetricProvider struct {
+	provider MetricProvider
+	mu       sync.Mutex
+	trace    MetricTrace
+}
+
+// RecordingMetricProvider decorates a MetricProvider and records every call and its result into a MetricTrace.
+// It is intended for capturing production issues so they can be replayed offline with a ReplayMetricProvider.
+func NewRecordingMetricProvider(provider MetricProvider) *RecordingMetricProvider {
+	return &RecordingMetricProvider{
+		provider: provider,
+	}
+}
+
+// GetMetricBatches delegates to the wrapped provider and records the returned batches or error.
+func (recorder *RecordingMetricProvider) GetMetricBatches(ctx context.Context, instance models.Instance) ([][]string, error) {
+	batches, err := recorder.provider.GetMetricBatches(ctx, instance)
+
+	record := BatchesRecord{
+		ResourceID: instance.ResourceID,
+		Identifier: instance.Identifier,
+		Batches:    batches,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	slog.Info("Recorded metric batches", "operation", "GetMetricBatches", "identifier", instance.Identifier, "batches", len(batches), "error", err)
+
+	recorder.mu.Lock()
+	recorder.trace.Batches = append(recorder.trace.Batches, record)
+	recorder.mu.Unlock()
+
+	return batches, err
+}
+
+// CollectMetricsForBatch delegates to the wrapped provider, forwarding every emitted metric to ch while recording it.
+func (recorder *RecordingMetricProvider) CollectMetricsForBatch(ctx context.Context, instance models.Instance, metricsBatch []string, ch chan<- prometheus.Metric) error {
+	record := CollectionRecord{
+		ResourceID:   instance.ResourceID,
+		Identifier:   instance.Identifier,
+		MetricsBatch: metricsBatch,
+	}
+
+	recorded := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for metric := range recorded {
+			recordedMetric, err := recordMetric(metric)
+			if err != nil {
+				slog.Error("Error recording metric", "identifier", instance.Identifier, "error", err)
+			} else {
+				record.Metrics = append(record.Metrics, recordedMetric)
+			}
+			ch <- metric
+		}
+	}()
+
+	err := recorder.provider.CollectMetricsForBatch(ctx, instance, metricsBatch, recorded)
+	close(recorded)
+	<-done
+
+	if err != nil {
+		record.Error = err.Error()
+	}
+	slog.Info("Recorded metrics", "operation", "CollectMetricsForBatch", "identifier", instance.Identifier, "metrics", len(record.Metrics), "error", err)
+
+	recorder.mu.Lock()
+	recorder.trace.Collections = append(recorder.trace.Collections, record)
+	recorder.mu.Unlock()
+
+	return err
+}
+
+// CollectDiscoveredMetricNames delegates to the wrapped provider. Discovered metric name counts are not recorded.
+func (recorder *RecordingMetricProvider) CollectDiscoveredMetricNames(region string, ch chan<- prometheus.Metric) {
+	recorder.provider.CollectDiscoveredMetricNames(region, ch)
+}
+
+// CollectDataPointsReturned delegates to the wrapped provider. Data point counts are not recorded.
+func (recorder *RecordingMetricProvider) CollectDataPointsReturned(region string, ch chan<- prometheus.Metric) {
+	recorder.provider.CollectDataPointsReturned(region, ch)
+}
+
+// CollectConversionErrors delegates to the wrapped provider. Conversion error counts are not recorded.
+func (recorder *RecordingMetricProvider) CollectConversionErrors(region string, ch chan<- prometheus.Metric) {
+	recorder.provider.CollectConversionErrors(region, ch)
+}
+
+// CollectPostProcessedMetrics delegates to the wrapped provider. Derived metrics are not recorded.
+func (recorder *RecordingMetricProvider) CollectPostProcessedMetrics(ctx context.Context, ch chan<- prometheus.Metric) {
+	recorder.provider.CollectPostProcessedMetrics(ctx, ch)
+}
+
+// ExplainExcludedMetrics delegates to the wrapped provider.
+func (recorder *RecordingMetricProvider) ExplainExcludedMetrics(ctx context.Context, instance models.Instance) ([]models.ExcludedMetric, error) {
+	return recorder.provider.ExplainExcludedMetrics(ctx, instance)
+}
+
+// GetMetricCatalog delegates to the wrapped provider.
+func (recorder *RecordingMetricProvider) GetMetricCatalog(ctx context.Context, instance models.Instance) (map[string]models.CatalogMetric, error) {
+	return recorder.provider.GetMetricCatalog(ctx, instance)
+}
+
+// Invalidate delegates to the wrapped provider.
+func (recorder *RecordingMetricProvider) Invalidate() {
+	recorder.provider.Invalidate()
+}
+
+// Trace returns a copy of everything recorded so far.
+func (recorder *RecordingMetricProvider) Trace() MetricTrace {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	return MetricTrace{
+		Batches:     append([]BatchesRecord(nil), recorder.trace.Batches...),
+		Collections: append([]CollectionRecord(nil), recorder.trace.Collections...),
+	}
+}
+
+// WriteTrace writes the recorded trace as JSON to the provided writer.
+func (recorder *RecordingMetricProvider) WriteTrace(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(recorder.Trace())
+}
+
+// LoadMetricTrace reads a JSON trace previously written by RecordingMetricProvider.WriteTrace.
+func LoadMetricTrace(r io.Reader) (MetricTrace, error) {
+	var trace MetricTrace
+	if err := json.NewDecoder(r).Decode(&trace); err != nil {
+		return MetricTrace{}, fmt.Errorf("failed to decode metric trace: %w", err)
+	}
+	return trace, nil
+}
+
+// recordMetric captures a metric emitted by formatting, which carries the name and help of its description.
+func recordMetric(metric prometheus.Metric) (RecordedMetric, error) {
+	described, ok := metric.(formatting.DescribedMetric)
+	if !ok {
+		return RecordedMetric{}, fmt.Errorf("metric %s does not carry its name and help", metric.Desc())
+	}
+
+	var written dto.Metric
+	if err := metric.Write(&written); err != nil {
+		return RecordedMetric{}, err
+	}
+
+	recordedMetric := RecordedMetric{
+		Name:   described.Name,
+		Help:   described.Help,
+		Labels: make(map[string]string, len(written.GetLabel())),
+	}
+	switch {
+	case written.Gauge != nil:
+		recordedMetric.Type = RecordedGauge
+		recordedMetric.Value = written.GetGauge().GetValue()
+	case written.Counter != nil:
+		recordedMetric.Type = RecordedCounter
+		recordedMetric.Value = written.GetCounter().GetValue()
+	default:
+		return RecordedMetric{}, fmt.Errorf("metric %s is neither a gauge nor a counter", described.Name)
+	}
+	for _, label := range written.GetLabel() {
+		recordedMetric.Labels[label.GetName()] = label.GetValue()
+	}
+	if written.TimestampMs != nil {
+		recordedMetric.Timestamp = time.UnixMilli(written.GetTimestampMs()).UTC()
+	}
+
+	return recordedMetric, nil
+}
+
+type ReplayMetricProvider struct {
+	mu          sync.Mutex
+	batches     map[string][]BatchesRecord
+	collections map[string][]CollectionRecord
+}
+
+// ReplayMetricProvider implements MetricProvider by serving results from a recorded MetricTrace instead of calling AWS.
+// Calls are matched by instance resource ID (and metric batch) and replayed in recorded order; once the recorded
+// calls for a key are exhausted, the last one is served again.
+func NewReplayMetricProvider(trace MetricTrace) *ReplayMetricProvider {
+	replay := &ReplayMetricProvider{
+		batches:     make(map[string][]BatchesRecord),
+		collections: make(map[string][]CollectionRecord),
+	}
+
+	for _, record := range trace.Batches {
+		replay.batches[record.ResourceID] = append(replay.batches[record.ResourceID], record)
+	}
+
+	for _, record := range trace.Collections {
+		key := collectionKey(record.ResourceID, record.MetricsBatch)
+		replay.collections[key] = append(replay.collections[key], record)
+	}
+
+	return replay
+}
+
+// GetMetricBatches returns the recorded batches or error for the instance.
+func (replay *ReplayMetricProvider) GetMetricBatches(ctx context.Context, instance models.Instance) ([][]string, error) {
+	replay.mu.Lock()
+	records, exists := replay.batches[instance.ResourceID]
+	if !exists || len(records) == 0 {
+		replay.mu.Unlock()
+		return nil, fmt.Errorf("no recorded metric batches for instance %s", instance.ResourceID)
+	}
+	record := records[0]
+	if len(records) > 1 {
+		replay.batches[instance.ResourceID] = records[1:]
+	}
+	replay.mu.Unlock()
+
+	if record.Error != "" {
+		return nil, errors.New(record.Error)
+	}
+	return record.Batches, nil
+}
+
+// CollectMetricsForBatch emits the recorded metrics for the instance and batch, then returns the recorded error if any.
+func (replay *ReplayMetricProvider) CollectMetricsForBatch(ctx context.Context, instance models.Instance, metricsBatch []string, ch chan<- prometheus.Metric) error {
+	key := collectionKey(instance.ResourceID, metricsBatch)
+
+	replay.mu.Lock()
+	records, exists := replay.collections[key]
+	if !exists || len(records) == 0 {
+		replay.mu.Unlock()
+		return fmt.Errorf("no recorded metric collection for instance %s and metrics: %v", instance.ResourceID, metricsBatch)
+	}
+	record := records[0]
+	if len(records) > 1 {
+		replay.collections[key] = records[1:]
+	}
+	replay.mu.Unlock()
+
+	for _, recordedMetric := range record.Metrics {
+		metric, err := replayMetric(recordedMetric)
+		if err != nil {
+			slog.Error("Error replaying metric", "metric", recordedMetric.Name, "error", err)
+			continue
+		}
+		ch <- metric
+	}
+
+	if record.Error != "" {
+		return errors.New(record.Error)
+	}
+	return nil
+}
+
+// CollectDiscoveredMetricNames emits nothing, as discovered metric name counts are not part of a recorded trace.
+func (replay *ReplayMetricProvider) CollectDiscoveredMetricNames(region string, ch chan<- prometheus.Metric) {
+}
+
+// CollectDataPointsReturned emits nothing, as data point counts are not part of a recorded trace.
+func (replay *ReplayMetricProvider) CollectDataPointsReturned(region string, ch chan<- prometheus.Metric) {
+}
+
+// CollectConversionErrors emits nothing, as recorded metrics are replayed without being converted again.
+func (replay *ReplayMetricProvider) CollectConversionErrors(region string, ch chan<- prometheus.Metric) {
+}
+
+// CollectPostProcessedMetrics emits nothing, as derived metrics are not part of a recorded trace.
+func (replay *ReplayMetricProvider) CollectPostProcessedMetrics(ctx context.Context, ch chan<- prometheus.Metric) {
+}
+
+// ExplainExcludedMetrics always fails, as the available metrics of an instance are not part of a recorded trace.
+func (replay *ReplayMetricProvider) ExplainExcludedMetrics(ctx context.Context, instance models.Instance) ([]models.ExcludedMetric, error) {
+	return nil, errors.New("excluded metrics are not available when replaying a trace")
+}
+
+// GetMetricCatalog always fails, as the available metrics of an instance are not part of a recorded trace.
+func (replay *ReplayMetricProvider) GetMetricCatalog(ctx context.Context, instance models.Instance) (map[string]models.CatalogMetric, error) {
+	return nil, errors.New("the metric catalog is not available when replaying a trace")
+}
+
+// Invalidate does nothing, as a recorded trace holds no metadata to list again.
+func (replay *ReplayMetricProvider) Invalidate() {
+}
+
+func replayMetric(recordedMetric RecordedMetric) (prometheus.Metric, error) {
+	labelNames := make([]string, 0, len(recordedMetric.Labels))
+	for labelName := range recordedMetric.Labels {
+		labelNames = append(labelNames, labelName)
+	}
+	sort.Strings(labelNames)
+
+	labelValues := make([]string, 0, len(labelNames))
+	for _, labelName := range labelNames {
+		labelValues = append(labelValues, recordedMetric.Labels[labelName])
+	}
+
+	valueType := prometheus.GaugeValue
+	if recordedMetric.Type == RecordedCounter {
+		valueType = prometheus.CounterValue
+	}
+
+	desc := prometheus.NewDesc(recordedMetric.Name, recordedMetric.Help, labelNames, nil)
+	metric, err := prometheus.NewConstMetric(desc, valueType, recordedMetric.Value, labelValues...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !recordedMetric.Timestamp.IsZero() {
+		metric = prometheus.NewMetricWithTimestamp(recordedMetric.Timestamp, metric)
+	}
+	return formatting.DescribedMetric{Metric: metric, Name: recordedMetric.Name, Help: recordedMetric.Help}, nil
+}
+
+func collectionKey(resourceID string, metricsBatch []string) string {
+	return resourceID + "|" + strings.Join(metricsBatch, ",")
+}
@@ -0,0 +1,209 @@
+package metric
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/processing/formatting"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
+)
+
+func TestRecordingMetricProviderGetMetricBatches(t *testing.T) {
+	testCases := []struct {
+		name            string
+		mockBatches     [][]string
+		mockError       error
+		expectedError   string
+		expectedBatches [][]string
+	}{
+		{
+			name:            "records batches on success",
+			mockBatches:     [][]string{testutils.TestMetricNamesWithStatsSmall},
+			expectedBatches: [][]string{testutils.TestMetricNamesWithStatsSmall},
+		},
+		{
+			name:          "records error on failure",
+			mockError:     errors.New("list metrics failed"),
+			expectedError: "list metrics failed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockProvider := &mocks.MockMetricProvider{}
+			if tc.mockBatches != nil {
+				mockProvider.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).Return(tc.mockBatches, tc.mockError)
+			} else {
+				mockProvider.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).Return(nil, tc.mockError)
+			}
+
+			recorder := NewRecordingMetricProvider(mockProvider)
+			batches, err := recorder.GetMetricBatches(context.Background(), testutils.TestInstancePostgreSQL)
+
+			assert.Equal(t, tc.mockError, err)
+			assert.Equal(t, tc.expectedBatches, batches)
+
+			trace := recorder.Trace()
+			require.Len(t, trace.Batches, 1)
+			assert.Equal(t, testutils.TestInstancePostgreSQL.ResourceID, trace.Batches[0].ResourceID)
+			assert.Equal(t, testutils.TestInstancePostgreSQL.Identifier, trace.Batches[0].Identifier)
+			assert.Equal(t, tc.expectedBatches, trace.Batches[0].Batches)
+			assert.Equal(t, tc.expectedError, trace.Batches[0].Error)
+			mockProvider.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRecordingMetricProviderCollectMetricsForBatch(t *testing.T) {
+	batch := []string{"os.general.numVCPUs.avg"}
+	mockProvider := &mocks.MockMetricProvider{}
+	mockProvider.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstancePostgreSQL, batch, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ch := args.Get(3).(chan<- prometheus.Metric)
+			ch <- newTestGauge(t, 4.0)
+		}).
+		Return(nil)
+
+	recorder := NewRecordingMetricProvider(mockProvider)
+	ch := make(chan prometheus.Metric, 10)
+	err := recorder.CollectMetricsForBatch(context.Background(), testutils.TestInstancePostgreSQL, batch, ch)
+	close(ch)
+
+	assert.NoError(t, err)
+	assert.Len(t, ch, 1)
+
+	trace := recorder.Trace()
+	require.Len(t, trace.Collections, 1)
+	collection := trace.Collections[0]
+	assert.Equal(t, batch, collection.MetricsBatch)
+	require.Len(t, collection.Metrics, 1)
+	assert.Equal(t, "dbi_os_general_numvcpus_avg", collection.Metrics[0].Name)
+	assert.Equal(t, "The number of virtual CPUs for the DB instance", collection.Metrics[0].Help)
+	assert.Equal(t, RecordedGauge, collection.Metrics[0].Type)
+	assert.Equal(t, 4.0, collection.Metrics[0].Value)
+	assert.Equal(t, map[string]string{"identifier": "test-postgres-db", "engine": "aurora-postgresql", "unit": "vCPUs"}, collection.Metrics[0].Labels)
+	assert.True(t, collection.Metrics[0].Timestamp.Equal(testutils.TestTimestamp))
+	mockProvider.AssertExpectations(t)
+}
+
+func TestRecordMetric(t *testing.T) {
+	t.Run("records counter value", func(t *testing.T) {
+		recorded, err := recordMetric(newTestMetric(t, prometheus.CounterValue, "dbi_metric_conversion_errors_total", "Metric data that could not be converted", 3.0))
+
+		require.NoError(t, err)
+		assert.Equal(t, "dbi_metric_conversion_errors_total", recorded.Name)
+		assert.Equal(t, RecordedCounter, recorded.Type)
+		assert.Equal(t, 3.0, recorded.Value)
+
+		replayed, err := replayMetric(recorded)
+		require.NoError(t, err)
+		var written dto.Metric
+		require.NoError(t, replayed.Write(&written))
+		assert.Equal(t, 3.0, written.GetCounter().GetValue())
+	})
+
+	t.Run("metric without name and help is not recorded", func(t *testing.T) {
+		desc := prometheus.NewDesc("dbi_os_general_numvcpus_avg", "The number of virtual CPUs for the DB instance", nil, nil)
+		metric := prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 4.0)
+
+		_, err := recordMetric(metric)
+		assert.Error(t, err)
+	})
+}
+
+func TestRecordingMetricProviderCollectDiscoveredMetricNames(t *testing.T) {
+	mockProvider := &mocks.MockMetricProvider{}
+	mockProvider.On("CollectDiscoveredMetricNames", "us-west-2", mock.Anything).Return()
+
+	recorder := NewRecordingMetricProvider(mockProvider)
+	recorder.CollectDiscoveredMetricNames("us-west-2", make(chan prometheus.Metric, 1))
+
+	mockProvider.AssertExpectations(t)
+}
+
+func TestRecordingMetricProviderInvalidate(t *testing.T) {
+	mockProvider := &mocks.MockMetricProvider{}
+	mockProvider.On("Invalidate").Return()
+
+	NewRecordingMetricProvider(mockProvider).Invalidate()
+
+	mockProvider.AssertExpectations(t)
+}
+
+func TestReplayMetricProvider(t *testing.T) {
+	batch := []string{"os.general.numVCPUs.avg"}
+	mockProvider := &mocks.MockMetricProvider{}
+	mockProvider.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).Return([][]string{batch}, nil)
+	mockProvider.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstancePostgreSQL, batch, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ch := args.Get(3).(chan<- prometheus.Metric)
+			ch <- newTestGauge(t, 4.0)
+		}).
+		Return(errors.New("partial failure"))
+
+	recorder := NewRecordingMetricProvider(mockProvider)
+	_, _ = recorder.GetMetricBatches(context.Background(), testutils.TestInstancePostgreSQL)
+	_ = recorder.CollectMetricsForBatch(context.Background(), testutils.TestInstancePostgreSQL, batch, make(chan prometheus.Metric, 10))
+
+	var buffer bytes.Buffer
+	require.NoError(t, recorder.WriteTrace(&buffer))
+	trace, err := LoadMetricTrace(&buffer)
+	require.NoError(t, err)
+
+	replay := NewReplayMetricProvider(trace)
+
+	t.Run("replays recorded batches", func(t *testing.T) {
+		batches, err := replay.GetMetricBatches(context.Background(), testutils.TestInstancePostgreSQL)
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{batch}, batches)
+	})
+
+	t.Run("replays recorded metrics and error", func(t *testing.T) {
+		ch := make(chan prometheus.Metric, 10)
+		err := replay.CollectMetricsForBatch(context.Background(), testutils.TestInstancePostgreSQL, batch, ch)
+		close(ch)
+
+		assert.EqualError(t, err, "partial failure")
+		require.Len(t, ch, 1)
+		replayed, err := recordMetric(<-ch)
+		require.NoError(t, err)
+		assert.Equal(t, trace.Collections[0].Metrics[0], replayed)
+	})
+
+	t.Run("unknown instance returns error", func(t *testing.T) {
+		_, err := replay.GetMetricBatches(context.Background(), testutils.TestInstanceMySQL)
+		assert.Error(t, err)
+
+		err = replay.CollectMetricsForBatch(context.Background(), testutils.TestInstanceMySQL, batch, make(chan prometheus.Metric, 1))
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadMetricTraceInvalid(t *testing.T) {
+	_, err := LoadMetricTrace(bytes.NewBufferString("not json"))
+	assert.Error(t, err)
+}
+
+func newTestGauge(t *testing.T, value float64) prometheus.Metric {
+	return newTestMetric(t, prometheus.GaugeValue, "dbi_os_general_numvcpus_avg", "The number of virtual CPUs for the DB instance", value)
+}
+
+func newTestMetric(t *testing.T, valueType prometheus.ValueType, name string, help string, value float64) prometheus.Metric {
+	desc := prometheus.NewDesc(name, help, []string{"identifier", "engine", "unit"}, nil)
+	metric, err := prometheus.NewConstMetric(desc, valueType, value, "test-postgres-db", "aurora-postgresql", "vCPUs")
+	require.NoError(t, err)
+	return formatting.DescribedMetric{
+		Metric: prometheus.NewMetricWithTimestamp(testutils.TestTimestamp, metric),
+		Name:   name,
+		Help:   help,
+	}
+}
@@ -0,0 +1,102 @@
+package region
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/utils"
+)
+
+const (
+	// ThrottlesBeforeBackoff is the number of throttled Performance Insights requests after which the limit is halved
+	ThrottlesBeforeBackoff = 3
+	// SuccessesBeforeRampUp is the number of successful requests without throttling after which the limit grows by one
+	SuccessesBeforeRampUp = 20
+)
+
+// AdaptiveLimiter bounds the number of concurrent Performance Insights requests in a region to a limit that adapts to
+// throttling. Every ThrottlesBeforeBackoff throttled requests halve the limit, down to one, and every SuccessesBeforeRampUp
+// successful requests in a row raise it by one, up to the configured collection concurrency. The limit is kept across
+// scrapes, so a region that was throttled in the previous scrape starts the next one at the reduced limit.
+type AdaptiveLimiter struct {
+	mu        sync.Mutex
+	region    string
+	maxLimit  int
+	limit     int
+	inUse     int
+	throttles int
+	successes int
+	released  chan struct{} // closed and replaced whenever a slot is released or the limit grows
+}
+
+func NewAdaptiveLimiter(region string, maxLimit int) *AdaptiveLimiter {
+	maxLimit = max(maxLimit, 1)
+	return &AdaptiveLimiter{
+		region:   region,
+		maxLimit: maxLimit,
+		limit:    maxLimit,
+		released: make(chan struct{}),
+	}
+}
+
+// Acquire blocks until the number of requests in flight is below the current limit or the context is cancelled.
+// Every successful Acquire must be paired with a Release.
+func (limiter *AdaptiveLimiter) Acquire(ctx context.Context) error {
+	for {
+		limiter.mu.Lock()
+		if limiter.inUse < limiter.limit {
+			limiter.inUse++
+			limiter.mu.Unlock()
+			return nil
+		}
+		released := limiter.released
+		limiter.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Release frees the slot of a finished request and adapts the limit to whether the request failed with the error of
+// a throttled AWS call. Requests that failed for any other reason leave the limit unchanged.
+func (limiter *AdaptiveLimiter) Release(err error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.inUse--
+	switch {
+	case utils.IsThrottlingAWSError(err):
+		limiter.successes = 0
+		limiter.throttles++
+		if limiter.throttles >= ThrottlesBeforeBackoff {
+			limiter.throttles = 0
+			if reduced := max(limiter.limit/2, 1); reduced < limiter.limit {
+				slog.Warn("Performance Insights is throttling requests, reducing collection concurrency",
+					"region", limiter.region, "from", limiter.limit, "to", reduced)
+				limiter.limit = reduced
+			}
+		}
+	case err == nil:
+		limiter.successes++
+		if limiter.successes >= SuccessesBeforeRampUp && limiter.limit < limiter.maxLimit {
+			limiter.successes = 0
+			limiter.throttles = 0
+			limiter.limit++
+			slog.Info("Increasing collection concurrency", "region", limiter.region, "to", limiter.limit)
+		}
+	}
+
+	close(limiter.released)
+	limiter.released = make(chan struct{})
+}
+
+// Limit returns the current number of requests allowed in flight.
+func (limiter *AdaptiveLimiter) Limit() int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	return limiter.limit
+}
@@ -0,0 +1,117 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errThrottled = &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+
+func TestNewAdaptiveLimiter(t *testing.T) {
+	limiter := NewAdaptiveLimiter("us-west-2", 4)
+
+	assert.Equal(t, 4, limiter.Limit())
+	assert.Equal(t, 0, limiter.inUse)
+	assert.Equal(t, 1, NewAdaptiveLimiter("us-west-2", 0).Limit(), "the limit never drops below one")
+}
+
+func TestAdaptiveLimiterBacksOffOnThrottling(t *testing.T) {
+	limiter := NewAdaptiveLimiter("us-west-2", 8)
+
+	for i := 0; i < ThrottlesBeforeBackoff-1; i++ {
+		require.NoError(t, limiter.Acquire(context.Background()))
+		limiter.Release(errThrottled)
+	}
+	assert.Equal(t, 8, limiter.Limit(), "a few throttled requests do not reduce the limit")
+
+	require.NoError(t, limiter.Acquire(context.Background()))
+	limiter.Release(errThrottled)
+	assert.Equal(t, 4, limiter.Limit())
+
+	for i := 0; i < 3*ThrottlesBeforeBackoff; i++ {
+		require.NoError(t, limiter.Acquire(context.Background()))
+		limiter.Release(errThrottled)
+	}
+	assert.Equal(t, 1, limiter.Limit())
+	assert.Equal(t, 0, limiter.inUse)
+}
+
+func TestAdaptiveLimiterIgnoresOtherErrors(t *testing.T) {
+	limiter := NewAdaptiveLimiter("us-west-2", 4)
+
+	for i := 0; i < 2*ThrottlesBeforeBackoff; i++ {
+		require.NoError(t, limiter.Acquire(context.Background()))
+		limiter.Release(errors.New("access denied"))
+	}
+
+	assert.Equal(t, 4, limiter.Limit())
+}
+
+func TestAdaptiveLimiterRampsUp(t *testing.T) {
+	limiter := NewAdaptiveLimiter("us-west-2", 4)
+	for i := 0; i < 2*ThrottlesBeforeBackoff; i++ {
+		require.NoError(t, limiter.Acquire(context.Background()))
+		limiter.Release(errThrottled)
+	}
+	require.Equal(t, 1, limiter.Limit())
+
+	for i := 0; i < SuccessesBeforeRampUp-1; i++ {
+		require.NoError(t, limiter.Acquire(context.Background()))
+		limiter.Release(nil)
+	}
+	assert.Equal(t, 1, limiter.Limit(), "the limit grows slowly")
+
+	require.NoError(t, limiter.Acquire(context.Background()))
+	limiter.Release(nil)
+	assert.Equal(t, 2, limiter.Limit())
+
+	for i := 0; i < 10*SuccessesBeforeRampUp; i++ {
+		require.NoError(t, limiter.Acquire(context.Background()))
+		limiter.Release(nil)
+	}
+	assert.Equal(t, 4, limiter.Limit(), "the limit never exceeds the collection concurrency")
+}
+
+func TestAdaptiveLimiterAcquireBlocksAtLimit(t *testing.T) {
+	limiter := NewAdaptiveLimiter("us-west-2", 1)
+	require.NoError(t, limiter.Acquire(context.Background()))
+
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- limiter.Acquire(context.Background())
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Acquire should block while the limit is reached")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	limiter.Release(nil)
+	select {
+	case err := <-acquired:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Acquire should return once a slot is released")
+	}
+	limiter.Release(nil)
+	assert.Equal(t, 0, limiter.inUse)
+}
+
+func TestAdaptiveLimiterAcquireContextCancelled(t *testing.T) {
+	limiter := NewAdaptiveLimiter("us-west-2", 1)
+	require.NoError(t, limiter.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, limiter.Acquire(ctx), context.DeadlineExceeded)
+	limiter.Release(nil)
+	assert.Equal(t, 0, limiter.inUse)
+}
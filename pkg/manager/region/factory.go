@@ -2,7 +2,9 @@ package region
 
 import (
 	"fmt"
+	"log/slog"
 
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients/pi"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/clients/rds"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/instance"
@@ -14,18 +16,27 @@ import (
 // It impelments the factory design pattern to encapsulate the initialization logic required to set up AWS service clients,
 // instance discovery, and metric collection components.
 type RegionManagerFactory struct {
+	apiMetrics *clients.APIMetrics
 }
 
-func NewRegionManagerFactory() *RegionManagerFactory {
-	return &RegionManagerFactory{}
+// NewRegionManagerFactory returns a factory whose AWS clients record their API calls in apiMetrics, if set.
+func NewRegionManagerFactory(apiMetrics *clients.APIMetrics) *RegionManagerFactory {
+	return &RegionManagerFactory{apiMetrics: apiMetrics}
 }
 
 // CreateRegionManager creates a multi-region manager to coordinate across configured regions.
 func (factory *RegionManagerFactory) CreateRegionManager(config *models.ParsedConfig) (RegionManager, error) {
-	multiRegionManager := NewMultiRegionManager()
+	multiRegionManager := NewMultiRegionManager(config.Discovery.Processing.RegionConcurrency)
+
+	// Every region shares the metadata cache, as they store their instances in the same file
+	var metadataCache *metric.MetadataCache
+	if config.Discovery.Metrics.MetadataCacheFile != "" {
+		metadataCache = metric.NewMetadataCache(config.Discovery.Metrics.MetadataCacheFile, config.Discovery.Metrics.MetadataTTL)
+	}
+
 	regions := config.Discovery.Regions
 	for _, region := range regions {
-		singleRegionManager, err := factory.createSingleRegionManager(region, config)
+		singleRegionManager, err := factory.createSingleRegionManager(region, config, metadataCache)
 		if err != nil {
 			return nil, err
 		}
@@ -35,16 +46,19 @@ func (factory *RegionManagerFactory) CreateRegionManager(config *models.ParsedCo
 	return multiRegionManager, nil
 }
 
-func (factory *RegionManagerFactory) createSingleRegionManager(region string, config *models.ParsedConfig) (RegionManager, error) {
-	rdsClient, err := rds.NewRDSClient(region)
+func (factory *RegionManagerFactory) createSingleRegionManager(region string, config *models.ParsedConfig, metadataCache *metric.MetadataCache) (RegionManager, error) {
+	rdsClient, err := rds.NewRDSClient(region, config.AWS, factory.apiMetrics)
 	if err != nil {
 		return nil, err
 	}
-	piClient, err := pi.NewPIClient(region)
+	piClient, err := pi.NewPIClient(region, config.AWS, factory.apiMetrics)
 	if err != nil {
 		return nil, err
 	}
 
+	// The managers of the region log with the region, so their records can be told apart from other regions
+	logger := slog.Default().With("region", region)
+
 	rdsInstanceManager, err := instance.NewRDSInstanceManager(rdsClient, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create RDS instance manager: %w", err)
@@ -55,5 +69,5 @@ func (factory *RegionManagerFactory) createSingleRegionManager(region string, co
 		return nil, fmt.Errorf("failed to create metric manager: %w", err)
 	}
 
-	return NewSingleRegionManager(region, rdsInstanceManager, metricManager, config.Discovery.Processing.Concurrency), nil
+	return NewSingleRegionManager(region, rdsInstanceManager.WithLogger(logger), metricManager.WithMetadataCache(metadataCache).WithLogger(logger), config), nil
 }
@@ -20,7 +20,7 @@ func TestNewRegionManagerFactory(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			factory := NewRegionManagerFactory()
+			factory := NewRegionManagerFactory(nil)
 
 			assert.NotNil(t, factory)
 		})
@@ -100,7 +100,7 @@ func TestCreateRegionManager(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			factory := NewRegionManagerFactory()
+			factory := NewRegionManagerFactory(nil)
 
 			regionManager, err := factory.CreateRegionManager(tc.config)
 
@@ -154,9 +154,9 @@ func TestCreateSingleRegionManager(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			factory := NewRegionManagerFactory()
+			factory := NewRegionManagerFactory(nil)
 
-			regionManager, err := factory.createSingleRegionManager(tc.region, tc.config)
+			regionManager, err := factory.createSingleRegionManager(tc.region, tc.config, nil)
 
 			if tc.shouldError {
 				assert.Error(t, err)
@@ -2,19 +2,29 @@ package region
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
 
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 type MultiRegionManager struct {
 	RegionManagers map[string]RegionManager
+	maxConcurrency int
 }
 
 // MultiRegionManager orchestrates database metric collection across multiple AWS regions.
 // It implements the RegionManager interface to provide a unified view of database instances and their metrics.
-func NewMultiRegionManager() *MultiRegionManager {
+// At most maxConcurrency regions are collected at the same time.
+func NewMultiRegionManager(maxConcurrency int) *MultiRegionManager {
 	return &MultiRegionManager{
 		RegionManagers: make(map[string]RegionManager),
+		maxConcurrency: max(maxConcurrency, 1),
 	}
 }
 
@@ -23,27 +33,112 @@ func (multiRegionManager *MultiRegionManager) AddRegionManager(region string, re
 }
 
 // CollectMetrics gathers metrics from all database instances across all configured regions.
-// This method invokes CollectMetrics on each region manager.
+// Regions are collected concurrently; see collectRegions for how failed regions are reported.
 func (multiRegionManager *MultiRegionManager) CollectMetrics(ctx context.Context, ch chan<- prometheus.Metric) error {
-	for _, regionManager := range multiRegionManager.RegionManagers {
-		err := regionManager.CollectMetrics(ctx, ch)
+	return multiRegionManager.collectRegions(func(regionManager RegionManager) error {
+		return regionManager.CollectMetrics(ctx, ch)
+	})
+}
+
+// CollectMetricsForInstances gathers metrics from the specified database instances across all configured regions.
+// Regions are collected concurrently; see collectRegions for how failed regions are reported.
+func (multiRegionManager *MultiRegionManager) CollectMetricsForInstances(ctx context.Context, instanceIdentifiers []string, ch chan<- prometheus.Metric) error {
+	return multiRegionManager.collectRegions(func(regionManager RegionManager) error {
+		return regionManager.CollectMetricsForInstances(ctx, instanceIdentifiers, ch)
+	})
+}
+
+// collectRegions runs collect for every region, at most maxConcurrency regions at a time, started in region order.
+// A failed region doesn't stop the others: its error is logged and the metrics of the other regions are still
+// exported. An error joining the errors of every region, in region order, is returned only when all regions failed.
+func (multiRegionManager *MultiRegionManager) collectRegions(collect func(regionManager RegionManager) error) error {
+	regions := multiRegionManager.sortedRegions()
+	regionErrors := make([]error, len(regions))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, multiRegionManager.maxConcurrency)
+	for i, region := range regions {
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			regionErrors[i] = collect(multiRegionManager.RegionManagers[region])
+		}()
+	}
+	wg.Wait()
+
+	var failed []error
+	for i, err := range regionErrors {
 		if err != nil {
-			return err
+			failed = append(failed, fmt.Errorf("region %s: %w", regions[i], err))
+		}
+	}
+	if len(failed) > 0 && len(failed) == len(regions) {
+		return errors.Join(failed...)
+	}
+	for i, err := range regionErrors {
+		if err != nil {
+			slog.Warn("Collection failed in region, exporting the metrics of the other regions", "region", regions[i], "error", err)
 		}
 	}
-
 	return nil
 }
 
-// CollectMetricsForInstancesics gathers metrics from the specified database instances across all configured regions
-// This method invokes CollectMetricsForInstancesics on each region manager.
-func (multiRegionManager *MultiRegionManager) CollectMetricsForInstances(ctx context.Context, instanceIdentifiers []string, ch chan<- prometheus.Metric) error {
-	for _, regionManager := range multiRegionManager.RegionManagers {
-		err := regionManager.CollectMetricsForInstances(ctx, instanceIdentifiers, ch)
+// ExplainExcludedMetrics lists the excluded metrics of the instance from whichever region discovered it.
+// ErrInstanceNotFound is returned when no region knows the instance.
+func (multiRegionManager *MultiRegionManager) ExplainExcludedMetrics(ctx context.Context, instanceIdentifier string) ([]models.ExcludedMetric, error) {
+	for _, region := range multiRegionManager.sortedRegions() {
+		excluded, err := multiRegionManager.RegionManagers[region].ExplainExcludedMetrics(ctx, instanceIdentifier)
+		if errors.Is(err, ErrInstanceNotFound) {
+			continue
+		}
+		return excluded, err
+	}
+
+	return nil, ErrInstanceNotFound
+}
+
+// GetMetricCatalog lists the available metrics of the instance from whichever region discovered it.
+// ErrInstanceNotFound is returned when no region knows the instance.
+func (multiRegionManager *MultiRegionManager) GetMetricCatalog(ctx context.Context, instanceIdentifier string) (map[string]models.CatalogMetric, error) {
+	for _, region := range multiRegionManager.sortedRegions() {
+		catalog, err := multiRegionManager.RegionManagers[region].GetMetricCatalog(ctx, instanceIdentifier)
+		if errors.Is(err, ErrInstanceNotFound) {
+			continue
+		}
+		return catalog, err
+	}
+
+	return nil, ErrInstanceNotFound
+}
+
+// ExplainInstances lists the instances and filter decisions of every region, ordered by region. A failed region does
+// not hide the instances of the other regions; the errors of all failed regions are returned joined.
+func (multiRegionManager *MultiRegionManager) ExplainInstances(ctx context.Context) ([]models.InstanceDecision, error) {
+	var decisions []models.InstanceDecision
+	var regionErrors []error
+	for _, region := range multiRegionManager.sortedRegions() {
+		regionDecisions, err := multiRegionManager.RegionManagers[region].ExplainInstances(ctx)
 		if err != nil {
-			return err
+			regionErrors = append(regionErrors, fmt.Errorf("region %s: %w", region, err))
+			continue
 		}
+		decisions = append(decisions, regionDecisions...)
 	}
 
-	return nil
+	return decisions, errors.Join(regionErrors...)
+}
+
+// Invalidate drops the cached instances and metric metadata of every region.
+func (multiRegionManager *MultiRegionManager) Invalidate() {
+	for _, regionManager := range multiRegionManager.RegionManagers {
+		regionManager.Invalidate()
+	}
+}
+
+// sortedRegions returns the regions in sorted order, so regions are visited and errors reported deterministically.
+func (multiRegionManager *MultiRegionManager) sortedRegions() []string {
+	return slices.Sorted(maps.Keys(multiRegionManager.RegionManagers))
 }
@@ -3,18 +3,22 @@ package region
 import (
 	"context"
 	"errors"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
 )
 
 func TestNewMultiRegionManager(t *testing.T) {
 	t.Run("Creates new multi region manager successfully", func(t *testing.T) {
-		manager := NewMultiRegionManager()
+		manager := NewMultiRegionManager(4)
 
 		assert.NotNil(t, manager)
 		assert.NotNil(t, manager.RegionManagers)
@@ -47,7 +51,7 @@ func TestAddRegionManager(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			manager := NewMultiRegionManager()
+			manager := NewMultiRegionManager(4)
 
 			for _, region := range tc.regions {
 				mockRM := &mocks.MockRegionManager{}
@@ -93,44 +97,45 @@ func TestMultiRegionManagerCollectMetrics(t *testing.T) {
 			expectedMetricCalls: 0,
 		},
 		{
-			name:                "Collect metrics with first region error (fail fast)",
+			name:                "Collect metrics with first region error continues other regions",
 			regions:             []string{"us-west-2", "us-east-1"},
-			regionManagerErrors: []error{errors.New("first region failed")},
-			expectedError:       errors.New("first region failed"),
-			expectedMetricCalls: 1,
+			regionManagerErrors: []error{errors.New("first region failed"), nil},
+			expectedError:       nil,
+			expectedMetricCalls: 2,
 		},
 		{
-			name:                "Collect metrics with second region error",
+			name:                "Collect metrics with second region error continues other regions",
 			regions:             []string{"us-west-2", "us-east-1"},
 			regionManagerErrors: []error{nil, errors.New("second region failed")},
-			expectedError:       errors.New("second region failed"),
+			expectedError:       nil,
+			expectedMetricCalls: 2,
+		},
+		{
+			name:                "Collect metrics with single region error",
+			regions:             []string{"us-west-2"},
+			regionManagerErrors: []error{errors.New("region failed")},
+			expectedError:       errors.New("region us-west-2: region failed"),
+			expectedMetricCalls: 1,
+		},
+		{
+			name:                "Collect metrics with every region failing",
+			regions:             []string{"us-west-2", "us-east-1"},
+			regionManagerErrors: []error{errors.New("first region failed"), errors.New("second region failed")},
+			expectedError:       errors.New("region us-east-1: second region failed\nregion us-west-2: first region failed"),
 			expectedMetricCalls: 2,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			manager := NewMultiRegionManager()
+			manager := NewMultiRegionManager(4)
 
 			var mockRMs []*mocks.MockRegionManager
 			for i, region := range tc.regions {
 				mockRM := &mocks.MockRegionManager{}
-
-				// Set up mock expectation if we have an error defined for this region
-				if i < len(tc.regionManagerErrors) {
-					if tc.expectedError != nil {
-						// For error cases, use Maybe() since map iteration order is non-deterministic
-						mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
-							Return(tc.regionManagerErrors[i]).Maybe()
-					} else {
-						mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
-							Return(tc.regionManagerErrors[i]).Once()
-					}
-				} else {
-					// For regions beyond the error list, set up Maybe() expectation
-					mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
-						Return(nil).Maybe()
-				}
+				// Every region is collected, whether or not another region failed
+				mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
+					Return(tc.regionManagerErrors[i]).Once()
 
 				manager.AddRegionManager(region, mockRM)
 				mockRMs = append(mockRMs, mockRM)
@@ -140,21 +145,59 @@ func TestMultiRegionManagerCollectMetrics(t *testing.T) {
 			err := manager.CollectMetrics(context.Background(), ch)
 
 			if tc.expectedError != nil {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tc.expectedError.Error())
+				assert.EqualError(t, err, tc.expectedError.Error())
 			} else {
 				assert.NoError(t, err)
 			}
 
 			close(ch)
 
+			calls := 0
 			for _, mockRM := range mockRMs {
 				mockRM.AssertExpectations(t)
+				calls += len(mockRM.Calls)
 			}
+			assert.Equal(t, tc.expectedMetricCalls, calls)
 		})
 	}
 }
 
+func TestMultiRegionManagerCollectMetricsConcurrency(t *testing.T) {
+	const maxConcurrency = 2
+	manager := NewMultiRegionManager(maxConcurrency)
+
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	for _, region := range []string{"us-west-2", "us-east-1", "eu-west-1", "ap-south-1", "eu-central-1"} {
+		mockRM := &mocks.MockRegionManager{}
+		mockRM.On("CollectMetrics", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				mu.Lock()
+				active++
+				maxActive = max(maxActive, active)
+				mu.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				mu.Lock()
+				active--
+				mu.Unlock()
+			}).
+			Return(nil).Once()
+		manager.AddRegionManager(region, mockRM)
+	}
+
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetrics(context.Background(), ch)
+	close(ch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, maxConcurrency, maxActive)
+	for _, regionManager := range manager.RegionManagers {
+		regionManager.(*mocks.MockRegionManager).AssertExpectations(t)
+	}
+}
+
 func TestMultiRegionManagerCollectMetricsForInstances(t *testing.T) {
 	testCases := []struct {
 		name                string
@@ -197,45 +240,32 @@ func TestMultiRegionManagerCollectMetricsForInstances(t *testing.T) {
 			expectedMetricCalls: 1,
 		},
 		{
-			name:                "Collect filtered metrics with first region error (fail fast)",
+			name:                "Collect filtered metrics with first region error continues other regions",
 			regions:             []string{"us-west-2", "us-east-1"},
 			instanceIdentifiers: []string{"test-db-1"},
-			regionManagerErrors: []error{errors.New("first region failed")},
-			expectedError:       errors.New("first region failed"),
-			expectedMetricCalls: 1,
+			regionManagerErrors: []error{errors.New("first region failed"), nil},
+			expectedError:       nil,
+			expectedMetricCalls: 2,
 		},
 		{
-			name:                "collect filtered metrics with second region error",
+			name:                "Collect filtered metrics with every region failing",
 			regions:             []string{"us-west-2", "us-east-1"},
 			instanceIdentifiers: []string{"test-db-1"},
-			regionManagerErrors: []error{nil, errors.New("second region failed")},
-			expectedError:       errors.New("second region failed"),
+			regionManagerErrors: []error{errors.New("first region failed"), errors.New("second region failed")},
+			expectedError:       errors.New("region us-east-1: second region failed\nregion us-west-2: first region failed"),
 			expectedMetricCalls: 2,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			manager := NewMultiRegionManager()
+			manager := NewMultiRegionManager(4)
 
 			var mockRMs []*mocks.MockRegionManager
 			for i, region := range tc.regions {
 				mockRM := &mocks.MockRegionManager{}
-
-				if i < len(tc.regionManagerErrors) {
-					if tc.expectedError != nil {
-						// For error cases, use Maybe() since map iteration order is non-deterministic
-						mockRM.On("CollectMetricsForInstances", mock.Anything, mock.Anything, mock.Anything).
-							Return(tc.regionManagerErrors[i]).Maybe()
-					} else {
-						mockRM.On("CollectMetricsForInstances", mock.Anything, mock.Anything, mock.Anything).
-							Return(tc.regionManagerErrors[i]).Once()
-					}
-				} else {
-					// For regions beyond the error list, set up Maybe() expectation
-					mockRM.On("CollectMetricsForInstances", mock.Anything, mock.Anything, mock.Anything).
-						Return(nil).Maybe()
-				}
+				mockRM.On("CollectMetricsForInstances", mock.Anything, tc.instanceIdentifiers, mock.Anything).
+					Return(tc.regionManagerErrors[i]).Once()
 
 				manager.AddRegionManager(region, mockRM)
 				mockRMs = append(mockRMs, mockRM)
@@ -245,17 +275,142 @@ func TestMultiRegionManagerCollectMetricsForInstances(t *testing.T) {
 			err := manager.CollectMetricsForInstances(context.Background(), tc.instanceIdentifiers, ch)
 
 			if tc.expectedError != nil {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tc.expectedError.Error())
+				assert.EqualError(t, err, tc.expectedError.Error())
 			} else {
 				assert.NoError(t, err)
 			}
 
 			close(ch)
 
+			calls := 0
 			for _, mockRM := range mockRMs {
 				mockRM.AssertExpectations(t)
+				calls += len(mockRM.Calls)
 			}
+			assert.Equal(t, tc.expectedMetricCalls, calls)
 		})
 	}
 }
+
+func TestMultiRegionManagerExplainExcludedMetrics(t *testing.T) {
+	excluded := []models.ExcludedMetric{{Name: "os.cpuUtilization.idle", Reason: models.ExclusionReasonExcludePattern}}
+
+	t.Run("Instance found in one region", func(t *testing.T) {
+		manager := NewMultiRegionManager(4)
+		usWest := &mocks.MockRegionManager{}
+		usEast := &mocks.MockRegionManager{}
+		usEast.On("ExplainExcludedMetrics", mock.Anything, "prod-db").Return(excluded, nil)
+		manager.AddRegionManager("us-west-2", usWest)
+		manager.AddRegionManager("us-east-1", usEast)
+
+		result, err := manager.ExplainExcludedMetrics(context.Background(), "prod-db")
+
+		assert.NoError(t, err)
+		assert.Equal(t, excluded, result)
+		usWest.AssertNotCalled(t, "ExplainExcludedMetrics", mock.Anything, "prod-db")
+	})
+
+	t.Run("Instance found in no region", func(t *testing.T) {
+		manager := NewMultiRegionManager(4)
+		mockRM := &mocks.MockRegionManager{}
+		mockRM.On("ExplainExcludedMetrics", mock.Anything, "prod-db").Return(nil, ErrInstanceNotFound)
+		manager.AddRegionManager("us-west-2", mockRM)
+
+		result, err := manager.ExplainExcludedMetrics(context.Background(), "prod-db")
+
+		assert.ErrorIs(t, err, ErrInstanceNotFound)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Region error", func(t *testing.T) {
+		manager := NewMultiRegionManager(4)
+		mockRM := &mocks.MockRegionManager{}
+		mockRM.On("ExplainExcludedMetrics", mock.Anything, "prod-db").Return(nil, errors.New("DescribeDBInstances failed"))
+		manager.AddRegionManager("us-west-2", mockRM)
+
+		_, err := manager.ExplainExcludedMetrics(context.Background(), "prod-db")
+
+		assert.EqualError(t, err, "DescribeDBInstances failed")
+	})
+}
+
+func TestMultiRegionManagerGetMetricCatalog(t *testing.T) {
+	catalog := map[string]models.CatalogMetric{"os.cpuUtilization.idle": {Name: "os.cpuUtilization.idle", Unit: "Percent", Category: "os"}}
+
+	t.Run("Instance found in one region", func(t *testing.T) {
+		manager := NewMultiRegionManager(4)
+		usWest := &mocks.MockRegionManager{}
+		usEast := &mocks.MockRegionManager{}
+		usEast.On("GetMetricCatalog", mock.Anything, "prod-db").Return(catalog, nil)
+		manager.AddRegionManager("us-west-2", usWest)
+		manager.AddRegionManager("us-east-1", usEast)
+
+		result, err := manager.GetMetricCatalog(context.Background(), "prod-db")
+
+		assert.NoError(t, err)
+		assert.Equal(t, catalog, result)
+		usWest.AssertNotCalled(t, "GetMetricCatalog", mock.Anything, "prod-db")
+	})
+
+	t.Run("Instance found in no region", func(t *testing.T) {
+		manager := NewMultiRegionManager(4)
+		mockRM := &mocks.MockRegionManager{}
+		mockRM.On("GetMetricCatalog", mock.Anything, "prod-db").Return(nil, ErrInstanceNotFound)
+		manager.AddRegionManager("us-west-2", mockRM)
+
+		result, err := manager.GetMetricCatalog(context.Background(), "prod-db")
+
+		assert.ErrorIs(t, err, ErrInstanceNotFound)
+		assert.Nil(t, result)
+	})
+}
+
+func TestMultiRegionManagerExplainInstances(t *testing.T) {
+	usEastDecisions := []models.InstanceDecision{{Identifier: "east-db", Region: "us-east-1"}}
+	usWestDecisions := []models.InstanceDecision{{Identifier: "west-db", Region: "us-west-2"}}
+
+	t.Run("Decisions of every region in region order", func(t *testing.T) {
+		manager := NewMultiRegionManager(4)
+		usWest := &mocks.MockRegionManager{}
+		usWest.On("ExplainInstances", mock.Anything).Return(usWestDecisions, nil)
+		usEast := &mocks.MockRegionManager{}
+		usEast.On("ExplainInstances", mock.Anything).Return(usEastDecisions, nil)
+		manager.AddRegionManager("us-west-2", usWest)
+		manager.AddRegionManager("us-east-1", usEast)
+
+		result, err := manager.ExplainInstances(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, append(slices.Clone(usEastDecisions), usWestDecisions...), result)
+	})
+
+	t.Run("Region error keeps the other regions", func(t *testing.T) {
+		manager := NewMultiRegionManager(4)
+		usWest := &mocks.MockRegionManager{}
+		usWest.On("ExplainInstances", mock.Anything).Return(usWestDecisions, nil)
+		usEast := &mocks.MockRegionManager{}
+		usEast.On("ExplainInstances", mock.Anything).Return(nil, errors.New("DescribeDBInstances failed"))
+		manager.AddRegionManager("us-west-2", usWest)
+		manager.AddRegionManager("us-east-1", usEast)
+
+		result, err := manager.ExplainInstances(context.Background())
+
+		assert.EqualError(t, err, "region us-east-1: DescribeDBInstances failed")
+		assert.Equal(t, usWestDecisions, result)
+	})
+}
+
+func TestMultiRegionManagerInvalidate(t *testing.T) {
+	manager := NewMultiRegionManager(4)
+	usWest := &mocks.MockRegionManager{}
+	usEast := &mocks.MockRegionManager{}
+	usWest.On("Invalidate").Return().Once()
+	usEast.On("Invalidate").Return().Once()
+	manager.AddRegionManager("us-west-2", usWest)
+	manager.AddRegionManager("us-east-1", usEast)
+
+	manager.Invalidate()
+
+	usWest.AssertExpectations(t)
+	usEast.AssertExpectations(t)
+}
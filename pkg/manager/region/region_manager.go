@@ -2,11 +2,21 @@ package region
 
 import (
 	"context"
+	"errors"
 
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// ErrInstanceNotFound is returned when no discovered instance has the requested identifier.
+var ErrInstanceNotFound = errors.New("instance not found")
+
 type RegionManager interface {
 	CollectMetrics(ctx context.Context, ch chan<- prometheus.Metric) error
 	CollectMetricsForInstances(ctx context.Context, instanceIdentifiers []string, ch chan<- prometheus.Metric) error
+	ExplainExcludedMetrics(ctx context.Context, instanceIdentifier string) ([]models.ExcludedMetric, error)
+	ExplainInstances(ctx context.Context) ([]models.InstanceDecision, error)
+	GetMetricCatalog(ctx context.Context, instanceIdentifier string) (map[string]models.CatalogMetric, error)
+	// Invalidate drops the cached instances and metric metadata, so they are discovered again on the next scrape.
+	Invalidate()
 }
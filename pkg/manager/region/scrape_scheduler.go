@@ -0,0 +1,97 @@
+package region
+
+import (
+	"context"
+	"sync"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+)
+
+// schedulerPriorities lists priorities in the order freed slots are handed out.
+var schedulerPriorities = []models.ScrapePriority{models.ScrapePriorityHigh, models.ScrapePriorityNormal}
+
+// ScrapeScheduler bounds the number of concurrent Performance Insights requests across every scrape running in a region.
+// When a slot is released it is handed to a waiting high priority request before any normal priority one,
+// so small targeted scrapes are not starved by a large full scrape that is already running.
+type ScrapeScheduler struct {
+	mu       sync.Mutex
+	capacity int
+	inUse    int
+	waiters  map[models.ScrapePriority][]chan struct{}
+}
+
+func NewScrapeScheduler(capacity int) *ScrapeScheduler {
+	return &ScrapeScheduler{
+		capacity: capacity,
+		waiters:  make(map[models.ScrapePriority][]chan struct{}),
+	}
+}
+
+// Acquire blocks until a slot is available for the given priority or the context is cancelled.
+// Every successful Acquire must be paired with a Release.
+func (scheduler *ScrapeScheduler) Acquire(ctx context.Context, priority models.ScrapePriority) error {
+	if !priority.IsValid() {
+		priority = models.ScrapePriorityNormal
+	}
+
+	scheduler.mu.Lock()
+	if scheduler.inUse < scheduler.capacity && !scheduler.hasWaiters() {
+		scheduler.inUse++
+		scheduler.mu.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	scheduler.waiters[priority] = append(scheduler.waiters[priority], ready)
+	scheduler.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		scheduler.mu.Lock()
+		removed := scheduler.removeWaiter(priority, ready)
+		scheduler.mu.Unlock()
+		if !removed {
+			// The slot was handed over while the context was being cancelled, give it back
+			scheduler.Release()
+		}
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot, transferring it directly to the highest priority waiter if there is one.
+func (scheduler *ScrapeScheduler) Release() {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+
+	for _, priority := range schedulerPriorities {
+		if waiters := scheduler.waiters[priority]; len(waiters) > 0 {
+			scheduler.waiters[priority] = waiters[1:]
+			close(waiters[0])
+			return
+		}
+	}
+
+	scheduler.inUse--
+}
+
+func (scheduler *ScrapeScheduler) hasWaiters() bool {
+	for _, waiters := range scheduler.waiters {
+		if len(waiters) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (scheduler *ScrapeScheduler) removeWaiter(priority models.ScrapePriority, ready chan struct{}) bool {
+	waiters := scheduler.waiters[priority]
+	for i, waiter := range waiters {
+		if waiter == ready {
+			scheduler.waiters[priority] = append(waiters[:i:i], waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
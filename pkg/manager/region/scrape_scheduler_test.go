@@ -0,0 +1,91 @@
+package region
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+)
+
+func TestNewScrapeScheduler(t *testing.T) {
+	scheduler := NewScrapeScheduler(4)
+
+	assert.NotNil(t, scheduler)
+	assert.Equal(t, 4, scheduler.capacity)
+	assert.Equal(t, 0, scheduler.inUse)
+}
+
+func TestScrapeSchedulerAcquireWithinCapacity(t *testing.T) {
+	scheduler := NewScrapeScheduler(2)
+
+	require.NoError(t, scheduler.Acquire(context.Background(), models.ScrapePriorityNormal))
+	require.NoError(t, scheduler.Acquire(context.Background(), models.ScrapePriorityHigh))
+	assert.Equal(t, 2, scheduler.inUse)
+
+	scheduler.Release()
+	scheduler.Release()
+	assert.Equal(t, 0, scheduler.inUse)
+}
+
+func TestScrapeSchedulerServesHighPriorityFirst(t *testing.T) {
+	scheduler := NewScrapeScheduler(1)
+	require.NoError(t, scheduler.Acquire(context.Background(), models.ScrapePriorityNormal))
+
+	order := make(chan models.ScrapePriority, 2)
+	acquire := func(priority models.ScrapePriority) {
+		require.NoError(t, scheduler.Acquire(context.Background(), priority))
+		order <- priority
+		scheduler.Release()
+	}
+
+	// Queue the normal request first so only the priority decides which waiter runs next
+	go acquire(models.ScrapePriorityNormal)
+	waitForWaiters(t, scheduler, models.ScrapePriorityNormal, 1)
+	go acquire(models.ScrapePriorityHigh)
+	waitForWaiters(t, scheduler, models.ScrapePriorityHigh, 1)
+
+	scheduler.Release()
+
+	assert.Equal(t, models.ScrapePriorityHigh, <-order)
+	assert.Equal(t, models.ScrapePriorityNormal, <-order)
+}
+
+func TestScrapeSchedulerAcquireContextCancelled(t *testing.T) {
+	scheduler := NewScrapeScheduler(1)
+	require.NoError(t, scheduler.Acquire(context.Background(), models.ScrapePriorityNormal))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- scheduler.Acquire(ctx, models.ScrapePriorityHigh)
+	}()
+	waitForWaiters(t, scheduler, models.ScrapePriorityHigh, 1)
+
+	cancel()
+
+	assert.ErrorIs(t, <-errCh, context.Canceled)
+	scheduler.mu.Lock()
+	assert.Empty(t, scheduler.waiters[models.ScrapePriorityHigh])
+	scheduler.mu.Unlock()
+
+	scheduler.Release()
+	assert.Equal(t, 0, scheduler.inUse)
+}
+
+func waitForWaiters(t *testing.T, scheduler *ScrapeScheduler, priority models.ScrapePriority, count int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		scheduler.mu.Lock()
+		waiting := len(scheduler.waiters[priority])
+		scheduler.mu.Unlock()
+		if waiting == count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d %s waiters", count, priority)
+}
@@ -2,11 +2,17 @@ package region
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/instance"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/metric"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/processing/formatting"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -15,6 +21,14 @@ type instanceBatches struct {
 	instance models.Instance
 	batches  [][]string
 	err      error
+	started  time.Time // when fetching the metric batches of the instance started
+	fetched  time.Time // when the metric batches of the instance were fetched
+}
+
+// collectedInstance tracks the metric batches of an instance collected without error and when its last batch completed
+type collectedInstance struct {
+	batches  int
+	finished time.Time
 }
 
 // metricRequest represents a single metric batch request for an instance
@@ -23,23 +37,64 @@ type metricRequest struct {
 	metricsBatch []string
 }
 
+// pendingInstance tracks the outstanding metric batches of an instance so its completion can be reported
+type pendingInstance struct {
+	instance  models.Instance
+	batches   int
+	remaining atomic.Int64
+	failed    atomic.Int64
+}
+
 type SingleRegionManager struct {
-	instanceManager instance.InstanceProvider
-	metricManager   metric.MetricProvider
-	region          string
-	maxConcurrency  int
+	instanceManager       instance.InstanceProvider
+	metricManager         metric.MetricProvider
+	region                string
+	discoveryConcurrency  int
+	collectionConcurrency int
+	batchSize             int
+	producerConcurrency   int
+	maxBatchesPerScrape   int
+	prometheusConfig      models.ParsedPrometheusConfig
+	targetedPriority      models.ScrapePriority
+	collectionOrder       models.ParsedCollectionOrderConfig
+	instancesConfig       models.ParsedInstancesConfig
+	postProcessing        bool
+	metadataTTL           time.Duration
+	scheduler             *ScrapeScheduler
+	limiter               *AdaptiveLimiter
+	logger                *slog.Logger
 }
 
 // SingleRegionManager handles the database metric collection within a single AWS region.
 // It coordiantes between instance discovery (via RDS) and metric collection (via Performance Insights)
 // to provide comprehensive database monitoring for all eligible instances in the region.
-func NewSingleRegionManager(region string, instanceManager instance.InstanceProvider, metricManager metric.MetricProvider, concurrency int) *SingleRegionManager {
-	return &SingleRegionManager{
-		instanceManager: instanceManager,
-		metricManager:   metricManager,
-		region:          region,
-		maxConcurrency:  concurrency,
+// When targeted scrapes are configured with high priority, every scrape in the region shares a ScrapeScheduler
+// so the Performance Insights requests of targeted scrapes are served before those of a concurrent full scrape.
+func NewSingleRegionManager(region string, instanceManager instance.InstanceProvider, metricManager metric.MetricProvider, config *models.ParsedConfig) *SingleRegionManager {
+	singleRegionManager := &SingleRegionManager{
+		instanceManager:       instanceManager,
+		metricManager:         metricManager,
+		region:                region,
+		discoveryConcurrency:  config.Discovery.Processing.DiscoveryConcurrency,
+		collectionConcurrency: config.Discovery.Processing.CollectionConcurrency,
+		batchSize:             config.Discovery.Processing.BatchSize,
+		producerConcurrency:   max(config.Discovery.Processing.ProducerConcurrency, 1),
+		maxBatchesPerScrape:   config.Discovery.Processing.MaxBatchesPerScrape,
+		prometheusConfig:      config.Export.Prometheus,
+		targetedPriority:      config.Export.TargetedPriority,
+		collectionOrder:       config.Discovery.CollectionOrder,
+		instancesConfig:       config.Discovery.Instances,
+		postProcessing:        len(config.Discovery.Metrics.PostProcessors) > 0,
+		metadataTTL:           config.Discovery.Metrics.MetadataTTL,
+		limiter:               NewAdaptiveLimiter(region, config.Discovery.Processing.CollectionConcurrency),
+		logger:                slog.Default().With("region", region),
+	}
+
+	if config.Export.TargetedPriority == models.ScrapePriorityHigh {
+		singleRegionManager.scheduler = NewScrapeScheduler(config.Discovery.Processing.CollectionConcurrency)
 	}
+
+	return singleRegionManager
 }
 
 // CollectMetrics discovers and collects metrics from all eligible database instances in the region.
@@ -47,12 +102,20 @@ func NewSingleRegionManager(region string, instanceManager instance.InstanceProv
 // and collects available Performance Insights metrics on each instance using a queue-based worker pool
 // to parallelize API calls across all metric batches from all instances.
 func (singleRegionManager *SingleRegionManager) CollectMetrics(ctx context.Context, ch chan<- prometheus.Metric) error {
+	stats := models.ScrapeStatsFromContext(ctx)
+	stats.AddRegionsScraped(1)
+	singleRegionManager.emitHeartbeat(ch)
+	singleRegionManager.emitEffectiveSettings(ch)
+
 	instances, err := singleRegionManager.instanceManager.GetInstances(ctx)
 	if err != nil {
+		stats.AddErrors(1)
 		return err
 	}
+	stats.AddInstancesDiscovered(len(instances))
+	singleRegionManager.emitInstanceLimitReached(ch)
 
-	return singleRegionManager.collectMetricsWithQueue(ctx, instances, ch)
+	return singleRegionManager.collectMetricsWithQueue(ctx, models.ScrapePriorityNormal, instances, ch)
 }
 
 // CollectMetricsForInstances discovers and collects metrics from all eligible and specified database instances in the region.
@@ -60,10 +123,17 @@ func (singleRegionManager *SingleRegionManager) CollectMetrics(ctx context.Conte
 // and collects available Performance Insights metrics on each instance using a queue-based worker pool
 // to parallelize API calls across all metric batches from all instances.
 func (srm *SingleRegionManager) CollectMetricsForInstances(ctx context.Context, instanceIdentifiers []string, ch chan<- prometheus.Metric) error {
+	stats := models.ScrapeStatsFromContext(ctx)
+	stats.AddRegionsScraped(1)
+	srm.emitHeartbeat(ch)
+	srm.emitEffectiveSettings(ch)
+
 	allInstances, err := srm.instanceManager.GetInstances(ctx)
 	if err != nil {
+		stats.AddErrors(1)
 		return err
 	}
+	stats.AddInstancesDiscovered(len(allInstances))
 
 	identifierMap := make(map[string]models.Instance, len(instanceIdentifiers))
 	for _, identifier := range instanceIdentifiers {
@@ -77,19 +147,86 @@ func (srm *SingleRegionManager) CollectMetricsForInstances(ctx context.Context,
 		}
 	}
 
-	return srm.collectMetricsWithQueue(ctx, filteredInstances, ch)
+	return srm.collectMetricsWithQueue(ctx, srm.targetedPriority, filteredInstances, ch)
+}
+
+// ExplainExcludedMetrics lists the metrics available for the discovered instance with the given identifier that are
+// filtered out of collection, returning ErrInstanceNotFound when the region has no such instance.
+func (srm *SingleRegionManager) ExplainExcludedMetrics(ctx context.Context, instanceIdentifier string) ([]models.ExcludedMetric, error) {
+	instances, err := srm.instanceManager.GetInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, instance := range instances {
+		if instance.Identifier == instanceIdentifier {
+			return srm.metricManager.ExplainExcludedMetrics(ctx, instance)
+		}
+	}
+	return nil, ErrInstanceNotFound
+}
+
+// GetMetricCatalog lists every metric available for the discovered instance with the given identifier, returning
+// ErrInstanceNotFound when the region has no such instance.
+func (srm *SingleRegionManager) GetMetricCatalog(ctx context.Context, instanceIdentifier string) (map[string]models.CatalogMetric, error) {
+	instances, err := srm.instanceManager.GetInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, instance := range instances {
+		if instance.Identifier == instanceIdentifier {
+			return srm.metricManager.GetMetricCatalog(ctx, instance)
+		}
+	}
+	return nil, ErrInstanceNotFound
+}
+
+// Invalidate drops the cached instances and metric metadata of the region.
+func (srm *SingleRegionManager) Invalidate() {
+	srm.instanceManager.Invalidate()
+	srm.metricManager.Invalidate()
+}
+
+// ExplainInstances lists the instances discovered in the region with the decision of the instance filters: the
+// collected instances, followed by the instances the filters excluded when the instance provider keeps them.
+func (srm *SingleRegionManager) ExplainInstances(ctx context.Context) ([]models.InstanceDecision, error) {
+	instances, err := srm.instanceManager.GetInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if reporter, ok := srm.instanceManager.(instance.FilteredInstanceReporter); ok {
+		instances = append(slices.Clone(instances), reporter.FilteredInstances()...)
+	}
+
+	decisions := make([]models.InstanceDecision, 0, len(instances))
+	for _, instance := range instances {
+		decision := srm.instancesConfig.EvaluateInstance(instance)
+		decisions = append(decisions, models.InstanceDecision{
+			Identifier:   instance.Identifier,
+			Engine:       instance.Engine,
+			ResourceID:   instance.ResourceID,
+			Region:       srm.region,
+			CreationTime: instance.CreationTime,
+			Tags:         instance.Tags,
+			Reason:       decision.Reason(),
+			Decision:     decision,
+		})
+	}
+	return decisions, nil
 }
 
 // fetchMetricBatchesInParallel fetches metric batches for all instances concurrently.
 // This avoids the sequential API call bottleneck on first run when metrics aren't cached.
-// Concurrency is limited by maxConcurrency to avoid overwhelming the API.
+// Concurrency is limited by discoveryConcurrency to avoid overwhelming the API.
 // Returns a slice of results containing instance, batches, and any errors encountered.
-func (srm *SingleRegionManager) fetchMetricBatchesInParallel(ctx context.Context, instances []models.Instance) []instanceBatches {
+func (srm *SingleRegionManager) fetchMetricBatchesInParallel(ctx context.Context, priority models.ScrapePriority, instances []models.Instance) []instanceBatches {
 	results := make([]instanceBatches, len(instances))
 	var wg sync.WaitGroup
 
 	// Semaphore to limit concurrent API calls
-	semaphore := make(chan struct{}, srm.maxConcurrency)
+	semaphore := make(chan struct{}, srm.discoveryConcurrency)
 
 	for i, inst := range instances {
 		wg.Add(1)
@@ -101,18 +238,29 @@ func (srm *SingleRegionManager) fetchMetricBatchesInParallel(ctx context.Context
 			case semaphore <- struct{}{}:
 				defer func() { <-semaphore }() // Release semaphore
 			case <-ctx.Done():
+				now := time.Now()
 				results[index] = instanceBatches{
 					instance: instance,
 					err:      ctx.Err(),
+					started:  now,
+					fetched:  now,
 				}
 				return
 			}
 
-			batches, err := srm.metricManager.GetMetricBatches(ctx, instance)
+			started := time.Now()
+			var batches [][]string
+			err := srm.schedule(ctx, priority, func() error {
+				var err error
+				batches, err = srm.metricManager.GetMetricBatches(ctx, instance)
+				return err
+			})
 			results[index] = instanceBatches{
 				instance: instance,
 				batches:  batches,
 				err:      err,
+				started:  started,
+				fetched:  time.Now(),
 			}
 		}(i, inst)
 	}
@@ -124,26 +272,56 @@ func (srm *SingleRegionManager) fetchMetricBatchesInParallel(ctx context.Context
 // collectMetricsWithQueue implements a queue-based worker pool pattern to parallelize
 // metric data collection across all instances and their metric batches.
 // This allows for better parallelization even when there's only a single instance with many metrics.
-// Uses a bounded queue with producer goroutine to balance memory usage and performance.
-// Continues processing on errors and collects all errors to report at the end.
-func (srm *SingleRegionManager) collectMetricsWithQueue(ctx context.Context, instances []models.Instance, ch chan<- prometheus.Metric) error {
+// Uses a bounded queue fed by producerConcurrency producer goroutines to balance memory usage and performance.
+// Continues processing on errors and reports the number of errors of every failed instance by the scrape instance
+// errors metric. An error is only returned when every instance failed, as the metrics of the other instances are served.
+// When maxBatchesPerScrape is set, only that many batches are queued,
+// the already queued batches are still collected, and the batch limit metric reports that the limit was reached.
+// Instances are queued in the configured collection order so the most important instances are collected first.
+// When metric post-processors are configured, the collected metric data is accumulated per instance and the
+// post-processors run once every batch has been collected.
+// When ctx carries a ScrapeProgress, the completion of each instance is reported as soon as its last batch is collected.
+func (srm *SingleRegionManager) collectMetricsWithQueue(ctx context.Context, priority models.ScrapePriority, instances []models.Instance, ch chan<- prometheus.Metric) error {
+	stats := models.ScrapeStatsFromContext(ctx)
+	progress := models.ScrapeProgressFromContext(ctx)
+	instances = srm.collectionOrder.OrderInstances(instances)
+	instances = srm.withCollectionRegion(instances)
+	instances = srm.withRefreshedMetadata(ctx, instances)
+	srm.emitInstanceInfo(ch, instances)
+	if srm.postProcessing {
+		ctx = models.ContextWithCollectedMetricData(ctx, models.NewCollectedMetricData())
+	}
+
 	// Fetch metric batches for all instances in parallel
-	batchResults := srm.fetchMetricBatchesInParallel(ctx, instances)
+	batchResults := srm.fetchMetricBatchesInParallel(ctx, priority, instances)
+	for _, result := range batchResults {
+		if result.err == nil {
+			stats.AddInstancesCollected(1)
+		} else if isPerformanceInsightsUnsupported(result.err) {
+			srm.emitInstancePIUnsupported(ch, result.instance)
+		}
+	}
+	pending := srm.trackPendingInstances(progress, batchResults)
 
 	// Use a bounded queue to limit memory usage
 	// Size = workers * 10 provides good balance between memory and throughput
-	queueSize := srm.maxConcurrency * 10
+	queueSize := srm.collectionConcurrency * 10
 	requestQueue := make(chan metricRequest, queueSize)
 
-	// Error slice to collect all errors (protected by mutex)
+	// Error slice to collect all errors and the error count of the instances that failed by resource ID (protected by mutex)
 	var errorsMu sync.Mutex
 	var errors []error
+	failedInstances := make(map[string]int)
+
+	// Collected batches of every instance by resource ID, to report the scrape duration and up metrics (protected by mutex)
+	var collectedMu sync.Mutex
+	collected := make(map[string]collectedInstance)
 
 	// WaitGroup for workers
 	var workerWg sync.WaitGroup
 
 	// Start worker pool
-	for i := 0; i < srm.maxConcurrency; i++ {
+	for i := 0; i < srm.collectionConcurrency; i++ {
 		workerWg.Add(1)
 		go func() {
 			defer workerWg.Done()
@@ -153,11 +331,24 @@ func (srm *SingleRegionManager) collectMetricsWithQueue(ctx context.Context, ins
 					if !ok {
 						return // Channel closed
 					}
-					if err := srm.metricManager.CollectMetricsForBatch(ctx, req.instance, req.metricsBatch, ch); err != nil {
+					err := srm.collectBatch(ctx, priority, req, ch)
+					if err != nil {
 						errorsMu.Lock()
 						errors = append(errors, err)
+						failedInstances[req.instance.ResourceID]++
 						errorsMu.Unlock()
 					}
+					collectedMu.Lock()
+					instanceCollected := collected[req.instance.ResourceID]
+					if err == nil {
+						instanceCollected.batches++
+					}
+					if now := time.Now(); now.After(instanceCollected.finished) {
+						instanceCollected.finished = now
+					}
+					collected[req.instance.ResourceID] = instanceCollected
+					collectedMu.Unlock()
+					srm.batchCompleted(progress, pending[req.instance.ResourceID], err)
 				case <-ctx.Done():
 					return // Context cancelled - exit immediately
 				}
@@ -165,45 +356,387 @@ func (srm *SingleRegionManager) collectMetricsWithQueue(ctx context.Context, ins
 		}()
 	}
 
-	// Producer goroutine: feeds the queue from fetched batches
-	var producerWg sync.WaitGroup
-	producerWg.Add(1)
-	go func() {
-		defer producerWg.Done()
-		defer close(requestQueue)
-
-		for _, result := range batchResults {
-			if result.err != nil {
-				errorsMu.Lock()
-				errors = append(errors, result.err)
-				errorsMu.Unlock()
-				continue
+	// Flatten the fetched batches into the requests to queue, in collection order and within the batch limit
+	var requests []metricRequest
+	var batchLimitReached bool
+	for _, result := range batchResults {
+		// Unsupported instances are reported by their own metric rather than failing the scrape
+		if isPerformanceInsightsUnsupported(result.err) {
+			continue
+		}
+		if result.err != nil {
+			errorsMu.Lock()
+			errors = append(errors, result.err)
+			failedInstances[result.instance.ResourceID]++
+			errorsMu.Unlock()
+			continue
+		}
+
+		for _, batch := range result.batches {
+			if srm.maxBatchesPerScrape > 0 && len(requests) >= srm.maxBatchesPerScrape {
+				batchLimitReached = true
+				break
 			}
+			requests = append(requests, metricRequest{
+				instance:     result.instance,
+				metricsBatch: batch,
+			})
+		}
+		if batchLimitReached {
+			srm.logger.Info("Batch limit reached, skipping remaining metric batches", "max_batches_per_scrape", srm.maxBatchesPerScrape)
+			break
+		}
+	}
+
+	// Producer goroutines: feed the queue from the flattened requests. A single producer preserves collection order;
+	// several producers claim requests through a shared index, so order is only approximately preserved.
+	var producerWg sync.WaitGroup
+	var nextRequest atomic.Int64
+	for i := 0; i < srm.producerConcurrency; i++ {
+		producerWg.Add(1)
+		go func() {
+			defer producerWg.Done()
+			for {
+				index := int(nextRequest.Add(1) - 1)
+				if index >= len(requests) {
+					return
+				}
 
-			// Queue all batches for this instance
-			for _, batch := range result.batches {
 				select {
-				case requestQueue <- metricRequest{
-					instance:     result.instance,
-					metricsBatch: batch,
-				}:
+				case requestQueue <- requests[index]:
 				case <-ctx.Done():
 					return
 				}
 			}
-		}
-	}()
+		}()
+	}
 
-	// Wait for producer to finish
+	// Wait for producers to finish
 	producerWg.Wait()
+	close(requestQueue)
 
 	// Wait for all workers to complete
 	workerWg.Wait()
+	srm.reportSkippedBatches(progress, batchResults, pending)
 
-	// Return the first error if any occurred
-	if len(errors) > 0 {
-		return errors[0]
+	if srm.maxBatchesPerScrape > 0 {
+		srm.emitBatchLimitReached(ch, batchLimitReached)
+	}
+
+	if srm.postProcessing {
+		srm.metricManager.CollectPostProcessedMetrics(ctx, ch)
+	}
+
+	if srm.prometheusConfig.DiscoveredMetricNames {
+		srm.metricManager.CollectDiscoveredMetricNames(srm.region, ch)
+	}
+
+	if srm.prometheusConfig.DataPointsReturned {
+		srm.metricManager.CollectDataPointsReturned(srm.region, ch)
+	}
+
+	if srm.prometheusConfig.ConversionErrors {
+		srm.metricManager.CollectConversionErrors(srm.region, ch)
+	}
+
+	stats.AddErrors(len(errors))
+	stats.AddInstancesFailed(len(failedInstances))
+	srm.emitScrapeInstanceErrors(ch, instances, failedInstances)
+	srm.emitInstanceScrapeMetrics(ch, batchResults, failedInstances, collected)
+
+	if len(errors) == 0 {
+		return nil
 	}
 
+	// Return the first error only when no instance was collected, a partial scrape still serves its metrics
+	if collectable := countCollectableInstances(batchResults); len(failedInstances) >= collectable {
+		return errors[0]
+	}
+	srm.logger.Warn("Metric collection failed for some instances, serving the metrics of the other instances",
+		"failed_instances", len(failedInstances), "instances", len(instances), "first_error", errors[0])
 	return nil
 }
+
+// countCollectableInstances counts the instances whose collection was attempted, excluding the instances
+// Performance Insights does not support.
+func countCollectableInstances(batchResults []instanceBatches) int {
+	collectable := 0
+	for _, result := range batchResults {
+		if !isPerformanceInsightsUnsupported(result.err) {
+			collectable++
+		}
+	}
+	return collectable
+}
+
+// trackPendingInstances reports the instances whose metric batches could not be fetched or that have no batches,
+// and returns the outstanding batches of every other instance keyed by resource ID. Nothing is tracked without progress.
+func (srm *SingleRegionManager) trackPendingInstances(progress *models.ScrapeProgress, batchResults []instanceBatches) map[string]*pendingInstance {
+	if progress == nil {
+		return nil
+	}
+
+	pending := make(map[string]*pendingInstance, len(batchResults))
+	for _, result := range batchResults {
+		event := models.InstanceProgress{Region: srm.region, Identifier: result.instance.Identifier}
+		if result.err != nil {
+			event.Error = result.err.Error()
+			progress.InstanceCompleted(event)
+			continue
+		}
+		if len(result.batches) == 0 {
+			progress.InstanceCompleted(event)
+			continue
+		}
+
+		tracked := &pendingInstance{instance: result.instance, batches: len(result.batches)}
+		tracked.remaining.Store(int64(len(result.batches)))
+		pending[result.instance.ResourceID] = tracked
+	}
+	return pending
+}
+
+// batchCompleted records a collected batch and reports the instance once its last batch is collected.
+func (srm *SingleRegionManager) batchCompleted(progress *models.ScrapeProgress, tracked *pendingInstance, err error) {
+	if tracked == nil {
+		return
+	}
+	if err != nil {
+		tracked.failed.Add(1)
+	}
+	if tracked.remaining.Add(-1) == 0 {
+		progress.InstanceCompleted(models.InstanceProgress{
+			Region:        srm.region,
+			Identifier:    tracked.instance.Identifier,
+			Batches:       tracked.batches,
+			FailedBatches: int(tracked.failed.Load()),
+		})
+	}
+}
+
+// reportSkippedBatches reports the instances with batches that were never collected, in collection order.
+func (srm *SingleRegionManager) reportSkippedBatches(progress *models.ScrapeProgress, batchResults []instanceBatches, pending map[string]*pendingInstance) {
+	for _, result := range batchResults {
+		tracked := pending[result.instance.ResourceID]
+		if tracked == nil {
+			continue
+		}
+		if remaining := tracked.remaining.Load(); remaining > 0 {
+			progress.InstanceCompleted(models.InstanceProgress{
+				Region:         srm.region,
+				Identifier:     tracked.instance.Identifier,
+				Batches:        tracked.batches,
+				FailedBatches:  int(tracked.failed.Load()),
+				SkippedBatches: int(remaining),
+			})
+		}
+	}
+}
+
+// schedule runs a Performance Insights request, first waiting for a slot from the shared scheduler if one is configured.
+func (srm *SingleRegionManager) schedule(ctx context.Context, priority models.ScrapePriority, request func() error) error {
+	if srm.scheduler == nil {
+		return request()
+	}
+
+	if err := srm.scheduler.Acquire(ctx, priority); err != nil {
+		return err
+	}
+	defer srm.scheduler.Release()
+
+	return request()
+}
+
+// collectBatch collects a metric batch once the adaptive limiter grants a slot, so that the number of
+// GetResourceMetrics calls in flight shrinks while Performance Insights throttles the region.
+func (srm *SingleRegionManager) collectBatch(ctx context.Context, priority models.ScrapePriority, req metricRequest, ch chan<- prometheus.Metric) error {
+	if err := srm.limiter.Acquire(ctx); err != nil {
+		return err
+	}
+
+	err := srm.schedule(ctx, priority, func() error {
+		return srm.metricManager.CollectMetricsForBatch(ctx, req.instance, req.metricsBatch, ch)
+	})
+	srm.limiter.Release(err)
+	return err
+}
+
+func isPerformanceInsightsUnsupported(err error) bool {
+	return errors.Is(err, metric.ErrPerformanceInsightsUnsupported)
+}
+
+func (srm *SingleRegionManager) emitInstancePIUnsupported(ch chan<- prometheus.Metric, instance models.Instance) {
+	metric, err := formatting.NewInstancePIUnsupportedMetric(srm.prometheusConfig, instance)
+	if err != nil {
+		srm.logger.Error("Error creating unsupported instance metric", "identifier", instance.Identifier, "error", err)
+		return
+	}
+	ch <- metric
+}
+
+// emitScrapeInstanceErrors reports the error count of every failed instance, in collection order.
+func (srm *SingleRegionManager) emitScrapeInstanceErrors(ch chan<- prometheus.Metric, instances []models.Instance, failedInstances map[string]int) {
+	for _, instance := range instances {
+		count, failed := failedInstances[instance.ResourceID]
+		if !failed {
+			continue
+		}
+		metric, err := formatting.NewScrapeInstanceErrorsMetric(srm.prometheusConfig, srm.region, instance, count)
+		if err != nil {
+			srm.logger.Error("Error creating scrape instance errors metric", "identifier", instance.Identifier, "error", err)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+// emitInstanceScrapeMetrics reports the collection time of every instance Performance Insights supports, from fetching
+// its metric batches to collecting its last batch, and whether all its batches were collected without error.
+// Instances with batches skipped by the batch limit or a cancelled scrape are reported as down.
+func (srm *SingleRegionManager) emitInstanceScrapeMetrics(ch chan<- prometheus.Metric, batchResults []instanceBatches, failedInstances map[string]int, collected map[string]collectedInstance) {
+	for _, result := range batchResults {
+		if isPerformanceInsightsUnsupported(result.err) {
+			continue
+		}
+
+		instanceCollected := collected[result.instance.ResourceID]
+		finished := result.fetched
+		if instanceCollected.finished.After(finished) {
+			finished = instanceCollected.finished
+		}
+		_, failed := failedInstances[result.instance.ResourceID]
+		up := !failed && instanceCollected.batches == len(result.batches)
+
+		durationMetric, err := formatting.NewInstanceScrapeDurationMetric(srm.prometheusConfig, srm.region, result.instance, finished.Sub(result.started))
+		if err != nil {
+			srm.logger.Error("Error creating instance scrape duration metric", "identifier", result.instance.Identifier, "error", err)
+		} else {
+			ch <- durationMetric
+		}
+
+		upMetric, err := formatting.NewInstanceUpMetric(srm.prometheusConfig, srm.region, result.instance, up)
+		if err != nil {
+			srm.logger.Error("Error creating instance up metric", "identifier", result.instance.Identifier, "error", err)
+		} else {
+			ch <- upMetric
+		}
+	}
+}
+
+// emitInstanceInfo emits the instance info metric of every instance to collect when export.prometheus.instance-info-metric
+// is enabled, including the instances Performance Insights does not support.
+func (srm *SingleRegionManager) emitInstanceInfo(ch chan<- prometheus.Metric, instances []models.Instance) {
+	if !srm.prometheusConfig.InstanceInfo {
+		return
+	}
+
+	for _, instance := range instances {
+		metric, err := formatting.NewInstanceInfoMetric(srm.prometheusConfig, srm.region, instance)
+		if err != nil {
+			srm.logger.Error("Error creating instance info metric", "identifier", instance.Identifier, "error", err)
+			continue
+		}
+		ch <- metric
+	}
+}
+
+// withCollectionRegion returns a copy of instances with CollectionRegion set to the region of the manager
+// when the collection_region label is enabled in export.prometheus.extra-labels or export.prometheus.region-label
+// is in effect, and instances unchanged otherwise.
+func (srm *SingleRegionManager) withCollectionRegion(instances []models.Instance) []models.Instance {
+	if !srm.prometheusConfig.RegionLabel && !slices.Contains(srm.prometheusConfig.ExtraLabels, "collection_region") {
+		return instances
+	}
+
+	stamped := make([]models.Instance, len(instances))
+	for i, instance := range instances {
+		instance.CollectionRegion = srm.region
+		stamped[i] = instance
+	}
+	return stamped
+}
+
+// withRefreshedMetadata returns a copy of instances with empty metrics when the scrape carried by ctx refreshes
+// metadata, so their metric metadata is listed again for this scrape only and the cached metrics of the instances are
+// left to the scrapes that don't refresh. Instances are returned unchanged otherwise.
+func (srm *SingleRegionManager) withRefreshedMetadata(ctx context.Context, instances []models.Instance) []models.Instance {
+	if !models.ScrapeRefreshFromContext(ctx).Metadata() {
+		return instances
+	}
+
+	refreshed := make([]models.Instance, len(instances))
+	for i, instance := range instances {
+		instance.Metrics = &models.Metrics{MetadataTTL: srm.metadataTTL}
+		refreshed[i] = instance
+	}
+	return refreshed
+}
+
+// emitHeartbeat emits the exporter time metric when export.prometheus.heartbeat-metric is enabled.
+// It is emitted before discovery so a scrape that finds no instances or fails still proves it ran.
+func (srm *SingleRegionManager) emitHeartbeat(ch chan<- prometheus.Metric) {
+	if !srm.prometheusConfig.HeartbeatMetric {
+		return
+	}
+
+	metric, err := formatting.NewExporterTimeMetric(srm.prometheusConfig, srm.region, time.Now())
+	if err != nil {
+		srm.logger.Error("Error creating exporter time metric", "error", err)
+		return
+	}
+	ch <- metric
+}
+
+// emitEffectiveSettings emits the concurrency and batch size in effect when export.prometheus.effective-settings-metrics
+// is enabled, so operators can verify the values used after config validation.
+func (srm *SingleRegionManager) emitEffectiveSettings(ch chan<- prometheus.Metric) {
+	if !srm.prometheusConfig.EffectiveSettings {
+		return
+	}
+
+	concurrency, err := formatting.NewEffectiveConcurrencyMetric(srm.prometheusConfig, srm.region, srm.collectionConcurrency)
+	if err != nil {
+		srm.logger.Error("Error creating effective concurrency metric", "error", err)
+		return
+	}
+	ch <- concurrency
+
+	adaptiveConcurrency, err := formatting.NewAdaptiveConcurrencyMetric(srm.prometheusConfig, srm.region, srm.limiter.Limit())
+	if err != nil {
+		srm.logger.Error("Error creating adaptive concurrency metric", "error", err)
+		return
+	}
+	ch <- adaptiveConcurrency
+
+	batchSize, err := formatting.NewEffectiveBatchSizeMetric(srm.prometheusConfig, srm.region, srm.batchSize)
+	if err != nil {
+		srm.logger.Error("Error creating effective batch size metric", "error", err)
+		return
+	}
+	ch <- batchSize
+}
+
+// emitInstanceLimitReached reports whether discovery was truncated by instances.max-instances, when the instance
+// provider caps the discovered instances.
+func (srm *SingleRegionManager) emitInstanceLimitReached(ch chan<- prometheus.Metric) {
+	reporter, ok := srm.instanceManager.(instance.InstanceLimitReporter)
+	if !ok {
+		return
+	}
+
+	metric, err := formatting.NewInstanceLimitReachedMetric(srm.prometheusConfig, srm.region, reporter.InstanceLimitReached())
+	if err != nil {
+		srm.logger.Error("Error creating instance limit metric", "error", err)
+		return
+	}
+	ch <- metric
+}
+
+func (srm *SingleRegionManager) emitBatchLimitReached(ch chan<- prometheus.Metric, reached bool) {
+	metric, err := formatting.NewBatchLimitReachedMetric(srm.prometheusConfig, srm.region, reached)
+	if err != nil {
+		srm.logger.Error("Error creating batch limit metric", "error", err)
+		return
+	}
+	ch <- metric
+}
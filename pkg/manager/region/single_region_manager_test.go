@@ -3,12 +3,20 @@ package region
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/instance"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/metric"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
@@ -22,14 +30,65 @@ func TestNewSingleRegionManager(t *testing.T) {
 		region := "us-west-2"
 
 		concurrency := utils.DefaultConcurrency
-		manager := NewSingleRegionManager(region, mockInstanceProvider, mockMetricProvider, concurrency)
+		config := testutils.NewTestConfigBuilder().WithConcurrency(concurrency).WithMaxBatchesPerScrape(10).Build()
+		manager := NewSingleRegionManager(region, mockInstanceProvider, mockMetricProvider, config)
 
 		assert.NotNil(t, manager)
 		assert.Equal(t, region, manager.region)
 		assert.Equal(t, mockInstanceProvider, manager.instanceManager)
 		assert.Equal(t, mockMetricProvider, manager.metricManager)
-		assert.Equal(t, concurrency, manager.maxConcurrency)
+		assert.Equal(t, concurrency, manager.discoveryConcurrency)
+		assert.Equal(t, concurrency, manager.collectionConcurrency)
+		assert.Equal(t, utils.DefaultProducerConcurrency, manager.producerConcurrency)
+		assert.Equal(t, 10, manager.maxBatchesPerScrape)
+		assert.Equal(t, config.Export.Prometheus, manager.prometheusConfig)
+		assert.Nil(t, manager.scheduler)
 	})
+
+	t.Run("creates shared scheduler when targeted scrapes have high priority", func(t *testing.T) {
+		config := testutils.NewTestConfigBuilder().WithTargetedScrapePriority(models.ScrapePriorityHigh).Build()
+		manager := NewSingleRegionManager("us-west-2", &mocks.MockInstanceProvider{}, &mocks.MockMetricProvider{}, config)
+
+		assert.Equal(t, models.ScrapePriorityHigh, manager.targetedPriority)
+		require.NotNil(t, manager.scheduler)
+		assert.Equal(t, config.Discovery.Processing.Concurrency, manager.scheduler.capacity)
+	})
+
+	t.Run("uses separate discovery and collection concurrency", func(t *testing.T) {
+		config := testutils.NewTestConfigBuilder().
+			WithDiscoveryConcurrency(1).
+			WithCollectionConcurrency(3).
+			WithTargetedScrapePriority(models.ScrapePriorityHigh).
+			Build()
+		manager := NewSingleRegionManager("us-west-2", &mocks.MockInstanceProvider{}, &mocks.MockMetricProvider{}, config)
+
+		assert.Equal(t, 1, manager.discoveryConcurrency)
+		assert.Equal(t, 3, manager.collectionConcurrency)
+		require.NotNil(t, manager.scheduler)
+		assert.Equal(t, 3, manager.scheduler.capacity, "the scheduler shares GetResourceMetrics slots")
+	})
+}
+
+func TestCollectMetricsForInstancesWithHighPriority(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	config := testutils.NewTestConfigBuilder().WithTargetedScrapePriority(models.ScrapePriorityHigh).Build()
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, config)
+
+	mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).
+		Return([][]string{testutils.TestMetricNamesWithStatsSmall}, nil)
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstancePostgreSQL, mock.Anything, mock.Anything).
+		Return(nil)
+
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetricsForInstances(context.Background(), []string{testutils.TestInstancePostgreSQL.Identifier}, ch)
+	close(ch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, manager.scheduler.inUse, "all scheduler slots should be released")
+	mockIP.AssertExpectations(t)
+	mockMP.AssertExpectations(t)
 }
 
 func TestCollectMetrics(t *testing.T) {
@@ -79,20 +138,29 @@ func TestCollectMetrics(t *testing.T) {
 			shouldCallGetInstances: true,
 		},
 		{
-			name:                   "collect metrics with first instance error (fail fast)",
+			name:                   "collect metrics with first instance error serves the second instance",
 			instances:              testutils.TestInstances,
 			getInstancesError:      nil,
 			collectMetricsErrors:   []error{errors.New("metric collection failed"), nil},
-			expectedError:          errors.New("metric collection failed"),
+			expectedError:          nil,
 			expectedMetricCalls:    1,
 			shouldCallGetInstances: true,
 		},
 		{
-			name:                   "collect metrics with second instance error",
+			name:                   "collect metrics with second instance error serves the first instance",
 			instances:              testutils.TestInstances,
 			getInstancesError:      nil,
 			collectMetricsErrors:   []error{nil, errors.New("second instance failed")},
-			expectedError:          errors.New("second instance failed"),
+			expectedError:          nil,
+			expectedMetricCalls:    2,
+			shouldCallGetInstances: true,
+		},
+		{
+			name:                   "collect metrics with every instance error",
+			instances:              testutils.TestInstances,
+			getInstancesError:      nil,
+			collectMetricsErrors:   []error{errors.New("metric collection failed"), errors.New("metric collection failed")},
+			expectedError:          errors.New("metric collection failed"),
 			expectedMetricCalls:    2,
 			shouldCallGetInstances: true,
 		},
@@ -102,7 +170,7 @@ func TestCollectMetrics(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockIP := &mocks.MockInstanceProvider{}
 			mockMP := &mocks.MockMetricProvider{}
-			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, utils.DefaultConcurrency)
+			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
 
 			if tc.shouldCallGetInstances {
 				mockIP.On("GetInstances", mock.Anything).
@@ -118,7 +186,7 @@ func TestCollectMetrics(t *testing.T) {
 						Return(batches, nil).Maybe()
 
 					// CollectMetricsForBatch is called for each batch
-					if tc.expectedError != nil && i < len(tc.collectMetricsErrors) && tc.collectMetricsErrors[i] != nil {
+					if i < len(tc.collectMetricsErrors) && tc.collectMetricsErrors[i] != nil {
 						mockMP.On("CollectMetricsForBatch", mock.Anything, instance, mock.Anything, mock.Anything).
 							Return(tc.collectMetricsErrors[i]).Maybe()
 					} else {
@@ -146,6 +214,704 @@ func TestCollectMetrics(t *testing.T) {
 	}
 }
 
+func TestCollectMetricsRecordsScrapeStats(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
+
+	mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstanceMySQL).
+		Return([][]string{testutils.TestMetricNamesWithStatsSmall}, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).
+		Return(nil, errors.New("list metrics failed"))
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstanceMySQL, mock.Anything, mock.Anything).
+		Return(nil)
+
+	stats := models.NewScrapeStats()
+	ctx := models.ContextWithScrapeStats(context.Background(), stats)
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetrics(ctx, ch)
+	close(ch)
+
+	assert.NoError(t, err, "a scrape with a collected instance succeeds")
+	assert.Equal(t, int64(1), stats.RegionsScraped())
+	assert.Equal(t, int64(2), stats.InstancesDiscovered())
+	assert.Equal(t, int64(1), stats.InstancesCollected())
+	assert.Equal(t, int64(1), stats.InstancesFailed())
+	assert.Equal(t, int64(1), stats.Errors())
+
+	mockIP.AssertExpectations(t)
+	mockMP.AssertExpectations(t)
+}
+
+func TestCollectMetricsRecordsFailedInstances(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
+
+	mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstanceMySQL).
+		Return([][]string{{"os.general.numVCPUs.avg"}, {"os.cpuUtilization.idle.avg"}}, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).
+		Return([][]string{{"os.general.numVCPUs.avg"}}, nil)
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstanceMySQL, mock.Anything, mock.Anything).
+		Return(errors.New("get resource metrics failed"))
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstancePostgreSQL, mock.Anything, mock.Anything).
+		Return(nil)
+
+	stats := models.NewScrapeStats()
+	ctx := models.ContextWithScrapeStats(context.Background(), stats)
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetrics(ctx, ch)
+	close(ch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), stats.InstancesCollected())
+	assert.Equal(t, int64(1), stats.InstancesFailed(), "an instance with several failed batches counts once")
+	assert.Equal(t, int64(2), stats.Errors())
+}
+
+func TestCollectMetricsWithScrapeInstanceErrors(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
+
+	mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstanceMySQL).
+		Return([][]string{{"os.general.numVCPUs.avg"}, {"os.cpuUtilization.idle.avg"}}, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).
+		Return([][]string{{"os.general.numVCPUs.avg"}}, nil)
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstanceMySQL, mock.Anything, mock.Anything).
+		Return(errors.New("throttled"))
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstancePostgreSQL, mock.Anything, mock.Anything).
+		Return(nil)
+
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetrics(context.Background(), ch)
+	close(ch)
+
+	assert.NoError(t, err, "a scrape with a collected instance succeeds")
+
+	instanceErrors := make(map[string]float64)
+	for metric := range ch {
+		if !strings.Contains(metric.Desc().String(), `"dbi_scrape_instance_errors"`) {
+			continue
+		}
+		var written dto.Metric
+		require.NoError(t, metric.Write(&written))
+		for _, label := range written.GetLabel() {
+			if label.GetName() == "identifier" {
+				instanceErrors[label.GetValue()] = written.GetGauge().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{testutils.TestInstanceMySQL.Identifier: 2}, instanceErrors)
+}
+
+func TestCollectMetricsWithInstanceScrapeMetrics(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
+
+	mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstanceMySQL).
+		Return([][]string{{"os.general.numVCPUs.avg"}, {"os.cpuUtilization.idle.avg"}}, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).
+		Return([][]string{{"os.general.numVCPUs.avg"}}, nil)
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstanceMySQL, []string{"os.general.numVCPUs.avg"}, mock.Anything).
+		Return(nil)
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstanceMySQL, []string{"os.cpuUtilization.idle.avg"}, mock.Anything).
+		Return(errors.New("throttled"))
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstancePostgreSQL, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { time.Sleep(10 * time.Millisecond) }).
+		Return(nil)
+
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetrics(context.Background(), ch)
+	close(ch)
+
+	require.NoError(t, err)
+
+	up := make(map[string]float64)
+	durations := make(map[string]float64)
+	for metric := range ch {
+		desc := metric.Desc().String()
+		var values map[string]float64
+		switch {
+		case strings.Contains(desc, `"dbi_instance_up"`):
+			values = up
+		case strings.Contains(desc, `"dbi_instance_scrape_duration_seconds"`):
+			values = durations
+		default:
+			continue
+		}
+
+		var written dto.Metric
+		require.NoError(t, metric.Write(&written))
+		labels := make(map[string]string, len(written.GetLabel()))
+		for _, label := range written.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		assert.Equal(t, "us-west-2", labels["region"])
+		values[labels["identifier"]] = written.GetGauge().GetValue()
+	}
+
+	assert.Equal(t, map[string]float64{
+		testutils.TestInstanceMySQL.Identifier:      0,
+		testutils.TestInstancePostgreSQL.Identifier: 1,
+	}, up)
+	require.Len(t, durations, 2)
+	assert.GreaterOrEqual(t, durations[testutils.TestInstancePostgreSQL.Identifier], 0.01)
+	assert.GreaterOrEqual(t, durations[testutils.TestInstanceMySQL.Identifier], 0.0)
+}
+
+func TestCollectMetricsWithBatchLimit(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		maxBatchesPerScrape  int
+		producerConcurrency  int
+		expectedBatchCalls   int
+		expectedLimitMetric  bool
+		expectedLimitReached float64
+	}{
+		{
+			name:                "no limit collects every batch",
+			maxBatchesPerScrape: 0,
+			expectedBatchCalls:  4,
+		},
+		{
+			name:                 "limit above batch count collects every batch",
+			maxBatchesPerScrape:  10,
+			expectedBatchCalls:   4,
+			expectedLimitMetric:  true,
+			expectedLimitReached: 0,
+		},
+		{
+			name:                 "limit stops queueing further batches",
+			maxBatchesPerScrape:  3,
+			expectedBatchCalls:   3,
+			expectedLimitMetric:  true,
+			expectedLimitReached: 1,
+		},
+		{
+			name:                "multiple producers collect every batch",
+			maxBatchesPerScrape: 0,
+			producerConcurrency: 4,
+			expectedBatchCalls:  4,
+		},
+		{
+			name:                 "multiple producers respect the limit",
+			maxBatchesPerScrape:  3,
+			producerConcurrency:  4,
+			expectedBatchCalls:   3,
+			expectedLimitMetric:  true,
+			expectedLimitReached: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockIP := &mocks.MockInstanceProvider{}
+			mockMP := &mocks.MockMetricProvider{}
+			config := testutils.NewTestConfigBuilder().
+				WithMaxBatchesPerScrape(tc.maxBatchesPerScrape).
+				WithProducerConcurrency(max(tc.producerConcurrency, 1)).
+				Build()
+			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, config)
+
+			batches := [][]string{{"os.general.numVCPUs.avg"}, {"os.cpuUtilization.idle.avg"}}
+			mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+			mockMP.On("GetMetricBatches", mock.Anything, mock.Anything).Return(batches, nil)
+			mockMP.On("CollectMetricsForBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			ch := make(chan prometheus.Metric, 100)
+			err := manager.CollectMetrics(context.Background(), ch)
+			close(ch)
+
+			assert.NoError(t, err)
+			mockMP.AssertNumberOfCalls(t, "CollectMetricsForBatch", tc.expectedBatchCalls)
+
+			var limitMetrics []prometheus.Metric
+			for metric := range ch {
+				if strings.Contains(metric.Desc().String(), "dbi_batch_limit_reached") {
+					limitMetrics = append(limitMetrics, metric)
+				}
+			}
+
+			if !tc.expectedLimitMetric {
+				assert.Empty(t, limitMetrics)
+				return
+			}
+
+			require.Len(t, limitMetrics, 1)
+			var written dto.Metric
+			require.NoError(t, limitMetrics[0].Write(&written))
+			assert.Equal(t, tc.expectedLimitReached, written.GetGauge().GetValue())
+		})
+	}
+}
+
+func TestCollectMetricsWithCollectionOrder(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	critical := testutils.NewTestInstance("db-CRITICAL", "critical-db", models.AuroraPostgreSQL)
+	critical.Tags = map[string]string{"Priority": "critical"}
+	config := testutils.NewTestConfigBuilder().
+		WithConcurrency(1).
+		WithCollectionOrder(models.ParsedCollectionOrderConfig{
+			Identifiers: []string{testutils.TestInstanceMySQL.Identifier},
+			Tag:         "Priority",
+			TagValues:   []string{"critical"},
+		}).
+		Build()
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, config)
+
+	instances := []models.Instance{testutils.TestInstancePostgreSQL, critical, testutils.TestInstanceMySQL}
+	var collected []string
+	mockIP.On("GetInstances", mock.Anything).Return(instances, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, mock.Anything).Return([][]string{{"os.general.numVCPUs.avg"}}, nil)
+	mockMP.On("CollectMetricsForBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			collected = append(collected, args.Get(1).(models.Instance).Identifier)
+		}).
+		Return(nil)
+
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetrics(context.Background(), ch)
+	close(ch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testutils.TestInstanceMySQL.Identifier, critical.Identifier, testutils.TestInstancePostgreSQL.Identifier}, collected)
+}
+
+func TestCollectMetricsWithCollectionRegion(t *testing.T) {
+	testCases := []struct {
+		name                     string
+		extraLabels              []string
+		regionLabel              bool
+		expectedCollectionRegion string
+	}{
+		{
+			name:                     "collection_region label enabled",
+			extraLabels:              []string{"region", "collection_region"},
+			expectedCollectionRegion: "eu-west-1",
+		},
+		{
+			name:                     "region label enabled",
+			regionLabel:              true,
+			expectedCollectionRegion: "eu-west-1",
+		},
+		{
+			name:                     "collection_region label disabled",
+			extraLabels:              []string{"region"},
+			expectedCollectionRegion: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockIP := &mocks.MockInstanceProvider{}
+			mockMP := &mocks.MockMetricProvider{}
+			config := testutils.NewTestConfigBuilder().WithExtraLabels(tc.extraLabels...).WithRegionLabel(tc.regionLabel).Build()
+			manager := NewSingleRegionManager("eu-west-1", mockIP, mockMP, config)
+
+			instances := []models.Instance{testutils.TestInstancePostgreSQL}
+			var collected []models.Instance
+			mockIP.On("GetInstances", mock.Anything).Return(instances, nil)
+			mockMP.On("GetMetricBatches", mock.Anything, mock.Anything).Return([][]string{{"os.general.numVCPUs.avg"}}, nil)
+			mockMP.On("CollectMetricsForBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					collected = append(collected, args.Get(1).(models.Instance))
+				}).
+				Return(nil)
+
+			ch := make(chan prometheus.Metric, 100)
+			err := manager.CollectMetrics(context.Background(), ch)
+			close(ch)
+
+			assert.NoError(t, err)
+			if assert.Len(t, collected, 1) {
+				assert.Equal(t, tc.expectedCollectionRegion, collected[0].CollectionRegion)
+			}
+			assert.Empty(t, instances[0].CollectionRegion, "discovered instances should not be modified")
+		})
+	}
+}
+
+func TestCollectMetricsWithRefreshedMetadata(t *testing.T) {
+	testCases := []struct {
+		name           string
+		refresh        models.ScrapeRefresh
+		expectedCached bool
+	}{
+		{
+			name:           "metadata refresh collects with empty metrics",
+			refresh:        models.ScrapeRefreshMetadata,
+			expectedCached: false,
+		},
+		{
+			name:           "instances refresh collects with the cached metrics",
+			refresh:        models.ScrapeRefreshInstances,
+			expectedCached: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockIP := &mocks.MockInstanceProvider{}
+			mockMP := &mocks.MockMetricProvider{}
+			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
+
+			instances := []models.Instance{testutils.TestInstancePostgreSQL}
+			var batched []models.Instance
+			mockIP.On("GetInstances", mock.Anything).Return(instances, nil)
+			mockMP.On("GetMetricBatches", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					batched = append(batched, args.Get(1).(models.Instance))
+				}).
+				Return([][]string{{"os.general.numVCPUs.avg"}}, nil)
+			mockMP.On("CollectMetricsForBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			ch := make(chan prometheus.Metric, 100)
+			err := manager.CollectMetrics(models.ContextWithScrapeRefresh(context.Background(), tc.refresh), ch)
+			close(ch)
+
+			assert.NoError(t, err)
+			if assert.Len(t, batched, 1) {
+				if tc.expectedCached {
+					assert.Same(t, instances[0].Metrics, batched[0].Metrics)
+				} else {
+					assert.NotSame(t, instances[0].Metrics, batched[0].Metrics)
+					assert.True(t, batched[0].Metrics.MetricsLastUpdated.IsZero())
+				}
+			}
+			assert.False(t, instances[0].Metrics.MetricsLastUpdated.IsZero(), "cached metrics should not be modified")
+		})
+	}
+}
+
+func TestCollectMetricsWithDiscoveredMetricNames(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	config := testutils.NewTestConfigBuilder().WithDiscoveredMetricNames(true).Build()
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, config)
+
+	mockIP.On("GetInstances", mock.Anything).Return([]models.Instance{testutils.TestInstancePostgreSQL}, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).Return([][]string{{"os.general.numVCPUs.avg"}}, nil)
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstancePostgreSQL, mock.Anything, mock.Anything).Return(nil)
+	mockMP.On("CollectDiscoveredMetricNames", "us-west-2", mock.Anything).Return().Once()
+
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetrics(context.Background(), ch)
+	close(ch)
+
+	assert.NoError(t, err)
+	mockMP.AssertExpectations(t)
+}
+
+func TestCollectMetricsWithDataPointsReturned(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	config := testutils.NewTestConfigBuilder().WithDataPointsReturned(true).Build()
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, config)
+
+	mockIP.On("GetInstances", mock.Anything).Return([]models.Instance{testutils.TestInstancePostgreSQL}, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).Return([][]string{{"os.general.numVCPUs.avg"}}, nil)
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstancePostgreSQL, mock.Anything, mock.Anything).Return(nil)
+	mockMP.On("CollectDataPointsReturned", "us-west-2", mock.Anything).Return().Once()
+
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetrics(context.Background(), ch)
+	close(ch)
+
+	assert.NoError(t, err)
+	mockMP.AssertExpectations(t)
+}
+
+func TestCollectMetricsWithConversionErrors(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	config := testutils.NewTestConfigBuilder().WithConversionErrors(true).Build()
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, config)
+
+	mockIP.On("GetInstances", mock.Anything).Return([]models.Instance{testutils.TestInstancePostgreSQL}, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).Return([][]string{{"os.general.numVCPUs.avg"}}, nil)
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstancePostgreSQL, mock.Anything, mock.Anything).Return(nil)
+	mockMP.On("CollectConversionErrors", "us-west-2", mock.Anything).Return().Once()
+
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetrics(context.Background(), ch)
+	close(ch)
+
+	assert.NoError(t, err)
+	mockMP.AssertExpectations(t)
+}
+
+func TestCollectMetricsWithPostProcessors(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	config := testutils.NewTestConfigBuilder().WithPostProcessors(models.PostProcessorMemoryFreePercent).Build()
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, config)
+
+	hasCollectedMetricData := mock.MatchedBy(func(ctx context.Context) bool {
+		return models.CollectedMetricDataFromContext(ctx) != nil
+	})
+
+	mockIP.On("GetInstances", mock.Anything).Return([]models.Instance{testutils.TestInstancePostgreSQL}, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).Return([][]string{{"os.memory.free.avg", "os.memory.total.avg"}}, nil)
+	mockMP.On("CollectMetricsForBatch", hasCollectedMetricData, testutils.TestInstancePostgreSQL, mock.Anything, mock.Anything).Return(nil)
+	mockMP.On("CollectPostProcessedMetrics", hasCollectedMetricData, mock.Anything).Return().Once()
+
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetrics(context.Background(), ch)
+	close(ch)
+
+	assert.NoError(t, err)
+	mockMP.AssertExpectations(t)
+}
+
+func TestCollectMetricsWithHeartbeatMetric(t *testing.T) {
+	testCases := []struct {
+		name              string
+		heartbeat         bool
+		expectedHeartbeat int
+	}{
+		{
+			name:              "heartbeat disabled",
+			heartbeat:         false,
+			expectedHeartbeat: 0,
+		},
+		{
+			name:              "heartbeat emitted even when discovery fails",
+			heartbeat:         true,
+			expectedHeartbeat: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockIP := &mocks.MockInstanceProvider{}
+			mockMP := &mocks.MockMetricProvider{}
+			config := testutils.NewTestConfigBuilder().WithHeartbeatMetric(tc.heartbeat).Build()
+			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, config)
+
+			mockIP.On("GetInstances", mock.Anything).Return(nil, errors.New("failed to get instances"))
+
+			before := time.Now()
+			ch := make(chan prometheus.Metric, 10)
+			err := manager.CollectMetrics(context.Background(), ch)
+			close(ch)
+
+			assert.Error(t, err)
+			heartbeats := 0
+			for metric := range ch {
+				require.Contains(t, metric.Desc().String(), `"dbi_exporter_time_seconds"`)
+				var written dto.Metric
+				require.NoError(t, metric.Write(&written))
+				assert.GreaterOrEqual(t, written.GetGauge().GetValue(), float64(before.Unix()))
+				heartbeats++
+			}
+			assert.Equal(t, tc.expectedHeartbeat, heartbeats)
+		})
+	}
+}
+
+type limitReportingInstanceProvider struct {
+	*mocks.MockInstanceProvider
+	limitReached bool
+}
+
+func (provider *limitReportingInstanceProvider) InstanceLimitReached() bool {
+	return provider.limitReached
+}
+
+func TestCollectMetricsWithInstanceLimit(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		reportsLimit         bool
+		limitReached         bool
+		expectedLimitMetric  bool
+		expectedLimitReached float64
+	}{
+		{
+			name:                "provider without a limit emits no metric",
+			reportsLimit:        false,
+			expectedLimitMetric: false,
+		},
+		{
+			name:                 "limit not reached",
+			reportsLimit:         true,
+			limitReached:         false,
+			expectedLimitMetric:  true,
+			expectedLimitReached: 0,
+		},
+		{
+			name:                 "limit reached",
+			reportsLimit:         true,
+			limitReached:         true,
+			expectedLimitMetric:  true,
+			expectedLimitReached: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockIP := &mocks.MockInstanceProvider{}
+			mockMP := &mocks.MockMetricProvider{}
+			var provider instance.InstanceProvider = mockIP
+			if tc.reportsLimit {
+				provider = &limitReportingInstanceProvider{MockInstanceProvider: mockIP, limitReached: tc.limitReached}
+			}
+			manager := NewSingleRegionManager("us-west-2", provider, mockMP, testutils.CreateDefaultParsedTestConfig())
+
+			mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+			mockMP.On("GetMetricBatches", mock.Anything, mock.Anything).Return([][]string{}, nil)
+
+			ch := make(chan prometheus.Metric, 100)
+			err := manager.CollectMetrics(context.Background(), ch)
+			close(ch)
+
+			assert.NoError(t, err)
+			var limitMetrics []prometheus.Metric
+			for metric := range ch {
+				if strings.Contains(metric.Desc().String(), "dbi_instance_limit_reached") {
+					limitMetrics = append(limitMetrics, metric)
+				}
+			}
+
+			if !tc.expectedLimitMetric {
+				assert.Empty(t, limitMetrics)
+				return
+			}
+
+			require.Len(t, limitMetrics, 1)
+			var written dto.Metric
+			require.NoError(t, limitMetrics[0].Write(&written))
+			assert.Equal(t, tc.expectedLimitReached, written.GetGauge().GetValue())
+		})
+	}
+}
+
+func TestCollectMetricsWithInstanceInfo(t *testing.T) {
+	testCases := []struct {
+		name                string
+		instanceInfo        bool
+		expectedIdentifiers []string
+	}{
+		{
+			name:                "instance info disabled",
+			instanceInfo:        false,
+			expectedIdentifiers: nil,
+		},
+		{
+			name:                "instance info emitted for every instance",
+			instanceInfo:        true,
+			expectedIdentifiers: []string{"test-postgres-db", "test-mysql-db"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockIP := &mocks.MockInstanceProvider{}
+			mockMP := &mocks.MockMetricProvider{}
+			config := testutils.NewTestConfigBuilder().WithInstanceInfo(tc.instanceInfo).Build()
+			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, config)
+
+			mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+			mockMP.On("GetMetricBatches", mock.Anything, mock.Anything).Return([][]string{}, nil)
+
+			ch := make(chan prometheus.Metric, 100)
+			err := manager.CollectMetrics(context.Background(), ch)
+			close(ch)
+
+			assert.NoError(t, err)
+			var identifiers []string
+			for metric := range ch {
+				if !strings.Contains(metric.Desc().String(), `"dbi_instance_info"`) {
+					continue
+				}
+				var written dto.Metric
+				require.NoError(t, metric.Write(&written))
+				for _, label := range written.GetLabel() {
+					if label.GetName() == "identifier" {
+						identifiers = append(identifiers, label.GetValue())
+					}
+				}
+			}
+			assert.ElementsMatch(t, tc.expectedIdentifiers, identifiers)
+		})
+	}
+}
+
+func TestCollectMetricsWithEffectiveSettings(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	config := testutils.NewTestConfigBuilder().WithConcurrency(8).WithEffectiveSettings(true).Build()
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, config)
+
+	mockIP.On("GetInstances", mock.Anything).Return(nil, errors.New("failed to get instances"))
+
+	ch := make(chan prometheus.Metric, 10)
+	err := manager.CollectMetrics(context.Background(), ch)
+	close(ch)
+
+	assert.Error(t, err)
+	values := make(map[string]float64)
+	for metric := range ch {
+		var written dto.Metric
+		require.NoError(t, metric.Write(&written))
+		require.Len(t, written.GetLabel(), 1)
+		assert.Equal(t, "us-west-2", written.GetLabel()[0].GetValue())
+		values[metric.Desc().String()] = written.GetGauge().GetValue()
+	}
+	require.Len(t, values, 3)
+	for desc, value := range values {
+		switch {
+		case strings.Contains(desc, `"dbi_effective_concurrency"`):
+			assert.Equal(t, 8.0, value)
+		case strings.Contains(desc, `"dbi_adaptive_concurrency"`):
+			assert.Equal(t, 8.0, value, "the adaptive limit starts at the collection concurrency")
+		case strings.Contains(desc, `"dbi_effective_batch_size"`):
+			assert.Equal(t, float64(utils.DefaultBatchSize), value)
+		default:
+			t.Errorf("unexpected metric %s", desc)
+		}
+	}
+}
+
+func TestCollectMetricsWithUnsupportedInstance(t *testing.T) {
+	mockIP := &mocks.MockInstanceProvider{}
+	mockMP := &mocks.MockMetricProvider{}
+	manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
+
+	mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).
+		Return(nil, fmt.Errorf("%w %s", metric.ErrPerformanceInsightsUnsupported, testutils.TestInstancePostgreSQL.Identifier))
+	mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstanceMySQL).Return([][]string{{"os.general.numVCPUs.avg"}}, nil)
+	mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstanceMySQL, mock.Anything, mock.Anything).Return(nil)
+
+	ch := make(chan prometheus.Metric, 100)
+	err := manager.CollectMetrics(context.Background(), ch)
+	close(ch)
+
+	assert.NoError(t, err, "unsupported instances must not fail the scrape")
+
+	var unsupported []string
+	for emitted := range ch {
+		if strings.Contains(emitted.Desc().String(), `"dbi_instance_pi_unsupported"`) {
+			var written dto.Metric
+			require.NoError(t, emitted.Write(&written))
+			for _, label := range written.GetLabel() {
+				if label.GetName() == "identifier" {
+					unsupported = append(unsupported, label.GetValue())
+				}
+			}
+		}
+	}
+	assert.Equal(t, []string{testutils.TestInstancePostgreSQL.Identifier}, unsupported)
+	mockMP.AssertNotCalled(t, "CollectMetricsForBatch", mock.Anything, testutils.TestInstancePostgreSQL, mock.Anything, mock.Anything)
+}
+
 func TestCollectMetricsForInstances(t *testing.T) {
 	testCases := []struct {
 		name                   string
@@ -228,32 +994,42 @@ func TestCollectMetricsForInstances(t *testing.T) {
 			shouldCallGetInstances: true,
 		},
 		{
-			name:                   "error during metric collection for first filtered instance (fail fast)",
+			name:                   "error during metric collection for first filtered instance serves the second instance",
 			instanceIdentifiers:    []string{"test-postgres-db", "test-mysql-db"},
 			instances:              testutils.TestInstances,
 			getInstancesError:      nil,
 			collectMetricsErrors:   []error{errors.New("metric collection failed"), nil},
-			expectedError:          errors.New("metric collection failed"),
+			expectedError:          nil,
 			expectedMetricCalls:    1,
 			shouldCallGetInstances: true,
 		},
 		{
-			name:                   "error during metric collection for second filtered instance",
+			name:                   "error during metric collection for second filtered instance serves the first instance",
 			instanceIdentifiers:    []string{"test-postgres-db", "test-mysql-db"},
 			instances:              testutils.TestInstances,
 			getInstancesError:      nil,
 			collectMetricsErrors:   []error{nil, errors.New("second instance failed")},
-			expectedError:          errors.New("second instance failed"),
+			expectedError:          nil,
 			expectedMetricCalls:    2,
 			shouldCallGetInstances: true,
 		},
+		{
+			name:                   "error during metric collection for the only filtered instance",
+			instanceIdentifiers:    []string{"test-postgres-db"},
+			instances:              testutils.TestInstances,
+			getInstancesError:      nil,
+			collectMetricsErrors:   []error{errors.New("metric collection failed")},
+			expectedError:          errors.New("metric collection failed"),
+			expectedMetricCalls:    1,
+			shouldCallGetInstances: true,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockIP := &mocks.MockInstanceProvider{}
 			mockMP := &mocks.MockMetricProvider{}
-			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, utils.DefaultConcurrency)
+			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
 
 			if tc.shouldCallGetInstances {
 				mockIP.On("GetInstances", mock.Anything).
@@ -279,7 +1055,7 @@ func TestCollectMetricsForInstances(t *testing.T) {
 						Return(batches, nil).Maybe()
 
 					// CollectMetricsForBatch is called for each batch
-					if tc.expectedError != nil && i < len(tc.collectMetricsErrors) && tc.collectMetricsErrors[i] != nil {
+					if i < len(tc.collectMetricsErrors) && tc.collectMetricsErrors[i] != nil {
 						mockMP.On("CollectMetricsForBatch", mock.Anything, instance, mock.Anything, mock.Anything).
 							Return(tc.collectMetricsErrors[i]).Maybe()
 					} else {
@@ -374,7 +1150,7 @@ func TestCollectMetricsWithQueue(t *testing.T) {
 			},
 			getBatchesErrors:          []error{errors.New("failed to get batches"), nil},
 			collectBatchErrors:        []error{nil},
-			expectedError:             errors.New("failed to get batches"),
+			expectedError:             nil,
 			expectedGetBatchesCalls:   2, // Continues to second instance
 			expectedCollectBatchCalls: 1, // Second instance batches are processed
 		},
@@ -415,7 +1191,7 @@ func TestCollectMetricsWithQueue(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockIP := &mocks.MockInstanceProvider{}
 			mockMP := &mocks.MockMetricProvider{}
-			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, utils.DefaultConcurrency)
+			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
 
 			mockIP.On("GetInstances", mock.Anything).
 				Return(tc.instances, nil)
@@ -581,7 +1357,7 @@ func TestFetchMetricBatchesInParallel(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockIP := &mocks.MockInstanceProvider{}
 			mockMP := &mocks.MockMetricProvider{}
-			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, tc.maxConcurrency)
+			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.NewTestConfigBuilder().WithConcurrency(tc.maxConcurrency).Build())
 
 			// Set up GetMetricBatches expectations
 			for i, instance := range tc.instances {
@@ -592,7 +1368,7 @@ func TestFetchMetricBatchesInParallel(t *testing.T) {
 			}
 
 			// Call the method
-			results := manager.fetchMetricBatchesInParallel(context.Background(), tc.instances)
+			results := manager.fetchMetricBatchesInParallel(context.Background(), models.ScrapePriorityNormal, tc.instances)
 
 			// Verify results
 			assert.Equal(t, tc.expectedResultCount, len(results), "Result count mismatch")
@@ -628,7 +1404,7 @@ func TestFetchMetricBatchesInParallelContextCancellation(t *testing.T) {
 	t.Run("context cancelled before API calls", func(t *testing.T) {
 		mockIP := &mocks.MockInstanceProvider{}
 		mockMP := &mocks.MockMetricProvider{}
-		manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, utils.DefaultConcurrency)
+		manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
 
 		instances := []models.Instance{
 			testutils.TestInstancePostgreSQL,
@@ -642,7 +1418,7 @@ func TestFetchMetricBatchesInParallelContextCancellation(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel() // Cancel immediately
 
-		results := manager.fetchMetricBatchesInParallel(ctx, instances)
+		results := manager.fetchMetricBatchesInParallel(ctx, models.ScrapePriorityNormal, instances)
 
 		// Should return results for all instances
 		assert.Equal(t, len(instances), len(results))
@@ -659,7 +1435,7 @@ func TestFetchMetricBatchesInParallelContextCancellation(t *testing.T) {
 	t.Run("context cancelled during API calls", func(t *testing.T) {
 		mockIP := &mocks.MockInstanceProvider{}
 		mockMP := &mocks.MockMetricProvider{}
-		manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, 1)
+		manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.NewTestConfigBuilder().WithConcurrency(1).Build())
 
 		instances := []models.Instance{
 			testutils.TestInstancePostgreSQL,
@@ -681,7 +1457,7 @@ func TestFetchMetricBatchesInParallelContextCancellation(t *testing.T) {
 		mockMP.On("GetMetricBatches", mock.Anything, mock.Anything).
 			Return([][]string{}, nil).Maybe()
 
-		results := manager.fetchMetricBatchesInParallel(ctx, instances)
+		results := manager.fetchMetricBatchesInParallel(ctx, models.ScrapePriorityNormal, instances)
 
 		// Should return results for all instances
 		assert.Equal(t, len(instances), len(results))
@@ -696,10 +1472,10 @@ func TestFetchMetricBatchesInParallelContextCancellation(t *testing.T) {
 }
 
 func TestFetchMetricBatchesInParallelConcurrencyLimit(t *testing.T) {
-	t.Run("respects maxConcurrency limit", func(t *testing.T) {
+	t.Run("respects discoveryConcurrency limit", func(t *testing.T) {
 		mockIP := &mocks.MockInstanceProvider{}
 		mockMP := &mocks.MockMetricProvider{}
-		manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, 2)
+		manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.NewTestConfigBuilder().WithDiscoveryConcurrency(2).Build())
 
 		// Create unique instances to avoid mock confusion
 		instances := []models.Instance{
@@ -716,7 +1492,7 @@ func TestFetchMetricBatchesInParallelConcurrencyLimit(t *testing.T) {
 				Return([][]string{{"metric1"}}, nil).Once()
 		}
 
-		results := manager.fetchMetricBatchesInParallel(context.Background(), instances)
+		results := manager.fetchMetricBatchesInParallel(context.Background(), models.ScrapePriorityNormal, instances)
 
 		assert.Equal(t, len(instances), len(results))
 
@@ -729,3 +1505,263 @@ func TestFetchMetricBatchesInParallelConcurrencyLimit(t *testing.T) {
 		mockMP.AssertExpectations(t)
 	})
 }
+
+func TestExplainExcludedMetrics(t *testing.T) {
+	excluded := []models.ExcludedMetric{{Name: "os.cpuUtilization.idle", Category: "os", Unit: "Percent", Reason: models.ExclusionReasonExcludePattern}}
+
+	testCases := []struct {
+		name             string
+		identifier       string
+		getInstancesErr  error
+		expectedExcluded []models.ExcludedMetric
+		expectedError    error
+	}{
+		{
+			name:             "Known instance",
+			identifier:       testutils.TestInstanceMySQL.Identifier,
+			expectedExcluded: excluded,
+		},
+		{
+			name:          "Unknown instance",
+			identifier:    "missing-db",
+			expectedError: ErrInstanceNotFound,
+		},
+		{
+			name:            "GetInstances error",
+			identifier:      testutils.TestInstanceMySQL.Identifier,
+			getInstancesErr: errors.New("DescribeDBInstances failed"),
+			expectedError:   errors.New("DescribeDBInstances failed"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockIP := &mocks.MockInstanceProvider{}
+			mockMP := &mocks.MockMetricProvider{}
+			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
+
+			if tc.getInstancesErr != nil {
+				mockIP.On("GetInstances", mock.Anything).Return(nil, tc.getInstancesErr)
+			} else {
+				mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+			}
+			mockMP.On("ExplainExcludedMetrics", mock.Anything, testutils.TestInstanceMySQL).Return(excluded, nil)
+
+			result, err := manager.ExplainExcludedMetrics(context.Background(), tc.identifier)
+
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+				assert.Nil(t, result)
+				mockMP.AssertNotCalled(t, "ExplainExcludedMetrics", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedExcluded, result)
+		})
+	}
+}
+
+func TestGetMetricCatalog(t *testing.T) {
+	catalog := map[string]models.CatalogMetric{
+		"os.cpuUtilization.idle": {Name: "os.cpuUtilization.idle", Description: "The percentage of CPU that is idle", Unit: "Percent", Category: "os"},
+	}
+
+	testCases := []struct {
+		name            string
+		identifier      string
+		getInstancesErr error
+		expectedCatalog map[string]models.CatalogMetric
+		expectedError   error
+	}{
+		{
+			name:            "Known instance",
+			identifier:      testutils.TestInstanceMySQL.Identifier,
+			expectedCatalog: catalog,
+		},
+		{
+			name:          "Unknown instance",
+			identifier:    "missing-db",
+			expectedError: ErrInstanceNotFound,
+		},
+		{
+			name:            "GetInstances error",
+			identifier:      testutils.TestInstanceMySQL.Identifier,
+			getInstancesErr: errors.New("DescribeDBInstances failed"),
+			expectedError:   errors.New("DescribeDBInstances failed"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockIP := &mocks.MockInstanceProvider{}
+			mockMP := &mocks.MockMetricProvider{}
+			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, testutils.CreateDefaultParsedTestConfig())
+
+			if tc.getInstancesErr != nil {
+				mockIP.On("GetInstances", mock.Anything).Return(nil, tc.getInstancesErr)
+			} else {
+				mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+			}
+			mockMP.On("GetMetricCatalog", mock.Anything, testutils.TestInstanceMySQL).Return(catalog, nil)
+
+			result, err := manager.GetMetricCatalog(context.Background(), tc.identifier)
+
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+				assert.Nil(t, result)
+				mockMP.AssertNotCalled(t, "GetMetricCatalog", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedCatalog, result)
+		})
+	}
+}
+
+type filteredReportingInstanceProvider struct {
+	*mocks.MockInstanceProvider
+	filtered []models.Instance
+}
+
+func (provider *filteredReportingInstanceProvider) FilteredInstances() []models.Instance {
+	return provider.filtered
+}
+
+func TestExplainInstances(t *testing.T) {
+	filtered := models.Instance{Identifier: "staging-db", Engine: models.MySQL, ResourceID: "db-STAGING"}
+
+	testCases := []struct {
+		name                string
+		reportsFiltered     bool
+		identifiers         []string
+		getInstancesErr     error
+		expectedIdentifiers []string
+		expectedIncluded    []bool
+		expectedError       error
+	}{
+		{
+			name:                "provider without filtered instances lists the collected instances",
+			expectedIdentifiers: []string{testutils.TestInstanceMySQL.Identifier, testutils.TestInstancePostgreSQL.Identifier},
+			expectedIncluded:    []bool{true, true},
+		},
+		{
+			name:                "filtered instances are listed after the collected instances",
+			reportsFiltered:     true,
+			identifiers:         []string{testutils.TestInstanceMySQL.Identifier, testutils.TestInstancePostgreSQL.Identifier},
+			expectedIdentifiers: []string{testutils.TestInstanceMySQL.Identifier, testutils.TestInstancePostgreSQL.Identifier, filtered.Identifier},
+			expectedIncluded:    []bool{true, true, false},
+		},
+		{
+			name:            "GetInstances error",
+			getInstancesErr: errors.New("DescribeDBInstances failed"),
+			expectedError:   errors.New("DescribeDBInstances failed"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockIP := &mocks.MockInstanceProvider{}
+			var instanceProvider instance.InstanceProvider = mockIP
+			if tc.reportsFiltered {
+				instanceProvider = &filteredReportingInstanceProvider{MockInstanceProvider: mockIP, filtered: []models.Instance{filtered}}
+			}
+			config := testutils.CreateDefaultParsedTestConfig()
+			config.Discovery.Instances.Identifiers = tc.identifiers
+			manager := NewSingleRegionManager("us-west-2", instanceProvider, &mocks.MockMetricProvider{}, config)
+
+			if tc.getInstancesErr != nil {
+				mockIP.On("GetInstances", mock.Anything).Return(nil, tc.getInstancesErr)
+			} else {
+				mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+			}
+
+			decisions, err := manager.ExplainInstances(context.Background())
+
+			if tc.expectedError != nil {
+				assert.EqualError(t, err, tc.expectedError.Error())
+				assert.Nil(t, decisions)
+				return
+			}
+			require.NoError(t, err)
+
+			var identifiers []string
+			var included []bool
+			for _, decision := range decisions {
+				identifiers = append(identifiers, decision.Identifier)
+				included = append(included, decision.Included)
+				assert.Equal(t, "us-west-2", decision.Region)
+				assert.Equal(t, decision.Decision.Reason(), decision.Reason)
+			}
+			assert.Equal(t, tc.expectedIdentifiers, identifiers)
+			assert.Equal(t, tc.expectedIncluded, included)
+		})
+	}
+}
+
+func TestCollectMetricsWithScrapeProgress(t *testing.T) {
+	testCases := []struct {
+		name                string
+		maxBatchesPerScrape int
+		expected            []models.InstanceProgress
+	}{
+		{
+			name: "every instance is reported",
+			expected: []models.InstanceProgress{
+				{Region: "us-west-2", Identifier: testutils.TestInstanceMySQL.Identifier, Batches: 2, FailedBatches: 1},
+				{Region: "us-west-2", Identifier: testutils.TestInstancePostgreSQL.Identifier, Error: "GetMetricBatches failed"},
+			},
+		},
+		{
+			name:                "batches beyond the batch limit are reported as skipped",
+			maxBatchesPerScrape: 1,
+			expected: []models.InstanceProgress{
+				{Region: "us-west-2", Identifier: testutils.TestInstanceMySQL.Identifier, Batches: 2, SkippedBatches: 1},
+				{Region: "us-west-2", Identifier: testutils.TestInstancePostgreSQL.Identifier, Error: "GetMetricBatches failed"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockIP := &mocks.MockInstanceProvider{}
+			mockMP := &mocks.MockMetricProvider{}
+			config := testutils.NewTestConfigBuilder().WithMaxBatchesPerScrape(tc.maxBatchesPerScrape).Build()
+			manager := NewSingleRegionManager("us-west-2", mockIP, mockMP, config)
+
+			batches := [][]string{{"os.general.numVCPUs.avg"}, {"os.cpuUtilization.idle.avg"}}
+			mockIP.On("GetInstances", mock.Anything).Return(testutils.TestInstances, nil)
+			mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstanceMySQL).Return(batches, nil)
+			mockMP.On("GetMetricBatches", mock.Anything, testutils.TestInstancePostgreSQL).Return(nil, errors.New("GetMetricBatches failed"))
+			mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstanceMySQL, batches[0], mock.Anything).Return(nil)
+			mockMP.On("CollectMetricsForBatch", mock.Anything, testutils.TestInstanceMySQL, batches[1], mock.Anything).Return(errors.New("GetResourceMetrics failed"))
+
+			var mu sync.Mutex
+			var reported []models.InstanceProgress
+			progress := models.NewScrapeProgress(func(event models.InstanceProgress) {
+				mu.Lock()
+				defer mu.Unlock()
+				reported = append(reported, event)
+			})
+			ctx := models.ContextWithScrapeProgress(context.Background(), progress)
+
+			ch := make(chan prometheus.Metric, 100)
+			_ = manager.CollectMetrics(ctx, ch)
+			close(ch)
+
+			assert.ElementsMatch(t, tc.expected, reported)
+		})
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	mockInstanceManager := &mocks.MockInstanceProvider{}
+	mockMetricManager := &mocks.MockMetricProvider{}
+	mockInstanceManager.On("Invalidate").Return().Once()
+	mockMetricManager.On("Invalidate").Return().Once()
+	srm := NewSingleRegionManager("us-west-2", mockInstanceManager, mockMetricManager, testutils.CreateDefaultParsedTestConfig())
+
+	srm.Invalidate()
+
+	mockInstanceManager.AssertExpectations(t)
+	mockMetricManager.AssertExpectations(t)
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"context"
+	"sync"
+)
+
+type collectedMetricDataKey struct{}
+
+// CollectedMetricData accumulates the metric data collected for every instance during a single scrape,
+// so metric post-processors can run once per instance with the full set of its metric data.
+// All methods are safe for concurrent use. On a nil receiver nothing is accumulated.
+type CollectedMetricData struct {
+	mu        sync.Mutex
+	instances map[string]Instance
+	data      map[string][]MetricData
+	order     []string
+}
+
+func NewCollectedMetricData() *CollectedMetricData {
+	return &CollectedMetricData{
+		instances: make(map[string]Instance),
+		data:      make(map[string][]MetricData),
+	}
+}
+
+// ContextWithCollectedMetricData returns a copy of ctx carrying the provided accumulator.
+func ContextWithCollectedMetricData(ctx context.Context, collected *CollectedMetricData) context.Context {
+	return context.WithValue(ctx, collectedMetricDataKey{}, collected)
+}
+
+// CollectedMetricDataFromContext returns the accumulator carried by ctx, or nil if there is none.
+func CollectedMetricDataFromContext(ctx context.Context) *CollectedMetricData {
+	collected, _ := ctx.Value(collectedMetricDataKey{}).(*CollectedMetricData)
+	return collected
+}
+
+// Add appends metric data collected for the instance.
+func (collected *CollectedMetricData) Add(instance Instance, metricData []MetricData) {
+	if collected == nil || len(metricData) == 0 {
+		return
+	}
+
+	collected.mu.Lock()
+	defer collected.mu.Unlock()
+
+	if _, exists := collected.instances[instance.ResourceID]; !exists {
+		collected.order = append(collected.order, instance.ResourceID)
+	}
+	collected.instances[instance.ResourceID] = instance
+	collected.data[instance.ResourceID] = append(collected.data[instance.ResourceID], metricData...)
+}
+
+// ForEach calls fn with every instance and all of its collected metric data, in the order instances were first added.
+func (collected *CollectedMetricData) ForEach(fn func(instance Instance, metricData []MetricData)) {
+	if collected == nil {
+		return
+	}
+
+	collected.mu.Lock()
+	order := append([]string(nil), collected.order...)
+	instances := make(map[string]Instance, len(collected.instances))
+	data := make(map[string][]MetricData, len(collected.data))
+	for resourceID, instance := range collected.instances {
+		instances[resourceID] = instance
+		data[resourceID] = append([]MetricData(nil), collected.data[resourceID]...)
+	}
+	collected.mu.Unlock()
+
+	for _, resourceID := range order {
+		fn(instances[resourceID], data[resourceID])
+	}
+}
@@ -1,6 +1,13 @@
 package models
 
 import (
+	"fmt"
+	"log/slog"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/filter"
@@ -9,84 +16,414 @@ import (
 type Config struct {
 	Discovery DiscoveryConfig
 	Export    ExportConfig
+	AWS       AWSConfig `yaml:"aws"`
+	Log       LogConfig
 }
 
 type DiscoveryConfig struct {
-	Regions    []string
-	Instances  InstancesConfig
-	Metrics    MetricsConfig
-	Processing ProcessingConfig
+	Regions               []string
+	StrictSingleRegion    bool               `yaml:"strict-single-region"`
+	IncludeStopped        bool               `yaml:"include-stopped"`
+	UnknownEngineBehavior string             `yaml:"unknown-engine-behavior"`
+	MinRefreshInterval    string             `yaml:"min-refresh-interval"`
+	SampleRate            float64            `yaml:"sample-rate"`
+	MinPIRetention        int32              `yaml:"min-pi-retention"`
+	PriorityTag           string             `yaml:"priority-tag"`
+	GlobalFilter          GlobalFilterConfig `yaml:"global-filter"`
+	Instances             InstancesConfig
+	Metrics               MetricsConfig
+	Processing            ProcessingConfig
+	CollectionOrder       CollectionOrderConfig `yaml:"collection-order"`
 }
 
 type ExportConfig struct {
-	Port       int
-	Prometheus PrometheusConfig
+	Port                int
+	BindAddress         string `yaml:"bind-address"`
+	Debug               bool
+	Prometheus          PrometheusConfig
+	RemoteWriteURL      string  `yaml:"remote-write-url"`
+	RemoteWriteInterval string  `yaml:"remote-write-interval"`
+	TargetedPriority    string  `yaml:"targeted-scrape-priority"`
+	MaxScrapeDuration   string  `yaml:"max-scrape-duration"`
+	ScrapeTimeoutOffset string  `yaml:"scrape-timeout-offset"`
+	ShutdownGracePeriod string  `yaml:"shutdown-grace-period"`
+	UnhealthyThreshold  float64 `yaml:"unhealthy-threshold"`
+	MaxIdentifiers      int     `yaml:"max-instance-identifiers"`
+	AllowRefresh        bool    `yaml:"allow-refresh"`
+	TLS                 TLSConfig
+	Auth                AuthConfig
+}
+
+type TLSConfig struct {
+	CertFile string `yaml:"cert-file"`
+	KeyFile  string `yaml:"key-file"`
+}
+
+type AuthConfig struct {
+	BearerToken string          `yaml:"bearer-token"`
+	BasicAuth   BasicAuthConfig `yaml:"basic-auth"`
+}
+
+type BasicAuthConfig struct {
+	Username       string
+	PasswordSHA256 string `yaml:"password-sha256"`
+}
+
+// GlobalFilterConfig holds include and exclude patterns applied to both instances and metrics.
+// Each pattern only applies to the objects that have its field.
+type GlobalFilterConfig struct {
+	Include FilterConfig `yaml:"include,omitempty"`
+	Exclude FilterConfig `yaml:"exclude,omitempty"`
 }
 
 type InstancesConfig struct {
-	MaxInstances int          `yaml:"max-instances"`
-	InstanceTTL  string       `yaml:"ttl"`
-	Include      FilterConfig `yaml:"include,omitempty"`
-	Exclude      FilterConfig `yaml:"exclude,omitempty"`
+	MaxInstances      int               `yaml:"max-instances"`
+	MaxInstancesLimit int               `yaml:"max-instances-limit"` // upper bound of max-instances, 0 for the default
+	MaxPages          int               `yaml:"max-pages"`
+	InstanceTTL       string            `yaml:"ttl"`
+	EmptyTTL          string            `yaml:"empty-ttl"` // how long a discovery that found no instances is cached
+	Selection         string            `yaml:"selection"`
+	Identifiers       []string          `yaml:"identifiers,omitempty"`
+	Statuses          []string          `yaml:"statuses,omitempty"`
+	Include           FilterConfig      `yaml:"include,omitempty"`
+	Exclude           FilterConfig      `yaml:"exclude,omitempty"`
+	FilterMode        string            `yaml:"filter-mode"`
+	CaseInsensitive   bool              `yaml:"case-insensitive"`            // compile include and exclude patterns ignoring case
+	FieldCombinators  map[string]string `yaml:"field-combinators,omitempty"` // any (the default) or all patterns of a field must match
 }
 
 type MetricsConfig struct {
-	Statistic   string
-	MetadataTTL string       `yaml:"metadata-ttl"`
-	Include     FilterConfig `yaml:"include,omitempty"`
-	Exclude     FilterConfig `yaml:"exclude,omitempty"`
+	Statistic                string
+	Statistics               []string          `yaml:"statistics,omitempty"`
+	DefaultStatisticByEngine map[string]string `yaml:"default-statistic-by-engine,omitempty"`
+	MetadataTTL              string            `yaml:"metadata-ttl"`
+	MetadataCacheFile        string            `yaml:"metadata-cache-file"`
+	FutureTimestamp          string            `yaml:"future-timestamp"`
+	OnMissing                string            `yaml:"on-missing"`
+	OnInvalid                string            `yaml:"on-invalid"`
+	PostProcessors           []string          `yaml:"post-processors"`
+	ShareCatalogPerEngine    bool              `yaml:"share-catalog-per-engine"`
+	Include                  FilterConfig      `yaml:"include,omitempty"`
+	Exclude                  FilterConfig      `yaml:"exclude,omitempty"`
+	FilterMode               string            `yaml:"filter-mode"`
+}
+
+type CollectionOrderConfig struct {
+	Identifiers []string
+	Tag         string
+	TagValues   []string `yaml:"tag-values"`
 }
 
 type ProcessingConfig struct {
-	Concurrency int
+	Concurrency           int
+	DiscoveryConcurrency  int    `yaml:"discovery-concurrency"`
+	CollectionConcurrency int    `yaml:"collection-concurrency"`
+	ProducerConcurrency   int    `yaml:"producer-concurrency"`
+	RegionConcurrency     int    `yaml:"region-concurrency"`
+	MaxBatchesPerScrape   int    `yaml:"max-batches-per-scrape"`
+	BatchSize             int    `yaml:"batch-size"`
+	DiscoveryMaxRetries   int    `yaml:"discovery-max-retries"`
+	RetryJitter           string `yaml:"retry-jitter"`
 }
 
 type PrometheusConfig struct {
-	MetricPrefix string `yaml:"metric-prefix"`
+	MetricPrefix          string            `yaml:"metric-prefix"`
+	Namespace             string            `yaml:"namespace"`
+	Subsystem             string            `yaml:"subsystem"`
+	DescriptionInfoMetric bool              `yaml:"description-info-metric"`
+	DiscoveredMetricNames bool              `yaml:"discovered-metric-names-metric"`
+	ExtraLabels           []string          `yaml:"extra-labels"`
+	TagLabels             []string          `yaml:"tag-labels"`
+	HeartbeatMetric       bool              `yaml:"heartbeat-metric"`
+	DataPointsReturned    bool              `yaml:"datapoints-returned-metric"`
+	NoEnginePrefixMetrics []string          `yaml:"no-engine-prefix-metrics"`
+	EngineInName          *bool             `yaml:"engine-in-name"` // nil means true
+	EffectiveSettings     bool              `yaml:"effective-settings-metrics"`
+	ConversionErrors      bool              `yaml:"conversion-errors-metric"`
+	StatusLabel           bool              `yaml:"status-label"`
+	RegionLabel           *bool             `yaml:"region-label"` // nil means true when more than one region is configured
+	InstanceInfo          bool              `yaml:"instance-info-metric"`
+	AWSAPIMetrics         bool              `yaml:"aws-api-metrics"`
+	NameStyle             string            `yaml:"name-style"`
+	StaticLabels          map[string]string `yaml:"static-labels"`
+}
+
+type AWSConfig struct {
+	STSRegion      string                      `yaml:"sts-region"`
+	Partition      string                      `yaml:"partition"`
+	APICallTimeout string                      `yaml:"api-call-timeout"`
+	AllowedRegions []string                    `yaml:"allowed-regions"`
+	RegionRoles    map[string]AssumeRoleConfig `yaml:"region-roles"`
+}
+
+// AssumeRoleConfig is an IAM role the clients of a region assume with the base credentials, to scrape
+// the instances of another account.
+type AssumeRoleConfig struct {
+	RoleARN    string `yaml:"role-arn"`
+	ExternalID string `yaml:"external-id"`
+}
+
+type LogConfig struct {
+	Level  string
+	Format string
 }
 
 type FilterConfig map[string][]string
 
+// FilterEntry is a single filter pattern in config.yml. It is either a plain string, interpreted as a regex,
+// or a mapping with an explicit match-type, e.g. {match-type: prefix, pattern: "os."}.
+type FilterEntry struct {
+	MatchType string `yaml:"match-type"`
+	Pattern   string
+}
+
+// UnmarshalYAML accepts both plain string and typed filter entries.
+func (entry *FilterEntry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var pattern string
+	if err := unmarshal(&pattern); err == nil {
+		*entry = FilterEntry{MatchType: string(MatchTypeRegex), Pattern: pattern}
+		return nil
+	}
+
+	type typedEntry FilterEntry
+	var typed typedEntry
+	if err := unmarshal(&typed); err != nil {
+		return err
+	}
+	*entry = FilterEntry(typed)
+	if entry.MatchType == "" {
+		entry.MatchType = string(MatchTypeRegex)
+	}
+	return nil
+}
+
+// Regex returns the regex equivalent of the entry, so every match type is evaluated by the same regex filter.
+func (entry FilterEntry) Regex() (string, error) {
+	quoted := regexp.QuoteMeta(entry.Pattern)
+	switch NewMatchType(entry.MatchType) {
+	case MatchTypeRegex:
+		return entry.Pattern, nil
+	case MatchTypeExact:
+		return "^" + quoted + "$", nil
+	case MatchTypePrefix:
+		return "^" + quoted, nil
+	case MatchTypeSuffix:
+		return quoted + "$", nil
+	case MatchTypeContains:
+		return quoted, nil
+	default:
+		return "", fmt.Errorf("invalid match-type '%s' for filter pattern '%s', must be one of: %s, %s, %s, %s, %s",
+			entry.MatchType, entry.Pattern, MatchTypeRegex, MatchTypeExact, MatchTypePrefix, MatchTypeSuffix, MatchTypeContains)
+	}
+}
+
+// UnmarshalYAML reads filter entries of any match type and stores each as its equivalent regex pattern.
+func (filterConfig *FilterConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var entries map[string][]FilterEntry
+	if err := unmarshal(&entries); err != nil {
+		return err
+	}
+
+	if entries == nil {
+		*filterConfig = nil
+		return nil
+	}
+
+	config := make(FilterConfig, len(entries))
+	for fieldName, fieldEntries := range entries {
+		patterns := make([]string, 0, len(fieldEntries))
+		for _, entry := range fieldEntries {
+			pattern, err := entry.Regex()
+			if err != nil {
+				return fmt.Errorf("invalid filter field '%s': %w", fieldName, err)
+			}
+			patterns = append(patterns, pattern)
+		}
+		config[fieldName] = patterns
+	}
+	*filterConfig = config
+	return nil
+}
+
 type ParsedConfig struct {
 	Discovery ParsedDiscoveryConfig
 	Export    ParsedExportConfig
+	AWS       ParsedAWSConfig
+	Log       ParsedLogConfig
 }
 
 type ParsedDiscoveryConfig struct {
-	Regions    []string
-	Instances  ParsedInstancesConfig
-	Metrics    ParsedMetricsConfig
-	Processing ParsedProcessingConfig
+	Regions               []string
+	IncludeStopped        bool
+	UnknownEngineBehavior UnknownEngineBehavior
+	MinRefreshInterval    time.Duration
+	SampleRate            float64
+	MinPIRetention        int32
+	PriorityTag           string
+	Instances             ParsedInstancesConfig
+	Metrics               ParsedMetricsConfig
+	Processing            ParsedProcessingConfig
+	CollectionOrder       ParsedCollectionOrderConfig
 }
 
 type ParsedExportConfig struct {
-	Port       int
-	Prometheus ParsedPrometheusConfig
+	Port                int
+	BindAddress         string // host the HTTP server listens on, empty for all interfaces
+	Debug               bool
+	Prometheus          ParsedPrometheusConfig
+	RemoteWriteURL      string
+	RemoteWriteInterval time.Duration
+	TargetedPriority    ScrapePriority
+	MaxScrapeDuration   time.Duration
+	ScrapeTimeoutOffset time.Duration // subtracted from the Prometheus scrape timeout to leave time to serve the response
+	ShutdownGracePeriod time.Duration // time in-flight requests get to complete on SIGTERM before the server is closed
+	UnhealthyThreshold  float64       // fraction of instances failing collection above which the exporter is unhealthy, 0 disables
+	MaxIdentifiers      int           // instances a targeted scrape may name in ?identifiers, at most instances.max-instances
+	AllowRefresh        bool          // whether a scrape may bypass the instance and metadata TTLs with ?refresh
+	TLS                 ParsedTLSConfig
+	Auth                ParsedAuthConfig
+}
+
+// ParsedTLSConfig holds the certificate and private key the HTTP server serves HTTPS with, both empty for plaintext HTTP.
+type ParsedTLSConfig struct {
+	CertFile string
+	KeyFile  string
+}
+
+// ParsedAuthConfig holds the credentials requests to the metrics endpoints must carry: a bearer token or a basic-auth
+// username with the SHA-256 hash of its password. All empty if the endpoints are open.
+type ParsedAuthConfig struct {
+	BearerToken    string
+	Username       string
+	PasswordSHA256 []byte
+}
+
+// Enabled reports whether requests must be authenticated.
+func (authConfig ParsedAuthConfig) Enabled() bool {
+	return authConfig.BearerToken != "" || authConfig.Username != ""
+}
+
+// Enabled reports whether the HTTP server serves HTTPS.
+func (tlsConfig ParsedTLSConfig) Enabled() bool {
+	return tlsConfig.CertFile != "" && tlsConfig.KeyFile != ""
+}
+
+// ListenAddress returns the address the HTTP server listens on, combining the bind address and the port.
+func (exportConfig ParsedExportConfig) ListenAddress() string {
+	return net.JoinHostPort(exportConfig.BindAddress, strconv.Itoa(exportConfig.Port))
 }
 
 type ParsedInstancesConfig struct {
 	MaxInstances int `yaml:"max-instances"`
+	MaxPages     int // pages of DescribeDBInstances results read per discovery, 0 for no limit
 	InstanceTTL  time.Duration
+	EmptyTTL     time.Duration     // how long a discovery that found no instances is cached, never shorter than InstanceTTL
+	Selection    InstanceSelection // order instances are sorted in before max-instances keeps the first ones
+	Statuses     []string          // instance statuses that are collected, including stopped with include-stopped
+	Identifiers  []string          // instances.identifiers allowlist, empty allows every identifier
 	Filter       filter.Filter
+	GlobalFilter filter.Filter // discovery.global-filter patterns on instance fields
 }
 
 type ParsedMetricsConfig struct {
-	Statistic   Statistic
-	MetadataTTL time.Duration `yaml:"metadata-ttl"`
-	Filter      filter.Filter
-	Include     FilterConfig
-	Exclude     FilterConfig
+	Statistic                Statistic
+	Statistics               []Statistic // metrics.statistics, ordered as in GetAllStatistics; empty if only Statistic is configured
+	DefaultStatisticByEngine map[Engine]Statistic
+	MetadataTTL              time.Duration `yaml:"metadata-ttl"`
+	MetadataCacheFile        string        // persists the available metrics of instances across restarts, empty disables it
+	FutureTimestamp          FutureTimestampBehavior
+	OnMissing                MissingMetricBehavior
+	OnInvalid                InvalidMetricBehavior
+	PostProcessors           []PostProcessorName
+	ShareCatalogPerEngine    bool // reuse the metric catalog fetched for one instance for every instance of the same engine
+	Filter                   filter.Filter
+	GlobalFilter             filter.Filter // discovery.global-filter patterns on metric fields
+	Include                  FilterConfig
+	Exclude                  FilterConfig
+	FilterMode               filter.Mode // how Exclude combines with Include
+}
+
+// ParsedCollectionOrderConfig determines the order in which instances are collected within a scrape.
+// Instances listed in Identifiers come first in the listed order, followed by instances whose Tag value appears in
+// TagValues in the listed order, followed by every other instance in discovery order.
+type ParsedCollectionOrderConfig struct {
+	Identifiers []string
+	Tag         string
+	TagValues   []string
 }
 
 type ParsedProcessingConfig struct {
-	Concurrency int
+	Concurrency           int
+	DiscoveryConcurrency  int // instances whose metric batches are fetched at the same time
+	CollectionConcurrency int // GetResourceMetrics calls in flight per scrape
+	ProducerConcurrency   int
+	RegionConcurrency     int // regions collected at the same time
+	MaxBatchesPerScrape   int
+	BatchSize             int // metrics requested per Performance Insights GetResourceMetrics call
+	DiscoveryMaxRetries   int
+	RetryJitter           RetryJitter
 }
 
 type ParsedPrometheusConfig struct {
-	MetricPrefix string `yaml:"metric-prefix"`
+	MetricPrefix          string `yaml:"metric-prefix"`
+	Namespace             string
+	Subsystem             string
+	StatusLabel           bool
+	RegionLabel           bool // metrics are labeled with the configured region they were collected in
+	DescriptionInfoMetric bool
+	DiscoveredMetricNames bool
+	ExtraLabels           []string
+	TagLabels             []TagLabel // instance tags exported as labels, in the configured order
+	HeartbeatMetric       bool
+	DataPointsReturned    bool
+	NoEnginePrefixMetrics []*regexp.Regexp // db metric names, without statistic, exported without the engine short name
+	OmitEnginePrefix      bool             // every db metric is exported without the engine short name, export.prometheus.engine-in-name: false
+	EffectiveSettings     bool
+	ConversionErrors      bool
+	InstanceInfo          bool
+	AWSAPIMetrics         bool // AWS API call counts and latency since the exporter started
+	NameStyle             MetricNameStyle
+	StaticLabels          map[string]string // constant labels added to every emitted metric
+}
+
+// TagLabel is an instance tag exported as a metric label, named tag_ followed by the tag key with the characters
+// that are invalid in Prometheus label names replaced by underscores.
+type TagLabel struct {
+	Tag   string
+	Label string
+}
+
+// ParsedAWSConfig holds settings for the AWS SDK clients shared across regions.
+// STSRegion is the region used to resolve credentials (e.g. web identity or assume-role via STS),
+// independent of the regions the RDS and PI clients target.
+// Partition is the AWS partition (aws, aws-cn, aws-us-gov) that every configured region belongs to.
+// APICallTimeout bounds each individual Performance Insights call, 0 if calls are not bounded.
+type ParsedAWSConfig struct {
+	STSRegion      string
+	Partition      Partition
+	APICallTimeout time.Duration
+	RegionRoles    map[string]AssumeRoleConfig // IAM role assumed by the clients of a region, by region
+}
+
+// ParsedLogConfig holds the minimum level of the records the exporter logs and the format they are written in.
+type ParsedLogConfig struct {
+	Level  slog.Level
+	Format LogFormat
 }
 
+// IdentifierNotAllowedReason is the exclude reason EvaluateInstance reports for an instance missing from the
+// instances.identifiers allowlist.
+const IdentifierNotAllowedReason = "identifier not in instances.identifiers"
+
+// ShouldIncludeInstance reports whether the instance is in the instances.identifiers allowlist, when configured, and
+// passes the global and instance filters.
 func (instanceConfig *ParsedInstancesConfig) ShouldIncludeInstance(instance filter.Filterable) bool {
+	if len(instanceConfig.Identifiers) > 0 && !slices.Contains(instanceConfig.Identifiers, instance.GetFilterableFields()["identifier"]) {
+		return false
+	}
+	if instanceConfig.GlobalFilter != nil && !instanceConfig.GlobalFilter.ShouldInclude(instance) {
+		return false
+	}
 	if instanceConfig.Filter == nil {
 		return true
 	}
@@ -94,8 +431,101 @@ func (instanceConfig *ParsedInstancesConfig) ShouldIncludeInstance(instance filt
 }
 
 func (metricConfig *ParsedMetricsConfig) ShouldIncludeMetric(metricDetails filter.Filterable) bool {
+	if metricConfig.GlobalFilter != nil && !metricConfig.GlobalFilter.ShouldInclude(metricDetails) {
+		return false
+	}
 	if metricConfig.Filter == nil {
 		return true
 	}
 	return metricConfig.Filter.ShouldInclude(metricDetails)
 }
+
+// DefaultStatisticForEngine returns the default statistic configured for the engine, falling back to the global statistic.
+func (metricConfig *ParsedMetricsConfig) DefaultStatisticForEngine(engine Engine) Statistic {
+	if statistic, exists := metricConfig.DefaultStatisticByEngine[engine]; exists {
+		return statistic
+	}
+	return metricConfig.Statistic
+}
+
+// DefaultStatisticsForEngine returns the default statistics collected for every metric of the engine: the engine's
+// default statistic if one is configured, else the global statistics, else the global statistic.
+func (metricConfig *ParsedMetricsConfig) DefaultStatisticsForEngine(engine Engine) []Statistic {
+	if statistic, exists := metricConfig.DefaultStatisticByEngine[engine]; exists {
+		return []Statistic{statistic}
+	}
+	if len(metricConfig.Statistics) > 0 {
+		return metricConfig.Statistics
+	}
+	return []Statistic{metricConfig.Statistic}
+}
+
+// OrderInstances returns the instances sorted by collection priority. The input slice is not modified.
+func (orderConfig *ParsedCollectionOrderConfig) OrderInstances(instances []Instance) []Instance {
+	if len(orderConfig.Identifiers) == 0 && len(orderConfig.TagValues) == 0 {
+		return instances
+	}
+
+	identifierRanks := make(map[string]int, len(orderConfig.Identifiers))
+	for i, identifier := range orderConfig.Identifiers {
+		if _, exists := identifierRanks[identifier]; !exists {
+			identifierRanks[identifier] = i
+		}
+	}
+
+	tagValueRanks := make(map[string]int, len(orderConfig.TagValues))
+	for i, tagValue := range orderConfig.TagValues {
+		if _, exists := tagValueRanks[tagValue]; !exists {
+			tagValueRanks[tagValue] = len(orderConfig.Identifiers) + i
+		}
+	}
+
+	unranked := len(orderConfig.Identifiers) + len(orderConfig.TagValues)
+	rank := func(instance Instance) int {
+		if identifierRank, exists := identifierRanks[instance.Identifier]; exists {
+			return identifierRank
+		}
+		if tagValue, exists := instance.Tags[orderConfig.Tag]; exists && orderConfig.Tag != "" {
+			if tagValueRank, exists := tagValueRanks[tagValue]; exists {
+				return tagValueRank
+			}
+		}
+		return unranked
+	}
+
+	ordered := append([]Instance(nil), instances...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return rank(ordered[i]) < rank(ordered[j])
+	})
+	return ordered
+}
+
+// EvaluateInstance explains the instance filter decision for debugging. Included always matches ShouldIncludeInstance.
+// An instance missing from the instances.identifiers allowlist is reported with an exclude reason.
+func (instanceConfig *ParsedInstancesConfig) EvaluateInstance(instance filter.Filterable) filter.Decision {
+	var decision filter.Decision
+	if len(instanceConfig.Identifiers) > 0 && !slices.Contains(instanceConfig.Identifiers, instance.GetFilterableFields()["identifier"]) {
+		decision.ExcludeReasons = append(decision.ExcludeReasons, IdentifierNotAllowedReason)
+	}
+	if instanceConfig.GlobalFilter != nil {
+		decision = decision.Merge(instanceConfig.GlobalFilter.Evaluate(instance))
+	}
+	if instanceConfig.Filter != nil {
+		decision = decision.Merge(instanceConfig.Filter.Evaluate(instance))
+	}
+	decision.Included = instanceConfig.ShouldIncludeInstance(instance)
+	return decision
+}
+
+// EvaluateMetric explains the metric filter decision for debugging. Included always matches ShouldIncludeMetric.
+func (metricConfig *ParsedMetricsConfig) EvaluateMetric(metricDetails filter.Filterable) filter.Decision {
+	var decision filter.Decision
+	if metricConfig.GlobalFilter != nil {
+		decision = metricConfig.GlobalFilter.Evaluate(metricDetails)
+	}
+	if metricConfig.Filter != nil {
+		decision = decision.Merge(metricConfig.Filter.Evaluate(metricDetails))
+	}
+	decision.Included = metricConfig.ShouldIncludeMetric(metricDetails)
+	return decision
+}
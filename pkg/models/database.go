@@ -1,23 +1,169 @@
 package models
 
 import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/filter"
+)
+
+const (
+	InstanceStatusAvailable = "available"
+	InstanceStatusStopped   = "stopped"
 )
 
 type Instance struct {
 	ResourceID   string
 	Identifier   string
 	Engine       Engine
+	Status       string
 	CreationTime time.Time
 	Tags         map[string]string
 	Metrics      *Metrics
+
+	StorageEncrypted bool
+	StorageType      string
+	// PIRetentionPeriod is the Performance Insights retention period in days, 0 if unknown
+	PIRetentionPeriod int32
+	// SubnetGroup and VpcID describe the network placement of the instance, empty if unknown
+	SubnetGroup string
+	VpcID       string
+	// ClusterIdentifier is the DB cluster the instance is a member of, empty for instances outside a cluster
+	ClusterIdentifier string
+	// InstanceClass is the compute and memory capacity class of the instance (e.g. db.r6g.large), empty if unknown
+	InstanceClass string
+	// AllocatedStorage is the allocated storage in GiB, 0 if unknown
+	AllocatedStorage int32
+
+	// ARN is the Amazon Resource Name of the instance, empty if unknown
+	ARN string
+	// AccountID is the AWS account that owns the instance, parsed from its ARN, empty if unknown
+	AccountID string
+	// CollectionRegion is the region the Performance Insights API calls are made in, only set when the collection_region label is enabled
+	CollectionRegion string
 }
 
 func (instance Instance) GetFilterableFields() map[string]string {
 	return map[string]string{
-		"identifier": instance.Identifier,
-		"engine":     string(instance.Engine),
+		"identifier":   instance.Identifier,
+		"engine":       string(instance.Engine),
+		"class":        instance.InstanceClass,
+		"status":       instance.Status,
+		"encrypted":    strconv.FormatBool(instance.StorageEncrypted),
+		"storage_type": instance.StorageType,
+		"pi_retention": strconv.Itoa(int(instance.PIRetentionPeriod)),
+		"subnet_group": instance.SubnetGroup,
+		"vpc_id":       instance.VpcID,
+		"cluster":      instance.ClusterIdentifier,
+	}
+}
+
+// ExtraLabels lists the opt-in instance labels that can be enabled with export.prometheus.extra-labels.
+var ExtraLabels = []string{"encrypted", "storage_type", "pi_retention", "subnet_group", "vpc_id", "cluster", "region", "collection_region", "account_id"}
+
+// ExtraLabelValue returns the value of an opt-in instance label, or an empty string for an unknown label.
+func (instance Instance) ExtraLabelValue(label string) string {
+	switch label {
+	case "encrypted":
+		return strconv.FormatBool(instance.StorageEncrypted)
+	case "storage_type":
+		return instance.StorageType
+	case "pi_retention":
+		return strconv.Itoa(int(instance.PIRetentionPeriod))
+	case "subnet_group":
+		return instance.SubnetGroup
+	case "vpc_id":
+		return instance.VpcID
+	case "cluster":
+		return instance.ClusterIdentifier
+	case "region":
+		return instance.Region()
+	case "collection_region":
+		return instance.CollectionRegion
+	case "account_id":
+		return instance.AccountID
+	default:
+		return ""
+	}
+}
+
+// Region returns the region of the instance parsed from its ARN, or an empty string if the ARN is missing or malformed.
+func (instance Instance) Region() string {
+	return arnPart(instance.ARN, 3)
+}
+
+// AccountIDFromARN returns the AWS account ID of an ARN, or an empty string if the ARN is missing or malformed.
+func AccountIDFromARN(arn string) string {
+	return arnPart(arn, 4)
+}
+
+// arnPart returns the colon separated part of an ARN at index, or an empty string if the ARN is malformed.
+func arnPart(arn string, index int) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[index]
+}
+
+// Priority returns the numeric value of the priority tag of the instance, and false if the tag is missing or not a number.
+func (instance Instance) Priority(tag string) (float64, bool) {
+	value, exists := instance.Tags[tag]
+	if !exists {
+		return 0, false
+	}
+
+	priority, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(priority) {
+		return 0, false
 	}
+	return priority, true
+}
+
+// InstanceDecision describes a discovered instance and whether the instance filters include it, for debugging.
+type InstanceDecision struct {
+	Identifier   string            `json:"identifier"`
+	Engine       Engine            `json:"engine"`
+	ResourceID   string            `json:"resourceId"`
+	Region       string            `json:"region"`
+	CreationTime time.Time         `json:"creationTime"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	Reason       string            `json:"reason"`
+	filter.Decision
+}
+
+// SortInstances orders instances by the selection strategy, oldest first unless another strategy is configured.
+func SortInstances(instances []Instance, selection InstanceSelection) {
+	switch selection {
+	case InstanceSelectionNewest:
+		sort.Slice(instances, func(i, j int) bool {
+			return instances[i].CreationTime.After(instances[j].CreationTime)
+		})
+	case InstanceSelectionAlphabetical:
+		sort.Slice(instances, func(i, j int) bool {
+			return instances[i].Identifier < instances[j].Identifier
+		})
+	default:
+		sort.Slice(instances, func(i, j int) bool {
+			return instances[i].CreationTime.Before(instances[j].CreationTime)
+		})
+	}
+}
+
+// SortInstancesByPriority orders instances by the numeric value of the priority tag, highest first.
+// Instances without a numeric priority come last. The sort is stable, so instances with equal priority keep their order.
+func SortInstancesByPriority(instances []Instance, tag string) {
+	sort.SliceStable(instances, func(i, j int) bool {
+		priorityI, okI := instances[i].Priority(tag)
+		priorityJ, okJ := instances[j].Priority(tag)
+		if okI != okJ {
+			return okI
+		}
+		return priorityI > priorityJ
+	})
 }
 
 func (instance Instance) GetFilterableTags() map[string]string {
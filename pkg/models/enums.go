@@ -21,8 +21,122 @@ const (
 	MariaDB          Engine = "mariadb"
 	Oracle           Engine = "oracle"
 	SQLServer        Engine = "sqlserver"
+	// Other is assigned to instances whose engine is not recognized when unknown engines are included
+	Other Engine = "other"
 )
 
+type UnknownEngineBehavior string
+
+const (
+	UnknownEngineDrop           UnknownEngineBehavior = "drop"
+	UnknownEngineIncludeAsOther UnknownEngineBehavior = "include-as-other"
+)
+
+type FutureTimestampBehavior string
+
+const (
+	FutureTimestampKeep  FutureTimestampBehavior = "keep"
+	FutureTimestampClamp FutureTimestampBehavior = "clamp"
+	FutureTimestampDrop  FutureTimestampBehavior = "drop"
+)
+
+type PostProcessorName string
+
+const (
+	PostProcessorMemoryFreePercent PostProcessorName = "memory-free-percent"
+)
+
+type MissingMetricBehavior string
+
+const (
+	MissingMetricAbsent MissingMetricBehavior = "absent"
+	MissingMetricZero   MissingMetricBehavior = "zero"
+	MissingMetricStale  MissingMetricBehavior = "stale"
+)
+
+type InvalidMetricBehavior string
+
+const (
+	InvalidMetricFail  InvalidMetricBehavior = "fail"
+	InvalidMetricPrune InvalidMetricBehavior = "prune"
+)
+
+// RetryJitter is how the exponential backoff delay between retries of AWS calls is randomized, so callers throttled
+// at the same time don't retry in lockstep.
+type RetryJitter string
+
+const (
+	RetryJitterNone  RetryJitter = "none"  // the full backoff delay
+	RetryJitterFull  RetryJitter = "full"  // a random delay between 0 and the backoff delay
+	RetryJitterEqual RetryJitter = "equal" // half the backoff delay plus a random delay up to the other half
+)
+
+// MetricNameStyle is how the segments of a Performance Insights metric name are normalized in the exported
+// Prometheus metric name, e.g. os.cpuUtilization.idle.avg.
+type MetricNameStyle string
+
+const (
+	MetricNameStyleLowercase    MetricNameStyle = "lowercase"     // os_cpuutilization_idle_avg
+	MetricNameStylePreserveCase MetricNameStyle = "preserve-case" // os_cpuUtilization_idle_avg
+	MetricNameStyleSnakeCase    MetricNameStyle = "snake-case"    // os_cpu_utilization_idle_avg
+)
+
+// ScrapeRefresh is the cache a scrape requested with ?refresh bypasses, to collect with freshly discovered instances,
+// freshly listed metric metadata, or both, regardless of their TTLs.
+type ScrapeRefresh string
+
+const (
+	ScrapeRefreshNone      ScrapeRefresh = ""
+	ScrapeRefreshInstances ScrapeRefresh = "instances" // instances.ttl
+	ScrapeRefreshMetadata  ScrapeRefresh = "metadata"  // metrics.metadata-ttl
+	ScrapeRefreshAll       ScrapeRefresh = "all"
+)
+
+// LogFormat is how the exporter writes its log records.
+type LogFormat string
+
+const (
+	LogFormatText LogFormat = "text" // key=value pairs, e.g. level=INFO msg="Discovered instances" count=3
+	LogFormatJSON LogFormat = "json" // one JSON object per record
+)
+
+// InstanceSelection is the order discovered instances are sorted in before instances.max-instances keeps the first
+// ones, e.g. newest keeps the most recently created instances.
+type InstanceSelection string
+
+const (
+	InstanceSelectionOldest       InstanceSelection = "oldest"       // creation time, oldest first
+	InstanceSelectionNewest       InstanceSelection = "newest"       // creation time, newest first
+	InstanceSelectionAlphabetical InstanceSelection = "alphabetical" // identifier, ascending
+)
+
+type MatchType string
+
+const (
+	MatchTypeRegex    MatchType = "regex"
+	MatchTypeExact    MatchType = "exact"
+	MatchTypePrefix   MatchType = "prefix"
+	MatchTypeSuffix   MatchType = "suffix"
+	MatchTypeContains MatchType = "contains"
+)
+
+type ScrapePriority string
+
+const (
+	ScrapePriorityNormal ScrapePriority = "normal"
+	ScrapePriorityHigh   ScrapePriority = "high"
+)
+
+type Partition string
+
+const (
+	PartitionAWS      Partition = "aws"
+	PartitionAWSCN    Partition = "aws-cn"
+	PartitionAWSUSGov Partition = "aws-us-gov"
+)
+
+// Statistic is the aggregation appended to a metric name in a Performance Insights GetResourceMetrics query.
+// The API only accepts avg, min, max and sum; it has no percentile statistics.
 type Statistic string
 
 const (
@@ -60,6 +174,249 @@ func (engine Engine) IsValid() bool {
 	}
 }
 
+func NewUnknownEngineBehavior(behaviorString string) UnknownEngineBehavior {
+	behavior := UnknownEngineBehavior(behaviorString)
+	if !behavior.IsValid() {
+		return ""
+	}
+	return behavior
+}
+
+func (behavior UnknownEngineBehavior) IsValid() bool {
+	switch behavior {
+	case UnknownEngineDrop, UnknownEngineIncludeAsOther:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewFutureTimestampBehavior(behaviorString string) FutureTimestampBehavior {
+	behavior := FutureTimestampBehavior(behaviorString)
+	if !behavior.IsValid() {
+		return ""
+	}
+	return behavior
+}
+
+func (behavior FutureTimestampBehavior) IsValid() bool {
+	switch behavior {
+	case FutureTimestampKeep, FutureTimestampClamp, FutureTimestampDrop:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewPostProcessorName(nameString string) PostProcessorName {
+	name := PostProcessorName(nameString)
+	if !name.IsValid() {
+		return ""
+	}
+	return name
+}
+
+func (name PostProcessorName) IsValid() bool {
+	switch name {
+	case PostProcessorMemoryFreePercent:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewMissingMetricBehavior(behaviorString string) MissingMetricBehavior {
+	behavior := MissingMetricBehavior(behaviorString)
+	if !behavior.IsValid() {
+		return ""
+	}
+	return behavior
+}
+
+func (behavior MissingMetricBehavior) IsValid() bool {
+	switch behavior {
+	case MissingMetricAbsent, MissingMetricZero, MissingMetricStale:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewInvalidMetricBehavior(behaviorString string) InvalidMetricBehavior {
+	behavior := InvalidMetricBehavior(behaviorString)
+	if !behavior.IsValid() {
+		return ""
+	}
+	return behavior
+}
+
+func (behavior InvalidMetricBehavior) IsValid() bool {
+	switch behavior {
+	case InvalidMetricFail, InvalidMetricPrune:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewRetryJitter(jitterString string) RetryJitter {
+	jitter := RetryJitter(jitterString)
+	if !jitter.IsValid() {
+		return ""
+	}
+	return jitter
+}
+
+func (jitter RetryJitter) IsValid() bool {
+	switch jitter {
+	case RetryJitterNone, RetryJitterFull, RetryJitterEqual:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewMetricNameStyle(styleString string) MetricNameStyle {
+	style := MetricNameStyle(styleString)
+	if !style.IsValid() {
+		return ""
+	}
+	return style
+}
+
+func (style MetricNameStyle) IsValid() bool {
+	switch style {
+	case MetricNameStyleLowercase, MetricNameStylePreserveCase, MetricNameStyleSnakeCase:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewScrapeRefresh(refreshString string) ScrapeRefresh {
+	refresh := ScrapeRefresh(refreshString)
+	if !refresh.IsValid() {
+		return ScrapeRefreshNone
+	}
+	return refresh
+}
+
+func (refresh ScrapeRefresh) IsValid() bool {
+	switch refresh {
+	case ScrapeRefreshInstances, ScrapeRefreshMetadata, ScrapeRefreshAll:
+		return true
+	default:
+		return false
+	}
+}
+
+// Instances reports whether the scrape discovers instances regardless of instances.ttl.
+func (refresh ScrapeRefresh) Instances() bool {
+	return refresh == ScrapeRefreshInstances || refresh == ScrapeRefreshAll
+}
+
+// Metadata reports whether the scrape lists the metric metadata of instances regardless of metrics.metadata-ttl.
+func (refresh ScrapeRefresh) Metadata() bool {
+	return refresh == ScrapeRefreshMetadata || refresh == ScrapeRefreshAll
+}
+
+func NewLogFormat(formatString string) LogFormat {
+	format := LogFormat(formatString)
+	if !format.IsValid() {
+		return ""
+	}
+	return format
+}
+
+func (format LogFormat) IsValid() bool {
+	switch format {
+	case LogFormatText, LogFormatJSON:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewInstanceSelection(selectionString string) InstanceSelection {
+	selection := InstanceSelection(selectionString)
+	if !selection.IsValid() {
+		return ""
+	}
+	return selection
+}
+
+func (selection InstanceSelection) IsValid() bool {
+	switch selection {
+	case InstanceSelectionOldest, InstanceSelectionNewest, InstanceSelectionAlphabetical:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewMatchType(matchTypeString string) MatchType {
+	matchType := MatchType(matchTypeString)
+	if !matchType.IsValid() {
+		return ""
+	}
+	return matchType
+}
+
+func (matchType MatchType) IsValid() bool {
+	switch matchType {
+	case MatchTypeRegex, MatchTypeExact, MatchTypePrefix, MatchTypeSuffix, MatchTypeContains:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewScrapePriority(priorityString string) ScrapePriority {
+	priority := ScrapePriority(priorityString)
+	if !priority.IsValid() {
+		return ""
+	}
+	return priority
+}
+
+func (priority ScrapePriority) IsValid() bool {
+	switch priority {
+	case ScrapePriorityNormal, ScrapePriorityHigh:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewPartition(partitionString string) Partition {
+	partition := Partition(partitionString)
+	if !partition.IsValid() {
+		return ""
+	}
+	return partition
+}
+
+func (partition Partition) IsValid() bool {
+	switch partition {
+	case PartitionAWS, PartitionAWSCN, PartitionAWSUSGov:
+		return true
+	default:
+		return false
+	}
+}
+
+// PartitionForRegion returns the AWS partition a region belongs to, based on its name prefix.
+func PartitionForRegion(region string) Partition {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return PartitionAWSCN
+	case strings.HasPrefix(region, "us-gov-"):
+		return PartitionAWSUSGov
+	default:
+		return PartitionAWS
+	}
+}
+
 func NewStatistic(statisticString string) Statistic {
 	statistic := Statistic(statisticString)
 	if !statistic.IsValid() {
@@ -90,6 +447,8 @@ type FilterType string
 const (
 	FilterTypeIdentifier FilterType = "identifier"
 	FilterTypeEngine     FilterType = "engine"
+	FilterTypeClass      FilterType = "class"
+	FilterTypeStatus     FilterType = "status"
 	FilterTypeName       FilterType = "name"
 	FilterTypeCategory   FilterType = "category"
 	FilterTypeUnit       FilterType = "unit"
@@ -102,7 +461,7 @@ func (filterType FilterType) String() string {
 
 func (filterType FilterType) IsValid() bool {
 	switch filterType {
-	case FilterTypeIdentifier, FilterTypeEngine, FilterTypeName, FilterTypeCategory, FilterTypeUnit:
+	case FilterTypeIdentifier, FilterTypeEngine, FilterTypeClass, FilterTypeStatus, FilterTypeName, FilterTypeCategory, FilterTypeUnit:
 		return true
 	default:
 		return strings.HasPrefix(string(filterType), string(FilterTypeTagPrefix))
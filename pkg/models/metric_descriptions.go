@@ -0,0 +1,49 @@
+package models
+
+import (
+	"context"
+	"sync"
+)
+
+type metricDescriptionsKey struct{}
+
+// MetricDescriptions tracks which metric names have already had their description emitted during a single scrape,
+// so the description info metric is emitted once per metric name rather than once per instance.
+// All methods are safe for concurrent use. On a nil receiver nothing is tracked and no description is emitted.
+type MetricDescriptions struct {
+	mu        sync.Mutex
+	described map[string]struct{}
+}
+
+func NewMetricDescriptions() *MetricDescriptions {
+	return &MetricDescriptions{
+		described: make(map[string]struct{}),
+	}
+}
+
+// ContextWithMetricDescriptions returns a copy of ctx carrying the provided description tracker.
+func ContextWithMetricDescriptions(ctx context.Context, descriptions *MetricDescriptions) context.Context {
+	return context.WithValue(ctx, metricDescriptionsKey{}, descriptions)
+}
+
+// MetricDescriptionsFromContext returns the description tracker carried by ctx, or nil if there is none.
+func MetricDescriptionsFromContext(ctx context.Context) *MetricDescriptions {
+	descriptions, _ := ctx.Value(metricDescriptionsKey{}).(*MetricDescriptions)
+	return descriptions
+}
+
+// MarkDescribed records metricName and reports whether this is the first time it was seen in the scrape.
+func (descriptions *MetricDescriptions) MarkDescribed(metricName string) bool {
+	if descriptions == nil {
+		return false
+	}
+
+	descriptions.mu.Lock()
+	defer descriptions.mu.Unlock()
+
+	if _, exists := descriptions.described[metricName]; exists {
+		return false
+	}
+	descriptions.described[metricName] = struct{}{}
+	return true
+}
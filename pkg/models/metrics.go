@@ -3,8 +3,35 @@ package models
 import (
 	"strings"
 	"time"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/filter"
+)
+
+const (
+	ExclusionReasonExcludePattern = "matched-exclude-pattern"
+	ExclusionReasonNoIncludeMatch = "no-include-match"
+	ExclusionReasonNoStatistic    = "no-statistic"
 )
 
+// ExcludedMetric describes a metric Performance Insights reports as available for an instance that is not collected,
+// with the reason it was filtered out and the filter decision behind it.
+type ExcludedMetric struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Unit     string `json:"unit"`
+	Reason   string `json:"reason"`
+	filter.Decision
+}
+
+// CatalogMetric describes a metric Performance Insights reports as available for an instance, whether or not the
+// metric filters collect it.
+type CatalogMetric struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Unit        string `json:"unit"`
+	Category    string `json:"category"`
+}
+
 type Metrics struct {
 	MetricsDetails     map[string]MetricDetails
 	MetricsList        []string // list of metricNames.statitic
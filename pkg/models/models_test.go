@@ -1,6 +1,10 @@
 package models
 
 import (
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
 	"testing"
 	"time"
 
@@ -302,6 +306,469 @@ func TestNewStatistic(t *testing.T) {
 	}
 }
 
+func TestNewUnknownEngineBehavior(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected UnknownEngineBehavior
+	}{
+		{
+			name:     "Valid drop behavior",
+			input:    "drop",
+			expected: UnknownEngineDrop,
+		},
+		{
+			name:     "Valid include-as-other behavior",
+			input:    "include-as-other",
+			expected: UnknownEngineIncludeAsOther,
+		},
+		{
+			name:     "Invalid behavior returns empty",
+			input:    "keep",
+			expected: "",
+		},
+		{
+			name:     "Empty string returns empty",
+			input:    "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewUnknownEngineBehavior(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNewFutureTimestampBehavior(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected FutureTimestampBehavior
+	}{
+		{
+			name:     "Valid keep behavior",
+			input:    "keep",
+			expected: FutureTimestampKeep,
+		},
+		{
+			name:     "Valid clamp behavior",
+			input:    "clamp",
+			expected: FutureTimestampClamp,
+		},
+		{
+			name:     "Valid drop behavior",
+			input:    "drop",
+			expected: FutureTimestampDrop,
+		},
+		{
+			name:     "Invalid behavior returns empty",
+			input:    "shift",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewFutureTimestampBehavior(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNewMissingMetricBehavior(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected MissingMetricBehavior
+	}{
+		{
+			name:     "Valid absent behavior",
+			input:    "absent",
+			expected: MissingMetricAbsent,
+		},
+		{
+			name:     "Valid zero behavior",
+			input:    "zero",
+			expected: MissingMetricZero,
+		},
+		{
+			name:     "Valid stale behavior",
+			input:    "stale",
+			expected: MissingMetricStale,
+		},
+		{
+			name:     "Invalid behavior returns empty",
+			input:    "nan",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewMissingMetricBehavior(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNewRetryJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected RetryJitter
+	}{
+		{
+			name:     "Valid none jitter",
+			input:    "none",
+			expected: RetryJitterNone,
+		},
+		{
+			name:     "Valid full jitter",
+			input:    "full",
+			expected: RetryJitterFull,
+		},
+		{
+			name:     "Valid equal jitter",
+			input:    "equal",
+			expected: RetryJitterEqual,
+		},
+		{
+			name:     "Invalid jitter returns empty",
+			input:    "decorrelated",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewRetryJitter(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNewMetricNameStyle(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected MetricNameStyle
+	}{
+		{
+			name:     "Valid lowercase style",
+			input:    "lowercase",
+			expected: MetricNameStyleLowercase,
+		},
+		{
+			name:     "Valid preserve-case style",
+			input:    "preserve-case",
+			expected: MetricNameStylePreserveCase,
+		},
+		{
+			name:     "Valid snake-case style",
+			input:    "snake-case",
+			expected: MetricNameStyleSnakeCase,
+		},
+		{
+			name:     "Invalid style returns empty",
+			input:    "camelCase",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewMetricNameStyle(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNewLogFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected LogFormat
+	}{
+		{
+			name:     "Valid text format",
+			input:    "text",
+			expected: LogFormatText,
+		},
+		{
+			name:     "Valid json format",
+			input:    "json",
+			expected: LogFormatJSON,
+		},
+		{
+			name:     "Invalid format returns empty",
+			input:    "logfmt",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewLogFormat(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNewInstanceSelection(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected InstanceSelection
+	}{
+		{
+			name:     "Valid oldest selection",
+			input:    "oldest",
+			expected: InstanceSelectionOldest,
+		},
+		{
+			name:     "Valid newest selection",
+			input:    "newest",
+			expected: InstanceSelectionNewest,
+		},
+		{
+			name:     "Valid alphabetical selection",
+			input:    "alphabetical",
+			expected: InstanceSelectionAlphabetical,
+		},
+		{
+			name:     "Invalid selection returns empty",
+			input:    "random",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewInstanceSelection(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNewInvalidMetricBehavior(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected InvalidMetricBehavior
+	}{
+		{
+			name:     "Valid fail behavior",
+			input:    "fail",
+			expected: InvalidMetricFail,
+		},
+		{
+			name:     "Valid prune behavior",
+			input:    "prune",
+			expected: InvalidMetricPrune,
+		},
+		{
+			name:     "Invalid behavior returns empty",
+			input:    "ignore",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewInvalidMetricBehavior(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNewPostProcessorName(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected PostProcessorName
+	}{
+		{
+			name:     "Valid memory-free-percent processor",
+			input:    "memory-free-percent",
+			expected: PostProcessorMemoryFreePercent,
+		},
+		{
+			name:     "Invalid processor returns empty",
+			input:    "cpu-busy-percent",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewPostProcessorName(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestFilterEntryRegex(t *testing.T) {
+	tests := []struct {
+		name          string
+		entry         FilterEntry
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "regex is used as is",
+			entry:    FilterEntry{MatchType: "regex", Pattern: "^os\\.cpu"},
+			expected: "^os\\.cpu",
+		},
+		{
+			name:     "exact is anchored at both ends",
+			entry:    FilterEntry{MatchType: "exact", Pattern: "os.general.numVCPUs"},
+			expected: "^os\\.general\\.numVCPUs$",
+		},
+		{
+			name:     "prefix is anchored at the start",
+			entry:    FilterEntry{MatchType: "prefix", Pattern: "db.Cache"},
+			expected: "^db\\.Cache",
+		},
+		{
+			name:     "suffix is anchored at the end",
+			entry:    FilterEntry{MatchType: "suffix", Pattern: ".idle"},
+			expected: "\\.idle$",
+		},
+		{
+			name:     "contains is unanchored",
+			entry:    FilterEntry{MatchType: "contains", Pattern: "cpu(1)"},
+			expected: "cpu\\(1\\)",
+		},
+		{
+			name:          "unknown match type",
+			entry:         FilterEntry{MatchType: "glob", Pattern: "os.*"},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regex, err := tt.entry.Regex()
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, regex)
+		})
+	}
+}
+
+func TestNewScrapePriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected ScrapePriority
+	}{
+		{
+			name:     "Valid normal priority",
+			input:    "normal",
+			expected: ScrapePriorityNormal,
+		},
+		{
+			name:     "Valid high priority",
+			input:    "high",
+			expected: ScrapePriorityHigh,
+		},
+		{
+			name:     "Invalid priority returns empty",
+			input:    "urgent",
+			expected: "",
+		},
+		{
+			name:     "Empty string returns empty",
+			input:    "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewScrapePriority(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestNewPartition(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Partition
+	}{
+		{
+			name:     "Valid aws partition",
+			input:    "aws",
+			expected: PartitionAWS,
+		},
+		{
+			name:     "Valid aws-cn partition",
+			input:    "aws-cn",
+			expected: PartitionAWSCN,
+		},
+		{
+			name:     "Valid aws-us-gov partition",
+			input:    "aws-us-gov",
+			expected: PartitionAWSUSGov,
+		},
+		{
+			name:     "Invalid partition returns empty",
+			input:    "aws-moon",
+			expected: "",
+		},
+		{
+			name:     "Empty string returns empty",
+			input:    "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewPartition(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestPartitionForRegion(t *testing.T) {
+	tests := []struct {
+		name     string
+		region   string
+		expected Partition
+	}{
+		{
+			name:     "Commercial region",
+			region:   "us-west-2",
+			expected: PartitionAWS,
+		},
+		{
+			name:     "China region",
+			region:   "cn-north-1",
+			expected: PartitionAWSCN,
+		},
+		{
+			name:     "GovCloud region",
+			region:   "us-gov-west-1",
+			expected: PartitionAWSUSGov,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, PartitionForRegion(tt.region))
+		})
+	}
+}
+
 func TestStatisticString(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -384,6 +851,16 @@ func TestFilterTypeString(t *testing.T) {
 			filterType: FilterTypeEngine,
 			expected:   "engine",
 		},
+		{
+			name:       "FilterTypeClass to string",
+			filterType: FilterTypeClass,
+			expected:   "class",
+		},
+		{
+			name:       "FilterTypeStatus to string",
+			filterType: FilterTypeStatus,
+			expected:   "status",
+		},
 		{
 			name:       "FilterTypeName to string",
 			filterType: FilterTypeName,
@@ -430,6 +907,16 @@ func TestFilterTypeIsValid(t *testing.T) {
 			filterType: FilterTypeEngine,
 			expected:   true,
 		},
+		{
+			name:       "FilterTypeClass is valid",
+			filterType: FilterTypeClass,
+			expected:   true,
+		},
+		{
+			name:       "FilterTypeStatus is valid",
+			filterType: FilterTypeStatus,
+			expected:   true,
+		},
 		{
 			name:       "FilterTypeName is valid",
 			filterType: FilterTypeName,
@@ -494,8 +981,16 @@ func TestInstanceGetFilterableFields(t *testing.T) {
 				Engine:     PostgreSQL,
 			},
 			expected: map[string]string{
-				"identifier": "test-postgres-db",
-				"engine":     "postgres",
+				"identifier":   "test-postgres-db",
+				"engine":       "postgres",
+				"class":        "",
+				"status":       "",
+				"encrypted":    "false",
+				"storage_type": "",
+				"pi_retention": "0",
+				"subnet_group": "",
+				"vpc_id":       "",
+				"cluster":      "",
 			},
 		},
 		{
@@ -506,8 +1001,16 @@ func TestInstanceGetFilterableFields(t *testing.T) {
 				Engine:     MySQL,
 			},
 			expected: map[string]string{
-				"identifier": "test-mysql-db",
-				"engine":     "mysql",
+				"identifier":   "test-mysql-db",
+				"engine":       "mysql",
+				"class":        "",
+				"status":       "",
+				"encrypted":    "false",
+				"storage_type": "",
+				"pi_retention": "0",
+				"subnet_group": "",
+				"vpc_id":       "",
+				"cluster":      "",
 			},
 		},
 		{
@@ -518,8 +1021,16 @@ func TestInstanceGetFilterableFields(t *testing.T) {
 				Engine:     AuroraPostgreSQL,
 			},
 			expected: map[string]string{
-				"identifier": "aurora-postgres-cluster",
-				"engine":     "aurora-postgresql",
+				"identifier":   "aurora-postgres-cluster",
+				"engine":       "aurora-postgresql",
+				"class":        "",
+				"status":       "",
+				"encrypted":    "false",
+				"storage_type": "",
+				"pi_retention": "0",
+				"subnet_group": "",
+				"vpc_id":       "",
+				"cluster":      "",
 			},
 		},
 		{
@@ -530,8 +1041,16 @@ func TestInstanceGetFilterableFields(t *testing.T) {
 				Engine:     PostgreSQL,
 			},
 			expected: map[string]string{
-				"identifier": "",
-				"engine":     "postgres",
+				"identifier":   "",
+				"engine":       "postgres",
+				"class":        "",
+				"status":       "",
+				"encrypted":    "false",
+				"storage_type": "",
+				"pi_retention": "0",
+				"subnet_group": "",
+				"vpc_id":       "",
+				"cluster":      "",
 			},
 		},
 	}
@@ -544,6 +1063,160 @@ func TestInstanceGetFilterableFields(t *testing.T) {
 	}
 }
 
+func TestInstanceGetFilterableFieldsWithStorage(t *testing.T) {
+	instance := Instance{
+		Identifier:       "encrypted-db",
+		Engine:           AuroraPostgreSQL,
+		StorageEncrypted: true,
+		StorageType:      "aurora-iopt1",
+	}
+
+	fields := instance.GetFilterableFields()
+
+	assert.Equal(t, "true", fields["encrypted"])
+	assert.Equal(t, "aurora-iopt1", fields["storage_type"])
+}
+
+func TestInstanceGetFilterableFieldsWithPIRetention(t *testing.T) {
+	instance := Instance{
+		Identifier:        "long-retention-db",
+		Engine:            AuroraPostgreSQL,
+		PIRetentionPeriod: 731,
+	}
+
+	assert.Equal(t, "731", instance.GetFilterableFields()["pi_retention"])
+	assert.Equal(t, "731", instance.ExtraLabelValue("pi_retention"))
+}
+
+func TestInstanceGetFilterableFieldsWithNetwork(t *testing.T) {
+	instance := Instance{
+		Identifier:  "private-db",
+		Engine:      AuroraPostgreSQL,
+		SubnetGroup: "prod-private",
+		VpcID:       "vpc-0abc1234",
+	}
+
+	fields := instance.GetFilterableFields()
+
+	assert.Equal(t, "prod-private", fields["subnet_group"])
+	assert.Equal(t, "vpc-0abc1234", fields["vpc_id"])
+	assert.Equal(t, "prod-private", instance.ExtraLabelValue("subnet_group"))
+	assert.Equal(t, "vpc-0abc1234", instance.ExtraLabelValue("vpc_id"))
+}
+
+func TestInstanceGetFilterableFieldsWithCluster(t *testing.T) {
+	instance := Instance{
+		Identifier:        "aurora-writer",
+		Engine:            AuroraPostgreSQL,
+		ClusterIdentifier: "prod-aurora",
+	}
+
+	assert.Equal(t, "prod-aurora", instance.GetFilterableFields()["cluster"])
+	assert.Equal(t, "prod-aurora", instance.ExtraLabelValue("cluster"))
+}
+
+func TestInstanceGetFilterableFieldsWithClassAndStatus(t *testing.T) {
+	instance := Instance{
+		Identifier:    "reporting-db",
+		Engine:        PostgreSQL,
+		InstanceClass: "db.r6g.large",
+		Status:        "available",
+	}
+
+	fields := instance.GetFilterableFields()
+
+	assert.Equal(t, "db.r6g.large", fields["class"])
+	assert.Equal(t, "available", fields["status"])
+}
+
+func TestInstanceRegion(t *testing.T) {
+	testCases := []struct {
+		name     string
+		arn      string
+		expected string
+	}{
+		{
+			name:     "instance ARN",
+			arn:      "arn:aws:rds:us-west-2:123456789012:db:test-postgres-db",
+			expected: "us-west-2",
+		},
+		{
+			name:     "GovCloud partition",
+			arn:      "arn:aws-us-gov:rds:us-gov-west-1:123456789012:db:gov-db",
+			expected: "us-gov-west-1",
+		},
+		{
+			name:     "empty ARN",
+			arn:      "",
+			expected: "",
+		},
+		{
+			name:     "malformed ARN",
+			arn:      "rds:us-west-2:db",
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := Instance{ARN: tc.arn}
+			assert.Equal(t, tc.expected, instance.Region())
+			assert.Equal(t, tc.expected, instance.ExtraLabelValue("region"))
+		})
+	}
+}
+
+func TestAccountIDFromARN(t *testing.T) {
+	testCases := []struct {
+		name     string
+		arn      string
+		expected string
+	}{
+		{
+			name:     "instance ARN",
+			arn:      "arn:aws:rds:us-west-2:123456789012:db:test-postgres-db",
+			expected: "123456789012",
+		},
+		{
+			name:     "China partition",
+			arn:      "arn:aws-cn:rds:cn-north-1:210987654321:db:cn-db",
+			expected: "210987654321",
+		},
+		{
+			name:     "empty ARN",
+			arn:      "",
+			expected: "",
+		},
+		{
+			name:     "malformed ARN",
+			arn:      "rds:us-west-2:123456789012:db",
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, AccountIDFromARN(tc.arn))
+		})
+	}
+}
+
+func TestInstanceAccountIDLabel(t *testing.T) {
+	instance := Instance{AccountID: "123456789012"}
+
+	assert.Equal(t, "123456789012", instance.ExtraLabelValue("account_id"))
+}
+
+func TestInstanceCollectionRegionLabel(t *testing.T) {
+	instance := Instance{
+		ARN:              "arn:aws:rds:us-east-1:123456789012:db:cross-region-db",
+		CollectionRegion: "us-west-2",
+	}
+
+	assert.Equal(t, "us-east-1", instance.ExtraLabelValue("region"))
+	assert.Equal(t, "us-west-2", instance.ExtraLabelValue("collection_region"))
+}
+
 func TestInstanceGetFilterableTags(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -756,6 +1429,40 @@ func TestParsedInstancesConfigShouldIncludeInstance(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "identifier in allowlist is included",
+			config: ParsedInstancesConfig{
+				Identifiers: []string{"orders-db", "billing-db"},
+			},
+			instance: Instance{
+				Identifier: "billing-db",
+				Engine:     PostgreSQL,
+			},
+			expected: true,
+		},
+		{
+			name: "identifier not in allowlist is excluded",
+			config: ParsedInstancesConfig{
+				Identifiers: []string{"orders-db", "billing-db"},
+			},
+			instance: Instance{
+				Identifier: "analytics-db",
+				Engine:     PostgreSQL,
+			},
+			expected: false,
+		},
+		{
+			name: "allowlist is combined with the filter",
+			config: ParsedInstancesConfig{
+				Identifiers: []string{"orders-db", "billing-db"},
+				Filter:      filter.NewPatternFilter(nil, filter.Patterns{"identifier": {regexp.MustCompile("^billing-")}}),
+			},
+			instance: Instance{
+				Identifier: "billing-db",
+				Engine:     PostgreSQL,
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -981,6 +1688,249 @@ func TestParsedMetricsConfigWithFilter(t *testing.T) {
 	}
 }
 
+func TestParsedCollectionOrderConfigOrderInstances(t *testing.T) {
+	alpha := Instance{Identifier: "alpha", Tags: map[string]string{"Priority": "low"}}
+	beta := Instance{Identifier: "beta", Tags: map[string]string{"Priority": "critical"}}
+	gamma := Instance{Identifier: "gamma"}
+	delta := Instance{Identifier: "delta", Tags: map[string]string{"Priority": "high"}}
+	instances := []Instance{alpha, beta, gamma, delta}
+
+	tests := []struct {
+		name     string
+		config   ParsedCollectionOrderConfig
+		expected []string
+	}{
+		{
+			name:     "no order keeps discovery order",
+			config:   ParsedCollectionOrderConfig{},
+			expected: []string{"alpha", "beta", "gamma", "delta"},
+		},
+		{
+			name:     "identifiers first in listed order",
+			config:   ParsedCollectionOrderConfig{Identifiers: []string{"gamma", "beta"}},
+			expected: []string{"gamma", "beta", "alpha", "delta"},
+		},
+		{
+			name:     "tag values in listed order",
+			config:   ParsedCollectionOrderConfig{Tag: "Priority", TagValues: []string{"critical", "high"}},
+			expected: []string{"beta", "delta", "alpha", "gamma"},
+		},
+		{
+			name: "identifiers take precedence over tag values",
+			config: ParsedCollectionOrderConfig{
+				Identifiers: []string{"alpha"},
+				Tag:         "Priority",
+				TagValues:   []string{"critical"},
+			},
+			expected: []string{"alpha", "beta", "gamma", "delta"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered := tt.config.OrderInstances(instances)
+
+			identifiers := make([]string, 0, len(ordered))
+			for _, instance := range ordered {
+				identifiers = append(identifiers, instance.Identifier)
+			}
+			assert.Equal(t, tt.expected, identifiers)
+			assert.Equal(t, "alpha", instances[0].Identifier, "input slice must not be modified")
+		})
+	}
+}
+
+func TestSortInstances(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection InstanceSelection
+		expected  []string
+	}{
+		{
+			name:      "oldest first",
+			selection: InstanceSelectionOldest,
+			expected:  []string{"gamma", "alpha", "beta"},
+		},
+		{
+			name:      "empty selection sorts oldest first",
+			selection: "",
+			expected:  []string{"gamma", "alpha", "beta"},
+		},
+		{
+			name:      "newest first",
+			selection: InstanceSelectionNewest,
+			expected:  []string{"beta", "alpha", "gamma"},
+		},
+		{
+			name:      "alphabetical by identifier",
+			selection: InstanceSelectionAlphabetical,
+			expected:  []string{"alpha", "beta", "gamma"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances := []Instance{
+				{Identifier: "beta", CreationTime: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+				{Identifier: "gamma", CreationTime: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{Identifier: "alpha", CreationTime: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+			}
+
+			SortInstances(instances, tt.selection)
+
+			identifiers := make([]string, 0, len(instances))
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+			}
+			assert.Equal(t, tt.expected, identifiers)
+		})
+	}
+}
+
+func TestSortInstancesByPriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string
+		expected []string
+	}{
+		{
+			name:     "highest priority first, untagged and non-numeric last",
+			tag:      "Priority",
+			expected: []string{"delta", "beta", "epsilon", "alpha", "gamma", "zeta"},
+		},
+		{
+			name:     "unknown tag keeps order",
+			tag:      "Missing",
+			expected: []string{"alpha", "beta", "gamma", "delta", "epsilon", "zeta"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances := []Instance{
+				{Identifier: "alpha", Tags: map[string]string{"Priority": "-1"}},
+				{Identifier: "beta", Tags: map[string]string{"Priority": "10"}},
+				{Identifier: "gamma"},
+				{Identifier: "delta", Tags: map[string]string{"Priority": " 42.5 "}},
+				{Identifier: "epsilon", Tags: map[string]string{"Priority": "10"}},
+				{Identifier: "zeta", Tags: map[string]string{"Priority": "high"}},
+			}
+
+			SortInstancesByPriority(instances, tt.tag)
+
+			identifiers := make([]string, 0, len(instances))
+			for _, instance := range instances {
+				identifiers = append(identifiers, instance.Identifier)
+			}
+			assert.Equal(t, tt.expected, identifiers)
+		})
+	}
+}
+
+func TestParsedInstancesConfigEvaluateInstance(t *testing.T) {
+	instance := Instance{Identifier: "prod-temp-db", Engine: PostgreSQL}
+
+	t.Run("with nil filter includes everything", func(t *testing.T) {
+		config := ParsedInstancesConfig{}
+		assert.Equal(t, filter.Decision{Included: true}, config.EvaluateInstance(instance))
+	})
+
+	t.Run("with filter reports matched patterns", func(t *testing.T) {
+		config := ParsedInstancesConfig{
+			Filter: filter.NewPatternFilter(
+				filter.Patterns{"identifier": {regexp.MustCompile("^prod-")}},
+				filter.Patterns{"identifier": {regexp.MustCompile("-temp-")}},
+			),
+		}
+
+		decision := config.EvaluateInstance(instance)
+
+		assert.False(t, decision.Included)
+		assert.Equal(t, config.ShouldIncludeInstance(instance), decision.Included)
+		assert.Equal(t, []filter.PatternMatch{{Field: "identifier", Pattern: "-temp-"}}, decision.ExcludeMatches)
+		assert.Equal(t, []filter.PatternMatch{{Field: "identifier", Pattern: "^prod-"}}, decision.IncludeMatches)
+	})
+
+	t.Run("with global filter reports its matches alongside the instance filter", func(t *testing.T) {
+		tagged := Instance{Identifier: "prod-db", Engine: PostgreSQL, Tags: map[string]string{"monitoring": "off"}}
+		config := ParsedInstancesConfig{
+			Filter:       filter.NewPatternFilter(filter.Patterns{"identifier": {regexp.MustCompile("^prod-")}}, nil),
+			GlobalFilter: filter.NewPatternFilter(nil, filter.Patterns{"tag.monitoring": {regexp.MustCompile("^off$")}}),
+		}
+
+		decision := config.EvaluateInstance(tagged)
+
+		assert.False(t, decision.Included)
+		assert.False(t, config.ShouldIncludeInstance(tagged))
+		assert.Equal(t, []filter.PatternMatch{{Field: "tag.monitoring", Pattern: "^off$"}}, decision.ExcludeMatches)
+		assert.Equal(t, []filter.PatternMatch{{Field: "identifier", Pattern: "^prod-"}}, decision.IncludeMatches)
+		assert.True(t, config.ShouldIncludeInstance(Instance{Identifier: "prod-db", Engine: PostgreSQL}))
+	})
+
+	t.Run("with identifiers allowlist reports the allowlist miss", func(t *testing.T) {
+		config := ParsedInstancesConfig{
+			Identifiers: []string{"prod-db"},
+			Filter:      filter.NewPatternFilter(filter.Patterns{"identifier": {regexp.MustCompile("^prod-")}}, nil),
+		}
+
+		decision := config.EvaluateInstance(instance)
+
+		assert.False(t, decision.Included)
+		assert.Equal(t, []string{IdentifierNotAllowedReason}, decision.ExcludeReasons)
+		assert.Equal(t, "excluded: identifier not in instances.identifiers", decision.Reason())
+
+		allowed := config.EvaluateInstance(Instance{Identifier: "prod-db", Engine: PostgreSQL})
+		assert.True(t, allowed.Included)
+		assert.Empty(t, allowed.ExcludeReasons)
+	})
+
+	t.Run("with identifiers allowlist and global filter keeps the allowlist miss", func(t *testing.T) {
+		config := ParsedInstancesConfig{
+			Identifiers:  []string{"prod-db"},
+			GlobalFilter: filter.NewPatternFilter(nil, filter.Patterns{"engine": {regexp.MustCompile("^mysql$")}}),
+		}
+
+		decision := config.EvaluateInstance(instance)
+
+		assert.False(t, decision.Included)
+		assert.Equal(t, []string{IdentifierNotAllowedReason}, decision.ExcludeReasons)
+	})
+}
+
+func TestParsedMetricsConfigEvaluateMetric(t *testing.T) {
+	metricDetails := MetricDetails{Name: "os.cpuUtilization.idle", Unit: "Percent"}
+
+	t.Run("with nil filter includes everything", func(t *testing.T) {
+		config := ParsedMetricsConfig{}
+		assert.Equal(t, filter.Decision{Included: true}, config.EvaluateMetric(metricDetails))
+	})
+
+	t.Run("with filter reports include misses", func(t *testing.T) {
+		config := ParsedMetricsConfig{
+			Filter: filter.NewPatternFilter(filter.Patterns{"category": {regexp.MustCompile("^db$")}}, nil),
+		}
+
+		decision := config.EvaluateMetric(metricDetails)
+
+		assert.False(t, decision.Included)
+		assert.Empty(t, decision.IncludeMatches)
+		assert.Equal(t, []string{"category"}, decision.IncludeMisses)
+	})
+
+	t.Run("with global filter excludes matching metrics", func(t *testing.T) {
+		config := ParsedMetricsConfig{
+			GlobalFilter: filter.NewPatternFilter(nil, filter.Patterns{"name": {regexp.MustCompile(`\.idle$`)}}),
+		}
+
+		decision := config.EvaluateMetric(metricDetails)
+
+		assert.False(t, decision.Included)
+		assert.False(t, config.ShouldIncludeMetric(metricDetails))
+		assert.Equal(t, []filter.PatternMatch{{Field: "name", Pattern: `\.idle$`}}, decision.ExcludeMatches)
+		assert.True(t, config.ShouldIncludeMetric(MetricDetails{Name: "os.cpuUtilization.guest"}))
+	})
+}
+
 func TestMetricDataStructure(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -1120,3 +2070,164 @@ func TestFilterInterfaceImplementation(t *testing.T) {
 		})
 	}
 }
+
+func TestScrapeStats(t *testing.T) {
+	t.Run("accumulates counts", func(t *testing.T) {
+		stats := NewScrapeStats()
+		stats.AddRegionsScraped(1)
+		stats.AddInstancesDiscovered(3)
+		stats.AddInstancesCollected(2)
+		stats.AddInstancesFailed(1)
+		stats.AddMetricsEmitted(10)
+		stats.AddMetricsEmitted(5)
+		stats.AddErrors(1)
+
+		assert.Equal(t, int64(1), stats.RegionsScraped())
+		assert.Equal(t, int64(3), stats.InstancesDiscovered())
+		assert.Equal(t, int64(2), stats.InstancesCollected())
+		assert.Equal(t, int64(1), stats.InstancesFailed())
+		assert.Equal(t, int64(15), stats.MetricsEmitted())
+		assert.Equal(t, int64(1), stats.Errors())
+		assert.Equal(t, "regions=1 instances_discovered=3 instances_collected=2 instances_failed=1 metrics_emitted=15 errors=1", stats.String())
+
+		var buf bytes.Buffer
+		slog.New(slog.NewTextHandler(&buf, nil)).Info("Scrape summary", "stats", stats)
+		assert.Contains(t, buf.String(), "stats.regions=1 stats.instances_discovered=3 stats.instances_collected=2 stats.instances_failed=1 stats.metrics_emitted=15 stats.errors=1")
+	})
+
+	t.Run("nil stats are no-ops", func(t *testing.T) {
+		var stats *ScrapeStats
+		stats.AddRegionsScraped(1)
+		stats.AddMetricsEmitted(1)
+
+		assert.Equal(t, int64(0), stats.RegionsScraped())
+		assert.Equal(t, "regions=0 instances_discovered=0 instances_collected=0 instances_failed=0 metrics_emitted=0 errors=0", stats.String())
+	})
+
+	t.Run("round trips through context", func(t *testing.T) {
+		stats := NewScrapeStats()
+		ctx := ContextWithScrapeStats(context.Background(), stats)
+
+		assert.Same(t, stats, ScrapeStatsFromContext(ctx))
+		assert.Nil(t, ScrapeStatsFromContext(context.Background()))
+	})
+}
+
+func TestScrapeProgress(t *testing.T) {
+	t.Run("reports completed instances", func(t *testing.T) {
+		var reported []InstanceProgress
+		progress := NewScrapeProgress(func(event InstanceProgress) { reported = append(reported, event) })
+
+		progress.InstanceCompleted(InstanceProgress{Region: "us-west-2", Identifier: "prod-db", Batches: 2})
+
+		assert.Equal(t, []InstanceProgress{{Region: "us-west-2", Identifier: "prod-db", Batches: 2}}, reported)
+	})
+
+	t.Run("nil progress is a no-op", func(t *testing.T) {
+		var progress *ScrapeProgress
+		progress.InstanceCompleted(InstanceProgress{Identifier: "prod-db"})
+	})
+
+	t.Run("round trips through context", func(t *testing.T) {
+		progress := NewScrapeProgress(func(InstanceProgress) {})
+		ctx := ContextWithScrapeProgress(context.Background(), progress)
+
+		assert.Same(t, progress, ScrapeProgressFromContext(ctx))
+		assert.Nil(t, ScrapeProgressFromContext(context.Background()))
+	})
+}
+
+func TestMetricDescriptions(t *testing.T) {
+	t.Run("marks each metric name once", func(t *testing.T) {
+		descriptions := NewMetricDescriptions()
+
+		assert.True(t, descriptions.MarkDescribed("dbi_os_general_numvcpus_avg"))
+		assert.False(t, descriptions.MarkDescribed("dbi_os_general_numvcpus_avg"))
+		assert.True(t, descriptions.MarkDescribed("dbi_os_cpuutilization_idle_avg"))
+	})
+
+	t.Run("nil descriptions never mark", func(t *testing.T) {
+		var descriptions *MetricDescriptions
+
+		assert.False(t, descriptions.MarkDescribed("dbi_os_general_numvcpus_avg"))
+	})
+
+	t.Run("round trips through context", func(t *testing.T) {
+		descriptions := NewMetricDescriptions()
+		ctx := ContextWithMetricDescriptions(context.Background(), descriptions)
+
+		assert.Same(t, descriptions, MetricDescriptionsFromContext(ctx))
+		assert.Nil(t, MetricDescriptionsFromContext(context.Background()))
+	})
+}
+
+func TestCollectedMetricData(t *testing.T) {
+	t.Run("groups metric data by instance in insertion order", func(t *testing.T) {
+		collected := NewCollectedMetricData()
+		postgres := Instance{ResourceID: "db-POSTGRES", Identifier: "postgres"}
+		mysql := Instance{ResourceID: "db-MYSQL", Identifier: "mysql"}
+
+		collected.Add(postgres, []MetricData{{Metric: "os.memory.free.avg", Value: 1}})
+		collected.Add(mysql, []MetricData{{Metric: "os.memory.free.avg", Value: 2}})
+		collected.Add(postgres, []MetricData{{Metric: "os.memory.total.avg", Value: 4}})
+		collected.Add(mysql, nil)
+
+		var identifiers []string
+		counts := make(map[string]int)
+		collected.ForEach(func(instance Instance, metricData []MetricData) {
+			identifiers = append(identifiers, instance.Identifier)
+			counts[instance.Identifier] = len(metricData)
+		})
+
+		assert.Equal(t, []string{"postgres", "mysql"}, identifiers)
+		assert.Equal(t, map[string]int{"postgres": 2, "mysql": 1}, counts)
+	})
+
+	t.Run("nil accumulator ignores data", func(t *testing.T) {
+		var collected *CollectedMetricData
+
+		collected.Add(Instance{ResourceID: "db-POSTGRES"}, []MetricData{{Metric: "os.memory.free.avg"}})
+		collected.ForEach(func(instance Instance, metricData []MetricData) {
+			t.Fatal("nil accumulator should not call fn")
+		})
+	})
+
+	t.Run("round trips through context", func(t *testing.T) {
+		collected := NewCollectedMetricData()
+		ctx := ContextWithCollectedMetricData(context.Background(), collected)
+
+		assert.Same(t, collected, CollectedMetricDataFromContext(ctx))
+		assert.Nil(t, CollectedMetricDataFromContext(context.Background()))
+	})
+}
+
+func TestScrapeRefresh(t *testing.T) {
+	tests := []struct {
+		input             string
+		expected          ScrapeRefresh
+		expectedInstances bool
+		expectedMetadata  bool
+	}{
+		{input: "instances", expected: ScrapeRefreshInstances, expectedInstances: true},
+		{input: "metadata", expected: ScrapeRefreshMetadata, expectedMetadata: true},
+		{input: "all", expected: ScrapeRefreshAll, expectedInstances: true, expectedMetadata: true},
+		{input: "everything", expected: ScrapeRefreshNone},
+		{input: "", expected: ScrapeRefreshNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			refresh := NewScrapeRefresh(tt.input)
+			assert.Equal(t, tt.expected, refresh)
+			assert.Equal(t, tt.expectedInstances, refresh.Instances())
+			assert.Equal(t, tt.expectedMetadata, refresh.Metadata())
+		})
+	}
+
+	t.Run("round trips through context", func(t *testing.T) {
+		ctx := ContextWithScrapeRefresh(context.Background(), ScrapeRefreshAll)
+
+		assert.Equal(t, ScrapeRefreshAll, ScrapeRefreshFromContext(ctx))
+		assert.Equal(t, ScrapeRefreshNone, ScrapeRefreshFromContext(context.Background()))
+	})
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"context"
+)
+
+type scrapeProgressKey struct{}
+
+// InstanceProgress reports the end of metric collection for a single instance during a scrape.
+// Batches is the number of metric batches of the instance, of which FailedBatches failed and SkippedBatches were never
+// collected because the batch limit was reached or the scrape was cancelled. Error is set when the batches could not be fetched at all.
+type InstanceProgress struct {
+	Region         string `json:"region"`
+	Identifier     string `json:"identifier"`
+	Batches        int    `json:"batches"`
+	FailedBatches  int    `json:"failedBatches,omitempty"`
+	SkippedBatches int    `json:"skippedBatches,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// ScrapeProgress reports per-instance collection progress for a single scrape as it flows through the collection path.
+// The report function is called concurrently from collection workers. Methods are no-ops on a nil receiver,
+// so callers never need to check whether progress is being tracked for the current scrape.
+type ScrapeProgress struct {
+	report func(InstanceProgress)
+}
+
+func NewScrapeProgress(report func(InstanceProgress)) *ScrapeProgress {
+	return &ScrapeProgress{report: report}
+}
+
+// ContextWithScrapeProgress returns a copy of ctx carrying the provided progress reporter.
+func ContextWithScrapeProgress(ctx context.Context, progress *ScrapeProgress) context.Context {
+	return context.WithValue(ctx, scrapeProgressKey{}, progress)
+}
+
+// ScrapeProgressFromContext returns the progress reporter carried by ctx, or nil if there is none.
+func ScrapeProgressFromContext(ctx context.Context) *ScrapeProgress {
+	progress, _ := ctx.Value(scrapeProgressKey{}).(*ScrapeProgress)
+	return progress
+}
+
+// InstanceCompleted reports that collection finished for an instance.
+func (progress *ScrapeProgress) InstanceCompleted(event InstanceProgress) {
+	if progress != nil && progress.report != nil {
+		progress.report(event)
+	}
+}
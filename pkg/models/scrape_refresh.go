@@ -0,0 +1,16 @@
+package models
+
+import "context"
+
+type scrapeRefreshKey struct{}
+
+// ContextWithScrapeRefresh returns a copy of ctx carrying the caches the scrape bypasses.
+func ContextWithScrapeRefresh(ctx context.Context, refresh ScrapeRefresh) context.Context {
+	return context.WithValue(ctx, scrapeRefreshKey{}, refresh)
+}
+
+// ScrapeRefreshFromContext returns the caches the scrape carried by ctx bypasses, ScrapeRefreshNone if there are none.
+func ScrapeRefreshFromContext(ctx context.Context) ScrapeRefresh {
+	refresh, _ := ctx.Value(scrapeRefreshKey{}).(ScrapeRefresh)
+	return refresh
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+type scrapeStatsKey struct{}
+
+// ScrapeStats accumulates statistics for a single scrape as it flows through the collection path.
+// All methods are safe for concurrent use and are no-ops on a nil receiver, so callers never need to check
+// whether stats are being tracked for the current scrape.
+type ScrapeStats struct {
+	regionsScraped      atomic.Int64
+	instancesDiscovered atomic.Int64
+	instancesCollected  atomic.Int64
+	instancesFailed     atomic.Int64
+	metricsEmitted      atomic.Int64
+	errors              atomic.Int64
+}
+
+func NewScrapeStats() *ScrapeStats {
+	return &ScrapeStats{}
+}
+
+// ContextWithScrapeStats returns a copy of ctx carrying the provided stats accumulator.
+func ContextWithScrapeStats(ctx context.Context, stats *ScrapeStats) context.Context {
+	return context.WithValue(ctx, scrapeStatsKey{}, stats)
+}
+
+// ScrapeStatsFromContext returns the stats accumulator carried by ctx, or nil if there is none.
+func ScrapeStatsFromContext(ctx context.Context) *ScrapeStats {
+	stats, _ := ctx.Value(scrapeStatsKey{}).(*ScrapeStats)
+	return stats
+}
+
+func (stats *ScrapeStats) AddRegionsScraped(count int) {
+	if stats != nil {
+		stats.regionsScraped.Add(int64(count))
+	}
+}
+
+func (stats *ScrapeStats) AddInstancesDiscovered(count int) {
+	if stats != nil {
+		stats.instancesDiscovered.Add(int64(count))
+	}
+}
+
+func (stats *ScrapeStats) AddInstancesCollected(count int) {
+	if stats != nil {
+		stats.instancesCollected.Add(int64(count))
+	}
+}
+
+// AddInstancesFailed counts instances whose metric batches could not be fetched or of which at least one batch failed.
+func (stats *ScrapeStats) AddInstancesFailed(count int) {
+	if stats != nil {
+		stats.instancesFailed.Add(int64(count))
+	}
+}
+
+func (stats *ScrapeStats) AddMetricsEmitted(count int) {
+	if stats != nil {
+		stats.metricsEmitted.Add(int64(count))
+	}
+}
+
+func (stats *ScrapeStats) AddErrors(count int) {
+	if stats != nil {
+		stats.errors.Add(int64(count))
+	}
+}
+
+func (stats *ScrapeStats) RegionsScraped() int64 {
+	if stats == nil {
+		return 0
+	}
+	return stats.regionsScraped.Load()
+}
+
+func (stats *ScrapeStats) InstancesDiscovered() int64 {
+	if stats == nil {
+		return 0
+	}
+	return stats.instancesDiscovered.Load()
+}
+
+func (stats *ScrapeStats) InstancesCollected() int64 {
+	if stats == nil {
+		return 0
+	}
+	return stats.instancesCollected.Load()
+}
+
+func (stats *ScrapeStats) InstancesFailed() int64 {
+	if stats == nil {
+		return 0
+	}
+	return stats.instancesFailed.Load()
+}
+
+func (stats *ScrapeStats) MetricsEmitted() int64 {
+	if stats == nil {
+		return 0
+	}
+	return stats.metricsEmitted.Load()
+}
+
+func (stats *ScrapeStats) Errors() int64 {
+	if stats == nil {
+		return 0
+	}
+	return stats.errors.Load()
+}
+
+// LogValue logs the stats as a group of counters, e.g. stats.regions=1 stats.instances_discovered=3 in text logs.
+func (stats *ScrapeStats) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int64("regions", stats.RegionsScraped()),
+		slog.Int64("instances_discovered", stats.InstancesDiscovered()),
+		slog.Int64("instances_collected", stats.InstancesCollected()),
+		slog.Int64("instances_failed", stats.InstancesFailed()),
+		slog.Int64("metrics_emitted", stats.MetricsEmitted()),
+		slog.Int64("errors", stats.Errors()),
+	)
+}
+
+// String formats the stats as space separated key=value pairs.
+func (stats *ScrapeStats) String() string {
+	return fmt.Sprintf("regions=%d instances_discovered=%d instances_collected=%d instances_failed=%d metrics_emitted=%d errors=%d",
+		stats.RegionsScraped(), stats.InstancesDiscovered(), stats.InstancesCollected(), stats.InstancesFailed(), stats.MetricsEmitted(), stats.Errors())
+}
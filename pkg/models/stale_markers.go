@@ -0,0 +1,17 @@
+package models
+
+import "context"
+
+type staleMarkersKey struct{}
+
+// ContextWithStaleMarkers returns a copy of ctx whose collection may export staleness markers, which only
+// remote-write preserves.
+func ContextWithStaleMarkers(ctx context.Context) context.Context {
+	return context.WithValue(ctx, staleMarkersKey{}, true)
+}
+
+// StaleMarkersFromContext reports whether the collection carried by ctx may export staleness markers.
+func StaleMarkersFromContext(ctx context.Context) bool {
+	staleMarkers, _ := ctx.Value(staleMarkersKey{}).(bool)
+	return staleMarkers
+}
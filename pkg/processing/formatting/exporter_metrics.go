@@ -0,0 +1,284 @@
+package formatting
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+)
+
+const (
+	BatchLimitReachedMetricName      = "batch_limit_reached"
+	InstanceLimitReachedMetricName   = "instance_limit_reached"
+	MetricDescriptionInfoMetricName  = "metric_description_info"
+	DiscoveredMetricNamesMetricName  = "discovered_metric_names"
+	InstancePIUnsupportedMetricName  = "instance_pi_unsupported"
+	ExporterTimeMetricName           = "exporter_time_seconds"
+	DataPointsReturnedMetricName     = "datapoints_returned"
+	EffectiveConcurrencyMetricName   = "effective_concurrency"
+	EffectiveBatchSizeMetricName     = "effective_batch_size"
+	AdaptiveConcurrencyMetricName    = "adaptive_concurrency"
+	AWSAPICallsMetricName            = "aws_api_calls_total"
+	AWSAPICallDurationMetricName     = "aws_api_call_duration_seconds"
+	ConversionErrorsMetricName       = "metric_conversion_errors_total"
+	ExporterHealthyMetricName        = "exporter_healthy"
+	ScrapeInstanceErrorsMetricName   = "scrape_instance_errors"
+	InstanceScrapeDurationMetricName = "instance_scrape_duration_seconds"
+	InstanceUpMetricName             = "instance_up"
+	InstanceInfoMetricName           = "instance_info"
+)
+
+// NewBatchLimitReachedMetric reports whether a scrape in the region stopped queueing metric batches because
+// processing.max-batches-per-scrape was reached (1) or not (0).
+func NewBatchLimitReachedMetric(prometheusConfig models.ParsedPrometheusConfig, region string, reached bool) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, BatchLimitReachedMetricName),
+		"Whether the last scrape stopped queueing metric batches because processing.max-batches-per-scrape was reached",
+		[]string{"region"},
+		prometheusConfig.StaticLabels,
+	)
+
+	value := 0.0
+	if reached {
+		value = 1.0
+	}
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, value, region)
+}
+
+// NewInstanceLimitReachedMetric reports whether the last discovery in the region found more instances than
+// instances.max-instances (1), so that only the first ones are collected, or not (0).
+func NewInstanceLimitReachedMetric(prometheusConfig models.ParsedPrometheusConfig, region string, reached bool) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, InstanceLimitReachedMetricName),
+		"Whether the last instance discovery found more instances than instances.max-instances and was truncated",
+		[]string{"region"},
+		prometheusConfig.StaticLabels,
+	)
+
+	value := 0.0
+	if reached {
+		value = 1.0
+	}
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, value, region)
+}
+
+// NewMetricDescriptionInfoMetric exposes the Performance Insights description of an exported metric as an info metric,
+// so descriptions can be queried in Prometheus instead of only being available as help text.
+func NewMetricDescriptionInfoMetric(prometheusConfig models.ParsedPrometheusConfig, metricName string, description string) (prometheus.Metric, error) {
+	name := BuildExporterMetricName(prometheusConfig, MetricDescriptionInfoMetricName)
+	help := "Performance Insights description of an exported metric, always 1"
+	desc := prometheus.NewDesc(name, help, []string{"metric", "description"}, prometheusConfig.StaticLabels)
+
+	metric, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, 1, metricName, description)
+	if err != nil {
+		return nil, err
+	}
+	return DescribedMetric{Metric: metric, Name: name, Help: help}, nil
+}
+
+// NewDiscoveredMetricNamesMetric reports how many distinct Performance Insights metric names were last discovered
+// for an engine in a metric category, so changes to the metric catalog can be tracked over time.
+func NewDiscoveredMetricNamesMetric(prometheusConfig models.ParsedPrometheusConfig, region string, engine models.Engine, category string, count int) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, DiscoveredMetricNamesMetricName),
+		"Number of distinct Performance Insights metric names last discovered for the engine and category",
+		[]string{"region", "engine", "category"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, float64(count), region, string(engine), category)
+}
+
+// NewInstancePIUnsupportedMetric reports an instance that is skipped because Performance Insights does not support it.
+func NewInstancePIUnsupportedMetric(prometheusConfig models.ParsedPrometheusConfig, instance models.Instance) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, InstancePIUnsupportedMetricName),
+		"Instance skipped because Performance Insights reported it as unsupported, always 1",
+		[]string{"identifier", "engine"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, 1, instance.Identifier, string(instance.Engine))
+}
+
+// NewScrapeInstanceErrorsMetric reports the number of errors collecting an instance in the last scrape. Only instances
+// that failed are reported; the metrics of the other instances are served as usual.
+func NewScrapeInstanceErrorsMetric(prometheusConfig models.ParsedPrometheusConfig, region string, instance models.Instance, count int) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, ScrapeInstanceErrorsMetricName),
+		"Number of errors collecting the metrics of the instance in the last scrape, reported only for instances that failed",
+		[]string{"identifier", "engine", "region"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, float64(count), instance.Identifier, string(instance.Engine), region)
+}
+
+// NewInstanceScrapeDurationMetric reports the time the last scrape spent collecting the instance, from fetching its
+// metric batches to collecting its last batch.
+func NewInstanceScrapeDurationMetric(prometheusConfig models.ParsedPrometheusConfig, region string, instance models.Instance, duration time.Duration) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, InstanceScrapeDurationMetricName),
+		"Time the last scrape spent collecting the metrics of the instance in seconds",
+		[]string{"identifier", "region"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, duration.Seconds(), instance.Identifier, region)
+}
+
+// NewInstanceUpMetric reports whether the last scrape collected every metric batch of the instance (1) or not (0).
+func NewInstanceUpMetric(prometheusConfig models.ParsedPrometheusConfig, region string, instance models.Instance, up bool) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, InstanceUpMetricName),
+		"Whether the last scrape collected all metrics of the instance",
+		[]string{"identifier", "region"},
+		prometheusConfig.StaticLabels,
+	)
+
+	value := 0.0
+	if up {
+		value = 1.0
+	}
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, value, instance.Identifier, region)
+}
+
+// NewInstanceInfoMetric exposes the class and allocated storage of an instance as an info metric, so Performance Insights
+// metrics can be correlated with the instance size by joining on the identifier.
+func NewInstanceInfoMetric(prometheusConfig models.ParsedPrometheusConfig, region string, instance models.Instance) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, InstanceInfoMetricName),
+		"Class and allocated storage in GiB of the instance, always 1",
+		[]string{"identifier", "engine", "class", "storage_gb", "region"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, 1,
+		instance.Identifier, string(instance.Engine), instance.InstanceClass, strconv.Itoa(int(instance.AllocatedStorage)), region)
+}
+
+// NewExporterTimeMetric reports the time a scrape of the region ran as Unix seconds in UTC.
+// A value that stops advancing in Prometheus reveals a hung exporter or scrape, even when no instance is collected.
+func NewExporterTimeMetric(prometheusConfig models.ParsedPrometheusConfig, region string, now time.Time) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, ExporterTimeMetricName),
+		"Current time of the exporter in Unix seconds, set at every scrape",
+		[]string{"region"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, float64(now.UTC().UnixNano())/1e9, region)
+}
+
+// NewDataPointsReturnedMetric reports the total number of data points Performance Insights returned for a metric in the region,
+// before they are collapsed to the latest valid data point. Comparing its rate with the scrape rate reveals the data density.
+func NewDataPointsReturnedMetric(prometheusConfig models.ParsedPrometheusConfig, region string, metricName string, count uint64) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, DataPointsReturnedMetricName),
+		"Total number of data points returned by Performance Insights for the metric, before keeping only the latest",
+		[]string{"region", "metric"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.CounterValue, float64(count), region, metricName)
+}
+
+// NewConversionErrorsMetric reports the total number of metric data points in the region that were dropped because
+// they could not be converted to a Prometheus metric, by the reason returned by ConversionErrorReason.
+func NewConversionErrorsMetric(prometheusConfig models.ParsedPrometheusConfig, region string, reason string, count uint64) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, ConversionErrorsMetricName),
+		"Total number of metric data points dropped because they could not be converted to a Prometheus metric",
+		[]string{"region", "reason"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.CounterValue, float64(count), region, reason)
+}
+
+// NewExporterHealthyMetric reports whether the fraction of instances that failed collection in the last full scrape
+// stayed within export.unhealthy-threshold (1) or exceeded it (0).
+func NewExporterHealthyMetric(prometheusConfig models.ParsedPrometheusConfig, healthy bool) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, ExporterHealthyMetricName),
+		"Whether the fraction of instances that failed collection in the last full scrape was within export.unhealthy-threshold",
+		nil,
+		prometheusConfig.StaticLabels,
+	)
+
+	value := 0.0
+	if healthy {
+		value = 1.0
+	}
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, value)
+}
+
+// NewEffectiveConcurrencyMetric reports the number of concurrent collection workers in effect in the region,
+// after processing.collection-concurrency was validated and clamped.
+func NewEffectiveConcurrencyMetric(prometheusConfig models.ParsedPrometheusConfig, region string, concurrency int) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, EffectiveConcurrencyMetricName),
+		"Number of concurrent metric collection workers in effect",
+		[]string{"region"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, float64(concurrency), region)
+}
+
+// NewAdaptiveConcurrencyMetric reports the number of GetResourceMetrics calls the region currently allows in flight,
+// which drops below the effective concurrency while Performance Insights throttles the region.
+func NewAdaptiveConcurrencyMetric(prometheusConfig models.ParsedPrometheusConfig, region string, concurrency int) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, AdaptiveConcurrencyMetricName),
+		"Number of concurrent Performance Insights metric data requests currently allowed after throttling backoff",
+		[]string{"region"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, float64(concurrency), region)
+}
+
+// NewEffectiveBatchSizeMetric reports the maximum number of metrics requested per Performance Insights call in the region.
+func NewEffectiveBatchSizeMetric(prometheusConfig models.ParsedPrometheusConfig, region string, batchSize int) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, EffectiveBatchSizeMetricName),
+		"Maximum number of metrics requested per Performance Insights GetResourceMetrics call in effect",
+		[]string{"region"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.GaugeValue, float64(batchSize), region)
+}
+
+// NewAWSAPICallsMetric reports the total number of attempts of an AWS API operation since the exporter started, by
+// result, including retried attempts.
+func NewAWSAPICallsMetric(prometheusConfig models.ParsedPrometheusConfig, service string, operation string, result string, count uint64) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, AWSAPICallsMetricName),
+		"Total number of AWS API call attempts, including retries, by service, operation and result",
+		[]string{"service", "operation", "result"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstMetric(desc, prometheus.CounterValue, float64(count), service, operation, result)
+}
+
+// NewAWSAPICallDurationMetric reports the latency histogram of the attempts of an AWS API operation since the exporter
+// started. buckets holds the cumulative count of attempts per upper bound in seconds.
+func NewAWSAPICallDurationMetric(prometheusConfig models.ParsedPrometheusConfig, service string, operation string, count uint64, sum float64, buckets map[float64]uint64) (prometheus.Metric, error) {
+	desc := prometheus.NewDesc(
+		BuildExporterMetricName(prometheusConfig, AWSAPICallDurationMetricName),
+		"Latency of AWS API call attempts in seconds, by service and operation",
+		[]string{"service", "operation"},
+		prometheusConfig.StaticLabels,
+	)
+
+	return prometheus.NewConstHistogram(desc, count, sum, buckets, service, operation)
+}
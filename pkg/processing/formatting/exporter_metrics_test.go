@@ -0,0 +1,374 @@
+package formatting
+
+import (
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
+)
+
+func TestNewBatchLimitReachedMetric(t *testing.T) {
+	testCases := []struct {
+		name             string
+		prometheusConfig models.ParsedPrometheusConfig
+		reached          bool
+		expectedName     string
+		expectedValue    float64
+	}{
+		{
+			name:             "limit reached",
+			prometheusConfig: testutils.TestPrometheusConfig,
+			reached:          true,
+			expectedName:     "dbi_batch_limit_reached",
+			expectedValue:    1,
+		},
+		{
+			name:             "limit not reached",
+			prometheusConfig: testutils.TestPrometheusConfig,
+			reached:          false,
+			expectedName:     "dbi_batch_limit_reached",
+			expectedValue:    0,
+		},
+		{
+			name:             "namespace and subsystem applied",
+			prometheusConfig: models.ParsedPrometheusConfig{MetricPrefix: "dbi", Namespace: "aws", Subsystem: "rds"},
+			reached:          true,
+			expectedName:     "aws_rds_batch_limit_reached",
+			expectedValue:    1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metric, err := NewBatchLimitReachedMetric(tc.prometheusConfig, testutils.TestRegion, tc.reached)
+			require.NoError(t, err)
+
+			assert.Contains(t, metric.Desc().String(), `fqName: "`+tc.expectedName+`"`)
+
+			var written dto.Metric
+			require.NoError(t, metric.Write(&written))
+			assert.Equal(t, tc.expectedValue, written.GetGauge().GetValue())
+			require.Len(t, written.GetLabel(), 1)
+			assert.Equal(t, "region", written.GetLabel()[0].GetName())
+			assert.Equal(t, testutils.TestRegion, written.GetLabel()[0].GetValue())
+		})
+	}
+}
+
+func TestNewInstanceLimitReachedMetric(t *testing.T) {
+	testCases := []struct {
+		name          string
+		reached       bool
+		expectedValue float64
+	}{
+		{
+			name:          "limit reached",
+			reached:       true,
+			expectedValue: 1,
+		},
+		{
+			name:          "limit not reached",
+			reached:       false,
+			expectedValue: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metric, err := NewInstanceLimitReachedMetric(testutils.TestPrometheusConfig, testutils.TestRegion, tc.reached)
+			require.NoError(t, err)
+
+			assert.Contains(t, metric.Desc().String(), `fqName: "dbi_instance_limit_reached"`)
+
+			var written dto.Metric
+			require.NoError(t, metric.Write(&written))
+			assert.Equal(t, tc.expectedValue, written.GetGauge().GetValue())
+			require.Len(t, written.GetLabel(), 1)
+			assert.Equal(t, "region", written.GetLabel()[0].GetName())
+			assert.Equal(t, testutils.TestRegion, written.GetLabel()[0].GetValue())
+		})
+	}
+}
+
+func TestNewMetricDescriptionInfoMetric(t *testing.T) {
+	metric, err := NewMetricDescriptionInfoMetric(testutils.TestPrometheusConfig, "dbi_os_general_numvcpus_avg", "The number of virtual CPUs for the DB instance")
+	require.NoError(t, err)
+
+	assert.Contains(t, metric.Desc().String(), `fqName: "dbi_metric_description_info"`)
+
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+	assert.Equal(t, 1.0, written.GetGauge().GetValue())
+
+	labels := make(map[string]string, len(written.GetLabel()))
+	for _, label := range written.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, map[string]string{
+		"metric":      "dbi_os_general_numvcpus_avg",
+		"description": "The number of virtual CPUs for the DB instance",
+	}, labels)
+}
+
+func TestNewDiscoveredMetricNamesMetric(t *testing.T) {
+	metric, err := NewDiscoveredMetricNamesMetric(testutils.TestPrometheusConfig, "us-west-2", models.AuroraPostgreSQL, "os", 42)
+	require.NoError(t, err)
+
+	assert.Contains(t, metric.Desc().String(), `fqName: "dbi_discovered_metric_names"`)
+
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+	assert.Equal(t, 42.0, written.GetGauge().GetValue())
+
+	labels := make(map[string]string, len(written.GetLabel()))
+	for _, label := range written.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, map[string]string{"region": "us-west-2", "engine": "aurora-postgresql", "category": "os"}, labels)
+}
+
+func TestNewInstancePIUnsupportedMetric(t *testing.T) {
+	metric, err := NewInstancePIUnsupportedMetric(testutils.TestPrometheusConfig, testutils.TestInstancePostgreSQL)
+	require.NoError(t, err)
+
+	assert.Contains(t, metric.Desc().String(), `fqName: "dbi_instance_pi_unsupported"`)
+
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+	assert.Equal(t, 1.0, written.GetGauge().GetValue())
+
+	labels := make(map[string]string, len(written.GetLabel()))
+	for _, label := range written.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, map[string]string{"identifier": "test-postgres-db", "engine": "aurora-postgresql"}, labels)
+}
+
+func TestNewScrapeInstanceErrorsMetric(t *testing.T) {
+	metric, err := NewScrapeInstanceErrorsMetric(testutils.TestPrometheusConfig, testutils.TestRegion, testutils.TestInstancePostgreSQL, 3)
+	require.NoError(t, err)
+
+	assert.Contains(t, metric.Desc().String(), `fqName: "dbi_scrape_instance_errors"`)
+
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+	assert.Equal(t, 3.0, written.GetGauge().GetValue())
+
+	labels := make(map[string]string, len(written.GetLabel()))
+	for _, label := range written.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, map[string]string{"identifier": "test-postgres-db", "engine": "aurora-postgresql", "region": testutils.TestRegion}, labels)
+}
+
+func TestNewInstanceScrapeDurationMetric(t *testing.T) {
+	metric, err := NewInstanceScrapeDurationMetric(testutils.TestPrometheusConfig, testutils.TestRegion, testutils.TestInstancePostgreSQL, 1500*time.Millisecond)
+	require.NoError(t, err)
+
+	assert.Contains(t, metric.Desc().String(), `fqName: "dbi_instance_scrape_duration_seconds"`)
+
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+	assert.Equal(t, 1.5, written.GetGauge().GetValue())
+
+	labels := make(map[string]string, len(written.GetLabel()))
+	for _, label := range written.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, map[string]string{"identifier": "test-postgres-db", "region": testutils.TestRegion}, labels)
+}
+
+func TestNewInstanceUpMetric(t *testing.T) {
+	testCases := []struct {
+		name             string
+		prometheusConfig models.ParsedPrometheusConfig
+		up               bool
+		expectedName     string
+		expectedValue    float64
+	}{
+		{
+			name:             "instance up",
+			prometheusConfig: testutils.TestPrometheusConfig,
+			up:               true,
+			expectedName:     "dbi_instance_up",
+			expectedValue:    1,
+		},
+		{
+			name:             "instance down",
+			prometheusConfig: testutils.TestPrometheusConfig,
+			up:               false,
+			expectedName:     "dbi_instance_up",
+			expectedValue:    0,
+		},
+		{
+			name:             "custom metric prefix",
+			prometheusConfig: models.ParsedPrometheusConfig{MetricPrefix: "pi"},
+			up:               true,
+			expectedName:     "pi_instance_up",
+			expectedValue:    1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metric, err := NewInstanceUpMetric(tc.prometheusConfig, testutils.TestRegion, testutils.TestInstancePostgreSQL, tc.up)
+			require.NoError(t, err)
+
+			assert.Contains(t, metric.Desc().String(), `fqName: "`+tc.expectedName+`"`)
+
+			var written dto.Metric
+			require.NoError(t, metric.Write(&written))
+			assert.Equal(t, tc.expectedValue, written.GetGauge().GetValue())
+		})
+	}
+}
+
+func TestNewInstanceInfoMetric(t *testing.T) {
+	instance := testutils.TestInstancePostgreSQL
+	instance.InstanceClass = "db.r6g.large"
+	instance.AllocatedStorage = 100
+
+	metric, err := NewInstanceInfoMetric(testutils.TestPrometheusConfig, testutils.TestRegion, instance)
+	require.NoError(t, err)
+
+	assert.Contains(t, metric.Desc().String(), `fqName: "dbi_instance_info"`)
+
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+	assert.Equal(t, 1.0, written.GetGauge().GetValue())
+
+	labels := make(map[string]string, len(written.GetLabel()))
+	for _, label := range written.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, map[string]string{
+		"identifier": "test-postgres-db",
+		"engine":     "aurora-postgresql",
+		"class":      "db.r6g.large",
+		"storage_gb": "100",
+		"region":     testutils.TestRegion,
+	}, labels)
+}
+
+func TestNewExporterTimeMetric(t *testing.T) {
+	now := time.Date(2025, 10, 28, 10, 0, 0, 500000000, time.FixedZone("PST", -8*60*60))
+
+	metric, err := NewExporterTimeMetric(testutils.TestPrometheusConfig, testutils.TestRegion, now)
+	require.NoError(t, err)
+
+	assert.Contains(t, metric.Desc().String(), `fqName: "dbi_exporter_time_seconds"`)
+
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+	assert.Equal(t, float64(now.Unix())+0.5, written.GetGauge().GetValue())
+	require.Len(t, written.GetLabel(), 1)
+	assert.Equal(t, "region", written.GetLabel()[0].GetName())
+	assert.Equal(t, testutils.TestRegion, written.GetLabel()[0].GetValue())
+}
+
+func TestNewDataPointsReturnedMetric(t *testing.T) {
+	metric, err := NewDataPointsReturnedMetric(testutils.TestPrometheusConfig, "us-west-2", "os.general.numVCPUs.avg", 12)
+	require.NoError(t, err)
+
+	assert.Contains(t, metric.Desc().String(), `fqName: "dbi_datapoints_returned"`)
+
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+	assert.Equal(t, 12.0, written.GetCounter().GetValue())
+
+	labels := make(map[string]string, len(written.GetLabel()))
+	for _, label := range written.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, map[string]string{"region": "us-west-2", "metric": "os.general.numVCPUs.avg"}, labels)
+}
+
+func TestNewConversionErrorsMetric(t *testing.T) {
+	metric, err := NewConversionErrorsMetric(testutils.TestPrometheusConfig, "us-west-2", ConversionErrorMissingDetails, 3)
+	require.NoError(t, err)
+
+	assert.Contains(t, metric.Desc().String(), `fqName: "dbi_metric_conversion_errors_total"`)
+
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+	assert.Equal(t, 3.0, written.GetCounter().GetValue())
+
+	labels := make(map[string]string, len(written.GetLabel()))
+	for _, label := range written.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, map[string]string{"region": "us-west-2", "reason": "missing_metric_details"}, labels)
+}
+
+func TestExporterMetricsWithStaticLabels(t *testing.T) {
+	prometheusConfig := testutils.TestPrometheusConfig
+	prometheusConfig.StaticLabels = map[string]string{"account_id": "123456789012"}
+
+	metric, err := NewInstanceUpMetric(prometheusConfig, testutils.TestRegion, testutils.TestInstancePostgreSQL, true)
+	require.NoError(t, err)
+
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+
+	labels := make(map[string]string, len(written.GetLabel()))
+	for _, label := range written.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, map[string]string{"account_id": "123456789012", "identifier": "test-postgres-db", "region": testutils.TestRegion}, labels)
+}
+
+func TestNewExporterHealthyMetric(t *testing.T) {
+	testCases := []struct {
+		name     string
+		healthy  bool
+		expected float64
+	}{
+		{name: "healthy", healthy: true, expected: 1},
+		{name: "unhealthy", healthy: false, expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metric, err := NewExporterHealthyMetric(testutils.TestPrometheusConfig, tc.healthy)
+			require.NoError(t, err)
+
+			assert.Contains(t, metric.Desc().String(), `fqName: "dbi_exporter_healthy"`)
+
+			var written dto.Metric
+			require.NoError(t, metric.Write(&written))
+			assert.Equal(t, tc.expected, written.GetGauge().GetValue())
+			assert.Empty(t, written.GetLabel())
+		})
+	}
+}
+
+func TestNewEffectiveSettingsMetrics(t *testing.T) {
+	concurrency, err := NewEffectiveConcurrencyMetric(testutils.TestPrometheusConfig, "us-west-2", 8)
+	require.NoError(t, err)
+	assert.Contains(t, concurrency.Desc().String(), `fqName: "dbi_effective_concurrency"`)
+
+	var written dto.Metric
+	require.NoError(t, concurrency.Write(&written))
+	assert.Equal(t, 8.0, written.GetGauge().GetValue())
+
+	batchSize, err := NewEffectiveBatchSizeMetric(testutils.TestPrometheusConfig, "us-west-2", 15)
+	require.NoError(t, err)
+	assert.Contains(t, batchSize.Desc().String(), `fqName: "dbi_effective_batch_size"`)
+
+	require.NoError(t, batchSize.Write(&written))
+	assert.Equal(t, 15.0, written.GetGauge().GetValue())
+	require.Len(t, written.GetLabel(), 1)
+	assert.Equal(t, "us-west-2", written.GetLabel()[0].GetValue())
+
+	adaptiveConcurrency, err := NewAdaptiveConcurrencyMetric(testutils.TestPrometheusConfig, "us-west-2", 2)
+	require.NoError(t, err)
+	assert.Contains(t, adaptiveConcurrency.Desc().String(), `fqName: "dbi_adaptive_concurrency"`)
+
+	require.NoError(t, adaptiveConcurrency.Write(&written))
+	assert.Equal(t, 2.0, written.GetGauge().GetValue())
+}
@@ -0,0 +1,88 @@
+package formatting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/utils"
+)
+
+const (
+	memoryFreeMetric        = "os.memory.free"
+	memoryTotalMetric       = "os.memory.total"
+	memoryFreePercentMetric = "os.memory.freePercent"
+)
+
+// MetricPostProcessor derives synthetic metrics from the full set of metric data collected for an instance in a scrape.
+// Implementations must not modify metricData and only return the additional metric data to export.
+type MetricPostProcessor interface {
+	Process(instance models.Instance, metricData []models.MetricData) []DerivedMetricData
+}
+
+// DerivedMetricData is synthetic metric data produced by a MetricPostProcessor.
+// Details describes the derived metric, since it has no Performance Insights metric definition.
+type DerivedMetricData struct {
+	models.MetricData
+	Details models.MetricDetails
+}
+
+// NewMetricPostProcessor returns the built-in post-processor registered under name.
+func NewMetricPostProcessor(name models.PostProcessorName) (MetricPostProcessor, error) {
+	switch name {
+	case models.PostProcessorMemoryFreePercent:
+		return memoryFreePercentProcessor{}, nil
+	default:
+		return nil, fmt.Errorf("unknown metric post-processor %s", name)
+	}
+}
+
+// memoryFreePercentProcessor derives os.memory.freePercent from os.memory.free and os.memory.total
+// for every statistic both metrics were collected with.
+type memoryFreePercentProcessor struct{}
+
+func (memoryFreePercentProcessor) Process(instance models.Instance, metricData []models.MetricData) []DerivedMetricData {
+	free := make(map[string]models.MetricData)
+	total := make(map[string]models.MetricData)
+	for _, metricDatum := range metricData {
+		metricName := utils.TrimStatisticFromMetricName(metricDatum.Metric)
+		statistic := strings.TrimPrefix(metricDatum.Metric, metricName)
+		switch metricName {
+		case memoryFreeMetric:
+			free[statistic] = metricDatum
+		case memoryTotalMetric:
+			total[statistic] = metricDatum
+		}
+	}
+
+	statistics := make([]string, 0, len(free))
+	for statistic := range free {
+		statistics = append(statistics, statistic)
+	}
+	sort.Strings(statistics)
+
+	var derived []DerivedMetricData
+	for _, statistic := range statistics {
+		totalDatum, exists := total[statistic]
+		if !exists || totalDatum.Value <= 0 {
+			continue
+		}
+
+		freeDatum := free[statistic]
+		derived = append(derived, DerivedMetricData{
+			MetricData: models.MetricData{
+				Metric:    memoryFreePercentMetric + statistic,
+				Timestamp: freeDatum.Timestamp,
+				Value:     freeDatum.Value / totalDatum.Value * 100,
+			},
+			Details: models.MetricDetails{
+				Name:        memoryFreePercentMetric,
+				Description: "The percentage of memory that is free, derived from os.memory.free and os.memory.total",
+				Unit:        "Percent",
+			},
+		})
+	}
+
+	return derived
+}
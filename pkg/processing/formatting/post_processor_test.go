@@ -0,0 +1,119 @@
+package formatting
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
+)
+
+func TestNewMetricPostProcessor(t *testing.T) {
+	testCases := []struct {
+		name          string
+		processor     models.PostProcessorName
+		expectedError bool
+	}{
+		{
+			name:      "memory-free-percent",
+			processor: models.PostProcessorMemoryFreePercent,
+		},
+		{
+			name:          "unknown post-processor",
+			processor:     models.PostProcessorName("cpu-busy-percent"),
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			processor, err := NewMetricPostProcessor(tc.processor)
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, processor)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, processor)
+			}
+		})
+	}
+}
+
+func TestMemoryFreePercentProcessor(t *testing.T) {
+	testCases := []struct {
+		name           string
+		metricData     []models.MetricData
+		expectedValues map[string]float64
+	}{
+		{
+			name: "derives free percent per statistic",
+			metricData: []models.MetricData{
+				testutils.NewTestMetricData("os.memory.free.avg", 256),
+				testutils.NewTestMetricData("os.memory.total.avg", 1024),
+				testutils.NewTestMetricData("os.memory.free.min", 512),
+				testutils.NewTestMetricData("os.memory.total.min", 1024),
+				testutils.NewTestMetricData("os.cpuUtilization.idle.avg", 50),
+			},
+			expectedValues: map[string]float64{
+				"os.memory.freePercent.avg": 25,
+				"os.memory.freePercent.min": 50,
+			},
+		},
+		{
+			name: "missing total is skipped",
+			metricData: []models.MetricData{
+				testutils.NewTestMetricData("os.memory.free.avg", 256),
+			},
+			expectedValues: map[string]float64{},
+		},
+		{
+			name: "zero total is skipped",
+			metricData: []models.MetricData{
+				testutils.NewTestMetricData("os.memory.free.avg", 256),
+				testutils.NewTestMetricData("os.memory.total.avg", 0),
+			},
+			expectedValues: map[string]float64{},
+		},
+		{
+			name: "statistics must match",
+			metricData: []models.MetricData{
+				testutils.NewTestMetricData("os.memory.free.avg", 256),
+				testutils.NewTestMetricData("os.memory.total.max", 1024),
+			},
+			expectedValues: map[string]float64{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			derived := memoryFreePercentProcessor{}.Process(testutils.NewTestInstancePostgreSQL(), tc.metricData)
+
+			values := make(map[string]float64, len(derived))
+			for _, derivedMetric := range derived {
+				values[derivedMetric.Metric] = derivedMetric.Value
+				assert.Equal(t, "Percent", derivedMetric.Details.Unit)
+				assert.Equal(t, testutils.TestTimestamp, derivedMetric.Timestamp)
+			}
+			assert.Equal(t, tc.expectedValues, values)
+		})
+	}
+}
+
+func TestConvertDerivedMetric(t *testing.T) {
+	derived := memoryFreePercentProcessor{}.Process(testutils.NewTestInstancePostgreSQL(), []models.MetricData{
+		testutils.NewTestMetricData("os.memory.free.avg", 256),
+		testutils.NewTestMetricData("os.memory.total.avg", 1024),
+	})
+	require.Len(t, derived, 1)
+
+	ch := make(chan prometheus.Metric, 1)
+	err := ConvertDerivedMetric(ch, testutils.NewTestInstancePostgreSQL(), derived[0], testutils.TestPrometheusConfig)
+	require.NoError(t, err)
+
+	metric := <-ch
+	assert.Contains(t, metric.Desc().String(), `fqName: "dbi_os_memory_freepercent_avg"`)
+	assert.Contains(t, metric.Desc().String(), "variableLabels: {identifier,engine,unit}")
+}
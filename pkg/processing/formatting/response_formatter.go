@@ -1,7 +1,9 @@
 package formatting
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -10,42 +12,141 @@ import (
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/utils"
 )
 
-func ConvertToPrometheusMetric(ch chan<- prometheus.Metric, instance models.Instance, metricData models.MetricData, metricPrefix string) error {
+// Reasons reported by ConversionErrorReason for metric data that could not be converted to a Prometheus metric.
+const (
+	ConversionErrorEmptyMetricName = "empty_metric_name"
+	ConversionErrorMissingDetails  = "missing_metric_details"
+	ConversionErrorInvalidMetric   = "invalid_metric"
+	ConversionErrorUnknown         = "unknown"
+)
 
-	metricName := utils.TrimStatisticFromMetricName(metricData.Metric)
-	if metricName == "" {
-		return fmt.Errorf("metric name is empty")
+var (
+	invalidMetricNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+	camelCaseBoundary      = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// ConversionError is returned when metric data could not be converted to a Prometheus metric.
+// Reason is one of the ConversionError* constants and Err the underlying error.
+type ConversionError struct {
+	Reason string
+	Err    error
+}
+
+func (conversionError *ConversionError) Error() string {
+	return conversionError.Err.Error()
+}
+
+func (conversionError *ConversionError) Unwrap() error {
+	return conversionError.Err
+}
+
+// ConversionErrorReason returns the reason of a ConversionError, or ConversionErrorUnknown for any other error.
+func ConversionErrorReason(err error) string {
+	var conversionError *ConversionError
+	if errors.As(err, &conversionError) {
+		return conversionError.Reason
 	}
-	metric, err := safeGetMetricDetails(instance, metricName)
+	return ConversionErrorUnknown
+}
+
+// DescribedMetric is a Prometheus metric that carries the fully-qualified name and help text it was described with,
+// which prometheus.Desc does not expose, so emitted metrics can be recorded and replayed.
+type DescribedMetric struct {
+	prometheus.Metric
+	Name string
+	Help string
+}
+
+func ConvertToPrometheusMetric(ch chan<- prometheus.Metric, instance models.Instance, metricData models.MetricData, prometheusConfig models.ParsedPrometheusConfig) error {
+
+	metric, err := getMetricDetails(instance, metricData)
 	if err != nil {
 		return err
 	}
 
+	return convertWithDetails(ch, instance, metricData, metric, prometheusConfig)
+}
+
+// ConvertDerivedMetric converts metric data produced by a MetricPostProcessor, using the details it carries
+// instead of the Performance Insights metric definitions of the instance.
+func ConvertDerivedMetric(ch chan<- prometheus.Metric, instance models.Instance, derived DerivedMetricData, prometheusConfig models.ParsedPrometheusConfig) error {
+	return convertWithDetails(ch, instance, derived.MetricData, &derived.Details, prometheusConfig)
+}
+
+func convertWithDetails(ch chan<- prometheus.Metric, instance models.Instance, metricData models.MetricData, metric *models.MetricDetails, prometheusConfig models.ParsedPrometheusConfig) error {
 	metricLabels := []string{"identifier", "engine", "unit"}
+	labelValues := []string{instance.Identifier, string(instance.Engine), metric.Unit}
+	if prometheusConfig.StatusLabel {
+		metricLabels = append(metricLabels, "status")
+		labelValues = append(labelValues, instance.Status)
+	}
+	if prometheusConfig.RegionLabel {
+		metricLabels = append(metricLabels, "region")
+		labelValues = append(labelValues, instance.CollectionRegion)
+	}
+	for _, label := range prometheusConfig.ExtraLabels {
+		metricLabels = append(metricLabels, label)
+		labelValues = append(labelValues, instance.ExtraLabelValue(label))
+	}
+	// Instances without a configured tag get an empty value so every metric name keeps a single label set
+	for _, tagLabel := range prometheusConfig.TagLabels {
+		metricLabels = append(metricLabels, tagLabel.Label)
+		labelValues = append(labelValues, instance.Tags[tagLabel.Tag])
+	}
 
 	engineShortStr := utils.EngineToShortName(instance.Engine)
+	fqName := buildPrometheusMetricName(prometheusConfig, engineShortStr, metricData.Metric)
+	help := buildPrometheusHelp(prometheusConfig, metricData.Metric, metric.Description)
 	prometheusDesc := buildPrometheusDescription(
-		buildPrometheusMetricName(metricPrefix, engineShortStr, metricData.Metric),
-		metric.Description,
+		fqName,
+		help,
 		metricLabels,
+		prometheusConfig.StaticLabels,
 	)
 
 	prometheusMetric, err := prometheus.NewConstMetric(
 		prometheusDesc,
 		prometheus.GaugeValue,
 		metricData.Value,
-		instance.Identifier,
-		string(instance.Engine),
-		metric.Unit,
+		labelValues...,
 	)
 	if err != nil {
-		return err
+		return &ConversionError{Reason: ConversionErrorInvalidMetric, Err: err}
 	}
 
-	ch <- prometheus.NewMetricWithTimestamp(metricData.Timestamp, prometheusMetric)
+	ch <- DescribedMetric{
+		Metric: prometheus.NewMetricWithTimestamp(metricData.Timestamp, prometheusMetric),
+		Name:   fqName,
+		Help:   help,
+	}
 	return nil
 }
 
+// DescribePrometheusMetric returns the exported Prometheus metric name and the Performance Insights description
+// that ConvertToPrometheusMetric uses for the metric data of the instance.
+func DescribePrometheusMetric(instance models.Instance, metricData models.MetricData, prometheusConfig models.ParsedPrometheusConfig) (string, string, error) {
+	metric, err := getMetricDetails(instance, metricData)
+	if err != nil {
+		return "", "", err
+	}
+
+	engineShortStr := utils.EngineToShortName(instance.Engine)
+	return buildPrometheusMetricName(prometheusConfig, engineShortStr, metricData.Metric), metric.Description, nil
+}
+
+func getMetricDetails(instance models.Instance, metricData models.MetricData) (*models.MetricDetails, error) {
+	metricName := utils.TrimStatisticFromMetricName(metricData.Metric)
+	if metricName == "" {
+		return nil, &ConversionError{Reason: ConversionErrorEmptyMetricName, Err: fmt.Errorf("metric name is empty")}
+	}
+
+	metric, err := safeGetMetricDetails(instance, metricName)
+	if err != nil {
+		return nil, &ConversionError{Reason: ConversionErrorMissingDetails, Err: err}
+	}
+	return metric, nil
+}
+
 func safeGetMetricDetails(instance models.Instance, metricName string) (*models.MetricDetails, error) {
 	if instance.Metrics == nil {
 		return nil, fmt.Errorf("instance.Metrics is nil for instance %s", instance.Identifier)
@@ -63,18 +164,84 @@ func safeGetMetricDetails(instance models.Instance, metricName string) (*models.
 	return &metric, nil
 }
 
-func buildPrometheusDescription(metricNameWithStat string, metricDescription string, labels []string) *prometheus.Desc {
+// buildPrometheusDescription describes a Performance Insights metric with the instance labels and the constant
+// prometheus.static-labels.
+func buildPrometheusDescription(metricNameWithStat string, metricDescription string, labels []string, staticLabels map[string]string) *prometheus.Desc {
 	return prometheus.NewDesc(
 		metricNameWithStat,
 		metricDescription,
 		labels,
-		nil,
+		staticLabels,
 	)
 }
 
-func buildPrometheusMetricName(metricPrefix string, engineShortStr string, metricWithStatistic string) string {
-	if strings.HasPrefix(metricWithStatistic, "db.") {
-		metricPrefix = metricPrefix + "_" + engineShortStr
+// buildPrometheusMetricName joins the metric prefix and the metric name, normalized by prometheus.name-style, with underscores.
+// When a namespace or subsystem is configured, the name is built with prometheus.BuildFQName instead,
+// using the namespace (or the metric prefix if no namespace is set) and the subsystem.
+// db metrics are prefixed with the engine short name unless prometheus.engine-in-name is disabled or they match
+// prometheus.no-engine-prefix-metrics.
+func buildPrometheusMetricName(prometheusConfig models.ParsedPrometheusConfig, engineShortStr string, metricWithStatistic string) string {
+	name := formatMetricName(prometheusConfig.NameStyle, metricWithStatistic)
+	if strings.HasPrefix(metricWithStatistic, "db.") && !skipsEnginePrefix(prometheusConfig, metricWithStatistic) {
+		name = engineShortStr + "_" + name
+	}
+
+	return BuildExporterMetricName(prometheusConfig, name)
+}
+
+// buildPrometheusHelp returns the help text of a Performance Insights metric, its description. A db metric exported
+// without the engine short name shares its name across engines whose descriptions may differ, while a metric name must
+// have a single help text in a scrape, so its help text is built from the metric name instead.
+func buildPrometheusHelp(prometheusConfig models.ParsedPrometheusConfig, metricWithStatistic string, description string) string {
+	if strings.HasPrefix(metricWithStatistic, "db.") && skipsEnginePrefix(prometheusConfig, metricWithStatistic) {
+		return "Performance Insights metric " + metricWithStatistic
+	}
+	return description
+}
+
+// formatMetricName replaces the dots of a Performance Insights metric name with underscores and normalizes its case
+// according to the name style. The lowercase style, also used when no style is set, keeps the names exported before
+// prometheus.name-style existed.
+func formatMetricName(style models.MetricNameStyle, metricWithStatistic string) string {
+	switch style {
+	case models.MetricNameStylePreserveCase:
+		return invalidMetricNameChars.ReplaceAllString(strings.ReplaceAll(metricWithStatistic, ".", "_"), "")
+	case models.MetricNameStyleSnakeCase:
+		return utils.SnakeCase(camelCaseBoundary.ReplaceAllString(metricWithStatistic, "${1}_${2}"))
+	default:
+		return utils.SnakeCase(metricWithStatistic)
+	}
+}
+
+// skipsEnginePrefix reports whether the engine short name is omitted from every db metric, or the metric name
+// without its statistic matches a prometheus.no-engine-prefix-metrics pattern.
+func skipsEnginePrefix(prometheusConfig models.ParsedPrometheusConfig, metricWithStatistic string) bool {
+	if prometheusConfig.OmitEnginePrefix {
+		return true
+	}
+
+	metricName := utils.TrimStatisticFromMetricName(metricWithStatistic)
+	if metricName == "" {
+		metricName = metricWithStatistic
+	}
+	for _, pattern := range prometheusConfig.NoEnginePrefixMetrics {
+		if pattern.MatchString(metricName) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildExporterMetricName applies the configured metric prefix, namespace and subsystem to an already snake cased name.
+// It is shared by Performance Insights metrics and the exporter's own operational metrics so both follow the same naming.
+func BuildExporterMetricName(prometheusConfig models.ParsedPrometheusConfig, name string) string {
+	if prometheusConfig.Namespace == "" && prometheusConfig.Subsystem == "" {
+		return prometheusConfig.MetricPrefix + "_" + name
+	}
+
+	namespace := prometheusConfig.Namespace
+	if namespace == "" {
+		namespace = prometheusConfig.MetricPrefix
 	}
-	return metricPrefix + "_" + utils.SnakeCase(metricWithStatistic)
+	return prometheus.BuildFQName(namespace, prometheusConfig.Subsystem, name)
 }
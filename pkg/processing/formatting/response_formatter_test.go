@@ -1,12 +1,16 @@
 package formatting
 
 import (
+	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
 )
 
@@ -16,7 +20,7 @@ func TestConvertToPrometheusMetric(t *testing.T) {
 			t.Run(metricData.Metric, func(t *testing.T) {
 				ch := make(chan prometheus.Metric, 1)
 
-				err := ConvertToPrometheusMetric(ch, testutils.TestInstancePostgreSQL, metricData, "dbi")
+				err := ConvertToPrometheusMetric(ch, testutils.TestInstancePostgreSQL, metricData, testutils.TestPrometheusConfig)
 				assert.NoError(t, err)
 
 				select {
@@ -34,7 +38,7 @@ func TestConvertToPrometheusMetric(t *testing.T) {
 		dbMetric := testutils.NewTestMetricData("db.User.max_connections.avg", 100.0)
 		ch := make(chan prometheus.Metric, 1)
 
-		err := ConvertToPrometheusMetric(ch, testutils.TestInstancePostgreSQL, dbMetric, "dbi")
+		err := ConvertToPrometheusMetric(ch, testutils.TestInstancePostgreSQL, dbMetric, testutils.TestPrometheusConfig)
 		assert.NoError(t, err)
 
 		select {
@@ -56,7 +60,7 @@ func TestConvertToPrometheusMetric(t *testing.T) {
 		osMetric := testutils.NewTestMetricData("os.general.numVCPUs.avg", 4.0)
 		ch := make(chan prometheus.Metric, 1)
 
-		err := ConvertToPrometheusMetric(ch, testutils.TestInstancePostgreSQL, osMetric, "dbi")
+		err := ConvertToPrometheusMetric(ch, testutils.TestInstancePostgreSQL, osMetric, testutils.TestPrometheusConfig)
 		assert.NoError(t, err)
 
 		select {
@@ -81,7 +85,7 @@ func TestConvertToPrometheusMetric(t *testing.T) {
 
 		// Test with Aurora PostgreSQL instance (has apg prefix)
 		chPg := make(chan prometheus.Metric, 1)
-		err := ConvertToPrometheusMetric(chPg, testutils.TestInstancePostgreSQL, dbMetric, "dbi")
+		err := ConvertToPrometheusMetric(chPg, testutils.TestInstancePostgreSQL, dbMetric, testutils.TestPrometheusConfig)
 		assert.NoError(t, err)
 
 		metricPg := <-chPg
@@ -93,7 +97,7 @@ func TestConvertToPrometheusMetric(t *testing.T) {
 		// Create a MySQL instance with the full metrics details
 		mysqlInstance := testutils.NewTestInstance("db-TESTMYSQL", "test-mysql-db", testutils.TestEngineMySQL)
 		chMysql := make(chan prometheus.Metric, 1)
-		err = ConvertToPrometheusMetric(chMysql, mysqlInstance, dbMetric, "dbi")
+		err = ConvertToPrometheusMetric(chMysql, mysqlInstance, dbMetric, testutils.TestPrometheusConfig)
 		assert.NoError(t, err)
 
 		metricMysql := <-chMysql
@@ -103,6 +107,335 @@ func TestConvertToPrometheusMetric(t *testing.T) {
 	})
 }
 
+func TestConvertToPrometheusMetricErrorReasons(t *testing.T) {
+	invalidLabelInstance := testutils.NewTestInstance("db-INVALID", "invalid-\xff-db", testutils.TestEnginePostgreSQL)
+
+	testCases := []struct {
+		name           string
+		instance       models.Instance
+		metricData     models.MetricData
+		expectedReason string
+	}{
+		{
+			name:           "empty metric name",
+			instance:       testutils.TestInstancePostgreSQL,
+			metricData:     testutils.NewTestMetricData("", 1.0),
+			expectedReason: ConversionErrorEmptyMetricName,
+		},
+		{
+			name:           "metric not in the instance metric details",
+			instance:       testutils.TestInstancePostgreSQL,
+			metricData:     testutils.NewTestMetricData("os.unknown.metric.avg", 1.0),
+			expectedReason: ConversionErrorMissingDetails,
+		},
+		{
+			name:           "instance without metric details",
+			instance:       models.Instance{Identifier: "no-metrics-db", Engine: models.AuroraPostgreSQL},
+			metricData:     testutils.NewTestMetricData("os.general.numVCPUs.avg", 1.0),
+			expectedReason: ConversionErrorMissingDetails,
+		},
+		{
+			name:           "label value that is not valid UTF-8",
+			instance:       invalidLabelInstance,
+			metricData:     testutils.NewTestMetricData("os.general.numVCPUs.avg", 1.0),
+			expectedReason: ConversionErrorInvalidMetric,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ch := make(chan prometheus.Metric, 1)
+
+			err := ConvertToPrometheusMetric(ch, tc.instance, tc.metricData, testutils.TestPrometheusConfig)
+			require.Error(t, err)
+			assert.Equal(t, tc.expectedReason, ConversionErrorReason(err))
+			assert.Empty(t, ch)
+		})
+	}
+
+	assert.Equal(t, ConversionErrorUnknown, ConversionErrorReason(assert.AnError))
+}
+
+func TestConvertToPrometheusMetricWithStatusLabel(t *testing.T) {
+	testCases := []struct {
+		name          string
+		statusLabel   bool
+		expectedLabel string
+	}{
+		{
+			name:          "status label disabled",
+			statusLabel:   false,
+			expectedLabel: "variableLabels: {identifier,engine,unit}",
+		},
+		{
+			name:          "status label enabled",
+			statusLabel:   true,
+			expectedLabel: "variableLabels: {identifier,engine,unit,status}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prometheusConfig := testutils.TestPrometheusConfig
+			prometheusConfig.StatusLabel = tc.statusLabel
+			ch := make(chan prometheus.Metric, 1)
+
+			err := ConvertToPrometheusMetric(ch, testutils.NewTestInstanceStopped(), testutils.TestMetricData[0], prometheusConfig)
+			assert.NoError(t, err)
+
+			metric := <-ch
+			assert.Contains(t, metric.Desc().String(), tc.expectedLabel)
+		})
+	}
+}
+
+func TestConvertToPrometheusMetricWithStaticLabels(t *testing.T) {
+	prometheusConfig := testutils.TestPrometheusConfig
+	prometheusConfig.StaticLabels = map[string]string{"account_id": "123456789012", "team": "data"}
+	ch := make(chan prometheus.Metric, 1)
+
+	err := ConvertToPrometheusMetric(ch, testutils.TestInstancePostgreSQL, testutils.TestMetricData[0], prometheusConfig)
+	require.NoError(t, err)
+
+	var written dto.Metric
+	require.NoError(t, (<-ch).Write(&written))
+
+	labels := make(map[string]string, len(written.GetLabel()))
+	for _, label := range written.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, "123456789012", labels["account_id"])
+	assert.Equal(t, "data", labels["team"])
+	assert.Equal(t, "test-postgres-db", labels["identifier"])
+}
+
+func TestConvertToPrometheusMetricWithRegionLabel(t *testing.T) {
+	prometheusConfig := testutils.TestPrometheusConfig
+	prometheusConfig.RegionLabel = true
+	instance := testutils.NewTestInstancePostgreSQL()
+	instance.CollectionRegion = "eu-west-1"
+	ch := make(chan prometheus.Metric, 1)
+
+	err := ConvertToPrometheusMetric(ch, instance, testutils.TestMetricData[0], prometheusConfig)
+	require.NoError(t, err)
+
+	metric := <-ch
+	assert.Contains(t, metric.Desc().String(), "variableLabels: {identifier,engine,unit,region}")
+
+	var written dto.Metric
+	require.NoError(t, metric.Write(&written))
+	labels := make(map[string]string, len(written.GetLabel()))
+	for _, label := range written.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.Equal(t, "eu-west-1", labels["region"])
+}
+
+func TestConvertToPrometheusMetricWithExtraLabels(t *testing.T) {
+	testCases := []struct {
+		name           string
+		extraLabels    []string
+		expectedLabel  string
+		expectedValues map[string]string
+	}{
+		{
+			name:           "no extra labels",
+			extraLabels:    nil,
+			expectedLabel:  "variableLabels: {identifier,engine,unit}",
+			expectedValues: map[string]string{},
+		},
+		{
+			name:           "extra labels in configured order",
+			extraLabels:    []string{"storage_type", "encrypted"},
+			expectedLabel:  "variableLabels: {identifier,engine,unit,storage_type,encrypted}",
+			expectedValues: map[string]string{"storage_type": "gp3", "encrypted": "true"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prometheusConfig := testutils.TestPrometheusConfig
+			prometheusConfig.ExtraLabels = tc.extraLabels
+			instance := testutils.NewTestInstancePostgreSQL()
+			instance.StorageEncrypted = true
+			instance.StorageType = "gp3"
+			ch := make(chan prometheus.Metric, 1)
+
+			err := ConvertToPrometheusMetric(ch, instance, testutils.TestMetricData[0], prometheusConfig)
+			require.NoError(t, err)
+
+			metric := <-ch
+			assert.Contains(t, metric.Desc().String(), tc.expectedLabel)
+
+			var written dto.Metric
+			require.NoError(t, metric.Write(&written))
+			labels := make(map[string]string, len(written.GetLabel()))
+			for _, label := range written.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			for name, expected := range tc.expectedValues {
+				assert.Equal(t, expected, labels[name])
+			}
+		})
+	}
+}
+
+func TestConvertToPrometheusMetricWithTagLabels(t *testing.T) {
+	prometheusConfig := testutils.TestPrometheusConfig
+	prometheusConfig.ExtraLabels = []string{"storage_type"}
+	prometheusConfig.TagLabels = []models.TagLabel{
+		{Tag: "Environment", Label: "tag_Environment"},
+		{Tag: "Team", Label: "tag_Team"},
+	}
+
+	testCases := []struct {
+		name           string
+		tags           map[string]string
+		expectedValues map[string]string
+	}{
+		{
+			name:           "instance with every tag",
+			tags:           map[string]string{"Environment": "production", "Team": "data", "Owner": "dba"},
+			expectedValues: map[string]string{"tag_Environment": "production", "tag_Team": "data"},
+		},
+		{
+			name:           "instance missing a tag gets an empty value",
+			tags:           map[string]string{"Environment": "test"},
+			expectedValues: map[string]string{"tag_Environment": "test", "tag_Team": ""},
+		},
+		{
+			name:           "instance without tags",
+			tags:           nil,
+			expectedValues: map[string]string{"tag_Environment": "", "tag_Team": ""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := testutils.NewTestInstancePostgreSQL()
+			instance.Tags = tc.tags
+			ch := make(chan prometheus.Metric, 1)
+
+			err := ConvertToPrometheusMetric(ch, instance, testutils.TestMetricData[0], prometheusConfig)
+			require.NoError(t, err)
+
+			metric := <-ch
+			assert.Contains(t, metric.Desc().String(), "variableLabels: {identifier,engine,unit,storage_type,tag_Environment,tag_Team}")
+
+			var written dto.Metric
+			require.NoError(t, metric.Write(&written))
+			labels := make(map[string]string, len(written.GetLabel()))
+			for _, label := range written.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			for name, expected := range tc.expectedValues {
+				value, exists := labels[name]
+				assert.True(t, exists, "label %s must be present", name)
+				assert.Equal(t, expected, value)
+			}
+			assert.NotContains(t, labels, "tag_Owner")
+		})
+	}
+}
+
+// metricsCollector is an unchecked collector emitting fixed metrics, so a registry gathers them as a scrape would.
+type metricsCollector []prometheus.Metric
+
+func (metrics metricsCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (metrics metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, metric := range metrics {
+		ch <- metric
+	}
+}
+
+func TestConvertToPrometheusMetricWithoutEnginePrefixAcrossEngines(t *testing.T) {
+	testCases := []struct {
+		name             string
+		prometheusConfig models.ParsedPrometheusConfig
+	}{
+		{
+			name: "no-engine-prefix-metrics",
+			prometheusConfig: models.ParsedPrometheusConfig{
+				MetricPrefix:          "dbi",
+				NoEnginePrefixMetrics: []*regexp.Regexp{regexp.MustCompile(`^db\.SQL\.`)},
+			},
+		},
+		{
+			name:             "engine-in-name disabled",
+			prometheusConfig: models.ParsedPrometheusConfig{MetricPrefix: "dbi", OmitEnginePrefix: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			postgres := testutils.NewTestInstance("db-POSTGRES", "postgres-db", models.AuroraPostgreSQL)
+			postgres.Metrics = &models.Metrics{MetricsDetails: map[string]models.MetricDetails{
+				"db.SQL.queries": testutils.NewTestMetricDetails("db.SQL.queries", "Queries executed per second", "Queries per second"),
+			}}
+			mysql := testutils.NewTestInstance("db-MYSQL", "mysql-db", models.AuroraMySQL)
+			mysql.Metrics = &models.Metrics{MetricsDetails: map[string]models.MetricDetails{
+				"db.SQL.queries": testutils.NewTestMetricDetails("db.SQL.queries", "The number of statements executed by the server", "Queries per second"),
+			}}
+
+			ch := make(chan prometheus.Metric, 2)
+			require.NoError(t, ConvertToPrometheusMetric(ch, postgres, testutils.NewTestMetricData("db.SQL.queries.avg", 10), tc.prometheusConfig))
+			require.NoError(t, ConvertToPrometheusMetric(ch, mysql, testutils.NewTestMetricData("db.SQL.queries.avg", 20), tc.prometheusConfig))
+			close(ch)
+
+			var metrics metricsCollector
+			for metric := range ch {
+				metrics = append(metrics, metric)
+			}
+			registry := prometheus.NewRegistry()
+			require.NoError(t, registry.Register(metrics))
+
+			families, err := registry.Gather()
+			require.NoError(t, err)
+			require.Len(t, families, 1)
+			assert.Equal(t, "dbi_db_sql_queries_avg", families[0].GetName())
+			assert.Equal(t, "Performance Insights metric db.SQL.queries.avg", families[0].GetHelp())
+			assert.Len(t, families[0].GetMetric(), 2)
+		})
+	}
+}
+
+func TestDescribePrometheusMetric(t *testing.T) {
+	testCases := []struct {
+		name                string
+		metricData          models.MetricData
+		expectedName        string
+		expectedDescription string
+		expectedError       bool
+	}{
+		{
+			name:                "os metric",
+			metricData:          testutils.NewTestMetricData("os.general.numVCPUs.avg", 4.0),
+			expectedName:        "dbi_os_general_numvcpus_avg",
+			expectedDescription: "The number of virtual CPUs for the DB instance",
+		},
+		{
+			name:          "unknown metric",
+			metricData:    testutils.NewTestMetricData("os.unknown.metric.avg", 1.0),
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, description, err := DescribePrometheusMetric(testutils.TestInstancePostgreSQL, tc.metricData, testutils.TestPrometheusConfig)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedName, name)
+			assert.Equal(t, tc.expectedDescription, description)
+		})
+	}
+}
+
 func TestBuildPrometheusDescription(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -144,7 +477,7 @@ func TestBuildPrometheusDescription(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := buildPrometheusDescription(tc.metricName, tc.description, tc.labels)
+			result := buildPrometheusDescription(tc.metricName, tc.description, tc.labels, nil)
 			expected := prometheus.NewDesc(tc.expectedName, tc.expectedDesc, tc.expectedLabels, nil)
 
 			assert.Equal(t, expected, result)
@@ -152,6 +485,15 @@ func TestBuildPrometheusDescription(t *testing.T) {
 	}
 }
 
+func TestBuildPrometheusDescriptionWithStaticLabels(t *testing.T) {
+	staticLabels := map[string]string{"account_id": "123456789012", "team": "data"}
+
+	result := buildPrometheusDescription("dbi_test_metric", "Test description", []string{"identifier"}, staticLabels)
+	expected := prometheus.NewDesc("dbi_test_metric", "Test description", []string{"identifier"}, staticLabels)
+
+	assert.Equal(t, expected, result)
+}
+
 func TestBuildPrometheusMetricName(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -205,7 +547,190 @@ func TestBuildPrometheusMetricName(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := buildPrometheusMetricName("dbi", tc.engineShortStr, tc.input)
+			result := buildPrometheusMetricName(testutils.TestPrometheusConfig, tc.engineShortStr, tc.input)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestBuildPrometheusMetricNameWithNameStyle(t *testing.T) {
+	testCases := []struct {
+		name      string
+		nameStyle models.MetricNameStyle
+		input     string
+		expected  string
+	}{
+		{
+			name:      "lowercase style",
+			nameStyle: models.MetricNameStyleLowercase,
+			input:     "os.cpuUtilization.idle.avg",
+			expected:  "dbi_os_cpuutilization_idle_avg",
+		},
+		{
+			name:      "preserve-case style",
+			nameStyle: models.MetricNameStylePreserveCase,
+			input:     "os.cpuUtilization.idle.avg",
+			expected:  "dbi_os_cpuUtilization_idle_avg",
+		},
+		{
+			name:      "preserve-case style keeps upper case segments",
+			nameStyle: models.MetricNameStylePreserveCase,
+			input:     "db.SQL.total_query_time.sum",
+			expected:  "dbi_apg_db_SQL_total_query_time_sum",
+		},
+		{
+			name:      "snake-case style splits camel case words",
+			nameStyle: models.MetricNameStyleSnakeCase,
+			input:     "os.cpuUtilization.idle.avg",
+			expected:  "dbi_os_cpu_utilization_idle_avg",
+		},
+		{
+			name:      "snake-case style keeps acronyms together",
+			nameStyle: models.MetricNameStyleSnakeCase,
+			input:     "os.general.numVCPUs.avg",
+			expected:  "dbi_os_general_num_vcpus_avg",
+		},
+		{
+			name:      "snake-case style lowercases upper case segments",
+			nameStyle: models.MetricNameStyleSnakeCase,
+			input:     "db.SQL.total_query_time.sum",
+			expected:  "dbi_apg_db_sql_total_query_time_sum",
+		},
+		{
+			name:      "unset style defaults to lowercase",
+			nameStyle: "",
+			input:     "os.general.numVCPUs.avg",
+			expected:  "dbi_os_general_numvcpus_avg",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prometheusConfig := models.ParsedPrometheusConfig{MetricPrefix: "dbi", NameStyle: tc.nameStyle}
+			assert.Equal(t, tc.expected, buildPrometheusMetricName(prometheusConfig, "apg", tc.input))
+		})
+	}
+}
+
+func TestBuildPrometheusMetricNameWithNoEnginePrefixMetrics(t *testing.T) {
+	prometheusConfig := models.ParsedPrometheusConfig{
+		MetricPrefix:          "dbi",
+		NoEnginePrefixMetrics: []*regexp.Regexp{regexp.MustCompile(`^db\.SQL\.`), regexp.MustCompile(`^db\.User\.max_connections$`)},
+	}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "matched db metric skips engine prefix",
+			input:    "db.SQL.total_query_time.sum",
+			expected: "dbi_db_sql_total_query_time_sum",
+		},
+		{
+			name:     "pattern matches metric name without statistic",
+			input:    "db.User.max_connections.avg",
+			expected: "dbi_db_user_max_connections_avg",
+		},
+		{
+			name:     "unmatched db metric keeps engine prefix",
+			input:    "db.Transactions.xact_commit.avg",
+			expected: "dbi_apg_db_transactions_xact_commit_avg",
+		},
+		{
+			name:     "os metric is unaffected",
+			input:    "os.general.numVCPUs.avg",
+			expected: "dbi_os_general_numvcpus_avg",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := buildPrometheusMetricName(prometheusConfig, "apg", tc.input)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestBuildPrometheusMetricNameWithoutEngineInName(t *testing.T) {
+	prometheusConfig := models.ParsedPrometheusConfig{MetricPrefix: "dbi", OmitEnginePrefix: true}
+
+	testCases := []struct {
+		name           string
+		input          string
+		engineShortStr string
+		expected       string
+	}{
+		{
+			name:           "apg db metric",
+			input:          "db.User.max_connections.avg",
+			engineShortStr: "apg",
+			expected:       "dbi_db_user_max_connections_avg",
+		},
+		{
+			name:           "mysql db metric has the same name",
+			input:          "db.User.max_connections.avg",
+			engineShortStr: "mysql",
+			expected:       "dbi_db_user_max_connections_avg",
+		},
+		{
+			name:           "os metric is unaffected",
+			input:          "os.general.numVCPUs.avg",
+			engineShortStr: "apg",
+			expected:       "dbi_os_general_numvcpus_avg",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := buildPrometheusMetricName(prometheusConfig, tc.engineShortStr, tc.input)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestBuildPrometheusMetricNameWithNamespaceAndSubsystem(t *testing.T) {
+	testCases := []struct {
+		name             string
+		prometheusConfig models.ParsedPrometheusConfig
+		input            string
+		engineShortStr   string
+		expected         string
+	}{
+		{
+			name:             "namespace replaces metric prefix",
+			prometheusConfig: models.ParsedPrometheusConfig{MetricPrefix: "dbi", Namespace: "aws"},
+			input:            "os.general.numVCPUs.avg",
+			engineShortStr:   "apg",
+			expected:         "aws_os_general_numvcpus_avg",
+		},
+		{
+			name:             "namespace and subsystem",
+			prometheusConfig: models.ParsedPrometheusConfig{MetricPrefix: "dbi", Namespace: "aws", Subsystem: "rds"},
+			input:            "os.general.numVCPUs.avg",
+			engineShortStr:   "apg",
+			expected:         "aws_rds_os_general_numvcpus_avg",
+		},
+		{
+			name:             "subsystem only uses metric prefix as namespace",
+			prometheusConfig: models.ParsedPrometheusConfig{MetricPrefix: "dbi", Subsystem: "pi"},
+			input:            "os.general.numVCPUs.avg",
+			engineShortStr:   "apg",
+			expected:         "dbi_pi_os_general_numvcpus_avg",
+		},
+		{
+			name:             "db metric keeps engine short name after subsystem",
+			prometheusConfig: models.ParsedPrometheusConfig{MetricPrefix: "dbi", Namespace: "aws", Subsystem: "rds"},
+			input:            "db.User.max_connections.avg",
+			engineShortStr:   "apg",
+			expected:         "aws_rds_apg_db_user_max_connections_avg",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := buildPrometheusMetricName(tc.prometheusConfig, tc.engineShortStr, tc.input)
 			assert.Equal(t, tc.expected, result)
 		})
 	}
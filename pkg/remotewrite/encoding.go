@@ -0,0 +1,111 @@
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const metricNameLabel = "__name__"
+
+// Field numbers from the Prometheus remote-write protobuf definitions (prompb.WriteRequest and friends).
+const (
+	writeRequestTimeseriesField protowire.Number = 1
+	timeSeriesLabelsField       protowire.Number = 1
+	timeSeriesSamplesField      protowire.Number = 2
+	labelNameField              protowire.Number = 1
+	labelValueField             protowire.Number = 2
+	sampleValueField            protowire.Number = 1
+	sampleTimestampField        protowire.Number = 2
+)
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+type Sample struct {
+	Value       float64
+	TimestampMs int64
+}
+
+// TimeSeries is a single remote-write series: a sorted label set including __name__ and its samples.
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// TimeSeriesFromMetricFamilies converts gathered gauge families into remote-write series.
+// Metrics without an explicit timestamp are stamped with now.
+func TimeSeriesFromMetricFamilies(families []*dto.MetricFamily, now time.Time) []TimeSeries {
+	var series []TimeSeries
+
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			if metric.GetGauge() == nil {
+				continue
+			}
+
+			labels := make([]Label, 0, len(metric.GetLabel())+1)
+			labels = append(labels, Label{Name: metricNameLabel, Value: family.GetName()})
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, Label{Name: label.GetName(), Value: label.GetValue()})
+			}
+			sort.Slice(labels, func(i, j int) bool {
+				return labels[i].Name < labels[j].Name
+			})
+
+			timestampMs := now.UnixMilli()
+			if metric.TimestampMs != nil {
+				timestampMs = metric.GetTimestampMs()
+			}
+
+			series = append(series, TimeSeries{
+				Labels:  labels,
+				Samples: []Sample{{Value: metric.GetGauge().GetValue(), TimestampMs: timestampMs}},
+			})
+		}
+	}
+
+	return series
+}
+
+// EncodeWriteRequest serializes the series as a remote-write WriteRequest protobuf message.
+func EncodeWriteRequest(series []TimeSeries) []byte {
+	var request []byte
+	for _, timeSeries := range series {
+		request = protowire.AppendTag(request, writeRequestTimeseriesField, protowire.BytesType)
+		request = protowire.AppendBytes(request, encodeTimeSeries(timeSeries))
+	}
+	return request
+}
+
+func encodeTimeSeries(timeSeries TimeSeries) []byte {
+	var encoded []byte
+	for _, label := range timeSeries.Labels {
+		var encodedLabel []byte
+		encodedLabel = protowire.AppendTag(encodedLabel, labelNameField, protowire.BytesType)
+		encodedLabel = protowire.AppendString(encodedLabel, label.Name)
+		encodedLabel = protowire.AppendTag(encodedLabel, labelValueField, protowire.BytesType)
+		encodedLabel = protowire.AppendString(encodedLabel, label.Value)
+
+		encoded = protowire.AppendTag(encoded, timeSeriesLabelsField, protowire.BytesType)
+		encoded = protowire.AppendBytes(encoded, encodedLabel)
+	}
+
+	for _, sample := range timeSeries.Samples {
+		var encodedSample []byte
+		encodedSample = protowire.AppendTag(encodedSample, sampleValueField, protowire.Fixed64Type)
+		encodedSample = protowire.AppendFixed64(encodedSample, math.Float64bits(sample.Value))
+		encodedSample = protowire.AppendTag(encodedSample, sampleTimestampField, protowire.VarintType)
+		encodedSample = protowire.AppendVarint(encodedSample, uint64(sample.TimestampMs))
+
+		encoded = protowire.AppendTag(encoded, timeSeriesSamplesField, protowire.BytesType)
+		encoded = protowire.AppendBytes(encoded, encodedSample)
+	}
+
+	return encoded
+}
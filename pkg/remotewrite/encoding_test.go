@@ -0,0 +1,142 @@
+package remotewrite
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
+)
+
+func TestTimeSeriesFromMetricFamilies(t *testing.T) {
+	now := testutils.TestTimestamp.Add(time.Minute)
+
+	families := []*dto.MetricFamily{
+		{
+			Name: proto.String("dbi_os_general_numvcpus_avg"),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{
+				{
+					Label: []*dto.LabelPair{
+						{Name: proto.String("identifier"), Value: proto.String("test-postgres-db")},
+						{Name: proto.String("engine"), Value: proto.String("aurora-postgresql")},
+					},
+					Gauge:       &dto.Gauge{Value: proto.Float64(4)},
+					TimestampMs: proto.Int64(testutils.TestTimestamp.UnixMilli()),
+				},
+				{
+					Gauge: &dto.Gauge{Value: proto.Float64(2)},
+				},
+			},
+		},
+		{
+			Name:   proto.String("dbi_counter_total"),
+			Type:   dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{{Counter: &dto.Counter{Value: proto.Float64(1)}}},
+		},
+	}
+
+	series := TimeSeriesFromMetricFamilies(families, now)
+
+	require.Len(t, series, 2)
+	assert.Equal(t, []Label{
+		{Name: "__name__", Value: "dbi_os_general_numvcpus_avg"},
+		{Name: "engine", Value: "aurora-postgresql"},
+		{Name: "identifier", Value: "test-postgres-db"},
+	}, series[0].Labels)
+	assert.Equal(t, []Sample{{Value: 4, TimestampMs: testutils.TestTimestamp.UnixMilli()}}, series[0].Samples)
+
+	assert.Equal(t, []Label{{Name: "__name__", Value: "dbi_os_general_numvcpus_avg"}}, series[1].Labels)
+	assert.Equal(t, []Sample{{Value: 2, TimestampMs: now.UnixMilli()}}, series[1].Samples)
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	series := []TimeSeries{
+		{
+			Labels:  []Label{{Name: "__name__", Value: "dbi_test"}, {Name: "identifier", Value: "db-1"}},
+			Samples: []Sample{{Value: 1.5, TimestampMs: 1700000000000}},
+		},
+		{
+			Labels:  []Label{{Name: "__name__", Value: "dbi_test"}, {Name: "identifier", Value: "db-2"}},
+			Samples: []Sample{{Value: -3, TimestampMs: 1700000000001}},
+		},
+	}
+
+	decoded := decodeWriteRequest(t, EncodeWriteRequest(series))
+
+	assert.Equal(t, series, decoded)
+}
+
+func TestEncodeWriteRequestEmpty(t *testing.T) {
+	assert.Empty(t, EncodeWriteRequest(nil))
+}
+
+// decodeWriteRequest is a minimal WriteRequest decoder used to verify the encoded wire format.
+func decodeWriteRequest(t *testing.T, data []byte) []TimeSeries {
+	var series []TimeSeries
+	forEachField(t, data, func(number protowire.Number, value []byte, _ uint64) {
+		require.Equal(t, writeRequestTimeseriesField, number)
+
+		var timeSeries TimeSeries
+		forEachField(t, value, func(number protowire.Number, value []byte, _ uint64) {
+			switch number {
+			case timeSeriesLabelsField:
+				var label Label
+				forEachField(t, value, func(number protowire.Number, value []byte, _ uint64) {
+					if number == labelNameField {
+						label.Name = string(value)
+					} else {
+						label.Value = string(value)
+					}
+				})
+				timeSeries.Labels = append(timeSeries.Labels, label)
+			case timeSeriesSamplesField:
+				var sample Sample
+				forEachField(t, value, func(number protowire.Number, _ []byte, scalar uint64) {
+					if number == sampleValueField {
+						sample.Value = math.Float64frombits(scalar)
+					} else {
+						sample.TimestampMs = int64(scalar)
+					}
+				})
+				timeSeries.Samples = append(timeSeries.Samples, sample)
+			}
+		})
+		series = append(series, timeSeries)
+	})
+	return series
+}
+
+func forEachField(t *testing.T, data []byte, fn func(number protowire.Number, value []byte, scalar uint64)) {
+	for len(data) > 0 {
+		number, wireType, n := protowire.ConsumeTag(data)
+		require.GreaterOrEqual(t, n, 0)
+		data = data[n:]
+
+		switch wireType {
+		case protowire.BytesType:
+			value, n := protowire.ConsumeBytes(data)
+			require.GreaterOrEqual(t, n, 0)
+			fn(number, value, 0)
+			data = data[n:]
+		case protowire.Fixed64Type:
+			value, n := protowire.ConsumeFixed64(data)
+			require.GreaterOrEqual(t, n, 0)
+			fn(number, nil, value)
+			data = data[n:]
+		case protowire.VarintType:
+			value, n := protowire.ConsumeVarint(data)
+			require.GreaterOrEqual(t, n, 0)
+			fn(number, nil, value)
+			data = data[n:]
+		default:
+			t.Fatalf("unexpected wire type %v", wireType)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/collector"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/manager/region"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
+)
+
+const (
+	RemoteWriteVersion = "0.1.0"
+	RequestTimeout     = 30 * time.Second
+	// maxErrorBodyBytes bounds how much of an error response body is included in the returned error.
+	maxErrorBodyBytes = 512
+)
+
+type RemoteWriter struct {
+	url           string
+	interval      time.Duration
+	regionManager region.RegionManager
+	client        *http.Client
+}
+
+// RemoteWriter periodically collects metrics through the same Collector used by the /metrics endpoint and pushes them
+// to a Prometheus remote-write endpoint as a snappy-compressed protobuf WriteRequest.
+// It is intended for environments without a Prometheus server scraping the exporter.
+func NewRemoteWriter(url string, interval time.Duration, regionManager region.RegionManager) *RemoteWriter {
+	return &RemoteWriter{
+		url:           url,
+		interval:      interval,
+		regionManager: regionManager,
+		client:        &http.Client{Timeout: RequestTimeout},
+	}
+}
+
+// Run pushes metrics immediately and then on every interval until the context is cancelled.
+// Push failures are logged and retried on the next interval.
+func (writer *RemoteWriter) Run(ctx context.Context) {
+	slog.Info("Pushing metrics with remote write", "url", writer.url, "interval", writer.interval)
+
+	ticker := time.NewTicker(writer.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := writer.Push(ctx); err != nil {
+			slog.Error("Error pushing metrics with remote write", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping remote-write loop")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Push performs a single collection and sends the resulting samples to the remote-write endpoint.
+func (writer *RemoteWriter) Push(ctx context.Context) error {
+	start := time.Now()
+	stats := models.NewScrapeStats()
+
+	registry := prometheus.NewRegistry()
+	// Unlike the scrape endpoint, remote-write preserves staleness markers for metrics.on-missing stale
+	if err := registry.Register(collector.NewCollector(writer.regionManager, stats).WithContext(models.ContextWithStaleMarkers(ctx))); err != nil {
+		return fmt.Errorf("failed to register collector: %w", err)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	series := TimeSeriesFromMetricFamilies(families, start)
+	if len(series) == 0 {
+		slog.Info("No samples collected, skipping remote-write push", "stats", stats)
+		return nil
+	}
+
+	if err := writer.send(ctx, EncodeWriteRequest(series)); err != nil {
+		return err
+	}
+
+	slog.Info("Pushed metrics with remote write", "series", len(series), "stats", stats, "duration", time.Since(start))
+	return nil
+}
+
+func (writer *RemoteWriter) send(ctx context.Context, writeRequest []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, writer.url, bytes.NewReader(snappy.Encode(nil, writeRequest)))
+	if err != nil {
+		return fmt.Errorf("failed to create remote-write request: %w", err)
+	}
+	request.Header.Set("Content-Encoding", "snappy")
+	request.Header.Set("Content-Type", "application/x-protobuf")
+	request.Header.Set("X-Prometheus-Remote-Write-Version", RemoteWriteVersion)
+
+	response, err := writer.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to send remote-write request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodyBytes))
+		return fmt.Errorf("remote-write endpoint returned %s: %s", response.Status, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
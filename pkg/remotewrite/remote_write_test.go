@@ -0,0 +1,170 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
+)
+
+func TestNewRemoteWriter(t *testing.T) {
+	mockRegionManager := &mocks.MockRegionManager{}
+	writer := NewRemoteWriter("http://localhost:9090/api/v1/write", time.Minute, mockRegionManager)
+
+	assert.NotNil(t, writer)
+	assert.Equal(t, "http://localhost:9090/api/v1/write", writer.url)
+	assert.Equal(t, time.Minute, writer.interval)
+	assert.Equal(t, mockRegionManager, writer.regionManager)
+	assert.NotNil(t, writer.client)
+}
+
+func TestPush(t *testing.T) {
+	testCases := []struct {
+		name          string
+		emitMetric    bool
+		statusCode    int
+		expectRequest bool
+		expectedError string
+	}{
+		{
+			name:          "pushes collected samples",
+			emitMetric:    true,
+			statusCode:    http.StatusNoContent,
+			expectRequest: true,
+		},
+		{
+			name:          "skips push when nothing was collected",
+			emitMetric:    false,
+			statusCode:    http.StatusNoContent,
+			expectRequest: false,
+		},
+		{
+			name:          "returns error on non-2xx response",
+			emitMetric:    true,
+			statusCode:    http.StatusBadRequest,
+			expectRequest: true,
+			expectedError: "remote-write endpoint returned 400 Bad Request: out of order sample",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var received []TimeSeries
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+				assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+				assert.Equal(t, RemoteWriteVersion, r.Header.Get("X-Prometheus-Remote-Write-Version"))
+
+				compressed, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				data, err := snappy.Decode(nil, compressed)
+				require.NoError(t, err)
+				received = decodeWriteRequest(t, data)
+
+				w.WriteHeader(tc.statusCode)
+				if tc.statusCode != http.StatusNoContent {
+					_, _ = w.Write([]byte("out of order sample\n"))
+				}
+			}))
+			defer server.Close()
+
+			mockRegionManager := &mocks.MockRegionManager{}
+			mockRegionManager.On("CollectMetrics", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					if tc.emitMetric {
+						ch := args.Get(1).(chan<- prometheus.Metric)
+						ch <- newTestGauge(t)
+					}
+				}).
+				Return(nil)
+
+			writer := NewRemoteWriter(server.URL, time.Minute, mockRegionManager)
+			err := writer.Push(context.Background())
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if tc.expectRequest {
+				assert.Equal(t, 1, requests)
+				require.Len(t, received, 1)
+				assert.Equal(t, []Label{
+					{Name: "__name__", Value: "dbi_os_general_numvcpus_avg"},
+					{Name: "engine", Value: "aurora-postgresql"},
+					{Name: "identifier", Value: "test-postgres-db"},
+					{Name: "unit", Value: "vCPUs"},
+				}, received[0].Labels)
+				assert.Equal(t, []Sample{{Value: 4, TimestampMs: testutils.TestTimestamp.UnixMilli()}}, received[0].Samples)
+			} else {
+				assert.Equal(t, 0, requests)
+			}
+			mockRegionManager.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPushConnectionError(t *testing.T) {
+	mockRegionManager := &mocks.MockRegionManager{}
+	mockRegionManager.On("CollectMetrics", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ch := args.Get(1).(chan<- prometheus.Metric)
+			ch <- newTestGauge(t)
+		}).
+		Return(nil)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	writer := NewRemoteWriter(url, time.Minute, mockRegionManager)
+	err := writer.Push(context.Background())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to send remote-write request")
+}
+
+func TestRunStopsOnContextCancel(t *testing.T) {
+	mockRegionManager := &mocks.MockRegionManager{}
+	mockRegionManager.On("CollectMetrics", mock.Anything, mock.Anything).Return(errors.New("collection failed"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	writer := NewRemoteWriter("http://localhost:0/api/v1/write", time.Hour, mockRegionManager)
+
+	done := make(chan struct{})
+	go func() {
+		writer.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after context cancellation")
+	}
+}
+
+func newTestGauge(t *testing.T) prometheus.Metric {
+	desc := prometheus.NewDesc("dbi_os_general_numvcpus_avg", "The number of virtual CPUs for the DB instance", []string{"identifier", "engine", "unit"}, nil)
+	metric, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, 4.0, "test-postgres-db", "aurora-postgresql", "vCPUs")
+	require.NoError(t, err)
+	return prometheus.NewMetricWithTimestamp(testutils.TestTimestamp, metric)
+}
@@ -16,8 +16,8 @@ type MockRDSService struct {
 	mock.Mock
 }
 
-func (mockRDSService *MockRDSService) DescribeDBInstancesPaginator(ctx context.Context) ([]rdstypes.DBInstance, error) {
-	args := mockRDSService.Called(ctx)
+func (mockRDSService *MockRDSService) DescribeDBInstancesPaginator(ctx context.Context, maxPages int) ([]rdstypes.DBInstance, error) {
+	args := mockRDSService.Called(ctx, maxPages)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -60,6 +60,36 @@ func NewMockRDSDescribeInstances() []rdstypes.DBInstance {
 	}
 }
 
+// NewMockRDSDescribeInstancesWithStopped returns the default instances plus a stopped instance
+func NewMockRDSDescribeInstancesWithStopped() []rdstypes.DBInstance {
+	return append(NewMockRDSDescribeInstances(), rdstypes.DBInstance{
+		DBInstanceIdentifier:       aws.String("test-stopped-db"),
+		DBInstanceArn:              aws.String("arn:aws:rds:us-west-2:123456789012:db:test-stopped-db"),
+		InstanceCreateTime:         aws.Time(testutils.TestInstanceCreationTimeNoMetrics),
+		DbiResourceId:              aws.String("db-TESTSTOPPED"),
+		Engine:                     aws.String("aurora-postgresql"),
+		DBInstanceStatus:           aws.String("stopped"),
+		DBInstanceClass:            aws.String("db.t3.micro"),
+		AllocatedStorage:           aws.Int32(20),
+		PerformanceInsightsEnabled: aws.Bool(true),
+	})
+}
+
+// NewMockRDSDescribeInstancesWithUnknownEngine returns the default instances plus an instance with an unrecognized engine
+func NewMockRDSDescribeInstancesWithUnknownEngine() []rdstypes.DBInstance {
+	return append(NewMockRDSDescribeInstances(), rdstypes.DBInstance{
+		DBInstanceIdentifier:       aws.String("test-unknown-db"),
+		DBInstanceArn:              aws.String("arn:aws:rds:us-west-2:123456789012:db:test-unknown-db"),
+		InstanceCreateTime:         aws.Time(testutils.TestInstanceCreationTimeNoMetrics),
+		DbiResourceId:              aws.String("db-TESTUNKNOWN"),
+		Engine:                     aws.String("db2-se"),
+		DBInstanceStatus:           aws.String("available"),
+		DBInstanceClass:            aws.String("db.t3.micro"),
+		AllocatedStorage:           aws.Int32(20),
+		PerformanceInsightsEnabled: aws.Bool(true),
+	})
+}
+
 func NewMockRDSDescribeInstancesEmpty() []rdstypes.DBInstance {
 	return []rdstypes.DBInstance{}
 }
@@ -20,6 +20,11 @@ func (mockFilter *MockFilter) HasFilters() bool {
 	return args.Bool(0)
 }
 
+func (mockFilter *MockFilter) Evaluate(obj filter.Filterable) filter.Decision {
+	args := mockFilter.Called(obj)
+	return args.Get(0).(filter.Decision)
+}
+
 func NewMockFilter() *MockFilter {
 	return &MockFilter{}
 }
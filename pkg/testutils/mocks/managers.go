@@ -23,6 +23,34 @@ func (m *MockRegionManager) CollectMetricsForInstances(ctx context.Context, inst
 	return args.Error(0)
 }
 
+func (m *MockRegionManager) ExplainExcludedMetrics(ctx context.Context, instanceIdentifier string) ([]models.ExcludedMetric, error) {
+	args := m.Called(ctx, instanceIdentifier)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ExcludedMetric), args.Error(1)
+}
+
+func (m *MockRegionManager) GetMetricCatalog(ctx context.Context, instanceIdentifier string) (map[string]models.CatalogMetric, error) {
+	args := m.Called(ctx, instanceIdentifier)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]models.CatalogMetric), args.Error(1)
+}
+
+func (m *MockRegionManager) ExplainInstances(ctx context.Context) ([]models.InstanceDecision, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.InstanceDecision), args.Error(1)
+}
+
+func (m *MockRegionManager) Invalidate() {
+	m.Called()
+}
+
 type MockInstanceProvider struct {
 	mock.Mock
 }
@@ -35,6 +63,10 @@ func (mockInstanceProvider *MockInstanceProvider) GetInstances(ctx context.Conte
 	return args.Get(0).([]models.Instance), args.Error(1)
 }
 
+func (mockInstanceProvider *MockInstanceProvider) Invalidate() {
+	mockInstanceProvider.Called()
+}
+
 type MockMetricProvider struct {
 	mock.Mock
 }
@@ -51,3 +83,39 @@ func (mockMetricProvider *MockMetricProvider) CollectMetricsForBatch(ctx context
 	args := mockMetricProvider.Called(ctx, instance, metricsBatch, ch)
 	return args.Error(0)
 }
+
+func (mockMetricProvider *MockMetricProvider) CollectDiscoveredMetricNames(region string, ch chan<- prometheus.Metric) {
+	mockMetricProvider.Called(region, ch)
+}
+
+func (mockMetricProvider *MockMetricProvider) CollectDataPointsReturned(region string, ch chan<- prometheus.Metric) {
+	mockMetricProvider.Called(region, ch)
+}
+
+func (mockMetricProvider *MockMetricProvider) CollectConversionErrors(region string, ch chan<- prometheus.Metric) {
+	mockMetricProvider.Called(region, ch)
+}
+
+func (mockMetricProvider *MockMetricProvider) CollectPostProcessedMetrics(ctx context.Context, ch chan<- prometheus.Metric) {
+	mockMetricProvider.Called(ctx, ch)
+}
+
+func (mockMetricProvider *MockMetricProvider) GetMetricCatalog(ctx context.Context, instance models.Instance) (map[string]models.CatalogMetric, error) {
+	args := mockMetricProvider.Called(ctx, instance)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]models.CatalogMetric), args.Error(1)
+}
+
+func (mockMetricProvider *MockMetricProvider) ExplainExcludedMetrics(ctx context.Context, instance models.Instance) ([]models.ExcludedMetric, error) {
+	args := mockMetricProvider.Called(ctx, instance)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ExcludedMetric), args.Error(1)
+}
+
+func (mockMetricProvider *MockMetricProvider) Invalidate() {
+	mockMetricProvider.Called()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/aws/aws-sdk-go-v2/service/pi"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -26,3 +27,15 @@ func (mockPIClient *MockAWSPIClient) GetResourceMetrics(ctx context.Context, par
 	}
 	return args.Get(0).(*pi.GetResourceMetricsOutput), args.Error(1)
 }
+
+type MockAWSRDSClient struct {
+	mock.Mock
+}
+
+func (mockRDSClient *MockAWSRDSClient) DescribeDBInstances(ctx context.Context, params *rds.DescribeDBInstancesInput, optFns ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	args := mockRDSClient.Called(ctx, params, optFns)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*rds.DescribeDBInstancesOutput), args.Error(1)
+}
@@ -1,6 +1,8 @@
 package testutils
 
 import (
+	"cmp"
+	"slices"
 	"time"
 
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
@@ -234,16 +236,63 @@ var (
 	TestMaxInstances = 25
 )
 
+var (
+	TestPrometheusConfig = models.ParsedPrometheusConfig{
+		MetricPrefix: "dbi",
+	}
+
+	TestAWSConfig = models.ParsedAWSConfig{
+		STSRegion: TestRegion,
+		Partition: models.PartitionAWS,
+	}
+)
+
 // TestConfigBuilder provides a fluent interface for building test configurations
 type TestConfigBuilder struct {
-	regions      []string
-	maxInstances int
-	instanceTTL  time.Duration
-	statistic    models.Statistic
-	metadataTTL  time.Duration
-	concurrency  int
-	port         int
-	metricPrefix string
+	regions        []string
+	maxInstances   int
+	instanceTTL    time.Duration
+	statistic      models.Statistic
+	statistics     []models.Statistic
+	metadataTTL    time.Duration
+	concurrency    int
+	discoveryConc  int
+	collectionConc int
+	producers      int
+	port           int
+	metricPrefix   string
+	namespace      string
+	subsystem      string
+	includeStopped bool
+	statuses       []string
+	unknownEngine  models.UnknownEngineBehavior
+	minRefresh     time.Duration
+	sampleRate     float64
+	minRetention   int32
+	priorityTag    string
+	selection      models.InstanceSelection
+	stsRegion      string
+	order          models.ParsedCollectionOrderConfig
+	descriptions   bool
+	discovered     bool
+	extraLabels    []string
+	regionLabel    bool
+	heartbeat      bool
+	dataPoints     bool
+	effective      bool
+	instanceInfo   bool
+	conversions    bool
+	apiTimeout     time.Duration
+	retries        int
+	retryJitter    models.RetryJitter
+	future         models.FutureTimestampBehavior
+	onMissing      models.MissingMetricBehavior
+	onInvalid      models.InvalidMetricBehavior
+	postProcessors []models.PostProcessorName
+	shareCatalog   bool
+	maxBatches     int
+	batchSize      int
+	priority       models.ScrapePriority
 }
 
 func NewTestInstance(resourceID, identifier string, engine models.Engine) models.Instance {
@@ -323,16 +372,41 @@ func NewTestInstanceNoMetrics() models.Instance {
 	}
 }
 
+func NewTestInstanceStopped() models.Instance {
+	return models.Instance{
+		ResourceID:   "db-TESTSTOPPED",
+		Identifier:   "test-stopped-db",
+		Engine:       models.AuroraPostgreSQL,
+		Status:       models.InstanceStatusStopped,
+		CreationTime: TestInstanceCreationTimeNoMetrics,
+		Metrics: &models.Metrics{
+			MetricsDetails:     TestMetricsDetails,
+			MetricsList:        TestMetricNamesWithStats,
+			MetricsLastUpdated: time.Time{},
+			MetadataTTL:        TestTTL,
+		},
+	}
+}
+
 func NewTestConfigBuilder() *TestConfigBuilder {
 	return &TestConfigBuilder{
-		regions:      []string{"us-west-2"},
-		maxInstances: TestMaxInstances,
-		instanceTTL:  5 * time.Minute,
-		statistic:    models.StatisticAvg,
-		metadataTTL:  60 * time.Minute,
-		concurrency:  4,
-		port:         8081,
-		metricPrefix: "dbi",
+		regions:       []string{"us-west-2"},
+		maxInstances:  TestMaxInstances,
+		instanceTTL:   5 * time.Minute,
+		statistic:     models.StatisticAvg,
+		metadataTTL:   60 * time.Minute,
+		concurrency:   4,
+		producers:     1,
+		batchSize:     15,
+		retries:       3,
+		port:          8081,
+		metricPrefix:  "dbi",
+		unknownEngine: models.UnknownEngineDrop,
+		priority:      models.ScrapePriorityNormal,
+		future:        models.FutureTimestampKeep,
+		onMissing:     models.MissingMetricAbsent,
+		onInvalid:     models.InvalidMetricPrune,
+		sampleRate:    1,
 	}
 }
 
@@ -356,6 +430,11 @@ func (b *TestConfigBuilder) WithStatistic(statistic models.Statistic) *TestConfi
 	return b
 }
 
+func (b *TestConfigBuilder) WithStatistics(statistics ...models.Statistic) *TestConfigBuilder {
+	b.statistics = statistics
+	return b
+}
+
 func (b *TestConfigBuilder) WithMetadataTTL(ttl time.Duration) *TestConfigBuilder {
 	b.metadataTTL = ttl
 	return b
@@ -366,6 +445,21 @@ func (b *TestConfigBuilder) WithConcurrency(concurrency int) *TestConfigBuilder
 	return b
 }
 
+func (b *TestConfigBuilder) WithDiscoveryConcurrency(concurrency int) *TestConfigBuilder {
+	b.discoveryConc = concurrency
+	return b
+}
+
+func (b *TestConfigBuilder) WithCollectionConcurrency(concurrency int) *TestConfigBuilder {
+	b.collectionConc = concurrency
+	return b
+}
+
+func (b *TestConfigBuilder) WithProducerConcurrency(producers int) *TestConfigBuilder {
+	b.producers = producers
+	return b
+}
+
 func (b *TestConfigBuilder) WithPort(port int) *TestConfigBuilder {
 	b.port = port
 	return b
@@ -376,28 +470,246 @@ func (b *TestConfigBuilder) WithMetricPrefix(prefix string) *TestConfigBuilder {
 	return b
 }
 
+func (b *TestConfigBuilder) WithNamespace(namespace string) *TestConfigBuilder {
+	b.namespace = namespace
+	return b
+}
+
+func (b *TestConfigBuilder) WithSubsystem(subsystem string) *TestConfigBuilder {
+	b.subsystem = subsystem
+	return b
+}
+
+func (b *TestConfigBuilder) WithInstanceStatuses(statuses ...string) *TestConfigBuilder {
+	b.statuses = statuses
+	return b
+}
+
+func (b *TestConfigBuilder) WithIncludeStopped(includeStopped bool) *TestConfigBuilder {
+	b.includeStopped = includeStopped
+	return b
+}
+
+func (b *TestConfigBuilder) WithUnknownEngineBehavior(behavior models.UnknownEngineBehavior) *TestConfigBuilder {
+	b.unknownEngine = behavior
+	return b
+}
+
+func (b *TestConfigBuilder) WithMaxBatchesPerScrape(maxBatches int) *TestConfigBuilder {
+	b.maxBatches = maxBatches
+	return b
+}
+
+func (b *TestConfigBuilder) WithBatchSize(batchSize int) *TestConfigBuilder {
+	b.batchSize = batchSize
+	return b
+}
+
+func (b *TestConfigBuilder) WithTargetedScrapePriority(priority models.ScrapePriority) *TestConfigBuilder {
+	b.priority = priority
+	return b
+}
+
+func (b *TestConfigBuilder) WithSTSRegion(stsRegion string) *TestConfigBuilder {
+	b.stsRegion = stsRegion
+	return b
+}
+
+func (b *TestConfigBuilder) WithCollectionOrder(order models.ParsedCollectionOrderConfig) *TestConfigBuilder {
+	b.order = order
+	return b
+}
+
+func (b *TestConfigBuilder) WithDescriptionInfoMetric(enabled bool) *TestConfigBuilder {
+	b.descriptions = enabled
+	return b
+}
+
+func (b *TestConfigBuilder) WithDiscoveryMaxRetries(retries int) *TestConfigBuilder {
+	b.retries = retries
+	return b
+}
+
+func (b *TestConfigBuilder) WithRetryJitter(jitter models.RetryJitter) *TestConfigBuilder {
+	b.retryJitter = jitter
+	return b
+}
+
+func (b *TestConfigBuilder) WithDiscoveredMetricNames(enabled bool) *TestConfigBuilder {
+	b.discovered = enabled
+	return b
+}
+
+func (b *TestConfigBuilder) WithExtraLabels(labels ...string) *TestConfigBuilder {
+	b.extraLabels = labels
+	return b
+}
+
+func (b *TestConfigBuilder) WithRegionLabel(enabled bool) *TestConfigBuilder {
+	b.regionLabel = enabled
+	return b
+}
+
+func (b *TestConfigBuilder) WithMinRefreshInterval(interval time.Duration) *TestConfigBuilder {
+	b.minRefresh = interval
+	return b
+}
+
+func (b *TestConfigBuilder) WithSampleRate(rate float64) *TestConfigBuilder {
+	b.sampleRate = rate
+	return b
+}
+
+func (b *TestConfigBuilder) WithPostProcessors(names ...models.PostProcessorName) *TestConfigBuilder {
+	b.postProcessors = names
+	return b
+}
+
+func (b *TestConfigBuilder) WithHeartbeatMetric(enabled bool) *TestConfigBuilder {
+	b.heartbeat = enabled
+	return b
+}
+
+func (b *TestConfigBuilder) WithDataPointsReturned(enabled bool) *TestConfigBuilder {
+	b.dataPoints = enabled
+	return b
+}
+
+func (b *TestConfigBuilder) WithConversionErrors(enabled bool) *TestConfigBuilder {
+	b.conversions = enabled
+	return b
+}
+
+func (b *TestConfigBuilder) WithEffectiveSettings(enabled bool) *TestConfigBuilder {
+	b.effective = enabled
+	return b
+}
+
+func (b *TestConfigBuilder) WithInstanceInfo(enabled bool) *TestConfigBuilder {
+	b.instanceInfo = enabled
+	return b
+}
+
+func (b *TestConfigBuilder) WithMinPIRetention(days int32) *TestConfigBuilder {
+	b.minRetention = days
+	return b
+}
+
+func (b *TestConfigBuilder) WithInstanceSelection(selection models.InstanceSelection) *TestConfigBuilder {
+	b.selection = selection
+	return b
+}
+
+func (b *TestConfigBuilder) WithPriorityTag(tag string) *TestConfigBuilder {
+	b.priorityTag = tag
+	return b
+}
+
+func (b *TestConfigBuilder) WithOnMissing(behavior models.MissingMetricBehavior) *TestConfigBuilder {
+	b.onMissing = behavior
+	return b
+}
+
+func (b *TestConfigBuilder) WithOnInvalid(behavior models.InvalidMetricBehavior) *TestConfigBuilder {
+	b.onInvalid = behavior
+	return b
+}
+
+func (b *TestConfigBuilder) WithShareCatalogPerEngine(enabled bool) *TestConfigBuilder {
+	b.shareCatalog = enabled
+	return b
+}
+
+func (b *TestConfigBuilder) WithAPICallTimeout(timeout time.Duration) *TestConfigBuilder {
+	b.apiTimeout = timeout
+	return b
+}
+
+func (b *TestConfigBuilder) WithFutureTimestamp(behavior models.FutureTimestampBehavior) *TestConfigBuilder {
+	b.future = behavior
+	return b
+}
+
+// instanceStatuses returns the configured instance statuses, available by default, with stopped added by include-stopped.
+func (b *TestConfigBuilder) instanceStatuses() []string {
+	statuses := b.statuses
+	if len(statuses) == 0 {
+		statuses = []string{models.InstanceStatusAvailable}
+	}
+	if b.includeStopped && !slices.Contains(statuses, models.InstanceStatusStopped) {
+		statuses = append(slices.Clone(statuses), models.InstanceStatusStopped)
+	}
+	return statuses
+}
+
 func (b *TestConfigBuilder) Build() *models.ParsedConfig {
+	stsRegion := b.stsRegion
+	if stsRegion == "" && len(b.regions) > 0 {
+		stsRegion = b.regions[0]
+	}
+
 	return &models.ParsedConfig{
 		Discovery: models.ParsedDiscoveryConfig{
-			Regions: b.regions,
+			Regions:               b.regions,
+			IncludeStopped:        b.includeStopped,
+			UnknownEngineBehavior: b.unknownEngine,
+			MinRefreshInterval:    b.minRefresh,
+			SampleRate:            b.sampleRate,
+			MinPIRetention:        b.minRetention,
+			PriorityTag:           b.priorityTag,
 			Instances: models.ParsedInstancesConfig{
 				MaxInstances: b.maxInstances,
 				InstanceTTL:  b.instanceTTL,
+				Selection:    b.selection,
+				Statuses:     b.instanceStatuses(),
 			},
 			Metrics: models.ParsedMetricsConfig{
-				Statistic:   b.statistic,
-				MetadataTTL: b.metadataTTL,
+				Statistic:             b.statistic,
+				Statistics:            b.statistics,
+				MetadataTTL:           b.metadataTTL,
+				FutureTimestamp:       b.future,
+				OnMissing:             b.onMissing,
+				OnInvalid:             b.onInvalid,
+				PostProcessors:        b.postProcessors,
+				ShareCatalogPerEngine: b.shareCatalog,
 			},
 			Processing: models.ParsedProcessingConfig{
-				Concurrency: b.concurrency,
+				Concurrency:           b.concurrency,
+				DiscoveryConcurrency:  cmp.Or(b.discoveryConc, b.concurrency),
+				CollectionConcurrency: cmp.Or(b.collectionConc, b.concurrency),
+				ProducerConcurrency:   b.producers,
+				RegionConcurrency:     4,
+				MaxBatchesPerScrape:   b.maxBatches,
+				BatchSize:             b.batchSize,
+				DiscoveryMaxRetries:   b.retries,
+				RetryJitter:           b.retryJitter,
 			},
+			CollectionOrder: b.order,
 		},
 		Export: models.ParsedExportConfig{
-			Port: b.port,
+			Port:             b.port,
+			TargetedPriority: b.priority,
 			Prometheus: models.ParsedPrometheusConfig{
-				MetricPrefix: b.metricPrefix,
+				MetricPrefix:          b.metricPrefix,
+				Namespace:             b.namespace,
+				Subsystem:             b.subsystem,
+				StatusLabel:           b.includeStopped,
+				RegionLabel:           b.regionLabel,
+				DescriptionInfoMetric: b.descriptions,
+				DiscoveredMetricNames: b.discovered,
+				ExtraLabels:           b.extraLabels,
+				HeartbeatMetric:       b.heartbeat,
+				DataPointsReturned:    b.dataPoints,
+				EffectiveSettings:     b.effective,
+				ConversionErrors:      b.conversions,
+				InstanceInfo:          b.instanceInfo,
 			},
 		},
+		AWS: models.ParsedAWSConfig{
+			STSRegion:      stsRegion,
+			Partition:      models.PartitionForRegion(stsRegion),
+			APICallTimeout: b.apiTimeout,
+		},
 	}
 }
 
@@ -2,15 +2,24 @@ package utils
 
 import (
 	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
+	"maps"
+	"math"
 	"net"
+	"net/url"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/filter"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 
@@ -19,16 +28,66 @@ import (
 
 const (
 	MaxInstances        = 25
-	BatchSize           = 15
+	DefaultBatchSize    = 15
+	MaxBatchSize        = 15 // most metric queries Performance Insights accepts per GetResourceMetrics call
 	MaximumConcurrency  = 60
 	DefaultConcurrency  = 4
 	MinTTL              = time.Minute
 	MaxTTL              = time.Hour * 24
 	DefaultInstanceTTL  = time.Minute * 5
+	DefaultEmptyTTL     = time.Minute * 30
 	DefaultMetadataTTL  = time.Minute * 60
 	ValidPrometheusName = `^[a-zA-Z_:][a-zA-Z0-9_:]*$`
+	ValidHostName       = `^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`
+	InvalidLabelChars   = `[^a-zA-Z0-9_]`
+	TagLabelPrefix      = "tag_"
+
+	DefaultSampleRate = 1.0
+
+	DefaultDiscoveryMaxRetries = 3
+	MaxDiscoveryMaxRetries     = 10
+
+	DefaultProducerConcurrency = 1
+	MaxProducerConcurrency     = 16
+
+	DefaultRegionConcurrency = 4
+	MaxRegionConcurrency     = 32
+
+	MinRemoteWriteInterval     = time.Second * 10
+	MaxRemoteWriteInterval     = time.Hour
+	DefaultRemoteWriteInterval = time.Minute
+
+	MinAPICallTimeout     = time.Second
+	MaxAPICallTimeout     = time.Minute * 5
+	DefaultAPICallTimeout = time.Second * 30
+
+	MinMaxScrapeDuration     = time.Second
+	MaxMaxScrapeDuration     = time.Hour
+	DefaultMaxScrapeDuration = time.Minute * 5
+
+	MinScrapeTimeoutOffset     = 0
+	MaxScrapeTimeoutOffset     = time.Second * 10
+	DefaultScrapeTimeoutOffset = time.Millisecond * 500
+
+	MinShutdownGracePeriod     = 0
+	MaxShutdownGracePeriod     = time.Minute * 10
+	DefaultShutdownGracePeriod = time.Second * 30
+
+	DefaultMaxPages = 100
+	MaxMaxPages     = 1000
+
+	MaxMaxInstancesLimit = 1000
+
+	DefaultMaxInstanceIdentifiers = 5
+
+	MinExternalIDLength = 2
+	MaxExternalIDLength = 1224
 )
 
+// reservedLabels are the label names of the metrics the exporter emits, which export.prometheus.static-labels
+// must not use. The enabled extra labels and the tag_ labels are reserved as well.
+var reservedLabels = []string{"identifier", "engine", "unit", "status", "region", "metric", "description", "category", "reason", "class", "storage_gb"}
+
 func LoadConfig(filePath string) (*models.ParsedConfig, error) {
 	data, err := ioutil.ReadFile(filePath)
 	if err != nil {
@@ -53,7 +112,12 @@ func LoadConfig(filePath string) (*models.ParsedConfig, error) {
 func createDefaultConfig() models.Config {
 	return models.Config{
 		Discovery: models.DiscoveryConfig{
-			Regions: []string{},
+			Regions:               []string{},
+			UnknownEngineBehavior: "",
+			MinRefreshInterval:    "",
+			SampleRate:            0,
+			MinPIRetention:        0,
+			PriorityTag:           "",
 			Instances: models.InstancesConfig{
 				MaxInstances: 0,
 				InstanceTTL:  "",
@@ -63,14 +127,31 @@ func createDefaultConfig() models.Config {
 				MetadataTTL: "",
 			},
 			Processing: models.ProcessingConfig{
-				Concurrency: 0,
+				Concurrency:         0,
+				ProducerConcurrency: 0,
+				MaxBatchesPerScrape: 0,
+				DiscoveryMaxRetries: 0,
 			},
 		},
 		Export: models.ExportConfig{
-			Port: 0,
+			Port:        0,
+			BindAddress: "",
 			Prometheus: models.PrometheusConfig{
 				MetricPrefix: "",
 			},
+			RemoteWriteURL:      "",
+			RemoteWriteInterval: "",
+			TargetedPriority:    "",
+			MaxScrapeDuration:   "",
+			ScrapeTimeoutOffset: "",
+			ShutdownGracePeriod: "",
+			UnhealthyThreshold:  0,
+		},
+		AWS: models.AWSConfig{
+			STSRegion:      "",
+			Partition:      "",
+			APICallTimeout: "",
+			AllowedRegions: []string{},
 		},
 	}
 }
@@ -80,8 +161,8 @@ func applyDefaults(config *models.Config) {
 		config.Discovery.Regions = []string{"us-west-2"}
 	}
 
-	if config.Discovery.Instances.MaxInstances <= 0 {
-		config.Discovery.Instances.MaxInstances = MaxInstances
+	if config.Discovery.UnknownEngineBehavior == "" {
+		config.Discovery.UnknownEngineBehavior = string(models.UnknownEngineDrop)
 	}
 
 	if config.Discovery.Instances.InstanceTTL == "" {
@@ -100,6 +181,14 @@ func applyDefaults(config *models.Config) {
 		config.Discovery.Processing.Concurrency = DefaultConcurrency
 	}
 
+	if config.Discovery.Processing.ProducerConcurrency == 0 {
+		config.Discovery.Processing.ProducerConcurrency = DefaultProducerConcurrency
+	}
+
+	if config.Discovery.Processing.DiscoveryMaxRetries == 0 {
+		config.Discovery.Processing.DiscoveryMaxRetries = DefaultDiscoveryMaxRetries
+	}
+
 	if config.Export.Port == 0 {
 		config.Export.Port = 8081
 	}
@@ -107,22 +196,68 @@ func applyDefaults(config *models.Config) {
 	if config.Export.Prometheus.MetricPrefix == "" {
 		config.Export.Prometheus.MetricPrefix = "dbi"
 	}
+
+	if config.Export.RemoteWriteInterval == "" {
+		config.Export.RemoteWriteInterval = "1m"
+	}
+
+	if config.Export.TargetedPriority == "" {
+		config.Export.TargetedPriority = string(models.ScrapePriorityNormal)
+	}
+
+	if config.AWS.STSRegion == "" {
+		config.AWS.STSRegion = config.Discovery.Regions[0]
+	}
 }
 
 func parsedValidateConfig(config *models.Config) (*models.ParsedConfig, error) {
 	var parsedConfig models.ParsedConfig
 
-	if len(config.Discovery.Regions) > 1 {
-		// Current version only supports single region exporter
-		parsedConfig.Discovery.Regions = []string{config.Discovery.Regions[0]}
-	} else {
-		parsedConfig.Discovery.Regions = config.Discovery.Regions
+	if err := validateAllowedRegions(config.AWS.AllowedRegions, config.Discovery.Regions, config.AWS.STSRegion); err != nil {
+		return nil, err
+	}
+
+	if len(config.Discovery.Regions) > 1 && config.Discovery.StrictSingleRegion {
+		return nil, fmt.Errorf("invalid discovery.regions in config.yml, %d regions configured but discovery.strict-single-region allows a single region", len(config.Discovery.Regions))
+	}
+	parsedConfig.Discovery.Regions = config.Discovery.Regions
+
+	parsedConfig.Discovery.IncludeStopped = config.Discovery.IncludeStopped
+
+	unknownEngineBehavior, err := parseUnknownEngineBehavior(config.Discovery.UnknownEngineBehavior)
+	if err != nil {
+		return nil, err
+	}
+	parsedConfig.Discovery.UnknownEngineBehavior = unknownEngineBehavior
+
+	minRefreshInterval, err := parseMinRefreshInterval(config.Discovery.MinRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+	parsedConfig.Discovery.MinRefreshInterval = minRefreshInterval
+
+	sampleRate := config.Discovery.SampleRate
+	if sampleRate == 0 {
+		sampleRate = DefaultSampleRate
+	}
+	if sampleRate < 0 || sampleRate > 1 {
+		return nil, fmt.Errorf("invalid discovery.sample-rate %v in config.yml, must be greater than 0 and at most 1", sampleRate)
+	}
+	parsedConfig.Discovery.SampleRate = sampleRate
+
+	if config.Discovery.MinPIRetention < 0 {
+		return nil, fmt.Errorf("invalid discovery.min-pi-retention %d in config.yml, must not be negative", config.Discovery.MinPIRetention)
 	}
+	parsedConfig.Discovery.MinPIRetention = config.Discovery.MinPIRetention
+	parsedConfig.Discovery.PriorityTag = config.Discovery.PriorityTag
 
 	instancesConfig, err := parseInstancesConfig(config.Discovery.Instances)
 	if err != nil {
 		return nil, err
 	}
+	if config.Discovery.IncludeStopped && !slices.Contains(instancesConfig.Statuses, models.InstanceStatusStopped) {
+		instancesConfig.Statuses = append(instancesConfig.Statuses, models.InstanceStatusStopped)
+	}
 	parsedConfig.Discovery.Instances = instancesConfig
 
 	metricsConfig, err := parsedMetricsConfig(config.Discovery.Metrics)
@@ -131,7 +266,24 @@ func parsedValidateConfig(config *models.Config) (*models.ParsedConfig, error) {
 	}
 	parsedConfig.Discovery.Metrics = metricsConfig
 
-	parsedConfig.Discovery.Processing = parseProcessingConfig(config.Discovery.Processing)
+	instanceGlobalFilter, metricGlobalFilter, err := parseGlobalFilter(config.Discovery.GlobalFilter)
+	if err != nil {
+		return nil, err
+	}
+	parsedConfig.Discovery.Instances.GlobalFilter = instanceGlobalFilter
+	parsedConfig.Discovery.Metrics.GlobalFilter = metricGlobalFilter
+
+	processing, err := parseProcessingConfig(config.Discovery.Processing)
+	if err != nil {
+		return nil, err
+	}
+	parsedConfig.Discovery.Processing = processing
+
+	collectionOrder, err := parseCollectionOrderConfig(config.Discovery.CollectionOrder)
+	if err != nil {
+		return nil, err
+	}
+	parsedConfig.Discovery.CollectionOrder = collectionOrder
 
 	exportConfig, err := parseExportConfig(config.Export)
 	if err != nil {
@@ -139,9 +291,69 @@ func parsedValidateConfig(config *models.Config) (*models.ParsedConfig, error) {
 	}
 	parsedConfig.Export = exportConfig
 
+	// Staleness markers are only exported through remote-write, the text format of the scrape endpoint shows them as NaN
+	if parsedConfig.Discovery.Metrics.OnMissing == models.MissingMetricStale && parsedConfig.Export.RemoteWriteURL == "" {
+		return nil, fmt.Errorf("metrics.on-missing %s requires export.remote-write-url in config.yml", models.MissingMetricStale)
+	}
+
+	maxIdentifiers, err := parseMaxInstanceIdentifiers(config.Export.MaxIdentifiers, parsedConfig.Discovery.Instances.MaxInstances)
+	if err != nil {
+		return nil, err
+	}
+	parsedConfig.Export.MaxIdentifiers = maxIdentifiers
+	// Stopped instances are labeled by status so they can be told apart from available ones
+	parsedConfig.Export.Prometheus.StatusLabel = config.Export.Prometheus.StatusLabel || config.Discovery.IncludeStopped
+
+	regionLabel, err := parseRegionLabel(config.Export.Prometheus.RegionLabel, parsedConfig.Discovery.Regions, parsedConfig.Export.Prometheus.ExtraLabels)
+	if err != nil {
+		return nil, err
+	}
+	parsedConfig.Export.Prometheus.RegionLabel = regionLabel
+
+	awsConfig, err := parseAWSConfig(config.AWS, parsedConfig.Discovery.Regions)
+	if err != nil {
+		return nil, err
+	}
+	parsedConfig.AWS = awsConfig
+
+	logConfig, err := parseLogConfig(config.Log)
+	if err != nil {
+		return nil, err
+	}
+	parsedConfig.Log = logConfig
+
 	return &parsedConfig, nil
 }
 
+// logLevels are the values of log.level, from the most to the least verbose.
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+func parseLogConfig(config models.LogConfig) (models.ParsedLogConfig, error) {
+	level := slog.LevelInfo
+	switch config.Level {
+	case "", "info":
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		return models.ParsedLogConfig{}, fmt.Errorf("invalid log.level %s provided in config.yml, must be one of: %s", config.Level, strings.Join(logLevels, ", "))
+	}
+
+	format := models.LogFormatText
+	if config.Format != "" {
+		format = models.NewLogFormat(config.Format)
+		if format == "" {
+			return models.ParsedLogConfig{}, fmt.Errorf("invalid log.format %s provided in config.yml, must be one of: %s, %s",
+				config.Format, models.LogFormatText, models.LogFormatJSON)
+		}
+	}
+
+	return models.ParsedLogConfig{Level: level, Format: format}, nil
+}
+
 func getAllValidFilterFields() map[string]bool {
 	validFields := make(map[string]bool)
 
@@ -167,7 +379,7 @@ func isValidFilterField(fieldName string) bool {
 	return validFields[fieldName]
 }
 
-func compileFilterConfig(config models.FilterConfig) (filter.Patterns, error) {
+func compileFilterConfig(config models.FilterConfig, caseInsensitive bool) (filter.Patterns, error) {
 	if config == nil {
 		return nil, nil
 	}
@@ -178,7 +390,7 @@ func compileFilterConfig(config models.FilterConfig) (filter.Patterns, error) {
 			return nil, fmt.Errorf("invalid filter field '%s' in config.yml", fieldName)
 		}
 
-		compiledPatterns, err := compileRegexPatterns(patterns)
+		compiledPatterns, err := compileRegexPatterns(patterns, caseInsensitive)
 		if err != nil {
 			return nil, fmt.Errorf("invalid filter patterns in config.yml: %v", err)
 		}
@@ -189,8 +401,106 @@ func compileFilterConfig(config models.FilterConfig) (filter.Patterns, error) {
 	return filter, nil
 }
 
+// parseGlobalFilter compiles discovery.global-filter once and splits it into the patterns on instance fields and those
+// on metric fields, since instances and metrics have different filterable fields. Either filter is nil if it has no patterns.
+func parseGlobalFilter(config models.GlobalFilterConfig) (filter.Filter, filter.Filter, error) {
+	includePatterns, err := compileFilterConfig(config.Include, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid discovery.global-filter.include patterns in config.yml: %v", err)
+	}
+
+	excludePatterns, err := compileFilterConfig(config.Exclude, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid discovery.global-filter.exclude patterns in config.yml: %v", err)
+	}
+
+	instanceInclude, metricInclude := splitPatternsByTarget(includePatterns)
+	instanceExclude, metricExclude := splitPatternsByTarget(excludePatterns)
+
+	var instanceFilter, metricFilter filter.Filter
+	if len(instanceInclude) > 0 || len(instanceExclude) > 0 {
+		instanceFilter = filter.NewPatternFilter(instanceInclude, instanceExclude)
+	}
+	if len(metricInclude) > 0 || len(metricExclude) > 0 {
+		metricFilter = filter.NewPatternFilter(metricInclude, metricExclude)
+	}
+	return instanceFilter, metricFilter, nil
+}
+
+// splitPatternsByTarget separates the patterns on metric fields from those on instance fields, including tags.
+func splitPatternsByTarget(patterns filter.Patterns) (filter.Patterns, filter.Patterns) {
+	metricFields := models.MetricDetails{}.GetFilterableFields()
+
+	instancePatterns := filter.Patterns{}
+	metricPatterns := filter.Patterns{}
+	for fieldName, compiledPatterns := range patterns {
+		if _, isMetricField := metricFields[fieldName]; isMetricField {
+			metricPatterns[fieldName] = compiledPatterns
+		} else {
+			instancePatterns[fieldName] = compiledPatterns
+		}
+	}
+	return instancePatterns, metricPatterns
+}
+
+// parseMaxInstances returns the number of instances to collect, clamped to instances.max-instances-limit with a
+// warning. The limit defaults to MaxInstances and can be raised up to MaxMaxInstancesLimit for larger fleets.
+func parseMaxInstances(maxInstances int, limit int) (int, error) {
+	if limit == 0 {
+		limit = MaxInstances
+	}
+	if limit < 1 || limit > MaxMaxInstancesLimit {
+		return 0, fmt.Errorf("invalid instances.max-instances-limit %d in config.yml, must be between 1 and %d", limit, MaxMaxInstancesLimit)
+	}
+
+	if maxInstances < 1 {
+		return min(MaxInstances, limit), nil
+	}
+	if maxInstances > limit {
+		slog.Warn("instances.max-instances exceeds instances.max-instances-limit, clamping to the limit", "max_instances", maxInstances, "limit", limit)
+		return limit, nil
+	}
+	return maxInstances, nil
+}
+
+// parseMaxInstanceIdentifiers returns how many instances a targeted scrape may name in ?identifiers. It defaults to
+// DefaultMaxInstanceIdentifiers and is clamped to max-instances with a warning, as no more instances are collected.
+func parseMaxInstanceIdentifiers(maxIdentifiers int, maxInstances int) (int, error) {
+	if maxIdentifiers < 0 {
+		return 0, fmt.Errorf("invalid export.max-instance-identifiers %d in config.yml, must not be negative", maxIdentifiers)
+	}
+	if maxIdentifiers == 0 {
+		return min(DefaultMaxInstanceIdentifiers, maxInstances), nil
+	}
+	if maxIdentifiers > maxInstances {
+		slog.Warn("export.max-instance-identifiers exceeds instances.max-instances, clamping to instances.max-instances", "max_identifiers", maxIdentifiers, "max_instances", maxInstances)
+		return maxInstances, nil
+	}
+	return maxIdentifiers, nil
+}
+
+func parseUnknownEngineBehavior(behavior string) (models.UnknownEngineBehavior, error) {
+	if behavior == "" {
+		return models.UnknownEngineDrop, nil
+	}
+
+	unknownEngineBehavior := models.NewUnknownEngineBehavior(behavior)
+	if unknownEngineBehavior == "" {
+		return "", fmt.Errorf("invalid unknown-engine-behavior %s provided in config.yml", behavior)
+	}
+	return unknownEngineBehavior, nil
+}
+
 func parseInstancesConfig(config models.InstancesConfig) (models.ParsedInstancesConfig, error) {
-	maxInstances := GetOrDefault(config.MaxInstances, 1, MaxInstances, MaxInstances, "max-instances")
+	maxInstances, err := parseMaxInstances(config.MaxInstances, config.MaxInstancesLimit)
+	if err != nil {
+		return models.ParsedInstancesConfig{}, err
+	}
+
+	maxPages := DefaultMaxPages
+	if config.MaxPages != 0 {
+		maxPages = GetOrDefault(config.MaxPages, 1, MaxMaxPages, DefaultMaxPages, "instances.max-pages")
+	}
 
 	instanceTTL, err := time.ParseDuration(config.InstanceTTL)
 	if err != nil {
@@ -199,28 +509,170 @@ func parseInstancesConfig(config models.InstancesConfig) (models.ParsedInstances
 
 	instanceTTL = GetOrDefault(instanceTTL, MinTTL, MaxTTL, DefaultInstanceTTL, "instances.ttl")
 
-	includePatterns, err := compileFilterConfig(config.Include)
+	emptyTTL, err := parseEmptyTTL(config.EmptyTTL, instanceTTL)
+	if err != nil {
+		return models.ParsedInstancesConfig{}, err
+	}
+
+	includePatterns, err := compileFilterConfig(config.Include, config.CaseInsensitive)
 	if err != nil {
 		return models.ParsedInstancesConfig{}, fmt.Errorf("invalid instance.include patterns in config.yml: %v", err)
 	}
 
-	excludePatterns, err := compileFilterConfig(config.Exclude)
+	excludePatterns, err := compileFilterConfig(config.Exclude, config.CaseInsensitive)
 	if err != nil {
 		return models.ParsedInstancesConfig{}, fmt.Errorf("invalid instance.exclude patterns in config.yml: %v", err)
 	}
 
+	filterMode, err := parseFilterMode(config.FilterMode, "instances.filter-mode")
+	if err != nil {
+		return models.ParsedInstancesConfig{}, err
+	}
+
+	combinators, err := parseFieldCombinators(config.FieldCombinators)
+	if err != nil {
+		return models.ParsedInstancesConfig{}, err
+	}
+
 	var instanceFilter filter.Filter
 	if len(includePatterns) > 0 || len(excludePatterns) > 0 {
-		instanceFilter = filter.NewPatternFilter(includePatterns, excludePatterns)
+		instanceFilter = filter.NewPatternFilterWithOptions(includePatterns, excludePatterns, filter.Options{Mode: filterMode, Combinators: combinators})
+	}
+
+	selection, err := parseInstanceSelection(config.Selection)
+	if err != nil {
+		return models.ParsedInstancesConfig{}, err
+	}
+
+	statuses, err := parseInstanceStatuses(config.Statuses)
+	if err != nil {
+		return models.ParsedInstancesConfig{}, err
+	}
+
+	identifiers, err := parseInstanceIdentifiers(config.Identifiers)
+	if err != nil {
+		return models.ParsedInstancesConfig{}, err
 	}
 
 	return models.ParsedInstancesConfig{
 		MaxInstances: maxInstances,
+		MaxPages:     maxPages,
 		InstanceTTL:  instanceTTL,
+		EmptyTTL:     emptyTTL,
+		Selection:    selection,
+		Statuses:     statuses,
+		Identifiers:  identifiers,
 		Filter:       instanceFilter,
 	}, nil
 }
 
+// parseEmptyTTL parses how long a discovery that found no instances is cached. Empty uses DefaultEmptyTTL. The result is
+// never shorter than the instance TTL, so an empty region is never rediscovered more often than a populated one.
+func parseEmptyTTL(value string, instanceTTL time.Duration) (time.Duration, error) {
+	emptyTTL := DefaultEmptyTTL
+	if value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid instances.empty-ttl format '%s' in config.yml: %v", value, err)
+		}
+		emptyTTL = GetOrDefault(parsed, MinTTL, MaxTTL, DefaultEmptyTTL, "instances.empty-ttl")
+	}
+
+	if emptyTTL < instanceTTL {
+		slog.Info("instances.empty-ttl is shorter than instances.ttl, setting it to instances.ttl", "empty_ttl", emptyTTL, "ttl", instanceTTL)
+		return instanceTTL, nil
+	}
+	return emptyTTL, nil
+}
+
+// parseFilterMode validates how exclude patterns combine with include patterns. Empty applies exclude patterns always.
+func parseFilterMode(mode string, fieldName string) (filter.Mode, error) {
+	if mode == "" {
+		return filter.ModeExcludeAlways, nil
+	}
+
+	filterMode := filter.Mode(mode)
+	if !filterMode.IsValid() {
+		return "", fmt.Errorf("invalid %s %s provided in config.yml, must be one of: %s, %s",
+			fieldName, mode, filter.ModeExcludeAlways, filter.ModeExcludeWithinInclude)
+	}
+	return filterMode, nil
+}
+
+// parseFieldCombinators validates how the patterns of each instance field combine, any (the default) or all.
+func parseFieldCombinators(config map[string]string) (map[string]filter.Combinator, error) {
+	if len(config) == 0 {
+		return nil, nil
+	}
+
+	combinators := make(map[string]filter.Combinator, len(config))
+	for fieldName, value := range config {
+		if !isValidFilterField(fieldName) {
+			return nil, fmt.Errorf("invalid instances.field-combinators field '%s' in config.yml", fieldName)
+		}
+
+		combinator := filter.Combinator(value)
+		if !combinator.IsValid() {
+			return nil, fmt.Errorf("invalid instances.field-combinators value %s for field '%s' in config.yml, must be one of: %s, %s",
+				value, fieldName, filter.CombinatorAny, filter.CombinatorAll)
+		}
+		combinators[fieldName] = combinator
+	}
+	return combinators, nil
+}
+
+// parseInstanceSelection validates the order instances are sorted in before capping. Empty keeps the oldest instances.
+func parseInstanceSelection(selection string) (models.InstanceSelection, error) {
+	if selection == "" {
+		return models.InstanceSelectionOldest, nil
+	}
+
+	instanceSelection := models.NewInstanceSelection(selection)
+	if instanceSelection == "" {
+		return "", fmt.Errorf("invalid instances.selection %s provided in config.yml, must be one of: %s, %s, %s",
+			selection, models.InstanceSelectionOldest, models.InstanceSelectionNewest, models.InstanceSelectionAlphabetical)
+	}
+	return instanceSelection, nil
+}
+
+// parseInstanceStatuses validates the instance statuses that are collected. An empty list collects available instances.
+func parseInstanceStatuses(statuses []string) ([]string, error) {
+	if len(statuses) == 0 {
+		return []string{models.InstanceStatusAvailable}, nil
+	}
+
+	seen := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		if status == "" {
+			return nil, fmt.Errorf("invalid instances.statuses in config.yml, statuses cannot be empty")
+		}
+		if seen[status] {
+			return nil, fmt.Errorf("invalid instances.statuses in config.yml, duplicate status '%s'", status)
+		}
+		seen[status] = true
+	}
+	return slices.Clone(statuses), nil
+}
+
+// parseInstanceIdentifiers validates the instances.identifiers allowlist. An empty list allows every identifier.
+func parseInstanceIdentifiers(identifiers []string) ([]string, error) {
+	if len(identifiers) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(identifiers))
+	for _, identifier := range identifiers {
+		if strings.TrimSpace(identifier) == "" {
+			return nil, fmt.Errorf("invalid instances.identifiers in config.yml, identifiers cannot be empty")
+		}
+		if seen[identifier] {
+			return nil, fmt.Errorf("invalid instances.identifiers in config.yml, duplicate identifier '%s'", identifier)
+		}
+		seen[identifier] = true
+	}
+	return slices.Clone(identifiers), nil
+}
+
 func extractMetricAndStatistic(pattern string) (string, string) {
 	for _, statistic := range models.GetAllStatistics() {
 		suffix := "." + statistic.String()
@@ -238,6 +690,16 @@ func parsedMetricsConfig(config models.MetricsConfig) (models.ParsedMetricsConfi
 		return models.ParsedMetricsConfig{}, fmt.Errorf("invalid statistic %s provided in config.yml", config.Statistic)
 	}
 
+	statistics, err := parseStatistics(config.Statistics)
+	if err != nil {
+		return models.ParsedMetricsConfig{}, err
+	}
+
+	defaultStatisticByEngine, err := parseDefaultStatisticByEngine(config.DefaultStatisticByEngine)
+	if err != nil {
+		return models.ParsedMetricsConfig{}, err
+	}
+
 	metadataTTL, err := time.ParseDuration(config.MetadataTTL)
 	if err != nil {
 		return models.ParsedMetricsConfig{}, fmt.Errorf("invalid metrics.metadata-ttl format '%s' in config.yml: %v", config.MetadataTTL, err)
@@ -245,36 +707,394 @@ func parsedMetricsConfig(config models.MetricsConfig) (models.ParsedMetricsConfi
 
 	metadataTTL = GetOrDefault(metadataTTL, MinTTL, MaxTTL, DefaultMetadataTTL, "metrics.metadata-ttl")
 
-	includePatterns, err := compileFilterConfig(config.Include)
+	futureTimestamp, err := parseFutureTimestampBehavior(config.FutureTimestamp)
+	if err != nil {
+		return models.ParsedMetricsConfig{}, err
+	}
+
+	onMissing, err := parseMissingMetricBehavior(config.OnMissing)
+	if err != nil {
+		return models.ParsedMetricsConfig{}, err
+	}
+
+	onInvalid, err := parseInvalidMetricBehavior(config.OnInvalid)
+	if err != nil {
+		return models.ParsedMetricsConfig{}, err
+	}
+
+	postProcessors, err := parsePostProcessors(config.PostProcessors)
+	if err != nil {
+		return models.ParsedMetricsConfig{}, err
+	}
+
+	includePatterns, err := compileFilterConfig(config.Include, false)
 	if err != nil {
 		return models.ParsedMetricsConfig{}, fmt.Errorf("invalid metrics.include patterns in config.yml: %v", err)
 	}
 
-	excludePatterns, err := compileFilterConfig(config.Exclude)
+	excludePatterns, err := compileFilterConfig(config.Exclude, false)
 	if err != nil {
 		return models.ParsedMetricsConfig{}, fmt.Errorf("invalid metrics.exclude patterns in config.yml: %v", err)
 	}
 
+	filterMode, err := parseFilterMode(config.FilterMode, "metrics.filter-mode")
+	if err != nil {
+		return models.ParsedMetricsConfig{}, err
+	}
+
 	var metricFilter filter.Filter
 	if len(includePatterns) > 0 || len(excludePatterns) > 0 {
-		metricFilter = filter.NewPatternFilter(includePatterns, excludePatterns)
+		metricFilter = filter.NewPatternFilterWithOptions(includePatterns, excludePatterns, filter.Options{Mode: filterMode})
 	}
 
 	return models.ParsedMetricsConfig{
-		Statistic:   defaultStatistic,
-		MetadataTTL: metadataTTL,
-		Filter:      metricFilter,
-		Include:     config.Include,
-		Exclude:     config.Exclude,
+		Statistic:                defaultStatistic,
+		Statistics:               statistics,
+		DefaultStatisticByEngine: defaultStatisticByEngine,
+		MetadataTTL:              metadataTTL,
+		MetadataCacheFile:        config.MetadataCacheFile,
+		FutureTimestamp:          futureTimestamp,
+		OnMissing:                onMissing,
+		OnInvalid:                onInvalid,
+		PostProcessors:           postProcessors,
+		ShareCatalogPerEngine:    config.ShareCatalogPerEngine,
+		Filter:                   metricFilter,
+		Include:                  config.Include,
+		Exclude:                  config.Exclude,
+		FilterMode:               filterMode,
 	}, nil
 }
 
-func parseProcessingConfig(config models.ProcessingConfig) models.ParsedProcessingConfig {
+func parseFutureTimestampBehavior(behavior string) (models.FutureTimestampBehavior, error) {
+	if behavior == "" {
+		return models.FutureTimestampKeep, nil
+	}
+
+	futureTimestampBehavior := models.NewFutureTimestampBehavior(behavior)
+	if futureTimestampBehavior == "" {
+		return "", fmt.Errorf("invalid metrics.future-timestamp %s provided in config.yml", behavior)
+	}
+	return futureTimestampBehavior, nil
+}
+
+func parseMissingMetricBehavior(behavior string) (models.MissingMetricBehavior, error) {
+	if behavior == "" {
+		return models.MissingMetricAbsent, nil
+	}
+
+	missingMetricBehavior := models.NewMissingMetricBehavior(behavior)
+	if missingMetricBehavior == "" {
+		return "", fmt.Errorf("invalid metrics.on-missing %s provided in config.yml", behavior)
+	}
+	return missingMetricBehavior, nil
+}
+
+func parseInvalidMetricBehavior(behavior string) (models.InvalidMetricBehavior, error) {
+	if behavior == "" {
+		return models.InvalidMetricPrune, nil
+	}
+
+	invalidMetricBehavior := models.NewInvalidMetricBehavior(behavior)
+	if invalidMetricBehavior == "" {
+		return "", fmt.Errorf("invalid metrics.on-invalid %s provided in config.yml", behavior)
+	}
+	return invalidMetricBehavior, nil
+}
+
+// parsePostProcessors validates the configured metric post-processors, keeping the configured order.
+func parsePostProcessors(names []string) ([]models.PostProcessorName, error) {
+	var postProcessors []models.PostProcessorName
+	seen := make(map[models.PostProcessorName]bool, len(names))
+	for _, name := range names {
+		postProcessor := models.NewPostProcessorName(name)
+		if postProcessor == "" {
+			return nil, fmt.Errorf("invalid metrics.post-processors %s provided in config.yml", name)
+		}
+		if seen[postProcessor] {
+			return nil, fmt.Errorf("invalid metrics.post-processors in config.yml, post-processor %s is listed more than once", name)
+		}
+		seen[postProcessor] = true
+		postProcessors = append(postProcessors, postProcessor)
+	}
+	return postProcessors, nil
+}
+
+// parseStatistics validates metrics.statistics and orders the statistics as in GetAllStatistics.
+func parseStatistics(names []string) ([]models.Statistic, error) {
+	var statistics []models.Statistic
+	seen := make(map[models.Statistic]bool, len(names))
+	for _, name := range names {
+		statistic := models.NewStatistic(name)
+		if statistic == "" {
+			return nil, fmt.Errorf("invalid statistic %s in metrics.statistics in config.yml", name)
+		}
+		if seen[statistic] {
+			return nil, fmt.Errorf("invalid metrics.statistics in config.yml, statistic %s is listed more than once", name)
+		}
+		seen[statistic] = true
+		statistics = append(statistics, statistic)
+	}
+	return orderStatistics(statistics), nil
+}
+
+// parseDefaultStatisticByEngine validates the per-engine default statistics. Engines are matched the same way as
+// discovered instances, so e.g. sqlserver-ee resolves to sqlserver; other applies to unrecognized engines.
+func parseDefaultStatisticByEngine(config map[string]string) (map[models.Engine]models.Statistic, error) {
+	if len(config) == 0 {
+		return nil, nil
+	}
+
+	defaultStatisticByEngine := make(map[models.Engine]models.Statistic, len(config))
+	for engineString, statisticString := range config {
+		engine := models.NewEngine(engineString)
+		if engineString == string(models.Other) {
+			engine = models.Other
+		}
+		if engine == "" {
+			return nil, fmt.Errorf("invalid engine %s in metrics.default-statistic-by-engine in config.yml", engineString)
+		}
+
+		statistic := models.NewStatistic(statisticString)
+		if statistic == "" {
+			return nil, fmt.Errorf("invalid statistic %s for engine %s in metrics.default-statistic-by-engine in config.yml", statisticString, engineString)
+		}
+
+		defaultStatisticByEngine[engine] = statistic
+	}
+
+	return defaultStatisticByEngine, nil
+}
+
+func parseProcessingConfig(config models.ProcessingConfig) (models.ParsedProcessingConfig, error) {
 	concurrency := GetOrDefault(config.Concurrency, 1, DefaultConcurrency, DefaultConcurrency, "concurrency")
+	// Discovery and collection concurrency both default to processing.concurrency when unset
+	discoveryConcurrency := concurrency
+	if config.DiscoveryConcurrency != 0 {
+		discoveryConcurrency = GetOrDefault(config.DiscoveryConcurrency, 1, DefaultConcurrency, concurrency, "processing.discovery-concurrency")
+	}
+	collectionConcurrency := concurrency
+	if config.CollectionConcurrency != 0 {
+		collectionConcurrency = GetOrDefault(config.CollectionConcurrency, 1, DefaultConcurrency, concurrency, "processing.collection-concurrency")
+	}
+	producerConcurrency := GetOrDefault(config.ProducerConcurrency, 1, MaxProducerConcurrency, DefaultProducerConcurrency, "processing.producer-concurrency")
+	regionConcurrency := GetOrDefault(config.RegionConcurrency, 1, MaxRegionConcurrency, DefaultRegionConcurrency, "processing.region-concurrency")
+	// 0 means no limit on the number of metric batches collected per scrape
+	maxBatchesPerScrape := GetOrDefault(config.MaxBatchesPerScrape, 0, math.MaxInt, 0, "processing.max-batches-per-scrape")
+	discoveryMaxRetries := GetOrDefault(config.DiscoveryMaxRetries, 1, MaxDiscoveryMaxRetries, DefaultDiscoveryMaxRetries, "processing.discovery-max-retries")
+
+	batchSize, err := parseBatchSize(config.BatchSize)
+	if err != nil {
+		return models.ParsedProcessingConfig{}, err
+	}
+
+	retryJitter, err := parseRetryJitter(config.RetryJitter)
+	if err != nil {
+		return models.ParsedProcessingConfig{}, err
+	}
 
 	return models.ParsedProcessingConfig{
-		Concurrency: concurrency,
+		Concurrency:           concurrency,
+		DiscoveryConcurrency:  discoveryConcurrency,
+		CollectionConcurrency: collectionConcurrency,
+		ProducerConcurrency:   producerConcurrency,
+		RegionConcurrency:     regionConcurrency,
+		MaxBatchesPerScrape:   maxBatchesPerScrape,
+		BatchSize:             batchSize,
+		DiscoveryMaxRetries:   discoveryMaxRetries,
+		RetryJitter:           retryJitter,
+	}, nil
+}
+
+// parseBatchSize validates the number of metrics requested per GetResourceMetrics call. 0 uses DefaultBatchSize.
+func parseBatchSize(batchSize int) (int, error) {
+	if batchSize == 0 {
+		return DefaultBatchSize, nil
+	}
+	if batchSize < 1 || batchSize > MaxBatchSize {
+		return 0, fmt.Errorf("invalid processing.batch-size %d in config.yml, must be between 1 and %d, the most metrics Performance Insights accepts per call", batchSize, MaxBatchSize)
+	}
+	return batchSize, nil
+}
+
+func parseRetryJitter(jitter string) (models.RetryJitter, error) {
+	if jitter == "" {
+		return models.RetryJitterFull, nil
+	}
+
+	retryJitter := models.NewRetryJitter(jitter)
+	if retryJitter == "" {
+		return "", fmt.Errorf("invalid processing.retry-jitter %s provided in config.yml, must be one of: %s, %s, %s",
+			jitter, models.RetryJitterNone, models.RetryJitterFull, models.RetryJitterEqual)
+	}
+	return retryJitter, nil
+}
+
+func parseCollectionOrderConfig(config models.CollectionOrderConfig) (models.ParsedCollectionOrderConfig, error) {
+	if config.Tag == "" && len(config.TagValues) > 0 {
+		return models.ParsedCollectionOrderConfig{}, fmt.Errorf("invalid discovery.collection-order in config.yml, tag-values requires tag to be set")
+	}
+	if config.Tag != "" && len(config.TagValues) == 0 {
+		return models.ParsedCollectionOrderConfig{}, fmt.Errorf("invalid discovery.collection-order in config.yml, tag %s requires at least one entry in tag-values", config.Tag)
+	}
+
+	return models.ParsedCollectionOrderConfig{
+		Identifiers: config.Identifiers,
+		Tag:         config.Tag,
+		TagValues:   config.TagValues,
+	}, nil
+}
+
+func parseAWSConfig(config models.AWSConfig, regions []string) (models.ParsedAWSConfig, error) {
+	stsRegion := config.STSRegion
+	if stsRegion == "" && len(regions) > 0 {
+		stsRegion = regions[0]
+	}
+
+	partition, err := parsePartition(config.Partition, regions)
+	if err != nil {
+		return models.ParsedAWSConfig{}, err
+	}
+
+	// Credentials and clients cannot cross partitions, so every region must belong to the configured one
+	for _, region := range append(append([]string{}, regions...), stsRegion) {
+		if region != "" && models.PartitionForRegion(region) != partition {
+			return models.ParsedAWSConfig{}, fmt.Errorf("invalid aws.partition in config.yml, region %s does not belong to partition %s", region, partition)
+		}
+	}
+
+	apiCallTimeout, err := parseAPICallTimeout(config.APICallTimeout)
+	if err != nil {
+		return models.ParsedAWSConfig{}, err
+	}
+
+	regionRoles, err := parseRegionRoles(config.RegionRoles, regions, partition)
+	if err != nil {
+		return models.ParsedAWSConfig{}, err
+	}
+
+	return models.ParsedAWSConfig{
+		STSRegion:      stsRegion,
+		Partition:      partition,
+		APICallTimeout: apiCallTimeout,
+		RegionRoles:    regionRoles,
+	}, nil
+}
+
+// parseRegionRoles validates the IAM roles assumed per region. Every region must be monitored, and every role must be
+// an IAM role ARN in the configured partition.
+func parseRegionRoles(regionRoles map[string]models.AssumeRoleConfig, regions []string, partition models.Partition) (map[string]models.AssumeRoleConfig, error) {
+	if len(regionRoles) == 0 {
+		return nil, nil
+	}
+
+	for region, role := range regionRoles {
+		if !slices.Contains(regions, region) {
+			return nil, fmt.Errorf("invalid aws.region-roles in config.yml, region %s is not in discovery.regions", region)
+		}
+
+		roleARN, err := arn.Parse(role.RoleARN)
+		if err != nil || roleARN.Service != "iam" || !strings.HasPrefix(roleARN.Resource, "role/") {
+			return nil, fmt.Errorf("invalid aws.region-roles role-arn '%s' for region %s in config.yml, must be an IAM role ARN", role.RoleARN, region)
+		}
+		if models.Partition(roleARN.Partition) != partition {
+			return nil, fmt.Errorf("invalid aws.region-roles role-arn '%s' for region %s in config.yml, does not belong to partition %s", role.RoleARN, region, partition)
+		}
+
+		if role.ExternalID != "" && (len(role.ExternalID) < MinExternalIDLength || len(role.ExternalID) > MaxExternalIDLength) {
+			return nil, fmt.Errorf("invalid aws.region-roles external-id for region %s in config.yml, must be between %d and %d characters",
+				region, MinExternalIDLength, MaxExternalIDLength)
+		}
+	}
+
+	return maps.Clone(regionRoles), nil
+}
+
+// validateAllowedRegions rejects any configured discovery or STS region outside aws.allowed-regions.
+// An empty allow-list permits every region.
+func validateAllowedRegions(allowedRegions []string, regions []string, stsRegion string) error {
+	if len(allowedRegions) == 0 {
+		return nil
+	}
+
+	for _, region := range append(append([]string{}, regions...), stsRegion) {
+		if region != "" && !slices.Contains(allowedRegions, region) {
+			return fmt.Errorf("invalid region %s in config.yml, not in aws.allowed-regions %v", region, allowedRegions)
+		}
+	}
+	return nil
+}
+
+// parseAPICallTimeout parses the timeout of a single AWS API call. An empty value leaves calls unbounded.
+func parseAPICallTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid aws.api-call-timeout format '%s' in config.yml: %v", value, err)
+	}
+
+	return GetOrDefault(timeout, MinAPICallTimeout, MaxAPICallTimeout, DefaultAPICallTimeout, "aws.api-call-timeout"), nil
+}
+
+// parseMaxScrapeDuration parses the hard ceiling on the duration of a /metrics scrape. An empty value leaves scrapes unbounded.
+func parseMaxScrapeDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
 	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid export.max-scrape-duration format '%s' in config.yml: %v", value, err)
+	}
+
+	return GetOrDefault(duration, MinMaxScrapeDuration, MaxMaxScrapeDuration, DefaultMaxScrapeDuration, "export.max-scrape-duration"), nil
+}
+
+// parseScrapeTimeoutOffset parses the margin subtracted from the Prometheus scrape timeout of a /metrics scrape.
+func parseScrapeTimeoutOffset(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultScrapeTimeoutOffset, nil
+	}
+
+	offset, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid export.scrape-timeout-offset format '%s' in config.yml: %v", value, err)
+	}
+
+	return GetOrDefault(offset, MinScrapeTimeoutOffset, MaxScrapeTimeoutOffset, DefaultScrapeTimeoutOffset, "export.scrape-timeout-offset"), nil
+}
+
+// parseShutdownGracePeriod parses the time in-flight requests get to complete when the server shuts down.
+func parseShutdownGracePeriod(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultShutdownGracePeriod, nil
+	}
+
+	gracePeriod, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid export.shutdown-grace-period format '%s' in config.yml: %v", value, err)
+	}
+
+	return GetOrDefault(gracePeriod, MinShutdownGracePeriod, MaxShutdownGracePeriod, DefaultShutdownGracePeriod, "export.shutdown-grace-period"), nil
+}
+
+// parsePartition validates the configured partition. An empty value infers the partition from the first region.
+func parsePartition(partition string, regions []string) (models.Partition, error) {
+	if partition == "" {
+		if len(regions) == 0 {
+			return models.PartitionAWS, nil
+		}
+		return models.PartitionForRegion(regions[0]), nil
+	}
+
+	awsPartition := models.NewPartition(partition)
+	if awsPartition == "" {
+		return "", fmt.Errorf("invalid aws.partition %s provided in config.yml, must be one of: %s, %s, %s",
+			partition, models.PartitionAWS, models.PartitionAWSCN, models.PartitionAWSUSGov)
+	}
+	return awsPartition, nil
 }
 
 func parseExportConfig(config models.ExportConfig) (models.ParsedExportConfig, error) {
@@ -283,8 +1103,8 @@ func parseExportConfig(config models.ExportConfig) (models.ParsedExportConfig, e
 		port = 8081
 	}
 
-	if !isPortAvailable(port) {
-		return models.ParsedExportConfig{}, fmt.Errorf("invalid export.port in config.yml, port %d is not available", port)
+	if err := validateBindAddress(config.BindAddress); err != nil {
+		return models.ParsedExportConfig{}, err
 	}
 
 	metricPrefix := config.Prometheus.MetricPrefix
@@ -292,16 +1112,337 @@ func parseExportConfig(config models.ExportConfig) (models.ParsedExportConfig, e
 		return models.ParsedExportConfig{}, err
 	}
 
+	if err := validatePrometheusNameComponent("namespace", config.Prometheus.Namespace); err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
+	if err := validatePrometheusNameComponent("subsystem", config.Prometheus.Subsystem); err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
+	if err := validateRemoteWriteURL(config.RemoteWriteURL); err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
+	remoteWriteInterval := DefaultRemoteWriteInterval
+	if config.RemoteWriteInterval != "" {
+		interval, err := time.ParseDuration(config.RemoteWriteInterval)
+		if err != nil {
+			return models.ParsedExportConfig{}, fmt.Errorf("invalid export.remote-write-interval format '%s' in config.yml: %v", config.RemoteWriteInterval, err)
+		}
+		remoteWriteInterval = GetOrDefault(interval, MinRemoteWriteInterval, MaxRemoteWriteInterval, DefaultRemoteWriteInterval, "export.remote-write-interval")
+	}
+
+	targetedPriority, err := parseTargetedScrapePriority(config.TargetedPriority)
+	if err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
+	maxScrapeDuration, err := parseMaxScrapeDuration(config.MaxScrapeDuration)
+	if err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
+	scrapeTimeoutOffset, err := parseScrapeTimeoutOffset(config.ScrapeTimeoutOffset)
+	if err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
+	shutdownGracePeriod, err := parseShutdownGracePeriod(config.ShutdownGracePeriod)
+	if err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
+	if config.UnhealthyThreshold < 0 || config.UnhealthyThreshold > 1 {
+		return models.ParsedExportConfig{}, fmt.Errorf("invalid export.unhealthy-threshold %v in config.yml, must be between 0 and 1", config.UnhealthyThreshold)
+	}
+
+	tlsConfig, err := parseTLSConfig(config.TLS)
+	if err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
+	authConfig, err := parseAuthConfig(config.Auth)
+	if err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+	if config.AllowRefresh && !authConfig.Enabled() {
+		slog.Warn("export.allow-refresh is enabled without export.auth, any client reaching the metrics endpoint can force AWS API calls with ?refresh")
+	}
+
+	extraLabels, err := parseExtraLabels(config.Prometheus.ExtraLabels)
+	if err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
+	tagLabels, err := parseTagLabels(config.Prometheus.TagLabels)
+	if err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
+	noEnginePrefixMetrics, err := compileRegexPatterns(config.Prometheus.NoEnginePrefixMetrics, false)
+	if err != nil {
+		return models.ParsedExportConfig{}, fmt.Errorf("invalid export.prometheus.no-engine-prefix-metrics patterns in config.yml: %v", err)
+	}
+
+	nameStyle, err := parseMetricNameStyle(config.Prometheus.NameStyle)
+	if err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
+	staticLabels, err := parseStaticLabels(config.Prometheus.StaticLabels, extraLabels)
+	if err != nil {
+		return models.ParsedExportConfig{}, err
+	}
+
 	return models.ParsedExportConfig{
-		Port: port,
+		Port:        port,
+		BindAddress: config.BindAddress,
+		Debug:       config.Debug,
 		Prometheus: models.ParsedPrometheusConfig{
-			MetricPrefix: metricPrefix,
+			MetricPrefix:          metricPrefix,
+			Namespace:             config.Prometheus.Namespace,
+			Subsystem:             config.Prometheus.Subsystem,
+			DescriptionInfoMetric: config.Prometheus.DescriptionInfoMetric,
+			DiscoveredMetricNames: config.Prometheus.DiscoveredMetricNames,
+			ExtraLabels:           extraLabels,
+			TagLabels:             tagLabels,
+			HeartbeatMetric:       config.Prometheus.HeartbeatMetric,
+			DataPointsReturned:    config.Prometheus.DataPointsReturned,
+			NoEnginePrefixMetrics: noEnginePrefixMetrics,
+			OmitEnginePrefix:      config.Prometheus.EngineInName != nil && !*config.Prometheus.EngineInName,
+			EffectiveSettings:     config.Prometheus.EffectiveSettings,
+			ConversionErrors:      config.Prometheus.ConversionErrors,
+			InstanceInfo:          config.Prometheus.InstanceInfo,
+			AWSAPIMetrics:         config.Prometheus.AWSAPIMetrics,
+			NameStyle:             nameStyle,
+			StaticLabels:          staticLabels,
 		},
+		RemoteWriteURL:      config.RemoteWriteURL,
+		RemoteWriteInterval: remoteWriteInterval,
+		TargetedPriority:    targetedPriority,
+		MaxScrapeDuration:   maxScrapeDuration,
+		ScrapeTimeoutOffset: scrapeTimeoutOffset,
+		ShutdownGracePeriod: shutdownGracePeriod,
+		UnhealthyThreshold:  config.UnhealthyThreshold,
+		AllowRefresh:        config.AllowRefresh,
+		TLS:                 tlsConfig,
+		Auth:                authConfig,
+	}, nil
+}
+
+// parseRegionLabel decides whether metrics are labeled with the region they were collected in. Unset, the label is
+// added when more than one region is configured, so instances with the same identifier in different regions don't
+// export the same series, unless the region extra label already tells them apart.
+func parseRegionLabel(regionLabel *bool, regions []string, extraLabels []string) (bool, error) {
+	if regionLabel == nil {
+		return len(regions) > 1 && !slices.Contains(extraLabels, "region"), nil
+	}
+	if *regionLabel && slices.Contains(extraLabels, "region") {
+		return false, fmt.Errorf("invalid export.prometheus.region-label in config.yml, the region label is already added by export.prometheus.extra-labels")
+	}
+	return *regionLabel, nil
+}
+
+// parseMinRefreshInterval parses the minimum time between two instance discovery calls. An empty value disables the floor.
+func parseMinRefreshInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid discovery.min-refresh-interval format '%s' in config.yml: %v", value, err)
+	}
+
+	return GetOrDefault(interval, 0, MaxTTL, 0, "discovery.min-refresh-interval"), nil
+}
+
+func parseTargetedScrapePriority(priority string) (models.ScrapePriority, error) {
+	if priority == "" {
+		return models.ScrapePriorityNormal, nil
+	}
+
+	scrapePriority := models.NewScrapePriority(priority)
+	if scrapePriority == "" {
+		return "", fmt.Errorf("invalid export.targeted-scrape-priority %s provided in config.yml", priority)
+	}
+	return scrapePriority, nil
+}
+
+func parseMetricNameStyle(style string) (models.MetricNameStyle, error) {
+	if style == "" {
+		return models.MetricNameStyleLowercase, nil
+	}
+
+	nameStyle := models.NewMetricNameStyle(style)
+	if nameStyle == "" {
+		return "", fmt.Errorf("invalid export.prometheus.name-style %s provided in config.yml, must be one of: %s, %s, %s",
+			style, models.MetricNameStyleLowercase, models.MetricNameStylePreserveCase, models.MetricNameStyleSnakeCase)
+	}
+	return nameStyle, nil
+}
+
+// parseExtraLabels validates the opt-in instance labels against models.ExtraLabels, keeping the configured order.
+func parseExtraLabels(labels []string) ([]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		if !slices.Contains(models.ExtraLabels, label) {
+			return nil, fmt.Errorf("invalid export.prometheus.extra-labels %s provided in config.yml, must be one of %v", label, models.ExtraLabels)
+		}
+		if seen[label] {
+			return nil, fmt.Errorf("invalid export.prometheus.extra-labels in config.yml, label %s is listed more than once", label)
+		}
+		seen[label] = true
+	}
+
+	return append([]string(nil), labels...), nil
+}
+
+// parseTagLabels validates the instance tags exported as labels and derives their label names, e.g. tag_Environment
+// for the Environment tag. Tags whose label names collide once invalid characters are replaced are rejected.
+func parseTagLabels(tags []string) ([]models.TagLabel, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	invalidLabelChars := regexp.MustCompile(InvalidLabelChars)
+	tagsByLabel := make(map[string]string, len(tags))
+	tagLabels := make([]models.TagLabel, 0, len(tags))
+	for _, tag := range tags {
+		if tag == "" {
+			return nil, fmt.Errorf("invalid export.prometheus.tag-labels in config.yml, tag keys must not be empty")
+		}
+
+		label := TagLabelPrefix + invalidLabelChars.ReplaceAllString(tag, "_")
+		if previous, exists := tagsByLabel[label]; exists {
+			return nil, fmt.Errorf("invalid export.prometheus.tag-labels in config.yml, tags %s and %s are both exported as label %s", previous, tag, label)
+		}
+		tagsByLabel[label] = tag
+		tagLabels = append(tagLabels, models.TagLabel{Tag: tag, Label: label})
+	}
+
+	return tagLabels, nil
+}
+
+// parseStaticLabels validates the names of the constant labels added to every metric. Names the exporter sets itself,
+// including the enabled extra labels, are rejected, as a metric cannot have a label twice.
+func parseStaticLabels(labels map[string]string, extraLabels []string) (map[string]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	validName := regexp.MustCompile(ValidPrometheusName)
+	for name := range labels {
+		if !validName.MatchString(name) || strings.Contains(name, ":") || strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("invalid export.prometheus.static-labels in config.yml, label name '%s' is not valid", name)
+		}
+		if slices.Contains(reservedLabels, name) || slices.Contains(extraLabels, name) || strings.HasPrefix(name, TagLabelPrefix) {
+			return nil, fmt.Errorf("invalid export.prometheus.static-labels in config.yml, label name '%s' is reserved by the exporter", name)
+		}
+	}
+
+	return maps.Clone(labels), nil
+}
+
+// validateRemoteWriteURL validates the optional remote-write endpoint. An empty value disables remote-write.
+func validateRemoteWriteURL(remoteWriteURL string) error {
+	if remoteWriteURL == "" {
+		return nil
+	}
+
+	parsedURL, err := url.Parse(remoteWriteURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return fmt.Errorf("invalid export.remote-write-url in config.yml, url '%s' must be an absolute http or https URL", remoteWriteURL)
+	}
+
+	return nil
+}
+
+// validateBindAddress validates the optional host the HTTP server listens on, an IP address or a host name.
+// An empty value is allowed and means all interfaces.
+func validateBindAddress(address string) error {
+	if address == "" || net.ParseIP(address) != nil {
+		return nil
+	}
+
+	validHostName := regexp.MustCompile(ValidHostName)
+	if !validHostName.MatchString(address) {
+		return fmt.Errorf("invalid export.bind-address in config.yml, address '%s' is not an IP address or host name", address)
+	}
+
+	return nil
+}
+
+// CheckPortAvailable returns an error if something already listens on the export address. It is run once at startup
+// rather than while parsing the config, as a reloaded config is parsed while the exporter itself holds the port.
+func CheckPortAvailable(exportConfig models.ParsedExportConfig) error {
+	if !isPortAvailable(exportConfig.BindAddress, exportConfig.Port) {
+		return fmt.Errorf("invalid export.port in config.yml, port %d is not available", exportConfig.Port)
+	}
+	return nil
+}
+
+// parseTLSConfig validates the optional certificate and private key for serving HTTPS. Both files must be set
+// together and exist; when neither is set the HTTP server serves plaintext HTTP.
+func parseTLSConfig(config models.TLSConfig) (models.ParsedTLSConfig, error) {
+	if config.CertFile == "" && config.KeyFile == "" {
+		return models.ParsedTLSConfig{}, nil
+	}
+
+	if config.CertFile == "" || config.KeyFile == "" {
+		return models.ParsedTLSConfig{}, fmt.Errorf("invalid export.tls in config.yml, cert-file and key-file must be set together")
+	}
+
+	for _, file := range []struct{ field, path string }{{"cert-file", config.CertFile}, {"key-file", config.KeyFile}} {
+		if _, err := os.Stat(file.path); err != nil {
+			return models.ParsedTLSConfig{}, fmt.Errorf("invalid export.tls.%s '%s' in config.yml: %v", file.field, file.path, err)
+		}
+	}
+
+	return models.ParsedTLSConfig{
+		CertFile: config.CertFile,
+		KeyFile:  config.KeyFile,
+	}, nil
+}
+
+// parseAuthConfig validates the optional credentials of the metrics endpoints, either a bearer token or a basic-auth
+// username with the hex-encoded SHA-256 hash of its password. Without either the endpoints are open.
+func parseAuthConfig(config models.AuthConfig) (models.ParsedAuthConfig, error) {
+	basicAuth := config.BasicAuth
+	if config.BearerToken != "" && (basicAuth.Username != "" || basicAuth.PasswordSHA256 != "") {
+		return models.ParsedAuthConfig{}, fmt.Errorf("invalid export.auth in config.yml, bearer-token and basic-auth cannot be set together")
+	}
+
+	if config.BearerToken != "" {
+		return models.ParsedAuthConfig{BearerToken: config.BearerToken}, nil
+	}
+
+	if basicAuth.Username == "" && basicAuth.PasswordSHA256 == "" {
+		return models.ParsedAuthConfig{}, nil
+	}
+
+	if basicAuth.Username == "" || basicAuth.PasswordSHA256 == "" {
+		return models.ParsedAuthConfig{}, fmt.Errorf("invalid export.auth.basic-auth in config.yml, username and password-sha256 must be set together")
+	}
+
+	passwordSHA256, err := hex.DecodeString(basicAuth.PasswordSHA256)
+	if err != nil || len(passwordSHA256) != sha256.Size {
+		return models.ParsedAuthConfig{}, fmt.Errorf("invalid export.auth.basic-auth.password-sha256 in config.yml, must be the hex-encoded SHA-256 hash of the password")
+	}
+
+	return models.ParsedAuthConfig{
+		Username:       basicAuth.Username,
+		PasswordSHA256: passwordSHA256,
 	}, nil
 }
 
-func isPortAvailable(port int) bool {
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf(":%d", port), time.Second)
+func isPortAvailable(bindAddress string, port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(bindAddress, strconv.Itoa(port)), time.Second)
 	if err != nil {
 		return true
 	}
@@ -326,9 +1467,24 @@ func validatePrometheusMetricPrefix(prefix string) error {
 	return nil
 }
 
+// validatePrometheusNameComponent validates an optional namespace or subsystem used to build metric names.
+// An empty value is allowed and means the component is not used.
+func validatePrometheusNameComponent(fieldName string, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	validName := regexp.MustCompile(ValidPrometheusName)
+	if !validName.MatchString(value) || strings.HasPrefix(value, "_") {
+		return fmt.Errorf("invalid prometheus.%s in config.yml, %s '%s' is not valid", fieldName, fieldName, value)
+	}
+
+	return nil
+}
+
 func GetOrDefault[T cmp.Ordered](value, min, max, defaultValue T, fieldName string) T {
 	if value < min || value > max {
-		log.Printf("[CONFIG] %s %v is outside the allowed range [%v, %v], setting to %v", fieldName, value, min, max, defaultValue)
+		slog.Warn("Config value is outside the allowed range, using the default", "field", fieldName, "value", value, "min", min, "max", max, "default", defaultValue)
 		return defaultValue
 	}
 	return value
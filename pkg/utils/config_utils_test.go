@@ -1,7 +1,13 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -9,6 +15,7 @@ import (
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -35,6 +42,7 @@ export:
 				assert.Equal(t, 10, cfg.Discovery.Instances.MaxInstances)
 				assert.Equal(t, models.StatisticAvg, cfg.Discovery.Metrics.Statistic)
 				assert.Equal(t, 8081, cfg.Export.Port)
+				assert.Equal(t, models.ScrapePriorityNormal, cfg.Export.TargetedPriority)
 			},
 		},
 		{
@@ -54,7 +62,80 @@ export:
 			},
 		},
 		{
-			name: "load config with multiple regions (only first is used)",
+			name: "load config with IPv4 bind-address",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  bind-address: 127.0.0.1`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, "127.0.0.1", cfg.Export.BindAddress)
+				assert.Equal(t, "127.0.0.1:8081", cfg.Export.ListenAddress())
+			},
+		},
+		{
+			name: "load config with IPv6 bind-address",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  bind-address: "::1"`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, "[::1]:8081", cfg.Export.ListenAddress())
+			},
+		},
+		{
+			name: "load config with host name bind-address",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  bind-address: localhost`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, "localhost:8081", cfg.Export.ListenAddress())
+			},
+		},
+		{
+			name: "load config without bind-address listens on all interfaces",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Empty(t, cfg.Export.BindAddress)
+				assert.Equal(t, ":8081", cfg.Export.ListenAddress())
+			},
+		},
+		{
+			name: "load config with bind-address including a port",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  bind-address: 127.0.0.1:9090`,
+			expectedError: true,
+		},
+		{
+			name: "load config with invalid bind-address",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  bind-address: not a host`,
+			expectedError: true,
+		},
+		{
+			name: "load config with multiple regions",
 			configContent: `discovery:
   regions:
   - us-west-2
@@ -62,6 +143,31 @@ export:
   - eu-west-1
   metrics:
     statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []string{"us-west-2", "us-east-1", "eu-west-1"}, cfg.Discovery.Regions)
+				assert.Equal(t, DefaultRegionConcurrency, cfg.Discovery.Processing.RegionConcurrency)
+			},
+		},
+		{
+			name: "load config with multiple regions and strict-single-region",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  - us-east-1
+  strict-single-region: true
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with single region and strict-single-region",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  strict-single-region: true
 export:
   port: 8081`,
 			expectedError: false,
@@ -120,84 +226,2250 @@ export:
 			validate:      nil,
 		},
 		{
-			name: "load config with custom max instances",
+			name: "load config with custom max instances",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    max-instances: 5
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 5, cfg.Discovery.Instances.MaxInstances)
+			},
+		},
+		{
+			name: "load config with max instances exceeding limit gets capped",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    max-instances: 100
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, testutils.TestMaxInstances, cfg.Discovery.Instances.MaxInstances)
+			},
+		},
+		{
+			name: "load config with custom max pages",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    max-pages: 5
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 5, cfg.Discovery.Instances.MaxPages)
+			},
+		},
+		{
+			name: "load config without max pages applies default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, DefaultMaxPages, cfg.Discovery.Instances.MaxPages)
+			},
+		},
+		{
+			name: "load config with max pages exceeding limit applies default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    max-pages: 5000
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, DefaultMaxPages, cfg.Discovery.Instances.MaxPages)
+			},
+		},
+		{
+			name: "load config with newest instance selection",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    selection: newest
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.InstanceSelectionNewest, cfg.Discovery.Instances.Selection)
+			},
+		},
+		{
+			name: "load config without instance selection keeps oldest",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.InstanceSelectionOldest, cfg.Discovery.Instances.Selection)
+			},
+		},
+		{
+			name: "load config with invalid instance selection",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    selection: random
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: true,
+			validate:      nil,
+		},
+		{
+			name: "load config with instance identifiers",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    identifiers:
+    - orders-db
+    - billing-db
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []string{"orders-db", "billing-db"}, cfg.Discovery.Instances.Identifiers)
+			},
+		},
+		{
+			name: "load config with duplicate instance identifiers",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    identifiers:
+    - orders-db
+    - orders-db
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: true,
+			validate:      nil,
+		},
+		{
+			name: "load config with empty instance identifier",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    identifiers:
+    - ""
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: true,
+			validate:      nil,
+		},
+		{
+			name: "load config with exclude-within-include filter modes",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    filter-mode: exclude-within-include
+    exclude:
+      identifier:
+      - "-temp-"
+  metrics:
+    statistic: "avg"
+    filter-mode: exclude-within-include
+    exclude:
+      name:
+      - "idle"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Discovery.Instances.ShouldIncludeInstance(models.Instance{Identifier: "dev-temp-db"}))
+				assert.True(t, cfg.Discovery.Metrics.ShouldIncludeMetric(models.MetricDetails{Name: "os.cpuUtilization.idle"}))
+			},
+		},
+		{
+			name: "load config without filter mode applies exclude always",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    exclude:
+      identifier:
+      - "-temp-"
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.False(t, cfg.Discovery.Instances.ShouldIncludeInstance(models.Instance{Identifier: "dev-temp-db"}))
+			},
+		},
+		{
+			name: "load config with invalid instances filter mode",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    filter-mode: include-wins
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: true,
+			validate:      nil,
+		},
+		{
+			name: "load config with invalid metrics filter mode",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    statistic: "avg"
+    filter-mode: include-wins
+export:
+  port: 8081`,
+			expectedError: true,
+			validate:      nil,
+		},
+		{
+			name: "load config with case-insensitive instance filter",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    case-insensitive: true
+    include:
+      tag.Environment:
+      - "^prod"
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				instance := models.Instance{Identifier: "orders-db", Tags: map[string]string{"Environment": "PROD"}}
+				assert.True(t, cfg.Discovery.Instances.ShouldIncludeInstance(instance))
+			},
+		},
+		{
+			name: "load config with case-sensitive instance filter by default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    include:
+      tag.Environment:
+      - "^prod"
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				instance := models.Instance{Identifier: "orders-db", Tags: map[string]string{"Environment": "PROD"}}
+				assert.False(t, cfg.Discovery.Instances.ShouldIncludeInstance(instance))
+			},
+		},
+		{
+			name: "load config with all field combinator",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    field-combinators:
+      identifier: all
+    include:
+      identifier:
+      - "-prod-"
+      - "-primary$"
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Discovery.Instances.ShouldIncludeInstance(models.Instance{Identifier: "orders-prod-primary"}))
+				assert.False(t, cfg.Discovery.Instances.ShouldIncludeInstance(models.Instance{Identifier: "orders-prod-replica"}))
+			},
+		},
+		{
+			name: "load config with invalid field combinator",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    field-combinators:
+      identifier: both
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: true,
+			validate:      nil,
+		},
+		{
+			name: "load config with field combinator on unknown field",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    field-combinators:
+      owner: all
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: true,
+			validate:      nil,
+		},
+		{
+			name: "load config with zero max instances applies default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    max-instances: 0
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, testutils.TestMaxInstances, cfg.Discovery.Instances.MaxInstances)
+			},
+		},
+		{
+			name: "load config with negative max instances applies default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    max-instances: -5
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, testutils.TestMaxInstances, cfg.Discovery.Instances.MaxInstances)
+			},
+		},
+		{
+			name: "load config with max instances = 1",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    max-instances: 1
+  metrics:
+    statistic: "avg"
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 1, cfg.Discovery.Instances.MaxInstances)
+			},
+		},
+		{
+			name: "load config with max instances above the raised limit is clamped",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    max-instances: 500
+    max-instances-limit: 200
+export:
+  port: 8081
+  max-instance-identifiers: 20`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 200, cfg.Discovery.Instances.MaxInstances)
+				assert.Equal(t, 20, cfg.Export.MaxIdentifiers)
+			},
+		},
+		{
+			name: "load config with max instance identifiers defaults to 5",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, DefaultMaxInstanceIdentifiers, cfg.Export.MaxIdentifiers)
+			},
+		},
+		{
+			name: "load config with max instances limit above the ceiling",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    max-instances-limit: 5000
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with prometheus namespace and subsystem",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    namespace: "aws"
+    subsystem: "rds"`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, "dbi", cfg.Export.Prometheus.MetricPrefix)
+				assert.Equal(t, "aws", cfg.Export.Prometheus.Namespace)
+				assert.Equal(t, "rds", cfg.Export.Prometheus.Subsystem)
+			},
+		},
+		{
+			name: "load config with prometheus namespace, subsystem and metric prefix",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    metric-prefix: "pi"
+    namespace: "cloud"
+    subsystem: "database"`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, "pi", cfg.Export.Prometheus.MetricPrefix)
+				assert.Equal(t, "cloud", cfg.Export.Prometheus.Namespace)
+				assert.Equal(t, "database", cfg.Export.Prometheus.Subsystem)
+			},
+		},
+		{
+			name: "load config with sts-region defaults to first region",
+			configContent: `discovery:
+  regions:
+  - eu-west-1
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, "eu-west-1", cfg.AWS.STSRegion)
+			},
+		},
+		{
+			name: "load config with explicit sts-region",
+			configContent: `discovery:
+  regions:
+  - eu-west-1
+export:
+  port: 8081
+aws:
+  sts-region: us-east-1`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []string{"eu-west-1"}, cfg.Discovery.Regions)
+				assert.Equal(t, "us-east-1", cfg.AWS.STSRegion)
+			},
+		},
+		{
+			name: "load config with typed filter entries",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    include:
+      identifier:
+      - "^prod-"
+      - match-type: suffix
+        pattern: "-primary"
+  metrics:
+    include:
+      name:
+      - match-type: prefix
+        pattern: "os.cpuUtilization"
+      - {match-type: exact, pattern: "db.Transactions.xact_commit"}
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.FilterConfig{"name": {`^os\.cpuUtilization`, `^db\.Transactions\.xact_commit$`}}, cfg.Discovery.Metrics.Include)
+				assert.True(t, cfg.Discovery.Instances.ShouldIncludeInstance(models.Instance{Identifier: "orders-primary", Engine: models.PostgreSQL}))
+				assert.True(t, cfg.Discovery.Instances.ShouldIncludeInstance(models.Instance{Identifier: "prod-orders", Engine: models.PostgreSQL}))
+				assert.False(t, cfg.Discovery.Instances.ShouldIncludeInstance(models.Instance{Identifier: "orders-primary-replica", Engine: models.PostgreSQL}))
+			},
+		},
+		{
+			name: "load config with invalid filter match-type",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    include:
+      name:
+      - match-type: glob
+        pattern: "os.*"
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config defaults future-timestamp to keep",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.FutureTimestampKeep, cfg.Discovery.Metrics.FutureTimestamp)
+			},
+		},
+		{
+			name: "load config with future-timestamp clamp",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    future-timestamp: clamp
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.FutureTimestampClamp, cfg.Discovery.Metrics.FutureTimestamp)
+			},
+		},
+		{
+			name: "load config with invalid future-timestamp",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    future-timestamp: shift
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config defaults on-missing to absent",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.MissingMetricAbsent, cfg.Discovery.Metrics.OnMissing)
+			},
+		},
+		{
+			name: "load config with on-missing zero",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    on-missing: zero
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.MissingMetricZero, cfg.Discovery.Metrics.OnMissing)
+			},
+		},
+		{
+			name: "load config with on-missing stale and remote-write",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    on-missing: stale
+export:
+  port: 8081
+  remote-write-url: https://prometheus.example.com/api/v1/write`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.MissingMetricStale, cfg.Discovery.Metrics.OnMissing)
+			},
+		},
+		{
+			name: "load config with on-missing stale without remote-write",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    on-missing: stale
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with invalid on-missing",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    on-missing: nan
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config defaults on-invalid to prune",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.InvalidMetricPrune, cfg.Discovery.Metrics.OnInvalid)
+			},
+		},
+		{
+			name: "load config with on-invalid fail",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    on-invalid: fail
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.InvalidMetricFail, cfg.Discovery.Metrics.OnInvalid)
+			},
+		},
+		{
+			name: "load config with share-catalog-per-engine",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    share-catalog-per-engine: true
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Discovery.Metrics.ShareCatalogPerEngine)
+			},
+		},
+		{
+			name: "load config with invalid on-invalid",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    on-invalid: ignore
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with post-processors",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    post-processors:
+    - memory-free-percent
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []models.PostProcessorName{models.PostProcessorMemoryFreePercent}, cfg.Discovery.Metrics.PostProcessors)
+			},
+		},
+		{
+			name: "load config with unknown post-processor",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    post-processors:
+    - cpu-busy-percent
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with duplicate post-processors",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    post-processors:
+    - memory-free-percent
+    - memory-free-percent
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with min-pi-retention",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  min-pi-retention: 93
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, int32(93), cfg.Discovery.MinPIRetention)
+			},
+		},
+		{
+			name: "load config with priority-tag",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  priority-tag: CollectionPriority
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, "CollectionPriority", cfg.Discovery.PriorityTag)
+			},
+		},
+		{
+			name: "load config with negative min-pi-retention",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  min-pi-retention: -1
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with sample-rate",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  sample-rate: 0.25
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 0.25, cfg.Discovery.SampleRate)
+			},
+		},
+		{
+			name: "load config defaults sample-rate to 1",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 1.0, cfg.Discovery.SampleRate)
+			},
+		},
+		{
+			name: "load config with sample-rate above 1",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  sample-rate: 1.5
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with negative sample-rate",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  sample-rate: -0.5
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with min-refresh-interval",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  min-refresh-interval: 30s
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 30*time.Second, cfg.Discovery.MinRefreshInterval)
+			},
+		},
+		{
+			name: "load config without min-refresh-interval disables the floor",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Zero(t, cfg.Discovery.MinRefreshInterval)
+			},
+		},
+		{
+			name: "load config with invalid min-refresh-interval",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  min-refresh-interval: soon
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with api-call-timeout",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+aws:
+  api-call-timeout: 15s`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 15*time.Second, cfg.AWS.APICallTimeout)
+			},
+		},
+		{
+			name: "load config with out of range api-call-timeout uses default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+aws:
+  api-call-timeout: 1h`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, DefaultAPICallTimeout, cfg.AWS.APICallTimeout)
+			},
+		},
+		{
+			name: "load config with invalid api-call-timeout",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+aws:
+  api-call-timeout: forever`,
+			expectedError: true,
+		},
+		{
+			name: "load config with max-scrape-duration",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  max-scrape-duration: 45s`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 45*time.Second, cfg.Export.MaxScrapeDuration)
+			},
+		},
+		{
+			name: "load config without max-scrape-duration leaves scrapes unbounded",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Zero(t, cfg.Export.MaxScrapeDuration)
+			},
+		},
+		{
+			name: "load config with invalid max-scrape-duration",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  max-scrape-duration: forever`,
+			expectedError: true,
+		},
+		{
+			name: "load config with scrape-timeout-offset",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  scrape-timeout-offset: 1s`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, time.Second, cfg.Export.ScrapeTimeoutOffset)
+			},
+		},
+		{
+			name: "load config without scrape-timeout-offset uses the default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, DefaultScrapeTimeoutOffset, cfg.Export.ScrapeTimeoutOffset)
+			},
+		},
+		{
+			name: "load config with invalid scrape-timeout-offset",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  scrape-timeout-offset: soon`,
+			expectedError: true,
+		},
+		{
+			name: "load config with shutdown-grace-period",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  shutdown-grace-period: 10s`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 10*time.Second, cfg.Export.ShutdownGracePeriod)
+			},
+		},
+		{
+			name: "load config without shutdown-grace-period uses the default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, DefaultShutdownGracePeriod, cfg.Export.ShutdownGracePeriod)
+			},
+		},
+		{
+			name: "load config with out of range shutdown-grace-period uses the default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  shutdown-grace-period: 1h`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, DefaultShutdownGracePeriod, cfg.Export.ShutdownGracePeriod)
+			},
+		},
+		{
+			name: "load config with invalid shutdown-grace-period",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  shutdown-grace-period: forever`,
+			expectedError: true,
+		},
+		{
+			name: "load config with regions in allowed-regions",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+aws:
+  allowed-regions:
+  - us-west-2
+  - us-east-1`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []string{"us-west-2"}, cfg.Discovery.Regions)
+			},
+		},
+		{
+			name: "load config with region-roles",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  - eu-west-1
+export:
+  port: 8081
+aws:
+  region-roles:
+    eu-west-1:
+      role-arn: arn:aws:iam::210987654321:role/database-insights
+      external-id: exporter`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, map[string]models.AssumeRoleConfig{
+					"eu-west-1": {RoleARN: "arn:aws:iam::210987654321:role/database-insights", ExternalID: "exporter"},
+				}, cfg.AWS.RegionRoles)
+			},
+		},
+		{
+			name: "load config with region-roles for a region that is not monitored",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+aws:
+  region-roles:
+    eu-west-1:
+      role-arn: arn:aws:iam::210987654321:role/database-insights`,
+			expectedError: true,
+		},
+		{
+			name: "load config with region-roles without an IAM role ARN",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+aws:
+  region-roles:
+    us-west-2:
+      role-arn: arn:aws:iam::210987654321:user/exporter`,
+			expectedError: true,
+		},
+		{
+			name: "load config with region-roles role in another partition",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+aws:
+  region-roles:
+    us-west-2:
+      role-arn: arn:aws-cn:iam::210987654321:role/database-insights`,
+			expectedError: true,
+		},
+		{
+			name: "load config with region-roles external-id that is too short",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+aws:
+  region-roles:
+    us-west-2:
+      role-arn: arn:aws:iam::210987654321:role/database-insights
+      external-id: x`,
+			expectedError: true,
+		},
+		{
+			name: "load config with region outside allowed-regions",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  - eu-west-1
+export:
+  port: 8081
+aws:
+  allowed-regions:
+  - us-west-2`,
+			expectedError: true,
+		},
+		{
+			name: "load config with sts-region outside allowed-regions",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+aws:
+  sts-region: us-east-1
+  allowed-regions:
+  - us-west-2`,
+			expectedError: true,
+		},
+		{
+			name: "load config with global-filter split across instances and metrics",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  global-filter:
+    exclude:
+      tag.monitoring: ["^off$"]
+      name: ["\\.idle$"]
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.NotNil(t, cfg.Discovery.Instances.GlobalFilter)
+				assert.NotNil(t, cfg.Discovery.Metrics.GlobalFilter)
+				assert.Nil(t, cfg.Discovery.Instances.Filter)
+				assert.Nil(t, cfg.Discovery.Metrics.Filter)
+
+				assert.False(t, cfg.Discovery.Instances.ShouldIncludeInstance(models.Instance{Identifier: "prod-db", Tags: map[string]string{"monitoring": "off"}}))
+				assert.True(t, cfg.Discovery.Instances.ShouldIncludeInstance(models.Instance{Identifier: "prod-db"}))
+				assert.False(t, cfg.Discovery.Metrics.ShouldIncludeMetric(models.MetricDetails{Name: "os.cpuUtilization.idle"}))
+				assert.True(t, cfg.Discovery.Metrics.ShouldIncludeMetric(models.MetricDetails{Name: "os.cpuUtilization.guest"}))
+			},
+		},
+		{
+			name: "load config with global-filter include on metric fields only",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  global-filter:
+    include:
+      category: ["^os$"]
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Nil(t, cfg.Discovery.Instances.GlobalFilter, "metric fields must not restrict instances")
+				assert.True(t, cfg.Discovery.Instances.ShouldIncludeInstance(models.Instance{Identifier: "prod-db"}))
+				assert.False(t, cfg.Discovery.Metrics.ShouldIncludeMetric(models.MetricDetails{Name: "db.User.max_connections"}))
+			},
+		},
+		{
+			name: "load config with invalid global-filter field",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  global-filter:
+    exclude:
+      bogus: ["x"]
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with heartbeat-metric",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    heartbeat-metric: true`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Export.Prometheus.HeartbeatMetric)
+			},
+		},
+		{
+			name: "load config with log level and format",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+log:
+  level: debug
+  format: json`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, slog.LevelDebug, cfg.Log.Level)
+				assert.Equal(t, models.LogFormatJSON, cfg.Log.Format)
+			},
+		},
+		{
+			name: "load config without log section defaults to info text logs",
+			configContent: `discovery:
+  regions:
+  - us-west-2`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, slog.LevelInfo, cfg.Log.Level)
+				assert.Equal(t, models.LogFormatText, cfg.Log.Format)
+			},
+		},
+		{
+			name: "load config with invalid log level",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+log:
+  level: verbose`,
+			expectedError: true,
+		},
+		{
+			name: "load config with invalid log format",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+log:
+  format: logfmt`,
+			expectedError: true,
+		},
+		{
+			name: "load config with aws-api-metrics",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    aws-api-metrics: true`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Export.Prometheus.AWSAPIMetrics)
+			},
+		},
+		{
+			name: "load config with datapoints-returned-metric",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    datapoints-returned-metric: true`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Export.Prometheus.DataPointsReturned)
+			},
+		},
+		{
+			name: "load config with unhealthy-threshold",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  unhealthy-threshold: 0.25`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 0.25, cfg.Export.UnhealthyThreshold)
+			},
+		},
+		{
+			name: "load config with allow-refresh",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  allow-refresh: true
+  auth:
+    bearer-token: secret`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Export.AllowRefresh)
+			},
+		},
+		{
+			name: "load config with out of range unhealthy-threshold",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  unhealthy-threshold: 1.5`,
+			expectedError: true,
+		},
+		{
+			name: "load config with conversion-errors-metric",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    conversion-errors-metric: true`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Export.Prometheus.ConversionErrors)
+			},
+		},
+		{
+			name: "load config with no-engine-prefix-metrics",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    no-engine-prefix-metrics:
+    - ^db\.SQL\.`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				if assert.Len(t, cfg.Export.Prometheus.NoEnginePrefixMetrics, 1) {
+					assert.True(t, cfg.Export.Prometheus.NoEnginePrefixMetrics[0].MatchString("db.SQL.queries"))
+					assert.False(t, cfg.Export.Prometheus.NoEnginePrefixMetrics[0].MatchString("db.SQLqueries"))
+				}
+			},
+		},
+		{
+			name: "load config with invalid no-engine-prefix-metrics pattern",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    no-engine-prefix-metrics:
+    - "[invalid"`,
+			expectedError: true,
+		},
+		{
+			name: "load config keeps the engine in db metric names by default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.False(t, cfg.Export.Prometheus.OmitEnginePrefix)
+			},
+		},
+		{
+			name: "load config with engine-in-name enabled",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    engine-in-name: true`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.False(t, cfg.Export.Prometheus.OmitEnginePrefix)
+			},
+		},
+		{
+			name: "load config with engine-in-name disabled",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    engine-in-name: false`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Export.Prometheus.OmitEnginePrefix)
+			},
+		},
+		{
+			name: "load config with effective-settings-metrics",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    concurrency: 500
+export:
+  port: 8081
+  prometheus:
+    effective-settings-metrics: true`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Export.Prometheus.EffectiveSettings)
+				assert.Equal(t, DefaultConcurrency, cfg.Discovery.Processing.Concurrency)
+			},
+		},
+		{
+			name: "load config with instance-info-metric",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    instance-info-metric: true`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Export.Prometheus.InstanceInfo)
+			},
+		},
+		{
+			name: "load config with name-style",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    name-style: snake-case`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.MetricNameStyleSnakeCase, cfg.Export.Prometheus.NameStyle)
+			},
+		},
+		{
+			name: "load config defaults name-style to lowercase",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.MetricNameStyleLowercase, cfg.Export.Prometheus.NameStyle)
+			},
+		},
+		{
+			name: "load config with invalid name-style",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    name-style: camelCase`,
+			expectedError: true,
+		},
+		{
+			name: "load config with static-labels",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    static-labels:
+      account_id: "123456789012"
+      team: data`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, map[string]string{"account_id": "123456789012", "team": "data"}, cfg.Export.Prometheus.StaticLabels)
+			},
+		},
+		{
+			name: "load config with invalid static-labels name",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    static-labels:
+      account-id: "123456789012"`,
+			expectedError: true,
+		},
+		{
+			name: "load config with static-labels colliding with an instance label",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    static-labels:
+      engine: postgres`,
+			expectedError: true,
+		},
+		{
+			name: "load config with static-labels colliding with an enabled extra label",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    extra-labels:
+    - account_id
+    static-labels:
+      account_id: "123456789012"`,
+			expectedError: true,
+		},
+		{
+			name: "load config with account_id extra-label",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    extra-labels:
+    - account_id`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []string{"account_id"}, cfg.Export.Prometheus.ExtraLabels)
+			},
+		},
+		{
+			name: "load config with static-labels colliding with a tag label",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    static-labels:
+      tag_Environment: production`,
+			expectedError: true,
+		},
+		{
+			name: "load config with static-labels using a reserved prefix",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    static-labels:
+      __name__: metric`,
+			expectedError: true,
+		},
+		{
+			name: "load config with extra-labels",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    extra-labels:
+    - storage_type
+    - encrypted`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []string{"storage_type", "encrypted"}, cfg.Export.Prometheus.ExtraLabels)
+			},
+		},
+		{
+			name: "load config with region extra-labels",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    extra-labels:
+    - region
+    - collection_region`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []string{"region", "collection_region"}, cfg.Export.Prometheus.ExtraLabels)
+			},
+		},
+		{
+			name: "load config with tag-labels",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    tag-labels:
+    - Environment
+    - aws:cloudformation:stack-name`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []models.TagLabel{
+					{Tag: "Environment", Label: "tag_Environment"},
+					{Tag: "aws:cloudformation:stack-name", Label: "tag_aws_cloudformation_stack_name"},
+				}, cfg.Export.Prometheus.TagLabels)
+			},
+		},
+		{
+			name: "load config with empty tag-labels key",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    tag-labels:
+    - ""`,
+			expectedError: true,
+		},
+		{
+			name: "load config with tag-labels exported as the same label",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    tag-labels:
+    - cost-center
+    - cost_center`,
+			expectedError: true,
+		},
+		{
+			name: "load config with unknown extra-labels",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    extra-labels:
+    - kms_key`,
+			expectedError: true,
+		},
+		{
+			name: "load config with duplicate extra-labels",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    extra-labels:
+    - encrypted
+    - encrypted`,
+			expectedError: true,
+		},
+		{
+			name: "load config with discovery-max-retries",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    discovery-max-retries: 5
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 5, cfg.Discovery.Processing.DiscoveryMaxRetries)
+			},
+		},
+		{
+			name: "load config with out of range discovery-max-retries uses default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    discovery-max-retries: 50
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, DefaultDiscoveryMaxRetries, cfg.Discovery.Processing.DiscoveryMaxRetries)
+			},
+		},
+		{
+			name: "load config with retry-jitter",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    retry-jitter: equal
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.RetryJitterEqual, cfg.Discovery.Processing.RetryJitter)
+			},
+		},
+		{
+			name: "load config defaults retry-jitter to full",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.RetryJitterFull, cfg.Discovery.Processing.RetryJitter)
+			},
+		},
+		{
+			name: "load config with invalid retry-jitter",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    retry-jitter: decorrelated
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with collection-order",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  collection-order:
+    identifiers:
+    - prod-primary
+    tag: Priority
+    tag-values:
+    - critical
+    - high
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []string{"prod-primary"}, cfg.Discovery.CollectionOrder.Identifiers)
+				assert.Equal(t, "Priority", cfg.Discovery.CollectionOrder.Tag)
+				assert.Equal(t, []string{"critical", "high"}, cfg.Discovery.CollectionOrder.TagValues)
+			},
+		},
+		{
+			name: "load config with collection-order tag-values without tag",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  collection-order:
+    tag-values:
+    - critical
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with collection-order tag without tag-values",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  collection-order:
+    tag: Priority
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config infers partition from region",
+			configContent: `discovery:
+  regions:
+  - cn-north-1
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.PartitionAWSCN, cfg.AWS.Partition)
+			},
+		},
+		{
+			name: "load config with explicit govcloud partition",
+			configContent: `discovery:
+  regions:
+  - us-gov-west-1
+export:
+  port: 8081
+aws:
+  partition: aws-us-gov`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.PartitionAWSUSGov, cfg.AWS.Partition)
+				assert.Equal(t, "us-gov-west-1", cfg.AWS.STSRegion)
+			},
+		},
+		{
+			name: "load config with unknown partition",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+aws:
+  partition: aws-moon`,
+			expectedError: true,
+		},
+		{
+			name: "load config with region outside partition",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+aws:
+  partition: aws-cn`,
+			expectedError: true,
+		},
+		{
+			name: "load config with sts-region outside partition",
+			configContent: `discovery:
+  regions:
+  - cn-north-1
+export:
+  port: 8081
+aws:
+  sts-region: us-east-1`,
+			expectedError: true,
+		},
+		{
+			name: "load config with targeted-scrape-priority high",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  targeted-scrape-priority: high`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.ScrapePriorityHigh, cfg.Export.TargetedPriority)
+			},
+		},
+		{
+			name: "load config with invalid targeted-scrape-priority",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  targeted-scrape-priority: urgent`,
+			expectedError: true,
+		},
+		{
+			name: "load config with default-statistic-by-engine",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    statistic: avg
+    default-statistic-by-engine:
+      sqlserver-ee: sum
+      aurora-mysql: max
+      other: min
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, map[models.Engine]models.Statistic{
+					models.SQLServer:   models.StatisticSum,
+					models.AuroraMySQL: models.StatisticMax,
+					models.Other:       models.StatisticMin,
+				}, cfg.Discovery.Metrics.DefaultStatisticByEngine)
+				assert.Equal(t, models.StatisticSum, cfg.Discovery.Metrics.DefaultStatisticForEngine(models.SQLServer))
+				assert.Equal(t, models.StatisticAvg, cfg.Discovery.Metrics.DefaultStatisticForEngine(models.PostgreSQL))
+			},
+		},
+		{
+			name: "load config with invalid engine in default-statistic-by-engine",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    default-statistic-by-engine:
+      db2: sum
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with invalid statistic in default-statistic-by-engine",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    default-statistic-by-engine:
+      sqlserver: median
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with statistics",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    statistics: [max, avg]
+    default-statistic-by-engine:
+      sqlserver: sum
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, models.StatisticAvg, cfg.Discovery.Metrics.Statistic)
+				assert.Equal(t, []models.Statistic{models.StatisticAvg, models.StatisticMax}, cfg.Discovery.Metrics.Statistics)
+				assert.Equal(t, []models.Statistic{models.StatisticAvg, models.StatisticMax}, cfg.Discovery.Metrics.DefaultStatisticsForEngine(models.PostgreSQL))
+				assert.Equal(t, []models.Statistic{models.StatisticSum}, cfg.Discovery.Metrics.DefaultStatisticsForEngine(models.SQLServer))
+			},
+		},
+		{
+			name: "load config without statistics uses statistic",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    statistic: max
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Empty(t, cfg.Discovery.Metrics.Statistics)
+				assert.Equal(t, []models.Statistic{models.StatisticMax}, cfg.Discovery.Metrics.DefaultStatisticsForEngine(models.PostgreSQL))
+			},
+		},
+		{
+			name: "load config with invalid statistic in statistics",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    statistics: [avg, p95]
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with duplicate statistic in statistics",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  metrics:
+    statistics: [avg, max, avg]
+export:
+  port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with debug endpoints enabled",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  debug: true`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Export.Debug)
+			},
+		},
+		{
+			name: "load config with max-batches-per-scrape",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    max-batches-per-scrape: 50
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 50, cfg.Discovery.Processing.MaxBatchesPerScrape)
+			},
+		},
+		{
+			name: "load config with negative max-batches-per-scrape disables limit",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    max-batches-per-scrape: -1
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 0, cfg.Discovery.Processing.MaxBatchesPerScrape)
+			},
+		},
+		{
+			name: "load config with producer-concurrency",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    producer-concurrency: 4
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 4, cfg.Discovery.Processing.ProducerConcurrency)
+			},
+		},
+		{
+			name: "load config with out of range producer-concurrency uses default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    producer-concurrency: 64
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, DefaultProducerConcurrency, cfg.Discovery.Processing.ProducerConcurrency)
+			},
+		},
+		{
+			name: "load config with region-concurrency",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  - us-east-1
+  processing:
+    region-concurrency: 2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 2, cfg.Discovery.Processing.RegionConcurrency)
+			},
+		},
+		{
+			name: "load config without discovery-concurrency and collection-concurrency uses concurrency",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    concurrency: 3
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 3, cfg.Discovery.Processing.DiscoveryConcurrency)
+				assert.Equal(t, 3, cfg.Discovery.Processing.CollectionConcurrency)
+			},
+		},
+		{
+			name: "load config with discovery-concurrency and collection-concurrency",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    concurrency: 3
+    discovery-concurrency: 1
+    collection-concurrency: 4
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 3, cfg.Discovery.Processing.Concurrency)
+				assert.Equal(t, 1, cfg.Discovery.Processing.DiscoveryConcurrency)
+				assert.Equal(t, 4, cfg.Discovery.Processing.CollectionConcurrency)
+			},
+		},
+		{
+			name: "load config with out of range collection-concurrency uses concurrency",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  processing:
+    concurrency: 2
+    collection-concurrency: 50
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, 2, cfg.Discovery.Processing.CollectionConcurrency)
+			},
+		},
+		{
+			name: "load config with remote-write",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  remote-write-url: "https://aps-workspaces.us-west-2.amazonaws.com/workspaces/ws-1/api/v1/remote_write"
+  remote-write-interval: "30s"`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, "https://aps-workspaces.us-west-2.amazonaws.com/workspaces/ws-1/api/v1/remote_write", cfg.Export.RemoteWriteURL)
+				assert.Equal(t, 30*time.Second, cfg.Export.RemoteWriteInterval)
+			},
+		},
+		{
+			name: "load config with remote-write interval out of range uses default",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  remote-write-url: "http://localhost:9090/api/v1/write"
+  remote-write-interval: "1s"`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, DefaultRemoteWriteInterval, cfg.Export.RemoteWriteInterval)
+			},
+		},
+		{
+			name: "load config with invalid remote-write url",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  remote-write-url: "localhost:9090/api/v1/write"`,
+			expectedError: true,
+		},
+		{
+			name: "load config with invalid remote-write interval",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  remote-write-interval: "soon"`,
+			expectedError: true,
+		},
+		{
+			name: "load config with include-stopped enables status label",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  include-stopped: true
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.True(t, cfg.Discovery.IncludeStopped)
+				assert.True(t, cfg.Export.Prometheus.StatusLabel)
+				assert.Equal(t, []string{models.InstanceStatusAvailable, models.InstanceStatusStopped}, cfg.Discovery.Instances.Statuses)
+			},
+		},
+		{
+			name: "load config defaults instances.statuses to available",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []string{models.InstanceStatusAvailable}, cfg.Discovery.Instances.Statuses)
+				assert.False(t, cfg.Export.Prometheus.StatusLabel)
+			},
+		},
+		{
+			name: "load config with instances.statuses",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+  instances:
+    statuses:
+    - available
+    - backing-up
+export:
+  port: 8081`,
+			expectedError: false,
+			validate: func(t *testing.T, cfg *models.ParsedConfig) {
+				assert.Equal(t, []string{"available", "backing-up"}, cfg.Discovery.Instances.Statuses)
+			},
+		},
+		{
+			name: "load config with include-stopped and stopped in instances.statuses",
 			configContent: `discovery:
   regions:
   - us-west-2
+  include-stopped: true
   instances:
-    max-instances: 5
-  metrics:
-    statistic: "avg"
+    statuses:
+    - stopped
+    - available
 export:
   port: 8081`,
 			expectedError: false,
 			validate: func(t *testing.T, cfg *models.ParsedConfig) {
-				assert.Equal(t, 5, cfg.Discovery.Instances.MaxInstances)
+				assert.Equal(t, []string{"stopped", "available"}, cfg.Discovery.Instances.Statuses)
 			},
 		},
 		{
-			name: "load config with max instances exceeding limit gets capped",
+			name: "load config with duplicate instances.statuses",
 			configContent: `discovery:
   regions:
   - us-west-2
   instances:
-    max-instances: 100
-  metrics:
-    statistic: "avg"
+    statuses:
+    - available
+    - available
 export:
   port: 8081`,
-			expectedError: false,
-			validate: func(t *testing.T, cfg *models.ParsedConfig) {
-				assert.Equal(t, testutils.TestMaxInstances, cfg.Discovery.Instances.MaxInstances)
-			},
+			expectedError: true,
 		},
 		{
-			name: "load config with zero max instances applies default",
+			name: "load config with empty instances.statuses entry",
 			configContent: `discovery:
   regions:
   - us-west-2
   instances:
-    max-instances: 0
-  metrics:
-    statistic: "avg"
+    statuses:
+    - ""
 export:
   port: 8081`,
+			expectedError: true,
+		},
+		{
+			name: "load config with status-label",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    status-label: true`,
 			expectedError: false,
 			validate: func(t *testing.T, cfg *models.ParsedConfig) {
-				assert.Equal(t, testutils.TestMaxInstances, cfg.Discovery.Instances.MaxInstances)
+				assert.False(t, cfg.Discovery.IncludeStopped)
+				assert.True(t, cfg.Export.Prometheus.StatusLabel)
 			},
 		},
 		{
-			name: "load config with negative max instances applies default",
+			name: "load config with unknown-engine-behavior include-as-other",
 			configContent: `discovery:
   regions:
   - us-west-2
-  instances:
-    max-instances: -5
-  metrics:
-    statistic: "avg"
+  unknown-engine-behavior: include-as-other
 export:
   port: 8081`,
 			expectedError: false,
 			validate: func(t *testing.T, cfg *models.ParsedConfig) {
-				assert.Equal(t, testutils.TestMaxInstances, cfg.Discovery.Instances.MaxInstances)
+				assert.Equal(t, models.UnknownEngineIncludeAsOther, cfg.Discovery.UnknownEngineBehavior)
 			},
 		},
 		{
-			name: "load config with max instances = 1",
+			name: "load config with invalid unknown-engine-behavior",
 			configContent: `discovery:
   regions:
   - us-west-2
-  instances:
-    max-instances: 1
-  metrics:
-    statistic: "avg"
+  unknown-engine-behavior: keep
 export:
   port: 8081`,
-			expectedError: false,
-			validate: func(t *testing.T, cfg *models.ParsedConfig) {
-				assert.Equal(t, 1, cfg.Discovery.Instances.MaxInstances)
-			},
+			expectedError: true,
+		},
+		{
+			name: "load config with invalid prometheus namespace",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    namespace: "aws-rds"`,
+			expectedError: true,
+		},
+		{
+			name: "load config with invalid prometheus subsystem",
+			configContent: `discovery:
+  regions:
+  - us-west-2
+export:
+  port: 8081
+  prometheus:
+    subsystem: "_rds"`,
+			expectedError: true,
 		},
 	}
 
@@ -245,8 +2517,10 @@ func TestCreateDefaultConfig(t *testing.T) {
 
 	applyDefaults(&config)
 	assert.Equal(t, []string{"us-west-2"}, config.Discovery.Regions)
+	assert.Equal(t, "drop", config.Discovery.UnknownEngineBehavior)
 	assert.Equal(t, "avg", config.Discovery.Metrics.Statistic)
 	assert.Equal(t, 8081, config.Export.Port)
+	assert.Equal(t, "us-west-2", config.AWS.STSRegion)
 }
 
 func TestApplyDefaults(t *testing.T) {
@@ -358,10 +2632,11 @@ func TestParsedValidateConfig(t *testing.T) {
 				assert.Equal(t, []string{"us-west-2"}, cfg.Discovery.Regions)
 				assert.Equal(t, models.StatisticAvg, cfg.Discovery.Metrics.Statistic)
 				assert.Equal(t, 8081, cfg.Export.Port)
+				assert.Equal(t, DefaultSampleRate, cfg.Discovery.SampleRate)
 			},
 		},
 		{
-			name: "valid config with multiple regions (only first used)",
+			name: "valid config with multiple regions",
 			config: testutils.CreateTestConfig(map[string]interface{}{
 				"statistic": "max",
 				"port":      8082,
@@ -369,7 +2644,7 @@ func TestParsedValidateConfig(t *testing.T) {
 			}),
 			expectedError: false,
 			validate: func(t *testing.T, cfg *models.ParsedConfig) {
-				assert.Equal(t, []string{"us-west-2"}, cfg.Discovery.Regions)
+				assert.Equal(t, []string{"us-west-2", "us-east-1", "eu-west-1"}, cfg.Discovery.Regions)
 				assert.Equal(t, models.StatisticMax, cfg.Discovery.Metrics.Statistic)
 				assert.Equal(t, 8082, cfg.Export.Port)
 			},
@@ -540,7 +2815,7 @@ func TestCompileRegexPatterns(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := compileRegexPatterns(tt.patterns)
+			result, err := compileRegexPatterns(tt.patterns, false)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -553,6 +2828,48 @@ func TestCompileRegexPatterns(t *testing.T) {
 	}
 }
 
+func TestCompileRegexPatternsCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		name            string
+		pattern         string
+		caseInsensitive bool
+		value           string
+		expected        bool
+	}{
+		{
+			name:            "case sensitive by default",
+			pattern:         "^prod",
+			caseInsensitive: false,
+			value:           "PROD-db",
+			expected:        false,
+		},
+		{
+			name:            "case insensitive matches other case",
+			pattern:         "^prod",
+			caseInsensitive: true,
+			value:           "PROD-db",
+			expected:        true,
+		},
+		{
+			name:            "case insensitive exact pattern matches other case",
+			pattern:         "^prod-db$",
+			caseInsensitive: true,
+			value:           "Prod-DB",
+			expected:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := compileRegexPatterns([]string{tt.pattern}, tt.caseInsensitive)
+			require.NoError(t, err)
+
+			patternFilter := filter.NewPatternFilter(filter.Patterns{"identifier": patterns}, nil)
+			assert.Equal(t, tt.expected, patternFilter.ShouldInclude(models.Instance{Identifier: tt.value}))
+		})
+	}
+}
+
 func TestExtractMetricAndStatistic(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -632,6 +2949,7 @@ func TestParseInstancesConfig(t *testing.T) {
 			validate: func(t *testing.T, cfg models.ParsedInstancesConfig) {
 				assert.Equal(t, 10, cfg.MaxInstances)
 				assert.Equal(t, 5*time.Minute, cfg.InstanceTTL)
+				assert.Equal(t, DefaultEmptyTTL, cfg.EmptyTTL)
 				assert.Nil(t, cfg.Filter)
 			},
 		},
@@ -786,6 +3104,310 @@ func TestParseInstancesConfig(t *testing.T) {
 	}
 }
 
+func TestParseMaxInstances(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxInstances  int
+		limit         int
+		expected      int
+		expectedError bool
+	}{
+		{name: "within default limit", maxInstances: 10, limit: 0, expected: 10},
+		{name: "above default limit is clamped", maxInstances: 100, limit: 0, expected: MaxInstances},
+		{name: "unset defaults to 25", maxInstances: 0, limit: 0, expected: MaxInstances},
+		{name: "within raised limit", maxInstances: 100, limit: 200, expected: 100},
+		{name: "above raised limit is clamped", maxInstances: 300, limit: 200, expected: 200},
+		{name: "unset with lowered limit defaults to the limit", maxInstances: 0, limit: 10, expected: 10},
+		{name: "negative limit", maxInstances: 10, limit: -1, expectedError: true},
+		{name: "limit above ceiling", maxInstances: 10, limit: MaxMaxInstancesLimit + 1, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxInstances, err := parseMaxInstances(tt.maxInstances, tt.limit)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, maxInstances)
+		})
+	}
+}
+
+func TestParseBatchSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		batchSize     int
+		expected      int
+		expectedError bool
+	}{
+		{name: "unset defaults to 15", batchSize: 0, expected: DefaultBatchSize},
+		{name: "within range", batchSize: 5, expected: 5},
+		{name: "Performance Insights maximum", batchSize: MaxBatchSize, expected: MaxBatchSize},
+		{name: "above Performance Insights maximum", batchSize: MaxBatchSize + 1, expectedError: true},
+		{name: "negative", batchSize: -1, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batchSize, err := parseBatchSize(tt.batchSize)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, batchSize)
+		})
+	}
+}
+
+func TestParseEmptyTTL(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		instanceTTL   time.Duration
+		expected      time.Duration
+		expectedError bool
+	}{
+		{name: "unset defaults to 30m", value: "", instanceTTL: 5 * time.Minute, expected: DefaultEmptyTTL},
+		{name: "valid duration", value: "2h", instanceTTL: 5 * time.Minute, expected: 2 * time.Hour},
+		{name: "shorter than instance TTL is raised", value: "2m", instanceTTL: 5 * time.Minute, expected: 5 * time.Minute},
+		{name: "default shorter than instance TTL is raised", value: "", instanceTTL: time.Hour, expected: time.Hour},
+		{name: "out of range uses the default", value: "30s", instanceTTL: time.Minute, expected: DefaultEmptyTTL},
+		{name: "invalid format", value: "soon", instanceTTL: 5 * time.Minute, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emptyTTL, err := parseEmptyTTL(tt.value, tt.instanceTTL)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, emptyTTL)
+		})
+	}
+}
+
+func TestParseMaxInstanceIdentifiers(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxIdentifiers int
+		maxInstances   int
+		expected       int
+		expectedError  bool
+	}{
+		{name: "unset defaults to 5", maxIdentifiers: 0, maxInstances: 25, expected: DefaultMaxInstanceIdentifiers},
+		{name: "unset is capped by max instances", maxIdentifiers: 0, maxInstances: 3, expected: 3},
+		{name: "within max instances", maxIdentifiers: 20, maxInstances: 100, expected: 20},
+		{name: "above max instances is clamped", maxIdentifiers: 50, maxInstances: 25, expected: 25},
+		{name: "negative", maxIdentifiers: -1, maxInstances: 25, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxIdentifiers, err := parseMaxInstanceIdentifiers(tt.maxIdentifiers, tt.maxInstances)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, maxIdentifiers)
+		})
+	}
+}
+
+func TestParseRegionLabel(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name          string
+		regionLabel   *bool
+		regions       []string
+		extraLabels   []string
+		expected      bool
+		expectedError bool
+	}{
+		{name: "unset with a single region", regions: []string{"us-west-2"}, expected: false},
+		{name: "unset with several regions", regions: []string{"us-west-2", "eu-west-1"}, expected: true},
+		{name: "unset with several regions and the region extra label", regions: []string{"us-west-2", "eu-west-1"}, extraLabels: []string{"region"}, expected: false},
+		{name: "enabled with a single region", regionLabel: &enabled, regions: []string{"us-west-2"}, expected: true},
+		{name: "disabled with several regions", regionLabel: &disabled, regions: []string{"us-west-2", "eu-west-1"}, expected: false},
+		{name: "enabled with the region extra label", regionLabel: &enabled, regions: []string{"us-west-2"}, extraLabels: []string{"region"}, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regionLabel, err := parseRegionLabel(tt.regionLabel, tt.regions, tt.extraLabels)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, regionLabel)
+		})
+	}
+}
+
+func TestParseTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	assert.NoError(t, os.WriteFile(certFile, []byte("certificate"), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, []byte("key"), 0o600))
+
+	tests := []struct {
+		name          string
+		config        models.TLSConfig
+		expected      models.ParsedTLSConfig
+		expectedError bool
+	}{
+		{
+			name:     "no tls config serves plaintext",
+			config:   models.TLSConfig{},
+			expected: models.ParsedTLSConfig{},
+		},
+		{
+			name:     "cert and key files",
+			config:   models.TLSConfig{CertFile: certFile, KeyFile: keyFile},
+			expected: models.ParsedTLSConfig{CertFile: certFile, KeyFile: keyFile},
+		},
+		{
+			name:          "cert file without key file",
+			config:        models.TLSConfig{CertFile: certFile},
+			expectedError: true,
+		},
+		{
+			name:          "key file without cert file",
+			config:        models.TLSConfig{KeyFile: keyFile},
+			expectedError: true,
+		},
+		{
+			name:          "missing cert file",
+			config:        models.TLSConfig{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: keyFile},
+			expectedError: true,
+		},
+		{
+			name:          "missing key file",
+			config:        models.TLSConfig{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.key")},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseTLSConfig(tt.config)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+				assert.Equal(t, tt.config.CertFile != "", result.Enabled())
+			}
+		})
+	}
+}
+
+func TestCheckPortAvailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	t.Run("config with the port in use still loads", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), "config.yml")
+		configContent := fmt.Sprintf("discovery:\n  regions:\n  - us-west-2\nexport:\n  bind-address: 127.0.0.1\n  port: %d\n", port)
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0o600))
+
+		cfg, err := LoadConfig(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, port, cfg.Export.Port)
+
+		assert.EqualError(t, CheckPortAvailable(cfg.Export), fmt.Sprintf("invalid export.port in config.yml, port %d is not available", port))
+	})
+
+	t.Run("free port is available", func(t *testing.T) {
+		freeListener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		freePort := freeListener.Addr().(*net.TCPAddr).Port
+		require.NoError(t, freeListener.Close())
+
+		assert.NoError(t, CheckPortAvailable(models.ParsedExportConfig{BindAddress: "127.0.0.1", Port: freePort}))
+	})
+}
+
+func TestParseAuthConfig(t *testing.T) {
+	passwordSHA256 := sha256.Sum256([]byte("secret"))
+	passwordHex := hex.EncodeToString(passwordSHA256[:])
+
+	tests := []struct {
+		name          string
+		config        models.AuthConfig
+		expected      models.ParsedAuthConfig
+		expectedError bool
+	}{
+		{
+			name:     "no auth",
+			config:   models.AuthConfig{},
+			expected: models.ParsedAuthConfig{},
+		},
+		{
+			name:     "bearer token",
+			config:   models.AuthConfig{BearerToken: "token"},
+			expected: models.ParsedAuthConfig{BearerToken: "token"},
+		},
+		{
+			name:     "basic auth",
+			config:   models.AuthConfig{BasicAuth: models.BasicAuthConfig{Username: "prometheus", PasswordSHA256: passwordHex}},
+			expected: models.ParsedAuthConfig{Username: "prometheus", PasswordSHA256: passwordSHA256[:]},
+		},
+		{
+			name:          "bearer token and basic auth",
+			config:        models.AuthConfig{BearerToken: "token", BasicAuth: models.BasicAuthConfig{Username: "prometheus", PasswordSHA256: passwordHex}},
+			expectedError: true,
+		},
+		{
+			name:          "basic auth without password",
+			config:        models.AuthConfig{BasicAuth: models.BasicAuthConfig{Username: "prometheus"}},
+			expectedError: true,
+		},
+		{
+			name:          "basic auth without username",
+			config:        models.AuthConfig{BasicAuth: models.BasicAuthConfig{PasswordSHA256: passwordHex}},
+			expectedError: true,
+		},
+		{
+			name:          "basic auth with plaintext password",
+			config:        models.AuthConfig{BasicAuth: models.BasicAuthConfig{Username: "prometheus", PasswordSHA256: "secret"}},
+			expectedError: true,
+		},
+		{
+			name:          "basic auth with hash of wrong length",
+			config:        models.AuthConfig{BasicAuth: models.BasicAuthConfig{Username: "prometheus", PasswordSHA256: passwordHex[:32]}},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseAuthConfig(tt.config)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+				assert.Equal(t, tt.config != models.AuthConfig{}, result.Enabled())
+			}
+		})
+	}
+}
+
 func TestIsValidFilterField(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -802,6 +3424,31 @@ func TestIsValidFilterField(t *testing.T) {
 			fieldName: "engine",
 			expected:  true,
 		},
+		{
+			name:      "valid encrypted field",
+			fieldName: "encrypted",
+			expected:  true,
+		},
+		{
+			name:      "valid storage_type field",
+			fieldName: "storage_type",
+			expected:  true,
+		},
+		{
+			name:      "valid pi_retention field",
+			fieldName: "pi_retention",
+			expected:  true,
+		},
+		{
+			name:      "valid class field",
+			fieldName: "class",
+			expected:  true,
+		},
+		{
+			name:      "valid status field",
+			fieldName: "status",
+			expected:  true,
+		},
 		{
 			name:      "valid tag field",
 			fieldName: "tag.Environment",
@@ -894,6 +3541,19 @@ func TestCompileFilterConfig(t *testing.T) {
 				assert.Len(t, patterns["engine"], 1)
 			},
 		},
+		{
+			name: "valid class and status field config",
+			config: models.FilterConfig{
+				"class":  []string{`^db\.t3\.`},
+				"status": []string{"^available$", "^backing-up$"},
+			},
+			expectedError: false,
+			validate: func(t *testing.T, patterns filter.Patterns) {
+				assert.Len(t, patterns, 2)
+				assert.Len(t, patterns["class"], 1)
+				assert.Len(t, patterns["status"], 2)
+			},
+		},
 		{
 			name: "valid tag field config",
 			config: models.FilterConfig{
@@ -947,7 +3607,7 @@ func TestCompileFilterConfig(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := compileFilterConfig(tt.config)
+			result, err := compileFilterConfig(tt.config, false)
 
 			if tt.expectedError {
 				assert.Error(t, err)
@@ -1,15 +1,21 @@
 package utils
 
 import (
-	"fmt"
+	"errors"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/service/pi/types"
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/filter"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 )
 
+// ErrNoAvailableMetrics is returned when Performance Insights lists no metrics for an instance,
+// which is expected for an instance that has only just started publishing.
+var ErrNoAvailableMetrics = errors.New("[METRIC UTILS] NO metrics provided to build")
+
 // MetricDescriptionRegistry manages canonical descriptions for metrics to ensure consistency
 // across different database engines that may return varying descriptions for the same metric
 type MetricDescriptionRegistry struct {
@@ -77,9 +83,18 @@ func (per *PerEngineMetricRegistry) ResetEngineRegistry(engine models.Engine) {
 	}
 }
 
+// GetMetricNamesWithStatistic returns the name.statistic of every metric, sorted by metric name and then by statistic
+// in the order of the metric's Statistics.
 func GetMetricNamesWithStatistic(metricsDefinitionMap map[string]models.MetricDetails) []string {
+	metricNames := make([]string, 0, len(metricsDefinitionMap))
+	for metricName := range metricsDefinitionMap {
+		metricNames = append(metricNames, metricName)
+	}
+	sort.Strings(metricNames)
+
 	var metricNamesWithStat []string
-	for _, metric := range metricsDefinitionMap {
+	for _, metricName := range metricNames {
+		metric := metricsDefinitionMap[metricName]
 		for _, statistic := range metric.Statistics {
 			metricNamesWithStat = append(metricNamesWithStat, metric.Name+"."+statistic.String())
 		}
@@ -89,7 +104,7 @@ func GetMetricNamesWithStatistic(metricsDefinitionMap map[string]models.MetricDe
 
 func BuildMetricDefinitionMap(availableMetrics []types.ResponseResourceMetric, metricConfig *models.ParsedMetricsConfig, engine models.Engine, registry *PerEngineMetricRegistry) (map[string]models.MetricDetails, error) {
 	if len(availableMetrics) == 0 {
-		return nil, fmt.Errorf("[METRIC UTILS] NO metrics provided to build")
+		return nil, ErrNoAvailableMetrics
 	}
 
 	metricDefinitionMap := make(map[string]models.MetricDetails, len(availableMetrics))
@@ -98,7 +113,7 @@ func BuildMetricDefinitionMap(availableMetrics []types.ResponseResourceMetric, m
 	for _, metric := range availableMetrics {
 		if validResponseResourceMetric(metric) {
 			metricName := *metric.Metric
-			statistics := getMetricStatistics(metricName, metricConfig)
+			statistics := GetMetricStatistics(metricName, metricConfig, engine)
 
 			if len(statistics) > 0 {
 				canonicalDescription := engineRegistry.GetCanonicalDescription(metricName, *metric.Description)
@@ -120,7 +135,8 @@ func validResponseResourceMetric(metric types.ResponseResourceMetric) bool {
 	return metric.Metric != nil && metric.Description != nil && metric.Unit != nil
 }
 
-func getMetricStatistics(metricName string, metricConfig *models.ParsedMetricsConfig) []models.Statistic {
+// GetMetricStatistics returns the statistics collected for the metric, or none if the metric is excluded.
+func GetMetricStatistics(metricName string, metricConfig *models.ParsedMetricsConfig, engine models.Engine) []models.Statistic {
 	if metricConfig == nil {
 		return []models.Statistic{models.StatisticAvg}
 	}
@@ -129,13 +145,16 @@ func getMetricStatistics(metricName string, metricConfig *models.ParsedMetricsCo
 		return []models.Statistic{}
 	}
 
-	return determineIncludedStatistics(metricName, metricConfig)
+	return determineIncludedStatistics(metricName, metricConfig, engine)
 }
 
 func shouldExcludeMetric(metricName string, metricConfig *models.ParsedMetricsConfig) bool {
 	if len(metricConfig.Exclude) == 0 {
 		return false
 	}
+	if metricConfig.FilterMode == filter.ModeExcludeWithinInclude && len(metricConfig.Include) == 0 {
+		return false
+	}
 
 	if namePatterns, exists := metricConfig.Exclude[models.FilterTypeName.String()]; exists {
 		for _, pattern := range namePatterns {
@@ -157,15 +176,20 @@ func shouldExcludeMetric(metricName string, metricConfig *models.ParsedMetricsCo
 	return false
 }
 
-func determineIncludedStatistics(metricName string, metricConfig *models.ParsedMetricsConfig) []models.Statistic {
+// determineIncludedStatistics returns the engine's default statistic (or the global statistics when the engine has none)
+// and any statistics explicitly requested by include patterns such as name: ["db.load.avg.max"], ordered as in GetAllStatistics.
+func determineIncludedStatistics(metricName string, metricConfig *models.ParsedMetricsConfig, engine models.Engine) []models.Statistic {
 	var statistics []models.Statistic
 	seenStatistics := make(map[models.Statistic]bool)
 
-	statistics = append(statistics, metricConfig.Statistic)
-	seenStatistics[metricConfig.Statistic] = true
+	defaultStatistics := metricConfig.DefaultStatisticsForEngine(engine)
+	for _, defaultStatistic := range defaultStatistics {
+		statistics = append(statistics, defaultStatistic)
+		seenStatistics[defaultStatistic] = true
+	}
 
 	if len(metricConfig.Include) == 0 {
-		return statistics
+		return orderStatistics(statistics)
 	}
 
 	explicitStats := extractExplicitStatisticsFromInclude(metricName, metricConfig.Include)
@@ -177,12 +201,24 @@ func determineIncludedStatistics(metricName string, metricConfig *models.ParsedM
 	}
 
 	if matchesIncludePatterns(metricName, metricConfig.Include) {
-		if !seenStatistics[metricConfig.Statistic] {
-			statistics = append(statistics, metricConfig.Statistic)
-			seenStatistics[metricConfig.Statistic] = true
+		for _, defaultStatistic := range defaultStatistics {
+			if !seenStatistics[defaultStatistic] {
+				statistics = append(statistics, defaultStatistic)
+				seenStatistics[defaultStatistic] = true
+			}
 		}
 	}
 
+	return orderStatistics(statistics)
+}
+
+// orderStatistics sorts the statistics in the order of GetAllStatistics so metric names and batches are stable across scrapes.
+func orderStatistics(statistics []models.Statistic) []models.Statistic {
+	rank := make(map[models.Statistic]int, len(models.GetAllStatistics()))
+	for i, statistic := range models.GetAllStatistics() {
+		rank[statistic] = i
+	}
+	sort.SliceStable(statistics, func(i, j int) bool { return rank[statistics[i]] < rank[statistics[j]] })
 	return statistics
 }
 
@@ -228,6 +264,43 @@ func matchesIncludePatterns(metricName string, patterns models.FilterConfig) boo
 	return false
 }
 
+// FindUnmatchedIncludePatterns returns the include patterns, formatted as field=pattern, that match none of the provided metrics.
+// Patterns with an explicit statistic suffix are also tried without the suffix. This surfaces typos in the filter configuration.
+func FindUnmatchedIncludePatterns(metrics map[string]models.MetricDetails, include models.FilterConfig) []string {
+	var unmatched []string
+	for fieldName, patterns := range include {
+		for _, pattern := range patterns {
+			if !includePatternMatchesAnyMetric(fieldName, pattern, metrics) {
+				unmatched = append(unmatched, fieldName+"="+pattern)
+			}
+		}
+	}
+
+	sort.Strings(unmatched)
+	return unmatched
+}
+
+func includePatternMatchesAnyMetric(fieldName, pattern string, metrics map[string]models.MetricDetails) bool {
+	candidates := []string{pattern}
+	if basePattern, statisticStr := extractMetricAndStatistic(pattern); basePattern != "" && statisticStr != "" {
+		candidates = append(candidates, basePattern)
+	}
+
+	for _, candidate := range candidates {
+		regex, err := regexp.Compile(candidate)
+		if err != nil {
+			continue
+		}
+		for _, metric := range metrics {
+			if value, exists := metric.GetFilterableFields()[fieldName]; exists && regex.MatchString(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func patternMatchesMetric(pattern, metricName string) bool {
 	if pattern == metricName {
 		return true
@@ -259,6 +332,7 @@ func TrimStatisticFromMetricName(metricNameWithStat string) string {
 // mariadb -> mariadb
 // oracle -> oracle
 // sqlserver -> sqlserver
+// other -> other
 func EngineToShortName(engine models.Engine) string {
 	switch engine {
 	case models.AuroraPostgreSQL:
@@ -275,6 +349,8 @@ func EngineToShortName(engine models.Engine) string {
 		return "oracle"
 	case models.SQLServer:
 		return "sqlserver"
+	case models.Other:
+		return "other"
 	default:
 		return ""
 	}
@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/pi/types"
 
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/filter"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils"
 	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/testutils/mocks"
@@ -50,6 +51,133 @@ func TestGetMetricNamesWithStatistic(t *testing.T) {
 	}
 }
 
+func TestGetMetricNamesWithStatisticIsDeterministic(t *testing.T) {
+	metrics := map[string]models.MetricDetails{
+		"os.memory.total": {Name: "os.memory.total", Statistics: []models.Statistic{models.StatisticAvg}},
+		"db.load.avg":     {Name: "db.load.avg", Statistics: []models.Statistic{models.StatisticAvg, models.StatisticMin, models.StatisticMax, models.StatisticSum}},
+		"db.User.max_connections": {
+			Name:       "db.User.max_connections",
+			Statistics: []models.Statistic{models.StatisticMax},
+		},
+	}
+	expected := []string{
+		"db.User.max_connections.max",
+		"db.load.avg.avg",
+		"db.load.avg.min",
+		"db.load.avg.max",
+		"db.load.avg.sum",
+		"os.memory.total.avg",
+	}
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, expected, GetMetricNamesWithStatistic(metrics))
+	}
+}
+
+func TestGetMetricStatisticsWithFilterMode(t *testing.T) {
+	exclude := models.FilterConfig{"name": {"db.load.avg"}}
+
+	testCases := []struct {
+		name         string
+		metricConfig *models.ParsedMetricsConfig
+		expected     []models.Statistic
+	}{
+		{
+			name:         "exclude always excludes without include",
+			metricConfig: &models.ParsedMetricsConfig{Statistic: models.StatisticAvg, Exclude: exclude},
+			expected:     []models.Statistic{},
+		},
+		{
+			name: "exclude within include ignores exclude without include",
+			metricConfig: &models.ParsedMetricsConfig{
+				Statistic:  models.StatisticAvg,
+				Exclude:    exclude,
+				FilterMode: filter.ModeExcludeWithinInclude,
+			},
+			expected: []models.Statistic{models.StatisticAvg},
+		},
+		{
+			name: "exclude within include excludes with include",
+			metricConfig: &models.ParsedMetricsConfig{
+				Statistic:  models.StatisticAvg,
+				Include:    models.FilterConfig{"category": {"db"}},
+				Exclude:    exclude,
+				FilterMode: filter.ModeExcludeWithinInclude,
+			},
+			expected: []models.Statistic{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetMetricStatistics("db.load.avg", tc.metricConfig, models.PostgreSQL))
+		})
+	}
+}
+
+func TestGetMetricStatisticsOrdering(t *testing.T) {
+	testCases := []struct {
+		name         string
+		metricConfig *models.ParsedMetricsConfig
+		expected     []models.Statistic
+	}{
+		{
+			name:         "default statistic only",
+			metricConfig: &models.ParsedMetricsConfig{Statistic: models.StatisticMax},
+			expected:     []models.Statistic{models.StatisticMax},
+		},
+		{
+			name: "explicit statistics follow the order of GetAllStatistics",
+			metricConfig: &models.ParsedMetricsConfig{
+				Statistic: models.StatisticMax,
+				Include:   models.FilterConfig{"name": {"db.load.avg.sum", "db.load.avg.min", "db.load.avg.avg"}},
+			},
+			expected: []models.Statistic{models.StatisticAvg, models.StatisticMin, models.StatisticMax, models.StatisticSum},
+		},
+		{
+			name: "engine default statistic is ordered with explicit statistics",
+			metricConfig: &models.ParsedMetricsConfig{
+				Statistic:                models.StatisticAvg,
+				DefaultStatisticByEngine: map[models.Engine]models.Statistic{models.PostgreSQL: models.StatisticSum},
+				Include:                  models.FilterConfig{"name": {"db.load.avg.min"}},
+			},
+			expected: []models.Statistic{models.StatisticMin, models.StatisticSum},
+		},
+		{
+			name: "global statistics replace the default statistic",
+			metricConfig: &models.ParsedMetricsConfig{
+				Statistic:  models.StatisticAvg,
+				Statistics: []models.Statistic{models.StatisticAvg, models.StatisticMax},
+			},
+			expected: []models.Statistic{models.StatisticAvg, models.StatisticMax},
+		},
+		{
+			name: "global statistics are merged with explicit statistics",
+			metricConfig: &models.ParsedMetricsConfig{
+				Statistic:  models.StatisticMin,
+				Statistics: []models.Statistic{models.StatisticMin, models.StatisticSum},
+				Include:    models.FilterConfig{"name": {"db.load.avg.max", "db.load.avg.sum"}},
+			},
+			expected: []models.Statistic{models.StatisticMin, models.StatisticMax, models.StatisticSum},
+		},
+		{
+			name: "engine default statistic overrides global statistics",
+			metricConfig: &models.ParsedMetricsConfig{
+				Statistic:                models.StatisticAvg,
+				Statistics:               []models.Statistic{models.StatisticAvg, models.StatisticMax},
+				DefaultStatisticByEngine: map[models.Engine]models.Statistic{models.PostgreSQL: models.StatisticSum},
+			},
+			expected: []models.Statistic{models.StatisticSum},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetMetricStatistics("db.load.avg", tc.metricConfig, models.PostgreSQL))
+		})
+	}
+}
+
 func TestGetCanonicalDescription(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -492,6 +620,40 @@ func TestBuildMetricDefinitionMap(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:                "per-engine default statistic overrides global statistic",
+			resetGlobalRegistry: true,
+			engine:              models.SQLServer,
+			availableMetrics:    mocks.NewMockPIListMetricsResponse().Metrics,
+			metricConfig: &models.ParsedMetricsConfig{
+				Statistic:                models.StatisticAvg,
+				DefaultStatisticByEngine: map[models.Engine]models.Statistic{models.SQLServer: models.StatisticSum},
+			},
+			expectedError: false,
+			expectedCount: 5,
+			validateResults: func(t *testing.T, result map[string]models.MetricDetails) {
+				for _, metricDetails := range result {
+					assert.Equal(t, []models.Statistic{models.StatisticSum}, metricDetails.Statistics)
+				}
+			},
+		},
+		{
+			name:                "per-engine default statistic falls back to global statistic for other engines",
+			resetGlobalRegistry: true,
+			engine:              models.PostgreSQL,
+			availableMetrics:    mocks.NewMockPIListMetricsResponse().Metrics,
+			metricConfig: &models.ParsedMetricsConfig{
+				Statistic:                models.StatisticAvg,
+				DefaultStatisticByEngine: map[models.Engine]models.Statistic{models.SQLServer: models.StatisticSum},
+			},
+			expectedError: false,
+			expectedCount: 5,
+			validateResults: func(t *testing.T, result map[string]models.MetricDetails) {
+				for _, metricDetails := range result {
+					assert.Equal(t, []models.Statistic{models.StatisticAvg}, metricDetails.Statistics)
+				}
+			},
+		},
 		{
 			name:                "validation - filters out metrics with nil Metric field",
 			resetGlobalRegistry: true,
@@ -752,6 +914,58 @@ func TestValidResponseResourceMetric(t *testing.T) {
 	}
 }
 
+func TestFindUnmatchedIncludePatterns(t *testing.T) {
+	testCases := []struct {
+		name     string
+		include  models.FilterConfig
+		expected []string
+	}{
+		{
+			name:     "no include patterns",
+			include:  nil,
+			expected: nil,
+		},
+		{
+			name: "all patterns match",
+			include: models.FilterConfig{
+				"name":     {"^os\\.cpuUtilization", "max_connections"},
+				"category": {"os", "db"},
+				"unit":     {"Percent"},
+			},
+			expected: nil,
+		},
+		{
+			name: "typo in name pattern is reported",
+			include: models.FilterConfig{
+				"name": {"^os\\.cpuUtilisation", "max_connections"},
+			},
+			expected: []string{"name=^os\\.cpuUtilisation"},
+		},
+		{
+			name: "pattern with statistic suffix matches base metric",
+			include: models.FilterConfig{
+				"name": {"db.User.max_connections.max"},
+			},
+			expected: nil,
+		},
+		{
+			name: "unmatched patterns across fields are sorted",
+			include: models.FilterConfig{
+				"unit":     {"Bytes"},
+				"category": {"network"},
+			},
+			expected: []string{"category=network", "unit=Bytes"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := FindUnmatchedIncludePatterns(testutils.TestMetricsDetails, tc.include)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
 func TestTrimStatisticFromMetricName(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -854,6 +1068,11 @@ func TestEngineToShortName(t *testing.T) {
 			engine:   models.SQLServer,
 			expected: "sqlserver",
 		},
+		{
+			name:     "other to other",
+			engine:   models.Other,
+			expected: "other",
+		},
 		{
 			name:     "empty engine returns empty",
 			engine:   models.Engine(""),
@@ -2,10 +2,25 @@ package utils
 
 import (
 	"context"
+	"errors"
+	"math/rand/v2"
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 )
 
 func WithRetry[T any](ctx context.Context, operation func() (T, error), maxRetries int, baseDelay time.Duration) (T, error) {
+	return WithRetryJitter(ctx, operation, maxRetries, baseDelay, models.RetryJitterNone, nil)
+}
+
+// WithRetryJitter retries like WithRetry, with the backoff delay randomized by jitter so that callers throttled at the
+// same time don't retry in lockstep. Errors for which isRetryable returns false are returned without retrying; a nil
+// isRetryable retries every error.
+func WithRetryJitter[T any](ctx context.Context, operation func() (T, error), maxRetries int, baseDelay time.Duration, jitter models.RetryJitter, isRetryable func(error) bool) (T, error) {
 	var result T
 	var err error
 
@@ -15,12 +30,12 @@ func WithRetry[T any](ctx context.Context, operation func() (T, error), maxRetri
 			return result, nil
 		}
 
-		if attempt == maxRetries {
+		if attempt == maxRetries || (isRetryable != nil && !isRetryable(err)) {
 			return result, err
 		}
 
 		nextDelay := min(1<<attempt, 5)
-		delay := baseDelay * time.Duration(nextDelay)
+		delay := applyJitter(baseDelay*time.Duration(nextDelay), jitter)
 		select {
 		case <-ctx.Done():
 			return result, ctx.Err()
@@ -30,3 +45,48 @@ func WithRetry[T any](ctx context.Context, operation func() (T, error), maxRetri
 
 	return result, err
 }
+
+// IsRetryableAWSError reports whether an AWS call that failed with err may succeed when retried. Throttling, server
+// faults, connection errors and timeouts are retryable; cancelled calls and API errors with any other error code, such
+// as AccessDenied or an invalid resource ID, are not. Errors that cannot be classified are retried.
+func IsRetryableAWSError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	switch retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) {
+	case aws.TrueTernary:
+		return true
+	case aws.FalseTernary:
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorFault() == smithy.FaultServer
+	}
+	return true
+}
+
+// IsThrottlingAWSError reports whether err, or an error it wraps, is an AWS throttling error such as
+// ThrottlingException or RequestLimitExceeded. Unlike IsRetryableAWSError, server faults and timeouts are not throttling.
+func IsThrottlingAWSError(err error) bool {
+	return retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary
+}
+
+// applyJitter randomizes the backoff delay: full jitter picks a delay between 0 and delay, equal jitter between half
+// of delay and delay. Without jitter the delay is returned as is.
+func applyJitter(delay time.Duration, jitter models.RetryJitter) time.Duration {
+	switch jitter {
+	case models.RetryJitterFull:
+		return rand.N(delay + 1)
+	case models.RetryJitterEqual:
+		half := delay / 2
+		return half + rand.N(delay-half+1)
+	default:
+		return delay
+	}
+}
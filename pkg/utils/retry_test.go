@@ -3,10 +3,14 @@ package utils
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/aws/smithy-go"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/awslabs/prometheus-cloudwatch-database-insights-exporter/pkg/models"
 )
 
 func TestWithRetry(t *testing.T) {
@@ -222,3 +226,247 @@ func TestWithRetryDelayCap(t *testing.T) {
 		assert.InDelta(t, 250*time.Millisecond, delays[5], float64(tolerance), "delay 6 should be capped at ~250ms")
 	})
 }
+
+func TestWithRetryContextCancelled(t *testing.T) {
+	testCases := []struct {
+		name        string
+		cancelAfter time.Duration
+	}{
+		{
+			name:        "cancelled during the backoff delay",
+			cancelAfter: 50 * time.Millisecond,
+		},
+		{
+			name:        "cancelled before the first retry",
+			cancelAfter: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			callCount := 0
+			operation := func() (string, error) {
+				callCount++
+				if tc.cancelAfter == 0 {
+					cancel()
+				}
+				return "", errors.New("retry attempt failed")
+			}
+			if tc.cancelAfter > 0 {
+				time.AfterFunc(tc.cancelAfter, cancel)
+			}
+
+			start := time.Now()
+			_, err := WithRetry(ctx, operation, 3, 10*time.Second)
+			elapsed := time.Since(start)
+
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.Equal(t, 1, callCount, "no attempt should be made after the context is cancelled")
+			assert.Less(t, elapsed, time.Second, "should return promptly instead of waiting out the backoff")
+		})
+	}
+}
+
+func TestWithRetryJitter(t *testing.T) {
+	testCases := []struct {
+		name   string
+		jitter models.RetryJitter
+	}{
+		{name: "no jitter", jitter: models.RetryJitterNone},
+		{name: "full jitter", jitter: models.RetryJitterFull},
+		{name: "equal jitter", jitter: models.RetryJitterEqual},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			callCount := 0
+			operation := func() (string, error) {
+				callCount++
+				if callCount <= 3 {
+					return "", errors.New("retry attempt failed")
+				}
+				return "success", nil
+			}
+
+			baseDelay := 20 * time.Millisecond
+			start := time.Now()
+			result, err := WithRetryJitter(context.Background(), operation, 5, baseDelay, tc.jitter, nil)
+			elapsed := time.Since(start)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "success", result)
+			assert.Equal(t, 4, callCount)
+
+			// Without jitter the delays are 1x, 2x and 4x base delay; jitter never makes them longer
+			assert.Less(t, elapsed, 7*baseDelay+100*time.Millisecond)
+		})
+	}
+}
+
+func TestApplyJitter(t *testing.T) {
+	delay := 100 * time.Millisecond
+
+	testCases := []struct {
+		name        string
+		jitter      models.RetryJitter
+		expectedMin time.Duration
+		expectedMax time.Duration
+	}{
+		{
+			name:        "no jitter returns the delay",
+			jitter:      models.RetryJitterNone,
+			expectedMin: delay,
+			expectedMax: delay,
+		},
+		{
+			name:        "unset jitter returns the delay",
+			jitter:      "",
+			expectedMin: delay,
+			expectedMax: delay,
+		},
+		{
+			name:        "full jitter is between 0 and the delay",
+			jitter:      models.RetryJitterFull,
+			expectedMin: 0,
+			expectedMax: delay,
+		},
+		{
+			name:        "equal jitter is between half the delay and the delay",
+			jitter:      models.RetryJitterEqual,
+			expectedMin: delay / 2,
+			expectedMax: delay,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				jittered := applyJitter(delay, tc.jitter)
+				assert.GreaterOrEqual(t, jittered, tc.expectedMin)
+				assert.LessOrEqual(t, jittered, tc.expectedMax)
+			}
+		})
+	}
+
+	t.Run("zero delay", func(t *testing.T) {
+		assert.Zero(t, applyJitter(0, models.RetryJitterFull))
+		assert.Zero(t, applyJitter(0, models.RetryJitterEqual))
+	})
+}
+
+func TestWithRetryJitterNonRetryableError(t *testing.T) {
+	callCount := 0
+	operation := func() (string, error) {
+		callCount++
+		return "", errors.New("permanent error")
+	}
+
+	_, err := WithRetryJitter(context.Background(), operation, 3, time.Millisecond, models.RetryJitterNone,
+		func(error) bool { return false })
+
+	assert.EqualError(t, err, "permanent error")
+	assert.Equal(t, 1, callCount)
+}
+
+func TestIsRetryableAWSError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "throttling",
+			err:      &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"},
+			expected: true,
+		},
+		{
+			name:     "request limit exceeded",
+			err:      &smithy.GenericAPIError{Code: "RequestLimitExceeded"},
+			expected: true,
+		},
+		{
+			name:     "wrapped throttling",
+			err:      fmt.Errorf("error listing metrics: %w", &smithy.GenericAPIError{Code: "Throttling"}),
+			expected: true,
+		},
+		{
+			name:     "server fault",
+			err:      &smithy.GenericAPIError{Code: "InternalFailure", Fault: smithy.FaultServer},
+			expected: true,
+		},
+		{
+			name:     "access denied",
+			err:      &smithy.GenericAPIError{Code: "AccessDeniedException", Fault: smithy.FaultClient},
+			expected: false,
+		},
+		{
+			name:     "invalid argument",
+			err:      &smithy.GenericAPIError{Code: "InvalidArgumentException", Message: "Invalid resource ID"},
+			expected: false,
+		},
+		{
+			name:     "call timeout",
+			err:      context.DeadlineExceeded,
+			expected: true,
+		},
+		{
+			name:     "cancelled call",
+			err:      fmt.Errorf("operation error: %w", context.Canceled),
+			expected: false,
+		},
+		{
+			name:     "unclassified error",
+			err:      errors.New("unexpected error"),
+			expected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsRetryableAWSError(tc.err))
+		})
+	}
+}
+
+func TestIsThrottlingAWSError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "throttling",
+			err:      &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"},
+			expected: true,
+		},
+		{
+			name:     "wrapped request limit exceeded",
+			err:      fmt.Errorf("error collecting metrics: %w", &smithy.GenericAPIError{Code: "RequestLimitExceeded"}),
+			expected: true,
+		},
+		{
+			name:     "server fault",
+			err:      &smithy.GenericAPIError{Code: "InternalFailure", Fault: smithy.FaultServer},
+			expected: false,
+		},
+		{
+			name:     "call timeout",
+			err:      context.DeadlineExceeded,
+			expected: false,
+		},
+		{
+			name:     "unclassified error",
+			err:      errors.New("unexpected error"),
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsThrottlingAWSError(tc.err))
+		})
+	}
+}
@@ -39,9 +39,13 @@ func isRegexPattern(metricName string) bool {
 	return regexPattern.MatchString(metricName)
 }
 
-func compileRegexPatterns(patterns []string) ([]*regexp.Regexp, error) {
+// compileRegexPatterns compiles the patterns, ignoring case in every pattern when caseInsensitive is set.
+func compileRegexPatterns(patterns []string, caseInsensitive bool) ([]*regexp.Regexp, error) {
 	var regexPatterns []*regexp.Regexp
 	for _, pattern := range patterns {
+		if caseInsensitive {
+			pattern = "(?i)" + pattern
+		}
 		regex, err := regexp.Compile(pattern)
 		if err != nil {
 			return nil, err
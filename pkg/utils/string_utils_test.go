@@ -126,7 +126,7 @@ func TestBatchMetricNames(t *testing.T) {
 
 		for _, tt := range tests {
 			t.Run(tt.name, func(t *testing.T) {
-				batches := BatchMetricNames(tt.metricNames, BatchSize)
+				batches := BatchMetricNames(tt.metricNames, DefaultBatchSize)
 
 				assert.Len(t, batches, tt.expectedBatches)
 
@@ -136,7 +136,7 @@ func TestBatchMetricNames(t *testing.T) {
 
 					for i, batch := range batches {
 						if i < len(batches)-1 {
-							assert.Len(t, batch, BatchSize, "all batches except the last should have size %d", BatchSize)
+							assert.Len(t, batch, DefaultBatchSize, "all batches except the last should have size %d", DefaultBatchSize)
 						}
 					}
 
@@ -196,7 +196,7 @@ func TestBatchMetricNames(t *testing.T) {
 
 		for _, tt := range tests {
 			t.Run(tt.name, func(t *testing.T) {
-				batches := BatchMetricNames(tt.metricNames, BatchSize)
+				batches := BatchMetricNames(tt.metricNames, DefaultBatchSize)
 
 				assert.Len(t, batches, tt.expectedBatches)
 